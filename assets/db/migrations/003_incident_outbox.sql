@@ -0,0 +1,43 @@
+-- =====================================================
+-- Migration 003: incident_outbox
+-- Fecha: 2026-10-17
+-- Descripción: Outbox durable para los efectos secundarios de la creación
+--              de incidentes (score, notificaciones, contador de incidentes,
+--              reverse geocoding y procesamiento de imágenes).
+-- Base de datos: PostgreSQL
+--
+-- Los jobs se insertan en la MISMA transacción que el incident_report, y
+-- un pool de workers (internal/outbox) los procesa con reintentos y backoff.
+-- Los jobs que agotan max_attempts quedan en status = 'dead' para inspección.
+-- =====================================================
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS incident_outbox (
+    job_id       BIGSERIAL PRIMARY KEY,
+    job_type     VARCHAR(64) NOT NULL,
+    payload      JSONB NOT NULL DEFAULT '{}'::jsonb,
+    status       VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts     INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 8,
+    last_error   TEXT NULL,
+    run_after    TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_at    TIMESTAMP NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_incident_outbox_status CHECK (status IN ('pending', 'processing', 'done', 'dead'))
+);
+
+-- Usado por el worker para reclamar jobs listos (FOR UPDATE SKIP LOCKED)
+CREATE INDEX IF NOT EXISTS idx_incident_outbox_ready
+ON incident_outbox (run_after, job_id)
+WHERE status = 'pending';
+
+-- Usado por el endpoint de inspección y por la limpieza de jobs terminados
+CREATE INDEX IF NOT EXISTS idx_incident_outbox_status
+ON incident_outbox (status, updated_at);
+
+COMMENT ON TABLE incident_outbox IS
+'Outbox durable para side effects de newincident. Escrito en la misma transacción que incident_reports.';
+
+COMMIT;
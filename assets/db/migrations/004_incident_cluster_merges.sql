@@ -0,0 +1,41 @@
+-- =====================================================
+-- Migration 004: incident_cluster_merges
+-- Fecha: 2026-10-17
+-- Descripción: Auditoría de merges automáticos de clusters duplicados y
+--              soporte para que un moderador los revierta (split).
+-- Base de datos: PostgreSQL
+--
+-- Cada merge guarda los IDs exactos que se re-apuntaron del cluster origen
+-- al destino (reports, comentarios, saves, historial, notificaciones) para
+-- que el split pueda devolverlos sin ambigüedad.
+-- El cluster origen se desactiva y queda apuntando al destino con
+-- merged_into_incl_id.
+-- =====================================================
+
+BEGIN;
+
+ALTER TABLE incident_clusters
+    ADD COLUMN IF NOT EXISTS merged_into_incl_id BIGINT NULL;
+
+CREATE TABLE IF NOT EXISTS incident_cluster_merges (
+    merge_id              BIGSERIAL PRIMARY KEY,
+    target_incl_id        BIGINT NOT NULL,
+    source_incl_id        BIGINT NOT NULL,
+    merged_by             BIGINT NULL,                      -- NULL = cronjob
+    reason                VARCHAR(40) NOT NULL DEFAULT 'auto_overlap',
+    distance_meters       DOUBLE PRECISION NULL,
+    moved_reports         BIGINT[] NOT NULL DEFAULT '{}',
+    moved_comments        BIGINT[] NOT NULL DEFAULT '{}',
+    moved_saves           BIGINT[] NOT NULL DEFAULT '{}',
+    removed_save_accounts BIGINT[] NOT NULL DEFAULT '{}',   -- saves duplicados eliminados (la cuenta ya seguía el destino)
+    moved_history         BIGINT[] NOT NULL DEFAULT '{}',
+    moved_notifications   BIGINT[] NOT NULL DEFAULT '{}',
+    created_at            TIMESTAMP NOT NULL DEFAULT NOW(),
+    split_by              BIGINT NULL,
+    split_at              TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_cluster_merges_target ON incident_cluster_merges (target_incl_id);
+CREATE INDEX IF NOT EXISTS idx_incident_cluster_merges_source ON incident_cluster_merges (source_incl_id);
+
+COMMIT;
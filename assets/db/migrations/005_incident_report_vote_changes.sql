@@ -0,0 +1,24 @@
+-- =====================================================
+-- Migration 005: vote change / retraction
+-- Fecha: 2026-10-17
+-- Descripción: Permite cambiar o retirar un voto dentro de una ventana
+--              configurable (VOTE_CHANGE_WINDOW_MINUTES).
+-- Base de datos: PostgreSQL
+--
+-- vote_credibility guarda la credibilidad del votante al momento del voto
+-- para que al revertirlo se reste exactamente lo que se sumó a
+-- score_true/score_false del cluster.
+-- Un voto retirado queda como vote = NULL (el report sigue existiendo).
+-- =====================================================
+
+BEGIN;
+
+ALTER TABLE incident_reports
+    ADD COLUMN IF NOT EXISTS vote_credibility NUMERIC(3,1) NULL,
+    ADD COLUMN IF NOT EXISTS vote_updated_at  TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS idx_incident_reports_incl_account_vote
+    ON incident_reports (incl_id, account_id)
+    WHERE vote IS NOT NULL;
+
+COMMIT;
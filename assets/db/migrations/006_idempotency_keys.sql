@@ -0,0 +1,32 @@
+-- =====================================================
+-- Migration 006: idempotency_keys
+-- Fecha: 2026-10-17
+-- Descripción: Respuestas guardadas por Idempotency-Key para que los
+--              reintentos de la app (red inestable) no dupliquen
+--              incidentes, comentarios, votos ni validaciones de compra.
+-- Base de datos: PostgreSQL
+--
+-- fingerprint = sha256(método + ruta + body). Las filas vencen en
+-- expires_at (IDEMPOTENCY_TTL_HOURS, 24h por defecto) y el scheduler
+-- las purga cada hora.
+-- =====================================================
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    account_id      BIGINT NOT NULL,
+    idem_key        VARCHAR(255) NOT NULL,
+    fingerprint     CHAR(64) NOT NULL,
+    status          VARCHAR(16) NOT NULL DEFAULT 'processing'
+                    CHECK (status IN ('processing', 'completed')),
+    response_status INT NULL,
+    response_body   BYTEA NULL,
+    content_type    VARCHAR(100) NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at      TIMESTAMP NOT NULL,
+    PRIMARY KEY (account_id, idem_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+
+COMMIT;
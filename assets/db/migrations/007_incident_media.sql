@@ -0,0 +1,38 @@
+-- =====================================================
+-- Migration 007: incident_media
+-- Fecha: 2026-10-17
+-- Descripción: Varias imágenes por incident_report, con orden.
+-- Base de datos: PostgreSQL
+--
+-- Cada archivo subido crea una fila en status 'processing' dentro de la
+-- transacción del report; el job del outbox la pasa a 'ready' con la URL
+-- final. La imagen en position = 0 sigue copiándose a
+-- incident_reports.media_url / incident_clusters.media_url como portada
+-- para clientes antiguos.
+-- El cluster se obtiene vía incident_reports.incl_id (así los merges de
+-- clusters no necesitan tocar esta tabla).
+-- =====================================================
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS incident_media (
+    inme_id    BIGSERIAL PRIMARY KEY,
+    inre_id    BIGINT NOT NULL REFERENCES incident_reports (inre_id) ON DELETE CASCADE,
+    media_type VARCHAR(20) NOT NULL DEFAULT 'image',
+    media_url  VARCHAR(255) NULL,
+    status     VARCHAR(16) NOT NULL DEFAULT 'processing'
+               CHECK (status IN ('processing', 'ready', 'failed')),
+    position   SMALLINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (inre_id, position)
+);
+
+-- Reports existentes: su media_url pasa a ser la imagen 0
+INSERT INTO incident_media (inre_id, media_type, media_url, status, position, created_at)
+SELECT inre_id, 'image', media_url, 'ready', 0, COALESCE(created_at, NOW())
+FROM incident_reports
+WHERE media_url IS NOT NULL AND media_url <> '' AND media_url <> 'processing'
+ON CONFLICT (inre_id, position) DO NOTHING;
+
+COMMIT;
@@ -0,0 +1,21 @@
+-- =====================================================
+-- Migration 008: videos en incident_media
+-- Fecha: 2026-10-17
+-- Descripción: Datos del video procesado y motivo de fallo.
+-- Base de datos: PostgreSQL
+--
+-- poster_url: frame extraído del video (ya pixelado), para mostrar antes
+--             de reproducir.
+-- duration_ms: duración leída del contenedor.
+-- error_message: por qué el procesamiento terminó en 'failed'; se muestra
+--                al autor en GET /incident/media_status/:inre_id.
+-- =====================================================
+
+BEGIN;
+
+ALTER TABLE incident_media
+    ADD COLUMN IF NOT EXISTS poster_url VARCHAR(255) NULL,
+    ADD COLUMN IF NOT EXISTS duration_ms INTEGER NULL,
+    ADD COLUMN IF NOT EXISTS error_message VARCHAR(255) NULL;
+
+COMMIT;
@@ -0,0 +1,33 @@
+-- =====================================================
+-- Migration 009: edición y retiro de reports por su autor
+-- Fecha: 2026-10-17
+-- Descripción: Historial de cambios de incident_reports.
+-- Base de datos: PostgreSQL
+--
+-- Cada edición (descripción / subcategoría) o retiro guarda una fila con
+-- los valores anteriores y nuevos. Al retirar, el voto del report se
+-- revierte en el cluster y se guarda aquí en old_vote.
+-- =====================================================
+
+BEGIN;
+
+ALTER TABLE incident_reports
+    ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP NULL,
+    ADD COLUMN IF NOT EXISTS withdrawn_at TIMESTAMP NULL;
+
+CREATE TABLE IF NOT EXISTS incident_report_edits (
+    inrd_id              BIGSERIAL PRIMARY KEY,
+    inre_id              BIGINT NOT NULL REFERENCES incident_reports (inre_id) ON DELETE CASCADE,
+    account_id           BIGINT NOT NULL,
+    action               VARCHAR(16) NOT NULL CHECK (action IN ('edit', 'withdraw')),
+    old_description      TEXT NULL,
+    new_description      TEXT NULL,
+    old_subcategory_code VARCHAR(100) NULL,
+    new_subcategory_code VARCHAR(100) NULL,
+    old_vote             SMALLINT NULL,
+    created_at           TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_report_edits_inre ON incident_report_edits (inre_id, created_at);
+
+COMMIT;
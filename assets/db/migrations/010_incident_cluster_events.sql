@@ -0,0 +1,31 @@
+-- =====================================================
+-- Migration 010: incident_cluster_events
+-- Fecha: 2026-10-17
+-- Descripción: Eventos de un cluster que no quedan registrados en otra
+-- tabla, para armar su línea de tiempo (GET /cluster/timeline/:incl_id).
+-- Base de datos: PostgreSQL
+--
+-- event_type:
+--   location_moved   - el centro se movió al recalcularse con un report nuevo
+--   address_resolved - el geocoding asíncrono resolvió la dirección
+--   report_rejected  - un report fue rechazado por exceso de flags
+--   closed           - el cluster se cerró porque su último report fue retirado
+-- Reports, votos, comentarios, ediciones, merges y expiración se leen de
+-- sus propias tablas.
+-- account_id NULL = evento del sistema.
+-- =====================================================
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS incident_cluster_events (
+    ince_id    BIGSERIAL PRIMARY KEY,
+    incl_id    BIGINT NOT NULL REFERENCES incident_clusters (incl_id) ON DELETE CASCADE,
+    event_type VARCHAR(32) NOT NULL,
+    account_id BIGINT NULL,
+    details    JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_cluster_events_incl ON incident_cluster_events (incl_id, created_at);
+
+COMMIT;
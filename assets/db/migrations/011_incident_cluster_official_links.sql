@@ -0,0 +1,35 @@
+-- =====================================================
+-- Migration 011: incident_cluster_official_links
+-- Fecha: 2026-10-17
+-- Descripción: Vincula clusters creados por usuarios con los incidentes
+-- oficiales que publica el bot (TPS, TFS...) cuando coinciden en
+-- ubicación, tiempo y categoría.
+-- Base de datos: PostgreSQL
+--
+-- incident_reports.official_source: fuente del report del bot (NULL para
+--   reports de usuarios y para reports del bot anteriores a esta migración).
+-- incident_clusters.official_confirmed_at: primera confirmación oficial del
+--   cluster. Al expirar, un cluster confirmado se da por verdadero.
+-- Cada cluster se vincula a lo sumo una vez con cada report oficial.
+-- =====================================================
+
+BEGIN;
+
+ALTER TABLE incident_reports ADD COLUMN IF NOT EXISTS official_source VARCHAR(32) NULL;
+ALTER TABLE incident_clusters ADD COLUMN IF NOT EXISTS official_confirmed_at TIMESTAMP NULL;
+
+CREATE TABLE IF NOT EXISTS incident_cluster_official_links (
+    inol_id         BIGSERIAL PRIMARY KEY,
+    incl_id         BIGINT NOT NULL REFERENCES incident_clusters (incl_id) ON DELETE CASCADE,
+    inre_id         BIGINT NOT NULL REFERENCES incident_reports (inre_id) ON DELETE CASCADE,
+    source          VARCHAR(32) NOT NULL DEFAULT 'official',
+    distance_meters DOUBLE PRECISION NOT NULL,
+    time_diff_secs  INTEGER NOT NULL,
+    match_score     DOUBLE PRECISION NOT NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (incl_id, inre_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_cluster_official_links_inre ON incident_cluster_official_links (inre_id);
+
+COMMIT;
@@ -0,0 +1,18 @@
+-- =====================================================
+-- Migration 012: incident_reports.official_area
+-- Fecha: 2026-10-17
+-- Descripción: Área afectada de los incidentes oficiales que la publican
+-- (p.ej. cortes de luz de Toronto Hydro). El report sigue ubicado en el
+-- centroide; el polígono se usa en los exports (GET /public/export).
+-- Base de datos: PostgreSQL + PostGIS
+-- NULL = la fuente solo da un punto.
+-- =====================================================
+
+BEGIN;
+
+ALTER TABLE incident_reports ADD COLUMN IF NOT EXISTS official_area geography(Polygon, 4326) NULL;
+
+CREATE INDEX IF NOT EXISTS idx_incident_reports_official_area_incl
+    ON incident_reports (incl_id) WHERE official_area IS NOT NULL;
+
+COMMIT;
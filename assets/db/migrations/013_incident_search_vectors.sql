@@ -0,0 +1,38 @@
+-- =====================================================
+-- Migration 013: search_vector en incident_clusters e incident_reports
+-- Fecha: 2026-10-17
+-- Descripción: Búsqueda full-text (GET /cluster/search) sobre descripción,
+-- subcategoría, dirección y ciudad, en inglés y francés.
+-- Base de datos: PostgreSQL
+--
+-- Pesos:
+--   A - subcategory_name
+--   B - description
+--   C - address, city (configuración 'simple': nombres de calles sin stemming)
+-- Columnas generadas: se actualizan solas cuando cambia la fila.
+-- =====================================================
+
+BEGIN;
+
+ALTER TABLE incident_clusters ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', COALESCE(subcategory_name, '')), 'A') ||
+        setweight(to_tsvector('french', COALESCE(subcategory_name, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(description, '')), 'B') ||
+        setweight(to_tsvector('french', COALESCE(description, '')), 'B') ||
+        setweight(to_tsvector('simple', COALESCE(address, '') || ' ' || COALESCE(city, '')), 'C')
+    ) STORED;
+
+ALTER TABLE incident_reports ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', COALESCE(subcategory_name, '')), 'A') ||
+        setweight(to_tsvector('french', COALESCE(subcategory_name, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(description, '')), 'B') ||
+        setweight(to_tsvector('french', COALESCE(description, '')), 'B') ||
+        setweight(to_tsvector('simple', COALESCE(address, '') || ' ' || COALESCE(city, '')), 'C')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_incident_clusters_search_vector ON incident_clusters USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_incident_reports_search_vector ON incident_reports USING GIN (search_vector);
+
+COMMIT;
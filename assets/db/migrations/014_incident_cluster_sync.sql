@@ -0,0 +1,43 @@
+-- =====================================================
+-- Migration 014: updated_at mantenido por trigger y blocked_at en incident_clusters
+-- Fecha: 2026-10-17
+-- Descripción: Sync incremental del mapa (GET /cluster/sync). Los clientes piden los
+-- clusters de un área con updated_at posterior a su sync token.
+-- Base de datos: PostgreSQL
+--
+-- updated_at:
+--   - Default NOW() en INSERT y trigger en cada UPDATE.
+--   - Si solo cambia counter_total_views (o search_vector, derivado) no se toca: no cambian el mapa.
+--   - Las queries que ya hacen "updated_at = NOW()" siguen funcionando igual.
+-- blocked_at:
+--   - cjblockincident lo setea (y desactiva el cluster) cuando todos sus reportes
+--     quedaron rechazados por flags.
+-- =====================================================
+
+BEGIN;
+
+UPDATE incident_clusters SET updated_at = COALESCE(created_at, NOW()) WHERE updated_at IS NULL;
+ALTER TABLE incident_clusters ALTER COLUMN updated_at SET DEFAULT NOW();
+ALTER TABLE incident_clusters ALTER COLUMN updated_at SET NOT NULL;
+
+ALTER TABLE incident_clusters ADD COLUMN IF NOT EXISTS blocked_at TIMESTAMP NULL;
+
+CREATE OR REPLACE FUNCTION incident_clusters_touch_updated_at() RETURNS trigger AS $$
+BEGIN
+    IF (to_jsonb(NEW) - 'counter_total_views' - 'updated_at' - 'search_vector')
+       = (to_jsonb(OLD) - 'counter_total_views' - 'updated_at' - 'search_vector') THEN
+        RETURN NEW;
+    END IF;
+    NEW.updated_at := NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_incident_clusters_updated_at ON incident_clusters;
+CREATE TRIGGER trg_incident_clusters_updated_at
+    BEFORE UPDATE ON incident_clusters
+    FOR EACH ROW EXECUTE FUNCTION incident_clusters_touch_updated_at();
+
+CREATE INDEX IF NOT EXISTS idx_incident_clusters_updated_at ON incident_clusters (updated_at, incl_id);
+
+COMMIT;
@@ -0,0 +1,32 @@
+-- =====================================================
+-- Migration 015: lugares guardados de tipo ruta (trayecto al trabajo)
+-- Fecha: 2026-10-17
+-- Descripción: Un lugar en myplaces puede ser un punto con radio (como hasta ahora) o una
+-- ruta con un corredor de route_buffer metros a cada lado. POST /cluster/route consulta el
+-- mismo corredor sin guardarlo.
+-- Base de datos: PostgreSQL + PostGIS
+--
+-- place_type:
+--   - 'point' (default): location + radius.
+--   - 'route': route + route_buffer. latitude/longitude/location guardan el inicio de la
+--     ruta para que las pantallas y consultas que solo conocen puntos sigan funcionando.
+-- Alertas:
+--   - cjnewcluster hace ST_DWithin(center_location, route, route_buffer) para las rutas.
+-- =====================================================
+
+BEGIN;
+
+ALTER TABLE account_favorite_locations ADD COLUMN IF NOT EXISTS place_type VARCHAR(10) NOT NULL DEFAULT 'point';
+ALTER TABLE account_favorite_locations ADD COLUMN IF NOT EXISTS route GEOGRAPHY(LINESTRING, 4326) NULL;
+ALTER TABLE account_favorite_locations ADD COLUMN IF NOT EXISTS route_buffer INTEGER NULL;
+
+ALTER TABLE account_favorite_locations ADD CONSTRAINT chk_afl_place_type CHECK (
+    (place_type = 'point' AND route IS NULL)
+    OR (place_type = 'route' AND route IS NOT NULL AND route_buffer BETWEEN 25 AND 1000)
+);
+
+CREATE INDEX IF NOT EXISTS idx_favorite_locations_route_gist
+    ON account_favorite_locations USING GIST (route)
+    WHERE place_type = 'route';
+
+COMMIT;
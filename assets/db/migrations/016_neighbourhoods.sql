@@ -0,0 +1,39 @@
+-- =====================================================
+-- Migration 016: límites de barrios y distritos (neighbourhoods)
+-- Fecha: 2026-10-17
+-- Descripción: Polígonos de barrios/wards cargados desde GeoJSON (p. ej. los 158 barrios
+-- de la City of Toronto) con POST /neighbourhoods/import (solo admins).
+-- Base de datos: PostgreSQL + PostGIS
+--
+-- Uso:
+--   - GET /neighbourhoods lista y busca por nombre, ciudad o punto contenido.
+--   - /cluster/query y /cluster/stats aceptan neighbourhood_id (ST_Covers contra boundary).
+--   - GET /neighbourhoods/counts cuenta clusters por barrio de una ciudad.
+-- (source, kind, external_id) identifica el barrio: reimportar el mismo archivo actualiza
+-- los límites sin cambiar nbhd_id.
+-- =====================================================
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS neighbourhoods (
+    nbhd_id           BIGSERIAL PRIMARY KEY,
+    source            VARCHAR(50) NOT NULL,  -- quién publica los límites, p. ej. toronto_open_data
+    kind              VARCHAR(20) NOT NULL DEFAULT 'neighbourhood',
+    external_id       VARCHAR(100) NOT NULL, -- id en el archivo de origen (AREA_SHORT_CODE en Toronto)
+    name              VARCHAR(255) NOT NULL,
+    city              VARCHAR(100) NOT NULL,
+    province          VARCHAR(100) NULL,
+    boundary          GEOGRAPHY(MULTIPOLYGON, 4326) NOT NULL,
+    center_latitude   DOUBLE PRECISION NOT NULL,
+    center_longitude  DOUBLE PRECISION NOT NULL,
+    area_sq_meters    DOUBLE PRECISION NOT NULL,
+    created_at        TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_neighbourhoods_source UNIQUE (source, kind, external_id),
+    CONSTRAINT chk_neighbourhoods_kind CHECK (kind IN ('neighbourhood', 'ward'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_neighbourhoods_boundary_gist ON neighbourhoods USING GIST (boundary);
+CREATE INDEX IF NOT EXISTS idx_neighbourhoods_city_kind ON neighbourhoods (LOWER(city), kind);
+
+COMMIT;
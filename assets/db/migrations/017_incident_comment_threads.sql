@@ -0,0 +1,38 @@
+-- =====================================================
+-- Migration 017: respuestas, reacciones y marca "útil" en comentarios
+-- Fecha: 2026-10-17
+-- Descripción: Los comentarios de un cluster pasan de lista plana a hilos.
+-- Base de datos: PostgreSQL
+--
+-- parent_inco_id:
+--   - Comentario al que se responde (NULL = comentario raíz).
+--   - Una respuesta a otra respuesta se muestra dentro del hilo de la raíz;
+--     parent_inco_id guarda a quién se respondió para notificarlo (cjcomments).
+-- helpful_at / helpful_by:
+--   - El autor del incidente (incident_clusters.account_id) marca un comentario como útil.
+-- incident_comment_reactions:
+--   - Una fila por (comentario, cuenta, reacción); los códigos válidos están en comments.Reactions.
+-- =====================================================
+
+BEGIN;
+
+ALTER TABLE incident_comments ADD COLUMN IF NOT EXISTS parent_inco_id BIGINT NULL
+    REFERENCES incident_comments (inco_id) ON DELETE CASCADE;
+ALTER TABLE incident_comments ADD COLUMN IF NOT EXISTS helpful_at TIMESTAMP NULL;
+ALTER TABLE incident_comments ADD COLUMN IF NOT EXISTS helpful_by BIGINT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_incident_comments_parent
+    ON incident_comments (parent_inco_id) WHERE parent_inco_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS incident_comment_reactions (
+    inco_id    BIGINT NOT NULL REFERENCES incident_comments (inco_id) ON DELETE CASCADE,
+    account_id BIGINT NOT NULL,
+    reaction   VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (inco_id, account_id, reaction)
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_comment_reactions_account
+    ON incident_comment_reactions (account_id);
+
+COMMIT;
@@ -0,0 +1,20 @@
+-- =====================================================
+-- Migration 018: votos duplicados al fusionar clusters
+-- Fecha: 2026-10-17
+-- Descripción: Si una cuenta votó en los dos clusters de un merge, el destino
+-- se queda solo con su voto más reciente; el otro report pasa a vote = NULL.
+-- Base de datos: PostgreSQL
+--
+-- cleared_votes_true / cleared_votes_false:
+--   - inre_id de los reports cuyo voto (true/false) se anuló en el merge.
+--   - Su vote_credibility queda fijada para que el split devuelva exactamente
+--     lo que se restó de score_true/score_false.
+-- =====================================================
+
+BEGIN;
+
+ALTER TABLE incident_cluster_merges
+    ADD COLUMN IF NOT EXISTS cleared_votes_true  BIGINT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS cleared_votes_false BIGINT[] NOT NULL DEFAULT '{}';
+
+COMMIT;
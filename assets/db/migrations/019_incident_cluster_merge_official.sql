@@ -0,0 +1,22 @@
+-- =====================================================
+-- Migration 019: confirmación oficial al fusionar clusters
+-- Fecha: 2026-10-17
+-- Descripción: El merge conserva la confirmación oficial del origen sin
+-- aplicar dos veces el bonus de score_true, y el split la deshace.
+-- Base de datos: PostgreSQL
+--
+-- copied_official_links:
+--   - inol_id de los vínculos oficiales del origen copiados al destino
+--     (los del origen no se tocan; el split borra las copias).
+-- target_official_confirmed_at:
+--   - official_confirmed_at del destino antes del merge; el merge deja el
+--     más antiguo de los dos y el split restaura este valor.
+-- =====================================================
+
+BEGIN;
+
+ALTER TABLE incident_cluster_merges
+    ADD COLUMN IF NOT EXISTS copied_official_links        BIGINT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS target_official_confirmed_at TIMESTAMP NULL;
+
+COMMIT;
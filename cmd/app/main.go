@@ -6,14 +6,20 @@ import (
 	"alertly/internal/achievements"
 	"alertly/internal/analytics"
 	"alertly/internal/auth"
+	"alertly/internal/clustermerge"
+	"alertly/internal/clusterquery"
+	"alertly/internal/clustersync"
+	"alertly/internal/clustertimeline"
 	"alertly/internal/comments"
 	"alertly/internal/common"
+	"alertly/internal/commuteroute"
 
 	// "alertly/internal/config" // No longer needed
 	"alertly/internal/cronjob"
 	"alertly/internal/database"
 	"alertly/internal/editprofile"
 	"alertly/internal/emails"
+	"alertly/internal/export"
 	"alertly/internal/feedback"
 	"alertly/internal/getcategories"
 	"alertly/internal/getclusterby"
@@ -27,19 +33,27 @@ import (
 	"alertly/internal/media"
 	"alertly/internal/middleware"
 	"alertly/internal/myplaces"
+	"alertly/internal/neighbourhoods"
 	"alertly/internal/newincident"
 	"alertly/internal/notifications"
+	"alertly/internal/outbox"
 	"alertly/internal/profile"
+	"alertly/internal/publicfeed"
+	"alertly/internal/realtime"
 	"alertly/internal/referrals"
 	"alertly/internal/reportincident"
 	"alertly/internal/saveclusteraccount"
 	"alertly/internal/scheduler"
+	"alertly/internal/search"
+	"alertly/internal/sharepage"
 	"alertly/internal/signup"
+	"alertly/internal/tiles"
 	"alertly/internal/tutorial"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	// "github.com/joho/godotenv" // No longer needed
@@ -91,6 +105,17 @@ func main() {
 	scheduler.StartCronjobs()
 	log.Println("Cronjob scheduler started")
 
+	// OUTBOX: Side effects de incidentes persistidos y ejecutados con reintentos
+	newincident.RegisterJobs(database.DB)
+	outboxWorkers := 4
+	if v, err := strconv.Atoi(os.Getenv("OUTBOX_WORKERS")); err == nil && v > 0 {
+		outboxWorkers = v
+	}
+	outbox.NewDispatcher(outbox.NewRepository(database.DB), outboxWorkers).Start()
+
+	// REALTIME: Eventos de clusters para los streams SSE (hub en memoria de esta instancia)
+	realtime.Start(database.DB, realtime.NewHub())
+
 	router := gin.Default()
 
 	// PRODUCCIÓN: Configurar middlewares de seguridad
@@ -139,10 +164,35 @@ func main() {
 	publicRoutes := router.Group("/public")
 	publicRoutes.Use(middleware.RateLimitMiddlewarePublic()) // Rate limiting más estricto
 	publicRoutes.GET("/cluster/getbyid/:incl_id", getclusterby.ViewPublic)
-
-	api.POST("/incident/create", newincident.Create)
+	publicRoutes.GET("/export", export.Export) // GeoJSON, KML o CSV para grupos comunitarios y periodistas
+	publicRoutes.GET("/feed/:format", middleware.RateLimitMiddlewareFeed(), publicfeed.GetFeed) // rss, atom o json por área y categoría
+	publicRoutes.GET("/share/incident/:incl_id", sharepage.View) // HTML con Open Graph para links compartidos
+
+	// Idempotency-Key: los reintentos de la app no duplican incidentes, votos, comentarios ni compras
+	idempotencyMW := middleware.IdempotencyMiddleware(database.DB)
+	api.POST("/incident/create", idempotencyMW, newincident.Create)
+	api.POST("/cluster/vote/change", idempotencyMW, newincident.ChangeVote)
+	api.GET("/incident/media_status/:inre_id", newincident.GetMediaStatus)
+	api.POST("/incident/edit", newincident.EditReport)
+	api.POST("/incident/withdraw", newincident.WithdrawReport)
+	api.GET("/incident/suggestions", newincident.SuggestClusters)
 	api.GET("/cluster/getbyid/:incl_id", getclusterby.View)
+	api.GET("/cluster/timeline/:incl_id", clustertimeline.GetTimeline)
+	api.GET("/cluster/stream", realtime.Stream) // SSE: bbox o afl_id de un lugar guardado
+	// Filtro tipado (query string o JSON); /cluster/getbylocation y /cluster/getbyradius quedan por compatibilidad
+	router.GET("/cluster/query", clusterquery.Find)
+	router.POST("/cluster/query", clusterquery.Find)
+	router.GET("/cluster/stats", clusterquery.GetStats) // mismo filtro que /cluster/query (bbox, radio, barrio o polígono)
+	router.POST("/cluster/stats", clusterquery.GetStats)
+	router.GET("/cluster/sync", clustersync.Sync) // cambios desde sync_token, con ETag
+	router.POST("/cluster/route", commuteroute.Find) // incidentes a lo largo de una ruta (polyline o waypoints)
 	router.GET("/cluster/getbylocation/:min_latitude/:max_latitude/:min_longitude/:max_longitude/:from_date/:to_date/:insu_id", getclustersbylocation.Get)
+	router.GET("/cluster/aggregate/:min_latitude/:max_latitude/:min_longitude/:max_longitude/:from_date/:to_date/:insu_id", getclustersbylocation.GetAggregated)
+	router.GET("/tiles/:z/:x/:y", tiles.GetTile) // y = "{y}.mvt"
+	router.GET("/cluster/search", search.Search)
+	router.GET("/neighbourhoods", neighbourhoods.List)
+	router.GET("/neighbourhoods/counts", neighbourhoods.Counts)
+	router.GET("/neighbourhoods/:nbhd_id", neighbourhoods.GetById)
 	router.GET("/cluster/getbyradius/:latitude/:longitude/:radius/:from_date/:to_date/:insu_id", getclusterbyradius.GetByRadius)
 	api.GET("/cluster/getasreel/:min_latitude/:max_latitude/:min_longitude/:max_longitude", getincidentsasreels.GetReel)
 
@@ -166,6 +216,7 @@ func main() {
 	// Premium-protected: Multiple alert locations feature (Saved Places)
 	premiumMW := middleware.PremiumMiddleware(database.DB)
 	api.POST("/account/myplaces/add", premiumMW, myplaces.Add)
+	api.POST("/account/myplaces/add_route", premiumMW, myplaces.AddRoute)
 	api.GET("/account/myplaces/get", premiumMW, myplaces.GetByAccountId)
 	api.GET("/account/myplaces/get_by_id/:afl_id", premiumMW, myplaces.GetById)
 	api.POST("/account/myplaces/update", premiumMW, myplaces.Update)
@@ -174,14 +225,18 @@ func main() {
 	api.GET("/account/myplaces/delete/:afl_id", premiumMW, myplaces.Delete)
 	api.GET("/account/profile/get_by_id/:account_id", profile.GetById)
 	api.GET("/account/cluster/toggle_save/:incl_id", saveclusteraccount.ToggleSaveClusterAccount)
-	api.POST("/cluster/send_comment", middleware.ProfanityFilterMiddleware(), comments.SaveClusterComment)
+	api.POST("/cluster/send_comment", idempotencyMW, middleware.ProfanityFilterMiddleware(), comments.SaveClusterComment)
+	api.POST("/cluster/comment/:inco_id/reactions", comments.AddReaction)
+	api.DELETE("/cluster/comment/:inco_id/reactions/:reaction", comments.RemoveReaction)
+	api.POST("/cluster/comment/:inco_id/helpful", comments.MarkHelpful) // solo el autor del incidente
+	api.DELETE("/cluster/comment/:inco_id/helpful", comments.UnmarkHelpful)
 	api.GET("/saved/get_my_list", saveclusteraccount.GetMyList)
 	api.GET("/saved/delete/:acs_id", saveclusteraccount.DeleteFollowIncident)
 	api.POST("/account/report/:account_id", profile.ReportAccount)
 	api.POST("/account/block/:account_id", profile.BlockAccount)
 	api.DELETE("/account/block/:account_id", profile.UnblockAccount)
 	api.GET("/account/get_my_info", account.GetMyInfo)
-	api.POST("/purchase/apple/validate", account.ValidateAppleReceipt)
+	api.POST("/purchase/apple/validate", idempotencyMW, account.ValidateAppleReceipt)
 	api.POST("/account/update_premium_status", account.UpdatePremiumStatusHandler)
 	api.POST("/send_feedback", feedback.SendFeedback)
 	api.POST("/send_invitation", invitefriend.Save)
@@ -190,6 +245,20 @@ func main() {
 	// Tutorial
 	api.POST("/tutorial/complete", tutorial.CompleteHandler)
 
+	// Outbox inspection (solo admins)
+	adminMW := middleware.AdminMiddleware(database.DB)
+	api.GET("/outbox/jobs", adminMW, outbox.ListJobs)
+	api.GET("/outbox/stats", adminMW, outbox.GetStats)
+	api.POST("/outbox/jobs/:job_id/retry", adminMW, outbox.RetryJob)
+
+	// Cluster merge/split (moderadores)
+	api.POST("/cluster/merge", adminMW, clustermerge.MergeClusters)
+	api.POST("/cluster/merges/:merge_id/split", adminMW, clustermerge.SplitMerge)
+	api.GET("/cluster/merge_history/:incl_id", adminMW, clustermerge.GetMergeHistory)
+
+	// Límites de barrios/wards desde GeoJSON (admins)
+	api.POST("/neighbourhoods/import", adminMW, neighbourhoods.Import)
+
 	// Analytics endpoints
 	analyticsService := analytics.NewBasicAnalytics(database.DB)
 	analyticsHandler := analytics.NewHandler(analyticsService)
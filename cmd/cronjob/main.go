@@ -1,6 +1,7 @@
 package main
 
 import (
+	"alertly/internal/clustermerge"
 	"alertly/internal/cronjob"
 	"alertly/internal/cronjobs/cjbadgeearn"
 	"alertly/internal/cronjobs/cjblockincident"
@@ -13,6 +14,7 @@ import (
 	"alertly/internal/cronjobs/cjnewcluster"
 	"alertly/internal/cronjobs/cjuserank"
 	"alertly/internal/database" // Use the centralized database package
+	"alertly/internal/newincident"
 	"context"
 	"fmt"
 	"log"
@@ -88,6 +90,12 @@ func HandleRequest(ctx context.Context, event Event) (string, error) {
 		repo := cjincidentexpiration.NewRepository(database.DB)
 		svc := cjincidentexpiration.NewService(repo)
 		svc.Run()
+	case "cluster_merge":
+		repo := clustermerge.NewRepository(database.DB)
+		svc := clustermerge.NewService(repo, newincident.NewService(newincident.NewRepository(database.DB)))
+		if _, err := svc.MergeOverlapping(); err != nil {
+			log.Printf("Error in cluster_merge cronjob: %v", err)
+		}
 	case "premium_expiration":
 		svc := cronjob.NewPremiumExpirationService(database.DB)
 		err := svc.CheckAndExpirePremiumAccounts()
@@ -104,6 +112,10 @@ func HandleRequest(ctx context.Context, event Event) (string, error) {
 		repo := cjbot_creator.NewRepository(database.DB)
 		svc := cjbot_creator.NewService(repo)
 		svc.RunTFS()
+	case "bot_correlation":
+		repo := cjbot_creator.NewRepository(database.DB)
+		svc := cjbot_creator.NewService(repo)
+		svc.RunCorrelation()
 	case "bot_creator_ttc":
 		repo := cjbot_creator.NewRepository(database.DB)
 		svc := cjbot_creator.NewService(repo)
@@ -0,0 +1,96 @@
+package clustermerge
+
+import (
+	"alertly/internal/auth"
+	"alertly/internal/database"
+	"alertly/internal/newincident"
+	"alertly/internal/response"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newService() Service {
+	return NewService(NewRepository(database.DB), newincident.NewService(newincident.NewRepository(database.DB)))
+}
+
+// MergeClusters fusiona manualmente source_incl_id dentro de target_incl_id (moderadores)
+func MergeClusters(c *gin.Context) {
+	accountID, err := auth.GetUserFromContext(c)
+	if err != nil {
+		response.Send(c, http.StatusUnauthorized, true, "Unauthorized", nil)
+		return
+	}
+
+	var inputs MergeInputs
+	if err := c.ShouldBindJSON(&inputs); err != nil {
+		response.Send(c, http.StatusBadRequest, true, "Invalid input. Please check and try again.", err.Error())
+		return
+	}
+
+	mergeId, err := newService().Merge(inputs.TargetInclId, inputs.SourceInclId, accountID)
+	if err != nil {
+		if errors.Is(err, ErrSameCluster) {
+			response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+			return
+		}
+		if errors.Is(err, ErrNotMergeable) {
+			response.Send(c, http.StatusConflict, true, err.Error(), nil)
+			return
+		}
+		log.Printf("Error merging cluster %d into %d: %v", inputs.SourceInclId, inputs.TargetInclId, err)
+		response.Send(c, http.StatusInternalServerError, true, "Could not merge the clusters. Please try later.", nil)
+		return
+	}
+	response.Send(c, http.StatusOK, false, "Clusters merged", gin.H{"merge_id": mergeId})
+}
+
+// SplitMerge revierte un merge (moderadores)
+func SplitMerge(c *gin.Context) {
+	accountID, err := auth.GetUserFromContext(c)
+	if err != nil {
+		response.Send(c, http.StatusUnauthorized, true, "Unauthorized", nil)
+		return
+	}
+
+	mergeId, err := strconv.ParseInt(c.Param("merge_id"), 10, 64)
+	if err != nil || mergeId <= 0 {
+		response.Send(c, http.StatusBadRequest, true, "Invalid merge ID.", nil)
+		return
+	}
+
+	m, err := newService().Split(mergeId, accountID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrMergeNotFound):
+			response.Send(c, http.StatusNotFound, true, err.Error(), nil)
+		case errors.Is(err, ErrAlreadySplit), errors.Is(err, ErrSourceReplaced):
+			response.Send(c, http.StatusConflict, true, err.Error(), nil)
+		default:
+			log.Printf("Error splitting merge %d: %v", mergeId, err)
+			response.Send(c, http.StatusInternalServerError, true, "Could not split the clusters. Please try later.", nil)
+		}
+		return
+	}
+	response.Send(c, http.StatusOK, false, "Merge reverted", m)
+}
+
+// GetMergeHistory devuelve la auditoría de merges de un cluster (moderadores)
+func GetMergeHistory(c *gin.Context) {
+	inclId, err := strconv.ParseInt(c.Param("incl_id"), 10, 64)
+	if err != nil || inclId <= 0 {
+		response.Send(c, http.StatusBadRequest, true, "Invalid cluster ID.", nil)
+		return
+	}
+
+	merges, err := newService().History(inclId)
+	if err != nil {
+		log.Printf("Error getting merge history for cluster %d: %v", inclId, err)
+		response.Send(c, http.StatusInternalServerError, true, "Could not load merge history.", nil)
+		return
+	}
+	response.Send(c, http.StatusOK, false, "Success", merges)
+}
@@ -0,0 +1,44 @@
+package clustermerge
+
+import "time"
+
+const (
+	ReasonAutoOverlap = "auto_overlap"
+	ReasonModerator   = "moderator"
+)
+
+// Candidate es un par de clusters activos de la misma categoría (category_code) que se solapan en espacio y tiempo.
+// TargetInclId es el que se conserva (más votos, o el más antiguo en caso de empate).
+type Candidate struct {
+	TargetInclId   int64
+	SourceInclId   int64
+	DistanceMeters float64
+}
+
+// Merge es el registro de auditoría de incident_cluster_merges.
+type Merge struct {
+	MergeId             int64      `json:"merge_id"`
+	TargetInclId        int64      `json:"target_incl_id"`
+	SourceInclId        int64      `json:"source_incl_id"`
+	MergedBy            *int64     `json:"merged_by"`
+	Reason              string     `json:"reason"`
+	DistanceMeters      *float64   `json:"distance_meters"`
+	MovedReports        []int64    `json:"moved_reports"`
+	MovedComments       []int64    `json:"moved_comments"`
+	MovedSaves          []int64    `json:"moved_saves"`
+	RemovedSaveAccounts []int64    `json:"removed_save_accounts"`
+	MovedHistory        []int64    `json:"moved_history"`
+	MovedNotifications  []int64    `json:"moved_notifications"`
+	ClearedVotesTrue    []int64    `json:"cleared_votes_true"` // votos duplicados (misma cuenta en ambos clusters) anulados en el merge
+	ClearedVotesFalse   []int64    `json:"cleared_votes_false"`
+	CopiedOfficialLinks []int64    `json:"copied_official_links"`        // vínculos oficiales del origen copiados al destino
+	TargetConfirmedAt   *time.Time `json:"target_official_confirmed_at"` // official_confirmed_at del destino antes del merge
+	CreatedAt           time.Time  `json:"created_at"`
+	SplitBy             *int64     `json:"split_by"`
+	SplitAt             *time.Time `json:"split_at"`
+}
+
+type MergeInputs struct {
+	TargetInclId int64 `json:"target_incl_id" binding:"required,min=1"`
+	SourceInclId int64 `json:"source_incl_id" binding:"required,min=1,nefield=TargetInclId"`
+}
@@ -0,0 +1,547 @@
+package clustermerge
+
+import (
+	"alertly/internal/common"
+	"alertly/internal/dbtypes"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrNotMergeable   = errors.New("both clusters must exist, be active and not already merged")
+	ErrMergeNotFound  = errors.New("merge not found")
+	ErrAlreadySplit   = errors.New("this merge has already been split")
+	ErrSourceReplaced = errors.New("the source cluster is no longer merged into the target")
+	ErrSameCluster    = errors.New("cannot merge a cluster into itself")
+)
+
+// Tipos de notificación cuyo reference_id es un incl_id (ver common.HandleNotification).
+// new_comment no va: su reference_id es el inco_id, y los comentarios ya se mueven con el cluster.
+var clusterNotificationTypes = []string{
+	"new_cluster",
+	"new_incident_cluster",
+	"user_mentioned",
+	"incident_result_win",
+}
+
+type Repository interface {
+	FindCandidates(minRadiusMeters float64, limit int) ([]Candidate, error)
+	Merge(targetInclId, sourceInclId int64, mergedBy *int64, reason string, distanceMeters *float64) (int64, error)
+	Split(mergeId int64, splitBy int64) (Merge, error)
+	GetByID(mergeId int64) (Merge, error)
+	ListByCluster(inclId int64) ([]Merge, error)
+}
+
+type pgRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &pgRepository{db: db}
+}
+
+// FindCandidates busca pares de clusters activos de la misma categoría (aunque difiera la subcategoría)
+// cuyos centros están dentro del mayor default_circle_range de sus subcategorías (mínimo minRadiusMeters) y cuyos intervalos
+// start_time/end_time se solapan. Se conserva el cluster con más votos; si empatan, el más antiguo.
+func (r *pgRepository) FindCandidates(minRadiusMeters float64, limit int) ([]Candidate, error) {
+	query := `
+	SELECT
+		CASE WHEN COALESCE(b.counter_total_votes, 0) > COALESCE(a.counter_total_votes, 0) THEN b.incl_id ELSE a.incl_id END AS target_incl_id,
+		CASE WHEN COALESCE(b.counter_total_votes, 0) > COALESCE(a.counter_total_votes, 0) THEN a.incl_id ELSE b.incl_id END AS source_incl_id,
+		ST_Distance(a.center_location, b.center_location) AS distance
+	FROM incident_clusters a
+	JOIN incident_clusters b
+	  ON b.incl_id > a.incl_id
+	 AND b.category_code = a.category_code
+	 AND b.is_active = '1'
+	 AND b.end_time >= NOW()
+	 AND b.start_time <= a.end_time
+	 AND a.start_time <= b.end_time
+	LEFT JOIN incident_subcategories sa ON sa.insu_id = a.insu_id
+	LEFT JOIN incident_subcategories sb ON sb.insu_id = b.insu_id
+	WHERE a.is_active = '1'
+	  AND a.end_time >= NOW()
+	  AND ST_DWithin(a.center_location, b.center_location,
+		GREATEST(COALESCE(sa.default_circle_range, 0), COALESCE(sb.default_circle_range, 0), $1))
+	ORDER BY distance ASC
+	LIMIT $2;
+	`
+	rows, err := r.db.Query(query, minRadiusMeters, limit)
+	if err != nil {
+		return nil, fmt.Errorf("FindCandidates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []Candidate
+	for rows.Next() {
+		var c Candidate
+		if err := rows.Scan(&c.TargetInclId, &c.SourceInclId, &c.DistanceMeters); err != nil {
+			return nil, fmt.Errorf("scanning merge candidate: %w", err)
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// Merge mueve todo lo que cuelga de sourceInclId a targetInclId en una sola transacción,
+// suma los contadores del origen al destino (la confirmación oficial más antigua se conserva y su bonus cuenta una vez),
+// desactiva el origen y deja el registro de auditoría.
+func (r *pgRepository) Merge(targetInclId, sourceInclId int64, mergedBy *int64, reason string, distanceMeters *float64) (mergeId int64, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// Bloquear ambos clusters para que un voto o un merge concurrente no se cuele
+	var locked int
+	err = tx.QueryRow(`
+	SELECT COUNT(*) FROM (
+		SELECT incl_id FROM incident_clusters
+		WHERE incl_id IN ($1, $2) AND is_active = '1' AND merged_into_incl_id IS NULL
+		FOR UPDATE
+	) c`, targetInclId, sourceInclId).Scan(&locked)
+	if err != nil {
+		return 0, fmt.Errorf("locking clusters: %w", err)
+	}
+	if locked != 2 {
+		err = ErrNotMergeable
+		return 0, err
+	}
+
+	var targetConfirmed, sourceConfirmed sql.NullTime
+	err = tx.QueryRow(`
+	SELECT t.official_confirmed_at, s.official_confirmed_at
+	FROM incident_clusters t, incident_clusters s
+	WHERE t.incl_id = $1 AND s.incl_id = $2`, targetInclId, sourceInclId).Scan(&targetConfirmed, &sourceConfirmed)
+	if err != nil {
+		return 0, fmt.Errorf("reading official confirmations: %w", err)
+	}
+
+	var m Merge
+	if m.MovedReports, err = collectIDs(tx, `UPDATE incident_reports SET incl_id = $1 WHERE incl_id = $2 RETURNING inre_id`, targetInclId, sourceInclId); err != nil {
+		return 0, fmt.Errorf("moving reports: %w", err)
+	}
+	if m.MovedComments, err = collectIDs(tx, `UPDATE incident_comments SET incl_id = $1 WHERE incl_id = $2 RETURNING inco_id`, targetInclId, sourceInclId); err != nil {
+		return 0, fmt.Errorf("moving comments: %w", err)
+	}
+	// Si la cuenta ya seguía el destino, el save del origen sobra
+	if m.RemovedSaveAccounts, err = collectIDs(tx, `
+	DELETE FROM account_cluster_saved s
+	WHERE s.incl_id = $2
+	  AND EXISTS (SELECT 1 FROM account_cluster_saved t WHERE t.incl_id = $1 AND t.account_id = s.account_id)
+	RETURNING s.account_id`, targetInclId, sourceInclId); err != nil {
+		return 0, fmt.Errorf("removing duplicated saves: %w", err)
+	}
+	if m.MovedSaves, err = collectIDs(tx, `UPDATE account_cluster_saved SET incl_id = $1 WHERE incl_id = $2 RETURNING acs_id`, targetInclId, sourceInclId); err != nil {
+		return 0, fmt.Errorf("moving saves: %w", err)
+	}
+	if m.MovedHistory, err = collectIDs(tx, `UPDATE account_history SET incl_id = $1 WHERE incl_id = $2 RETURNING his_id`, targetInclId, sourceInclId); err != nil {
+		return 0, fmt.Errorf("moving history: %w", err)
+	}
+	if m.MovedNotifications, err = collectIDs(tx, `UPDATE notifications SET reference_id = $1 WHERE reference_id = $2 AND type = ANY($3) RETURNING noti_id`,
+		targetInclId, sourceInclId, pq.Array(clusterNotificationTypes)); err != nil {
+		return 0, fmt.Errorf("moving notifications: %w", err)
+	}
+	// Los vínculos oficiales se copian (no se mueven) para que el bot no vuelva a vincular el destino con el mismo report
+	if m.CopiedOfficialLinks, err = collectIDs(tx, `
+	INSERT INTO incident_cluster_official_links (incl_id, inre_id, source, distance_meters, time_diff_secs, match_score, created_at)
+	SELECT $1, inre_id, source, distance_meters, time_diff_secs, match_score, created_at
+	FROM incident_cluster_official_links
+	WHERE incl_id = $2
+	ON CONFLICT (incl_id, inre_id) DO NOTHING
+	RETURNING inol_id`, targetInclId, sourceInclId); err != nil {
+		return 0, fmt.Errorf("copying official links: %w", err)
+	}
+	if targetConfirmed.Valid {
+		m.TargetConfirmedAt = &targetConfirmed.Time
+	}
+
+	// Los votos viven en incident_reports.vote y ya se movieron con los reports. Si una cuenta votó en los dos
+	// clusters solo cuenta su voto más reciente: el resto se anula y su aporte se descuenta de la suma
+	var cleared voteTotals
+	if m.ClearedVotesTrue, m.ClearedVotesFalse, cleared, err = clearDuplicateVotes(tx, targetInclId, m.MovedReports); err != nil {
+		return 0, fmt.Errorf("clearing duplicated votes: %w", err)
+	}
+	// El bonus oficial ya va dentro de score_true de cada cluster confirmado: si ambos lo tienen, cuenta una vez
+	scoreTrueDiscount := cleared.ScoreTrue
+	if targetConfirmed.Valid && sourceConfirmed.Valid {
+		scoreTrueDiscount += common.OfficialConfirmationScore
+	}
+
+	_, err = tx.Exec(`
+	UPDATE incident_clusters t
+	SET
+		counter_total_votes       = GREATEST(COALESCE(t.counter_total_votes, 0)       + COALESCE(s.counter_total_votes, 0) - $3, 0),
+		counter_total_votes_true  = GREATEST(COALESCE(t.counter_total_votes_true, 0)  + COALESCE(s.counter_total_votes_true, 0) - $4, 0),
+		counter_total_votes_false = GREATEST(COALESCE(t.counter_total_votes_false, 0) + COALESCE(s.counter_total_votes_false, 0) - $5, 0),
+		counter_total_comments    = COALESCE(t.counter_total_comments, 0)    + COALESCE(s.counter_total_comments, 0),
+		counter_total_flags       = COALESCE(t.counter_total_flags, 0)       + COALESCE(s.counter_total_flags, 0),
+		counter_total_views       = COALESCE(t.counter_total_views, 0)       + COALESCE(s.counter_total_views, 0),
+		incident_count            = COALESCE(t.incident_count, 0)            + COALESCE(s.incident_count, 0),
+		score_true                = GREATEST(COALESCE(t.score_true, 0)  + COALESCE(s.score_true, 0) - $6, 0),
+		score_false               = GREATEST(COALESCE(t.score_false, 0) + COALESCE(s.score_false, 0) - $7, 0),
+		credibility               = GREATEST(COALESCE(t.score_true, 0) + COALESCE(s.score_true, 0) - $6, 0)
+									/ GREATEST(GREATEST(COALESCE(t.score_true, 0) + COALESCE(s.score_true, 0) - $6, 0) + GREATEST(COALESCE(t.score_false, 0) + COALESCE(s.score_false, 0) - $7, 0), 1)
+									* 10,
+		start_time                = LEAST(t.start_time, s.start_time),
+		end_time                  = GREATEST(t.end_time, s.end_time),
+		official_confirmed_at     = LEAST(t.official_confirmed_at, s.official_confirmed_at),
+		updated_at                = NOW()
+	FROM incident_clusters s
+	WHERE t.incl_id = $1 AND s.incl_id = $2`, targetInclId, sourceInclId,
+		cleared.Votes, cleared.VotesTrue, cleared.VotesFalse, scoreTrueDiscount, cleared.ScoreFalse)
+	if err != nil {
+		return 0, fmt.Errorf("merging counters: %w", err)
+	}
+
+	_, err = tx.Exec(`UPDATE incident_clusters SET is_active = '0', merged_into_incl_id = $1, updated_at = NOW() WHERE incl_id = $2`, targetInclId, sourceInclId)
+	if err != nil {
+		return 0, fmt.Errorf("deactivating source cluster: %w", err)
+	}
+
+	err = tx.QueryRow(`
+	INSERT INTO incident_cluster_merges (
+		target_incl_id, source_incl_id, merged_by, reason, distance_meters,
+		moved_reports, moved_comments, moved_saves, removed_save_accounts, moved_history, moved_notifications,
+		cleared_votes_true, cleared_votes_false, copied_official_links, target_official_confirmed_at
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	RETURNING merge_id`,
+		targetInclId, sourceInclId, mergedBy, reason, distanceMeters,
+		pq.Array(m.MovedReports), pq.Array(m.MovedComments), pq.Array(m.MovedSaves),
+		pq.Array(m.RemovedSaveAccounts), pq.Array(m.MovedHistory), pq.Array(m.MovedNotifications),
+		pq.Array(m.ClearedVotesTrue), pq.Array(m.ClearedVotesFalse), pq.Array(m.CopiedOfficialLinks), m.TargetConfirmedAt,
+	).Scan(&mergeId)
+	if err != nil {
+		return 0, fmt.Errorf("saving merge audit: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit merge: %w", err)
+	}
+	return mergeId, nil
+}
+
+// Split revierte un merge: devuelve al cluster origen exactamente los IDs registrados en la auditoría,
+// resta sus contadores del destino y lo reactiva. Lo que llegó al destino después del merge se queda ahí.
+func (r *pgRepository) Split(mergeId int64, splitBy int64) (m Merge, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return Merge{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	m, err = scanMerge(tx.QueryRow(selectMerge+` WHERE merge_id = $1 FOR UPDATE`, mergeId))
+	if err == sql.ErrNoRows {
+		err = ErrMergeNotFound
+		return Merge{}, err
+	}
+	if err != nil {
+		return Merge{}, err
+	}
+	if m.SplitAt != nil {
+		err = ErrAlreadySplit
+		return Merge{}, err
+	}
+
+	var mergedInto sql.NullInt64
+	var sourceConfirmed sql.NullTime
+	err = tx.QueryRow(`SELECT merged_into_incl_id, official_confirmed_at FROM incident_clusters WHERE incl_id = $1 FOR UPDATE`, m.SourceInclId).Scan(&mergedInto, &sourceConfirmed)
+	if err != nil {
+		return Merge{}, fmt.Errorf("locking source cluster: %w", err)
+	}
+	if !mergedInto.Valid || mergedInto.Int64 != m.TargetInclId {
+		err = ErrSourceReplaced
+		return Merge{}, err
+	}
+
+	moves := []struct {
+		query string
+		ids   []int64
+	}{
+		{`UPDATE incident_reports SET incl_id = $1 WHERE incl_id = $2 AND inre_id = ANY($3)`, m.MovedReports},
+		{`UPDATE incident_comments SET incl_id = $1 WHERE incl_id = $2 AND inco_id = ANY($3)`, m.MovedComments},
+		{`UPDATE account_cluster_saved SET incl_id = $1 WHERE incl_id = $2 AND acs_id = ANY($3)`, m.MovedSaves},
+		{`UPDATE account_history SET incl_id = $1 WHERE incl_id = $2 AND his_id = ANY($3)`, m.MovedHistory},
+		{`UPDATE notifications SET reference_id = $1 WHERE reference_id = $2 AND noti_id = ANY($3)`, m.MovedNotifications},
+	}
+	for _, mv := range moves {
+		if len(mv.ids) == 0 {
+			continue
+		}
+		if _, err = tx.Exec(mv.query, m.SourceInclId, m.TargetInclId, pq.Array(mv.ids)); err != nil {
+			return Merge{}, fmt.Errorf("splitting merge %d: %w", mergeId, err)
+		}
+	}
+
+	// Las copias de los vínculos oficiales sobran: los originales siguen en el origen
+	if len(m.CopiedOfficialLinks) > 0 {
+		_, err = tx.Exec(`DELETE FROM incident_cluster_official_links WHERE incl_id = $1 AND inol_id = ANY($2)`,
+			m.TargetInclId, pq.Array(m.CopiedOfficialLinks))
+		if err != nil {
+			return Merge{}, fmt.Errorf("removing copied official links: %w", err)
+		}
+	}
+
+	// Los votos anulados vuelven a contar: su aporte se devuelve al destino junto con la resta del origen
+	restored, err := restoreClearedVotes(tx, m.ClearedVotesTrue, m.ClearedVotesFalse)
+	if err != nil {
+		return Merge{}, fmt.Errorf("restoring cleared votes: %w", err)
+	}
+	// Si ambos estaban confirmados el merge descontó un bonus oficial: se devuelve al restar el origen
+	scoreTrueRefund := restored.ScoreTrue
+	if m.TargetConfirmedAt != nil && sourceConfirmed.Valid {
+		scoreTrueRefund += common.OfficialConfirmationScore
+	}
+
+	if len(m.RemovedSaveAccounts) > 0 {
+		_, err = tx.Exec(`INSERT INTO account_cluster_saved (account_id, incl_id) SELECT unnest($1::bigint[]), $2`,
+			pq.Array(m.RemovedSaveAccounts), m.SourceInclId)
+		if err != nil {
+			return Merge{}, fmt.Errorf("restoring saves: %w", err)
+		}
+	}
+
+	// El origen conserva sus contadores originales (no se tocaron al desactivarlo): se restan del destino
+	_, err = tx.Exec(`
+	UPDATE incident_clusters t
+	SET
+		counter_total_votes       = GREATEST(COALESCE(t.counter_total_votes, 0)       - COALESCE(s.counter_total_votes, 0) + $3, 0),
+		counter_total_votes_true  = GREATEST(COALESCE(t.counter_total_votes_true, 0)  - COALESCE(s.counter_total_votes_true, 0) + $4, 0),
+		counter_total_votes_false = GREATEST(COALESCE(t.counter_total_votes_false, 0) - COALESCE(s.counter_total_votes_false, 0) + $5, 0),
+		counter_total_comments    = GREATEST(COALESCE(t.counter_total_comments, 0)    - COALESCE(s.counter_total_comments, 0), 0),
+		counter_total_flags       = GREATEST(COALESCE(t.counter_total_flags, 0)       - COALESCE(s.counter_total_flags, 0), 0),
+		counter_total_views       = GREATEST(COALESCE(t.counter_total_views, 0)       - COALESCE(s.counter_total_views, 0), 0),
+		incident_count            = GREATEST(COALESCE(t.incident_count, 0)            - COALESCE(s.incident_count, 0), 1),
+		score_true                = GREATEST(COALESCE(t.score_true, 0)  - COALESCE(s.score_true, 0) + $6, 0),
+		score_false               = GREATEST(COALESCE(t.score_false, 0) - COALESCE(s.score_false, 0) + $7, 0),
+		credibility               = GREATEST(COALESCE(t.score_true, 0) - COALESCE(s.score_true, 0) + $6, 0)
+									/ GREATEST(GREATEST(COALESCE(t.score_true, 0) - COALESCE(s.score_true, 0) + $6, 0) + GREATEST(COALESCE(t.score_false, 0) - COALESCE(s.score_false, 0) + $7, 0), 1)
+									* 10,
+		official_confirmed_at     = $8,
+		updated_at                = NOW()
+	FROM incident_clusters s
+	WHERE t.incl_id = $1 AND s.incl_id = $2`, m.TargetInclId, m.SourceInclId,
+		restored.Votes, restored.VotesTrue, restored.VotesFalse, scoreTrueRefund, restored.ScoreFalse, m.TargetConfirmedAt)
+	if err != nil {
+		return Merge{}, fmt.Errorf("splitting counters: %w", err)
+	}
+
+	// Un vínculo oficial que llegó al destino después del merge no sumó bonus (ya estaba confirmado por el origen):
+	// si el destino se queda sin confirmación pero con vínculos propios, se confirma con el primero de ellos
+	_, err = tx.Exec(`
+	UPDATE incident_clusters c
+	SET official_confirmed_at = l.first_linked_at,
+		score_true = COALESCE(c.score_true, 0) + $2,
+		credibility = (COALESCE(c.score_true, 0) + $2)
+					/ GREATEST(COALESCE(c.score_true, 0) + $2 + COALESCE(c.score_false, 0), 1)
+					* 10
+	FROM (SELECT MIN(created_at) AS first_linked_at FROM incident_cluster_official_links WHERE incl_id = $1) l
+	WHERE c.incl_id = $1 AND c.official_confirmed_at IS NULL AND l.first_linked_at IS NOT NULL`,
+		m.TargetInclId, common.OfficialConfirmationScore)
+	if err != nil {
+		return Merge{}, fmt.Errorf("restoring official confirmation: %w", err)
+	}
+
+	// Solo se reactiva si todavía no venció: un cluster expirado vuelve como inactivo
+	_, err = tx.Exec(`
+	UPDATE incident_clusters
+	SET is_active = CASE WHEN end_time >= NOW() THEN '1' ELSE '0' END,
+		merged_into_incl_id = NULL,
+		updated_at = NOW()
+	WHERE incl_id = $1`, m.SourceInclId)
+	if err != nil {
+		return Merge{}, fmt.Errorf("reactivating source cluster: %w", err)
+	}
+
+	err = tx.QueryRow(`UPDATE incident_cluster_merges SET split_by = $1, split_at = NOW() WHERE merge_id = $2 RETURNING split_at`, splitBy, mergeId).Scan(&m.SplitAt)
+	if err != nil {
+		return Merge{}, fmt.Errorf("saving split audit: %w", err)
+	}
+	m.SplitBy = &splitBy
+
+	if err = tx.Commit(); err != nil {
+		return Merge{}, fmt.Errorf("failed to commit split: %w", err)
+	}
+	return m, nil
+}
+
+func (r *pgRepository) GetByID(mergeId int64) (Merge, error) {
+	m, err := scanMerge(r.db.QueryRow(selectMerge+` WHERE merge_id = $1`, mergeId))
+	if err == sql.ErrNoRows {
+		return Merge{}, ErrMergeNotFound
+	}
+	return m, err
+}
+
+// ListByCluster devuelve los merges donde el cluster fue origen o destino, del más reciente al más antiguo
+func (r *pgRepository) ListByCluster(inclId int64) ([]Merge, error) {
+	rows, err := r.db.Query(selectMerge+` WHERE target_incl_id = $1 OR source_incl_id = $1 ORDER BY created_at DESC`, inclId)
+	if err != nil {
+		return nil, fmt.Errorf("ListByCluster: %w", err)
+	}
+	defer rows.Close()
+
+	merges := []Merge{}
+	for rows.Next() {
+		m, err := scanMerge(rows)
+		if err != nil {
+			return nil, err
+		}
+		merges = append(merges, m)
+	}
+	return merges, rows.Err()
+}
+
+const selectMerge = `
+	SELECT merge_id, target_incl_id, source_incl_id, merged_by, reason, distance_meters,
+		moved_reports, moved_comments, moved_saves, removed_save_accounts, moved_history, moved_notifications,
+		cleared_votes_true, cleared_votes_false, copied_official_links, target_official_confirmed_at,
+		created_at, split_by, split_at
+	FROM incident_cluster_merges`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMerge(row rowScanner) (Merge, error) {
+	var m Merge
+	var mergedBy, splitBy sql.NullInt64
+	var distance sql.NullFloat64
+	var splitAt, targetConfirmed sql.NullTime
+	err := row.Scan(
+		&m.MergeId, &m.TargetInclId, &m.SourceInclId, &mergedBy, &m.Reason, &distance,
+		pq.Array(&m.MovedReports), pq.Array(&m.MovedComments), pq.Array(&m.MovedSaves),
+		pq.Array(&m.RemovedSaveAccounts), pq.Array(&m.MovedHistory), pq.Array(&m.MovedNotifications),
+		pq.Array(&m.ClearedVotesTrue), pq.Array(&m.ClearedVotesFalse), pq.Array(&m.CopiedOfficialLinks), &targetConfirmed,
+		&m.CreatedAt, &splitBy, &splitAt,
+	)
+	if err != nil {
+		return Merge{}, err
+	}
+	if mergedBy.Valid {
+		m.MergedBy = &mergedBy.Int64
+	}
+	if distance.Valid {
+		m.DistanceMeters = &distance.Float64
+	}
+	if splitBy.Valid {
+		m.SplitBy = &splitBy.Int64
+	}
+	if splitAt.Valid {
+		m.SplitAt = &splitAt.Time
+	}
+	if targetConfirmed.Valid {
+		m.TargetConfirmedAt = &targetConfirmed.Time
+	}
+	return m, nil
+}
+
+// collectIDs ejecuta un UPDATE/DELETE ... RETURNING <id> y devuelve los IDs afectados
+func collectIDs(tx *sql.Tx, query string, args ...interface{}) ([]int64, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// clearDuplicateVotes anula (vote = NULL) los votos de las cuentas que votaron tanto en un report movido como en uno
+// que ya era del destino, conservando solo el más reciente. Fija vote_credibility con la credibilidad que aportó
+// (la misma que usa GetAccountVote) y devuelve los reports anulados por valor de voto y la suma de su aporte.
+func clearDuplicateVotes(tx *sql.Tx, targetInclId int64, movedReports []int64) (clearedTrue, clearedFalse []int64, totals voteTotals, err error) {
+	clearedTrue, clearedFalse = []int64{}, []int64{}
+	if len(movedReports) == 0 {
+		return clearedTrue, clearedFalse, totals, nil
+	}
+
+	rows, err := tx.Query(`
+	WITH voted AS (
+		SELECT ir.inre_id, ir.account_id, ir.vote, ir.inre_id = ANY($2) AS moved,
+			COALESCE(ir.vote_credibility, a.credibility, 5) AS credibility,
+			ROW_NUMBER() OVER (PARTITION BY ir.account_id ORDER BY COALESCE(ir.vote_updated_at, ir.created_at) DESC, ir.inre_id DESC) AS rn
+		FROM incident_reports ir
+		LEFT JOIN account a ON a.account_id = ir.account_id
+		WHERE ir.incl_id = $1 AND ir.vote IS NOT NULL
+	),
+	duplicated AS (
+		SELECT account_id FROM voted GROUP BY account_id HAVING bool_or(moved) AND bool_or(NOT moved)
+	)
+	UPDATE incident_reports ir
+	SET vote = NULL, vote_credibility = v.credibility
+	FROM voted v
+	JOIN duplicated d ON d.account_id = v.account_id
+	WHERE ir.inre_id = v.inre_id AND v.rn > 1
+	RETURNING ir.inre_id, v.vote, v.credibility`, targetInclId, pq.Array(movedReports))
+	if err != nil {
+		return nil, nil, voteTotals{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var inreId, vote int64
+		var credibility float64
+		if err = rows.Scan(&inreId, &vote, &credibility); err != nil {
+			return nil, nil, voteTotals{}, err
+		}
+		totals.add(vote == 1, credibility)
+		if vote == 1 {
+			clearedTrue = append(clearedTrue, inreId)
+		} else {
+			clearedFalse = append(clearedFalse, inreId)
+		}
+	}
+	return clearedTrue, clearedFalse, totals, rows.Err()
+}
+
+// restoreClearedVotes devuelve su voto a los reports que anuló clearDuplicateVotes y suma lo que aportan
+func restoreClearedVotes(tx *sql.Tx, clearedTrue, clearedFalse []int64) (voteTotals, error) {
+	var totals voteTotals
+	for _, cleared := range []struct {
+		vote bool
+		ids  []int64
+	}{{true, clearedTrue}, {false, clearedFalse}} {
+		if len(cleared.ids) == 0 {
+			continue
+		}
+		rows, err := tx.Query(`UPDATE incident_reports SET vote = $1 WHERE inre_id = ANY($2) AND vote IS NULL RETURNING COALESCE(vote_credibility, 5)`,
+			dbtypes.BoolToInt(cleared.vote), pq.Array(cleared.ids))
+		if err != nil {
+			return voteTotals{}, err
+		}
+		for rows.Next() {
+			var credibility float64
+			if err := rows.Scan(&credibility); err != nil {
+				rows.Close()
+				return voteTotals{}, err
+			}
+			totals.add(cleared.vote, credibility)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return voteTotals{}, err
+		}
+	}
+	return totals, nil
+}
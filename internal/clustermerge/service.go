@@ -0,0 +1,101 @@
+package clustermerge
+
+import (
+	"log"
+)
+
+const (
+	// Radio mínimo de solapamiento aunque la subcategoría tenga un default_circle_range menor
+	minOverlapRadiusMeters = 100.0
+	// Máximo de merges por ejecución del cronjob
+	maxMergesPerRun = 50
+)
+
+// CenterRecomputer recalcula el centro de un cluster a partir de sus reports (newincident.Service)
+type CenterRecomputer interface {
+	RecomputeClusterCenter(inclId int64) error
+}
+
+type Service interface {
+	MergeOverlapping() (int, error)
+	Merge(targetInclId, sourceInclId int64, mergedBy int64) (int64, error)
+	Split(mergeId int64, splitBy int64) (Merge, error)
+	History(inclId int64) ([]Merge, error)
+}
+
+type service struct {
+	repo    Repository
+	centers CenterRecomputer
+}
+
+func NewService(repo Repository, centers CenterRecomputer) Service {
+	return &service{repo: repo, centers: centers}
+}
+
+// MergeOverlapping es el cronjob: fusiona los pares de clusters duplicados.
+// Un cluster participa como mucho en un merge por ejecución; las cadenas (A~B~C) se resuelven en la siguiente.
+func (s *service) MergeOverlapping() (int, error) {
+	candidates, err := s.repo.FindCandidates(minOverlapRadiusMeters, maxMergesPerRun)
+	if err != nil {
+		return 0, err
+	}
+
+	touched := map[int64]bool{}
+	merged := 0
+	for _, c := range candidates {
+		if touched[c.TargetInclId] || touched[c.SourceInclId] {
+			continue
+		}
+		touched[c.TargetInclId] = true
+		touched[c.SourceInclId] = true
+
+		distance := c.DistanceMeters
+		mergeId, err := s.repo.Merge(c.TargetInclId, c.SourceInclId, nil, ReasonAutoOverlap, &distance)
+		if err != nil {
+			log.Printf("clustermerge: Error merging cluster %d into %d: %v", c.SourceInclId, c.TargetInclId, err)
+			continue
+		}
+		log.Printf("clustermerge: Merged cluster %d into %d (%.0fm apart, merge_id=%d)", c.SourceInclId, c.TargetInclId, c.DistanceMeters, mergeId)
+		s.recomputeCenter(c.TargetInclId)
+		merged++
+	}
+	return merged, nil
+}
+
+// Merge fusiona manualmente dos clusters (moderador)
+func (s *service) Merge(targetInclId, sourceInclId int64, mergedBy int64) (int64, error) {
+	if targetInclId == sourceInclId {
+		return 0, ErrSameCluster
+	}
+	mergeId, err := s.repo.Merge(targetInclId, sourceInclId, &mergedBy, ReasonModerator, nil)
+	if err != nil {
+		return 0, err
+	}
+	s.recomputeCenter(targetInclId)
+	return mergeId, nil
+}
+
+// Split revierte un merge y recalcula el centro de ambos clusters
+func (s *service) Split(mergeId int64, splitBy int64) (Merge, error) {
+	m, err := s.repo.Split(mergeId, splitBy)
+	if err != nil {
+		return Merge{}, err
+	}
+	s.recomputeCenter(m.TargetInclId)
+	s.recomputeCenter(m.SourceInclId)
+	return m, nil
+}
+
+func (s *service) History(inclId int64) ([]Merge, error) {
+	return s.repo.ListByCluster(inclId)
+}
+
+// El merge ya está confirmado: un fallo aquí solo deja el centro anterior hasta el próximo report
+func (s *service) recomputeCenter(inclId int64) {
+	if s.centers == nil {
+		return
+	}
+	if err := s.centers.RecomputeClusterCenter(inclId); err != nil {
+		log.Printf("clustermerge: Error recomputing center for cluster %d: %v", inclId, err)
+	}
+}
@@ -0,0 +1,92 @@
+package clustermerge
+
+import (
+	"errors"
+	"testing"
+)
+
+type mockRepository struct {
+	candidates []Candidate
+	failSource int64
+	merges     [][2]int64 // {target, source}
+}
+
+func (m *mockRepository) FindCandidates(minRadiusMeters float64, limit int) ([]Candidate, error) {
+	return m.candidates, nil
+}
+func (m *mockRepository) Merge(targetInclId, sourceInclId int64, mergedBy *int64, reason string, distanceMeters *float64) (int64, error) {
+	if sourceInclId == m.failSource {
+		return 0, errors.New("db error")
+	}
+	m.merges = append(m.merges, [2]int64{targetInclId, sourceInclId})
+	return int64(len(m.merges)), nil
+}
+func (m *mockRepository) Split(mergeId int64, splitBy int64) (Merge, error) { return Merge{}, nil }
+func (m *mockRepository) GetByID(mergeId int64) (Merge, error)              { return Merge{}, nil }
+func (m *mockRepository) ListByCluster(inclId int64) ([]Merge, error)       { return nil, nil }
+
+type mockCenters struct {
+	recomputed []int64
+}
+
+func (m *mockCenters) RecomputeClusterCenter(inclId int64) error {
+	m.recomputed = append(m.recomputed, inclId)
+	return nil
+}
+
+func TestMergeOverlapping_OneMergePerClusterPerRun(t *testing.T) {
+	repo := &mockRepository{
+		candidates: []Candidate{
+			{TargetInclId: 1, SourceInclId: 2, DistanceMeters: 10},
+			{TargetInclId: 1, SourceInclId: 3, DistanceMeters: 20}, // 1 ya participó: siguiente ejecución
+			{TargetInclId: 2, SourceInclId: 4, DistanceMeters: 30}, // 2 ya fue fusionado
+			{TargetInclId: 5, SourceInclId: 6, DistanceMeters: 40},
+			{TargetInclId: 7, SourceInclId: 8, DistanceMeters: 50}, // falla en el repo
+		},
+		failSource: 8,
+	}
+	centers := &mockCenters{}
+
+	merged, err := NewService(repo, centers).MergeOverlapping()
+	if err != nil {
+		t.Fatalf("MergeOverlapping() error = %v", err)
+	}
+	if merged != 2 {
+		t.Errorf("merged = %d, want 2", merged)
+	}
+
+	want := [][2]int64{{1, 2}, {5, 6}}
+	if len(repo.merges) != len(want) {
+		t.Fatalf("merges = %v, want %v", repo.merges, want)
+	}
+	for i := range want {
+		if repo.merges[i] != want[i] {
+			t.Errorf("merge %d = %v, want %v", i, repo.merges[i], want[i])
+		}
+	}
+
+	if len(centers.recomputed) != 2 || centers.recomputed[0] != 1 || centers.recomputed[1] != 5 {
+		t.Errorf("recomputed centers = %v, want [1 5]", centers.recomputed)
+	}
+}
+
+func TestMerge_SameCluster(t *testing.T) {
+	repo := &mockRepository{}
+	if _, err := NewService(repo, nil).Merge(3, 3, 1); !errors.Is(err, ErrSameCluster) {
+		t.Errorf("Merge(3, 3) error = %v, want ErrSameCluster", err)
+	}
+	if len(repo.merges) != 0 {
+		t.Errorf("merges = %v, want none", repo.merges)
+	}
+}
+
+func TestVoteTotals_MatchesVoteContribution(t *testing.T) {
+	var totals voteTotals
+	totals.add(true, 8)
+	totals.add(false, 6)
+
+	want := voteTotals{Votes: 2, VotesTrue: 1, VotesFalse: 1, ScoreTrue: 8 + 4, ScoreFalse: 2 + 6}
+	if totals != want {
+		t.Errorf("totals = %+v, want %+v", totals, want)
+	}
+}
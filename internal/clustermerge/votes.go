@@ -0,0 +1,25 @@
+package clustermerge
+
+// voteTotals es lo que un conjunto de votos aporta a los contadores de incident_clusters.
+// Usa la misma fórmula que newincident.voteContribution: un voto true suma la credibilidad
+// del votante a score_true y (10 - credibilidad) a score_false; un voto false al revés.
+type voteTotals struct {
+	Votes      int
+	VotesTrue  int
+	VotesFalse int
+	ScoreTrue  float64
+	ScoreFalse float64
+}
+
+func (t *voteTotals) add(vote bool, credibility float64) {
+	t.Votes++
+	if vote {
+		t.VotesTrue++
+		t.ScoreTrue += credibility
+		t.ScoreFalse += 10 - credibility
+		return
+	}
+	t.VotesFalse++
+	t.ScoreTrue += 10 - credibility
+	t.ScoreFalse += credibility
+}
@@ -0,0 +1,236 @@
+package clusterquery
+
+import (
+	"alertly/internal/cronjobs/cjbot_creator"
+	"alertly/internal/pagination"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// metersPerDegreeLat se usa para el bbox de pre-filtro de los radios (usa los índices de lat/lng antes del ST_DWithin)
+const metersPerDegreeLat = 111320.0
+
+// Builder arma el WHERE, el orden y los parámetros numerados ($1, $2...) de una consulta sobre incident_clusters.
+// Es el mismo para /cluster/query, getclustersbylocation, getclusterbyradius y los reels.
+//
+//	b := clusterquery.NewBuilder("c").Apply(filter)
+//	query := "SELECT ... FROM incident_clusters c WHERE " + b.SQL() + b.OrderBy(filter.Sort, filter.Origin) + b.Limit(n)
+//	rows, err := db.Query(query, b.Params()...)
+type Builder struct {
+	alias       string
+	conds       []string
+	params      []interface{}
+	distanceArg string // ST_Distance ya armado, para no repetir los parámetros del origen
+}
+
+// NewBuilder recibe el alias de incident_clusters en la consulta y los parámetros que ya use la consulta
+// antes del WHERE (el Builder sigue la numeración desde ahí)
+func NewBuilder(alias string, params ...interface{}) *Builder {
+	return &Builder{alias: alias, params: params}
+}
+
+// Arg agrega un parámetro y devuelve su placeholder
+func (b *Builder) Arg(v interface{}) string {
+	b.params = append(b.params, v)
+	return fmt.Sprintf("$%d", len(b.params))
+}
+
+// Where agrega una condición propia de la consulta (usar Arg para los valores)
+func (b *Builder) Where(cond string) *Builder {
+	b.conds = append(b.conds, cond)
+	return b
+}
+
+func (b *Builder) col(name string) string {
+	return b.alias + "." + name
+}
+
+func (b *Builder) in(col string, values []interface{}) {
+	placeholders := make([]string, len(values))
+	for i, v := range values {
+		placeholders[i] = b.Arg(v)
+	}
+	b.Where(b.col(col) + " IN (" + strings.Join(placeholders, ",") + ")")
+}
+
+// Apply agrega las condiciones del filtro
+func (b *Builder) Apply(f Filter) *Builder {
+	switch f.Status {
+	// Los bloqueados por moderación quedan inactivos pero no se muestran como expirados
+	case StatusExpired:
+		b.Where(fmt.Sprintf("COALESCE(%s, '0') <> '1' AND %s IS NULL AND %s IS NULL", b.col("is_active"), b.col("merged_into_incl_id"), b.col("blocked_at")))
+	case StatusAll:
+		b.Where(fmt.Sprintf("%s IS NULL AND %s IS NULL", b.col("merged_into_incl_id"), b.col("blocked_at")))
+	case StatusAny:
+	default:
+		b.Where(b.col("is_active") + " = '1'")
+	}
+
+	if f.Sort == SortDistance {
+		b.Where(b.col("center_location") + " IS NOT NULL")
+	}
+	if f.BBox != nil {
+		b.Where(fmt.Sprintf("%s BETWEEN %s AND %s", b.col("center_latitude"), b.Arg(f.BBox.MinLatitude), b.Arg(f.BBox.MaxLatitude)))
+		b.Where(fmt.Sprintf("%s BETWEEN %s AND %s", b.col("center_longitude"), b.Arg(f.BBox.MinLongitude), b.Arg(f.BBox.MaxLongitude)))
+	}
+	if f.Center != nil {
+		// ✅ Bounding box primero: reduce las filas que llegan al ST_DWithin
+		box := radiusBBox(f.Center)
+		b.Where(fmt.Sprintf("%s BETWEEN %s AND %s", b.col("center_latitude"), b.Arg(box.MinLatitude), b.Arg(box.MaxLatitude)))
+		b.Where(fmt.Sprintf("%s BETWEEN %s AND %s", b.col("center_longitude"), b.Arg(box.MinLongitude), b.Arg(box.MaxLongitude)))
+		b.Where(fmt.Sprintf("ST_DWithin(%s, ST_MakePoint(%s, %s)::geography, %s)", b.col("center_location"),
+			b.Arg(f.Center.Longitude), b.Arg(f.Center.Latitude), b.Arg(f.Center.Radius)))
+	}
+	if f.Neighbourhood > 0 {
+		// Subquery escalar: se evalúa una vez y ST_Covers puede usar el índice GiST de center_location
+		b.Where(fmt.Sprintf("ST_Covers((SELECT n.boundary FROM neighbourhoods n WHERE n.nbhd_id = %s), %s)",
+			b.Arg(f.Neighbourhood), b.col("center_location")))
+	}
+	if f.Polygon != nil {
+		box := f.Polygon.BBox
+		b.Where(fmt.Sprintf("%s BETWEEN %s AND %s", b.col("center_latitude"), b.Arg(box.MinLatitude), b.Arg(box.MaxLatitude)))
+		b.Where(fmt.Sprintf("%s BETWEEN %s AND %s", b.col("center_longitude"), b.Arg(box.MinLongitude), b.Arg(box.MaxLongitude)))
+		b.Where(fmt.Sprintf("ST_Covers(ST_SetSRID(ST_GeomFromGeoJSON(%s), 4326)::geography, %s)",
+			b.Arg(f.Polygon.GeoJSON), b.col("center_location")))
+	}
+
+	if len(f.Categories) > 0 {
+		b.in("category_code", stringArgs(f.Categories))
+	}
+	if len(f.Subcategories) > 0 {
+		b.in("subcategory_code", stringArgs(f.Subcategories))
+	}
+	if len(f.InsuIDs) > 0 {
+		values := make([]interface{}, len(f.InsuIDs))
+		for i, id := range f.InsuIDs {
+			values[i] = id
+		}
+		b.in("insu_id", values)
+	}
+	if f.MinCredibility != nil {
+		b.Where(fmt.Sprintf("COALESCE(%s, 0) >= %s", b.col("credibility"), b.Arg(*f.MinCredibility)))
+	}
+
+	switch f.Source {
+	case SourceBot:
+		b.Where(fmt.Sprintf("%s = %s", b.col("account_id"), b.Arg(cjbot_creator.BOT_USER_ID)))
+	case SourceUser:
+		b.Where(fmt.Sprintf("%s <> %s", b.col("account_id"), b.Arg(cjbot_creator.BOT_USER_ID)))
+	}
+
+	// Vigentes en algún momento de la ventana (mismo criterio que el mapa)
+	if f.To != nil {
+		b.Where(fmt.Sprintf("%s <= %s::timestamp", b.col("start_time"), b.Arg(*f.To)))
+	}
+	if f.From != nil {
+		b.Where(fmt.Sprintf("%s >= %s::timestamp", b.col("end_time"), b.Arg(*f.From)))
+	}
+	if f.TimeOfDay != nil {
+		local := fmt.Sprintf("((%s AT TIME ZONE 'UTC') AT TIME ZONE %s)::time", b.col("created_at"), b.Arg(f.TimeOfDay.Location))
+		from, to := b.Arg(minuteOfDay(f.TimeOfDay.FromMinute)), b.Arg(minuteOfDay(f.TimeOfDay.ToMinute))
+		if f.TimeOfDay.FromMinute <= f.TimeOfDay.ToMinute {
+			b.Where(fmt.Sprintf("%s >= %s::time AND %s < %s::time", local, from, local, to))
+		} else {
+			b.Where(fmt.Sprintf("(%s >= %s::time OR %s < %s::time)", local, from, local, to))
+		}
+	}
+	return b
+}
+
+// SortExpr es la expresión por la que ordena sort. Para distance hace falta origin.
+func (b *Builder) SortExpr(sort Sort, origin *Point) string {
+	switch sort {
+	case SortCredibility:
+		return fmt.Sprintf("COALESCE(%s, 0)::float8", b.col("credibility"))
+	case SortVotes:
+		return fmt.Sprintf("COALESCE(%s, 0)::float8", b.col("counter_total_votes"))
+	case SortDistance:
+		return b.Distance(origin)
+	default:
+		return b.col("created_at")
+	}
+}
+
+// Distance es la distancia en metros desde origin (los parámetros se agregan una sola vez)
+func (b *Builder) Distance(origin *Point) string {
+	if b.distanceArg == "" {
+		b.distanceArg = fmt.Sprintf("ST_Distance(%s, ST_MakePoint(%s, %s)::geography)", b.col("center_location"),
+			b.Arg(origin.Longitude), b.Arg(origin.Latitude))
+	}
+	return b.distanceArg
+}
+
+// After agrega la condición de keyset para seguir después de c (nil = primera página)
+func (b *Builder) After(sort Sort, origin *Point, c *pagination.SortedCursor) *Builder {
+	if c == nil {
+		return b
+	}
+	var value interface{} = c.Value
+	if sort.byTime() {
+		value = c.CreatedAt
+	}
+	op := "<"
+	if sort.ascending() {
+		op = ">"
+	}
+	return b.Where(fmt.Sprintf("(%s, %s) %s (%s, %s)", b.SortExpr(sort, origin), b.col("incl_id"), op, b.Arg(value), b.Arg(c.ID)))
+}
+
+// OrderBy devuelve el ORDER BY de sort; incl_id desempata para que el keyset sea estable
+func (b *Builder) OrderBy(sort Sort, origin *Point) string {
+	dir := "DESC"
+	if sort.ascending() {
+		dir = "ASC"
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, %s %s", b.SortExpr(sort, origin), dir, b.col("incl_id"), dir)
+}
+
+func (b *Builder) Limit(n int) string {
+	return " LIMIT " + b.Arg(n)
+}
+
+// SQL devuelve las condiciones unidas con AND
+func (b *Builder) SQL() string {
+	if len(b.conds) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(b.conds, "\n      AND ")
+}
+
+func (b *Builder) Params() []interface{} {
+	return b.params
+}
+
+func (s Sort) ascending() bool {
+	return s == SortOldest || s == SortDistance
+}
+
+func (s Sort) byTime() bool {
+	return s == SortRecent || s == SortOldest || s == ""
+}
+
+// radiusBBox es el rectángulo que contiene el círculo; los grados de longitud se achican con la latitud
+func radiusBBox(c *Center) BBox {
+	latDelta := c.Radius / metersPerDegreeLat
+	lngDelta := 180.0
+	if cos := math.Cos(c.Latitude * math.Pi / 180); cos > 0.01 {
+		lngDelta = math.Min(c.Radius/(metersPerDegreeLat*cos), 180)
+	}
+	return BBox{
+		MinLatitude: c.Latitude - latDelta, MaxLatitude: c.Latitude + latDelta,
+		MinLongitude: c.Longitude - lngDelta, MaxLongitude: c.Longitude + lngDelta,
+	}
+}
+
+func minuteOfDay(m int) string {
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
+
+func stringArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
+}
@@ -0,0 +1,81 @@
+package clusterquery
+
+import (
+	"alertly/internal/pagination"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuilderApply(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	cred := 6.5
+	f := Filter{
+		Status:         StatusExpired,
+		Center:         &Center{Point: Point{Latitude: 43.65, Longitude: -79.38}, Radius: 1000},
+		Categories:     []string{"crime", "fire_incident"},
+		Subcategories:  []string{"robbery"},
+		InsuIDs:        []int{3},
+		MinCredibility: &cred,
+		Source:         SourceBot,
+		From:           &from,
+		TimeOfDay:      &TimeOfDay{FromMinute: 22 * 60, ToMinute: 4 * 60, Location: "America/Toronto"},
+	}
+	b := NewBuilder("c", "existing").Apply(f)
+	sql := b.SQL()
+
+	for _, want := range []string{
+		"COALESCE(c.is_active, '0') <> '1' AND c.merged_into_incl_id IS NULL AND c.blocked_at IS NULL",
+		"ST_DWithin(c.center_location, ST_MakePoint($6, $7)::geography, $8)",
+		"c.category_code IN ($9,$10)",
+		"c.subcategory_code IN ($11)",
+		"c.insu_id IN ($12)",
+		"COALESCE(c.credibility, 0) >= $13",
+		"c.account_id = $14",
+		"c.end_time >= $15::timestamp",
+		"(((c.created_at AT TIME ZONE 'UTC') AT TIME ZONE $16)::time >= $17::time OR",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL missing %q:\n%s", want, sql)
+		}
+	}
+	if got := len(b.Params()); got != 18 {
+		t.Errorf("expected 18 params, got %d", got)
+	}
+	if b.Params()[0] != "existing" || b.Params()[16] != "22:00" || b.Params()[17] != "04:00" {
+		t.Errorf("unexpected params: %v", b.Params())
+	}
+}
+
+func TestBuilderKeysetAndOrder(t *testing.T) {
+	origin := &Point{Latitude: 43.65, Longitude: -79.38}
+	b := NewBuilder("c").After(SortDistance, origin, &pagination.SortedCursor{Cursor: pagination.Cursor{ID: 9}, Sort: "distance", Value: 250.5})
+	order := b.OrderBy(SortDistance, origin)
+
+	if want := "(ST_Distance(c.center_location, ST_MakePoint($1, $2)::geography), c.incl_id) > ($3, $4)"; b.SQL() != want {
+		t.Errorf("keyset = %s, want %s", b.SQL(), want)
+	}
+	if !strings.Contains(order, "ST_MakePoint($1, $2)") || !strings.HasSuffix(order, "c.incl_id ASC") {
+		t.Errorf("order should reuse the origin params and sort ascending: %s", order)
+	}
+
+	recent := NewBuilder("t1").After(SortRecent, nil, &pagination.SortedCursor{Cursor: pagination.Cursor{CreatedAt: time.Now(), ID: 1}})
+	if !strings.Contains(recent.SQL(), "(t1.created_at, t1.incl_id) < ($1, $2)") {
+		t.Errorf("recent keyset = %s", recent.SQL())
+	}
+	if _, ok := recent.Params()[0].(time.Time); !ok {
+		t.Errorf("time sorts should compare against the cursor time, got %T", recent.Params()[0])
+	}
+}
+
+func TestRadiusBBoxWidensWithLatitude(t *testing.T) {
+	equator := radiusBBox(&Center{Point: Point{Latitude: 0}, Radius: 10000})
+	toronto := radiusBBox(&Center{Point: Point{Latitude: 43.65}, Radius: 10000})
+	if toronto.MaxLongitude-toronto.MinLongitude <= equator.MaxLongitude-equator.MinLongitude {
+		t.Errorf("a degree of longitude is shorter away from the equator, the box should be wider")
+	}
+	if math.Abs((toronto.MaxLatitude-toronto.MinLatitude)-(equator.MaxLatitude-equator.MinLatitude)) > 1e-9 {
+		t.Errorf("latitude delta should not depend on latitude")
+	}
+}
@@ -0,0 +1,77 @@
+package clusterquery
+
+import (
+	"alertly/internal/database"
+	"alertly/internal/response"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Find busca clusters con un filtro tipado: GET con query string o POST con el mismo filtro en JSON.
+// Reemplaza a las rutas con todos los filtros en el path (/cluster/getbylocation, /cluster/getbyradius).
+func Find(c *gin.Context) {
+	filter, ok := bindFilter(c)
+	if !ok {
+		return
+	}
+
+	service := NewService(NewRepository(database.DB))
+	result, err := service.Query(filter)
+	if errors.Is(err, ErrNeighbourhood) {
+		response.Send(c, http.StatusNotFound, true, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		log.Printf("Error querying clusters: %v", err)
+		response.Send(c, http.StatusInternalServerError, true, "We couldn’t load the incidents. Please try again later.", nil)
+		return
+	}
+	response.Send(c, http.StatusOK, false, "Success", result)
+}
+
+// GetStats devuelve totales y conteo por categoría del mismo filtro que Find (bbox, radio, barrio o polígono)
+func GetStats(c *gin.Context) {
+	filter, ok := bindFilter(c)
+	if !ok {
+		return
+	}
+
+	service := NewService(NewRepository(database.DB))
+	result, err := service.Stats(filter)
+	if errors.Is(err, ErrNeighbourhood) {
+		response.Send(c, http.StatusNotFound, true, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		log.Printf("Error counting clusters: %v", err)
+		response.Send(c, http.StatusInternalServerError, true, "We couldn’t load the stats. Please try again later.", nil)
+		return
+	}
+	response.Send(c, http.StatusOK, false, "Success", result)
+}
+
+// bindFilter lee la Query (JSON en POST, query string en GET) y la valida; si falla ya respondió 400
+func bindFilter(c *gin.Context) (Filter, bool) {
+	var q Query
+	var err error
+	if c.Request.Method == http.MethodPost {
+		err = c.ShouldBindJSON(&q)
+	} else {
+		err = c.ShouldBindQuery(&q)
+	}
+	if err != nil {
+		log.Printf("Error al bindear filtro: %v", err)
+		response.Send(c, http.StatusBadRequest, true, "Invalid filter. Please check and try again.", nil)
+		return Filter{}, false
+	}
+
+	filter, err := Parse(q)
+	if err != nil {
+		response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+		return Filter{}, false
+	}
+	return filter, true
+}
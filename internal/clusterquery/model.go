@@ -0,0 +1,179 @@
+package clusterquery
+
+import (
+	"alertly/internal/pagination"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Query es el filtro que recibe /cluster/query, como query string (GET) o JSON (POST).
+// En query string las listas se mandan repetidas (categories=crime&categories=fire_incident) o separadas por coma.
+type Query struct {
+	// Área: bbox, centro + radio, un barrio (neighbourhood_id) o un polígono GeoJSON (solo POST).
+	// latitude/longitude sin radius solo sirven para ordenar por distancia.
+	MinLatitude     *float64        `json:"min_latitude" form:"min_latitude"`
+	MaxLatitude     *float64        `json:"max_latitude" form:"max_latitude"`
+	MinLongitude    *float64        `json:"min_longitude" form:"min_longitude"`
+	MaxLongitude    *float64        `json:"max_longitude" form:"max_longitude"`
+	Latitude        *float64        `json:"latitude" form:"latitude"`
+	Longitude       *float64        `json:"longitude" form:"longitude"`
+	Radius          float64         `json:"radius" form:"radius"` // metros
+	NeighbourhoodID int64           `json:"neighbourhood_id" form:"neighbourhood_id"`
+	Polygon         json.RawMessage `json:"polygon" form:"-"` // GeoJSON Polygon o MultiPolygon
+
+	Categories     []string `json:"categories" form:"categories"`       // category_code
+	Subcategories  []string `json:"subcategories" form:"subcategories"` // subcategory_code
+	InsuIDs        IDList   `json:"insu_ids" form:"insu_ids"`
+	MinCredibility *float64 `json:"min_credibility" form:"min_credibility"` // 0-10
+	Source         string   `json:"source" form:"source"`                   // all (default), user, bot
+	Status         string   `json:"status" form:"status"`                   // active (default), expired, all
+
+	// Ventana de tiempo: clusters vigentes en algún momento entre from y to (RFC3339 o YYYY-MM-DD)
+	From string `json:"from" form:"from"`
+	To   string `json:"to" form:"to"`
+	// Franja horaria de created_at (HH:MM) en timezone; si from > to cruza la medianoche (22:00-04:00)
+	TimeOfDayFrom string `json:"time_of_day_from" form:"time_of_day_from"`
+	TimeOfDayTo   string `json:"time_of_day_to" form:"time_of_day_to"`
+	Timezone      string `json:"timezone" form:"timezone"` // IANA, por defecto UTC
+
+	Sort   string `json:"sort" form:"sort"` // recent (default), oldest, credibility, votes, distance
+	Cursor string `json:"cursor" form:"cursor"`
+	Limit  int    `json:"limit" form:"limit"`
+}
+
+// IDList son ids que llegan como en las otras listas: repetidos o separados por coma en query string,
+// y en JSON como números o strings ([3, 4] o ["3,4"]). Parse los separa con SplitList y los valida.
+type IDList []string
+
+func (l *IDList) UnmarshalJSON(b []byte) error {
+	var values []interface{}
+	if err := json.Unmarshal(b, &values); err != nil {
+		return err
+	}
+	out := make(IDList, 0, len(values))
+	for _, v := range values {
+		switch v := v.(type) {
+		case float64:
+			out = append(out, strconv.FormatFloat(v, 'f', -1, 64))
+		case string:
+			out = append(out, v)
+		default:
+			return fmt.Errorf("ids must be numbers or strings, got %v", v)
+		}
+	}
+	*l = out
+	return nil
+}
+
+type Source string
+
+const (
+	SourceAll  Source = "all"
+	SourceUser Source = "user"
+	SourceBot  Source = "bot"
+)
+
+type Status string
+
+const (
+	StatusActive  Status = "active"
+	StatusExpired Status = "expired"
+	StatusAll     Status = "all"
+	StatusAny     Status = "any" // sin filtro de estado, incluye fusionados (uso interno, p.ej. clustersync)
+)
+
+type Sort string
+
+const (
+	SortRecent      Sort = "recent"
+	SortOldest      Sort = "oldest"
+	SortCredibility Sort = "credibility"
+	SortVotes       Sort = "votes"
+	SortDistance    Sort = "distance"
+)
+
+// Filter es el filtro ya validado que entiende el Builder. Los campos vacíos no filtran.
+type Filter struct {
+	BBox           *BBox
+	Center         *Center
+	Neighbourhood  int64 // nbhd_id de neighbourhoods
+	Polygon        *Polygon
+	Origin         *Point // desde dónde se mide la distancia (Center o latitude/longitude sueltos)
+	Categories     []string
+	Subcategories  []string
+	InsuIDs        []int
+	MinCredibility *float64
+	Source         Source
+	Status         Status
+	From           *time.Time
+	To             *time.Time
+	TimeOfDay      *TimeOfDay
+	Sort           Sort
+	After          *pagination.SortedCursor
+	Limit          int
+}
+
+type BBox struct {
+	MinLatitude  float64
+	MaxLatitude  float64
+	MinLongitude float64
+	MaxLongitude float64
+}
+
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+type Center struct {
+	Point
+	Radius float64 // metros
+}
+
+// TimeOfDay es una franja horaria en minutos desde la medianoche, en la zona Location
+type TimeOfDay struct {
+	FromMinute int
+	ToMinute   int
+	Location   string
+}
+
+type Cluster struct {
+	InclId          int64     `json:"incl_id"`
+	Latitude        float64   `json:"latitude"`
+	Longitude       float64   `json:"longitude"`
+	InsuId          int64     `json:"insu_id"`
+	CategoryCode    string    `json:"category_code"`
+	SubcategoryCode string    `json:"subcategory_code"`
+	SubcategoryName string    `json:"subcategory_name"`
+	Credibility     float64   `json:"credibility"`
+	TotalVotes      int64     `json:"counter_total_votes"`
+	IsActive        bool      `json:"is_active"`
+	IsOfficial      bool      `json:"is_official"` // creado por el bot a partir de una fuente oficial
+	CreatedAt       time.Time `json:"created_at"`
+	DistanceMeters  *float64  `json:"distance_meters,omitempty"` // solo si se envió latitude/longitude
+	sortValue       float64   // valor de la columna de orden, para el cursor
+}
+
+// Stats resume los clusters de un filtro (sort, cursor y limit no aplican)
+type Stats struct {
+	Total          int64           `json:"total"`
+	Active         int64           `json:"active"`
+	Official       int64           `json:"official"`
+	AvgCredibility float64         `json:"avg_credibility"`
+	TotalVotes     int64           `json:"total_votes"`
+	ByCategory     []CategoryCount `json:"by_category"` // de mayor a menor
+}
+
+type CategoryCount struct {
+	CategoryCode string `json:"category_code"`
+	Count        int64  `json:"count"`
+}
+
+// ClustersPage: si HasMore es true se pide la siguiente página con el mismo filtro y cursor=NextCursor
+type ClustersPage struct {
+	Clusters   []Cluster `json:"clusters"`
+	HasMore    bool      `json:"has_more"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
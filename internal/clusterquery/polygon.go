@@ -0,0 +1,82 @@
+package clusterquery
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+)
+
+const maxPolygonVertices = 5000
+
+var ErrInvalidPolygon = errors.New("polygon must be a GeoJSON Polygon or MultiPolygon with closed rings of at least 4 [longitude, latitude] positions (up to 5000)")
+
+// Polygon es un área dibujada por el cliente, ya validada.
+// GeoJSON se pasa tal cual a ST_GeomFromGeoJSON; BBox sirve de pre-filtro con los índices de lat/lng.
+type Polygon struct {
+	GeoJSON string
+	BBox    BBox
+}
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// ParsePolygon valida una geometría GeoJSON (Polygon o MultiPolygon) enviada por el cliente
+func ParsePolygon(raw json.RawMessage) (*Polygon, error) {
+	var g geoJSONGeometry
+	if err := json.Unmarshal(raw, &g); err != nil {
+		return nil, ErrInvalidPolygon
+	}
+
+	var polygons [][][][]float64
+	switch g.Type {
+	case "Polygon":
+		var rings [][][]float64
+		if err := json.Unmarshal(g.Coordinates, &rings); err != nil {
+			return nil, ErrInvalidPolygon
+		}
+		polygons = [][][][]float64{rings}
+	case "MultiPolygon":
+		if err := json.Unmarshal(g.Coordinates, &polygons); err != nil {
+			return nil, ErrInvalidPolygon
+		}
+	default:
+		return nil, ErrInvalidPolygon
+	}
+
+	box := BBox{MinLatitude: math.Inf(1), MaxLatitude: math.Inf(-1), MinLongitude: math.Inf(1), MaxLongitude: math.Inf(-1)}
+	vertices := 0
+	for _, rings := range polygons {
+		if len(rings) == 0 {
+			return nil, ErrInvalidPolygon
+		}
+		for _, ring := range rings {
+			if len(ring) < 4 {
+				return nil, ErrInvalidPolygon
+			}
+			for _, pos := range ring {
+				if len(pos) < 2 || pos[0] < -180 || pos[0] > 180 || pos[1] < -90 || pos[1] > 90 {
+					return nil, ErrInvalidPolygon
+				}
+				box.MinLongitude, box.MaxLongitude = math.Min(box.MinLongitude, pos[0]), math.Max(box.MaxLongitude, pos[0])
+				box.MinLatitude, box.MaxLatitude = math.Min(box.MinLatitude, pos[1]), math.Max(box.MaxLatitude, pos[1])
+			}
+			first, last := ring[0], ring[len(ring)-1]
+			if first[0] != last[0] || first[1] != last[1] {
+				return nil, ErrInvalidPolygon
+			}
+			vertices += len(ring)
+		}
+	}
+	if len(polygons) == 0 || vertices > maxPolygonVertices {
+		return nil, ErrInvalidPolygon
+	}
+
+	// Se vuelve a serializar solo la geometría: el cliente puede mandar campos de más (bbox, crs...)
+	normalized, err := json.Marshal(geoJSONGeometry{Type: g.Type, Coordinates: g.Coordinates})
+	if err != nil {
+		return nil, ErrInvalidPolygon
+	}
+	return &Polygon{GeoJSON: string(normalized), BBox: box}, nil
+}
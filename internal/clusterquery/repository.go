@@ -0,0 +1,127 @@
+package clusterquery
+
+import (
+	"alertly/internal/cronjobs/cjbot_creator"
+	"database/sql"
+	"fmt"
+)
+
+type Repository interface {
+	Query(f Filter, limit int) ([]Cluster, error)
+	CountByCategory(f Filter) ([]categoryStats, error)
+	NeighbourhoodExists(nbhdID int64) (bool, error)
+}
+
+type pgRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &pgRepository{db: db}
+}
+
+// Query devuelve hasta limit clusters del filtro en el orden de f.Sort, empezando después de f.After
+func (r *pgRepository) Query(f Filter, limit int) ([]Cluster, error) {
+	b := NewBuilder("c", cjbot_creator.BOT_USER_ID).Apply(f).After(f.Sort, f.Origin, f.After)
+
+	distance := "NULL::float8"
+	if f.Origin != nil {
+		distance = b.Distance(f.Origin)
+	}
+	sortValue := "0::float8"
+	if !f.Sort.byTime() {
+		sortValue = b.SortExpr(f.Sort, f.Origin)
+	}
+
+	query := fmt.Sprintf(`
+    SELECT
+        c.incl_id,
+        c.center_latitude,
+        c.center_longitude,
+        c.insu_id,
+        COALESCE(c.category_code, ''),
+        COALESCE(c.subcategory_code, ''),
+        COALESCE(c.subcategory_name, ''),
+        COALESCE(c.credibility, 0),
+        COALESCE(c.counter_total_votes, 0),
+        c.is_active = '1',
+        COALESCE(c.account_id, 0) = $1,
+        c.created_at,
+        %s,
+        %s
+    FROM incident_clusters c
+    WHERE %s`, distance, sortValue, b.SQL())
+	query += b.OrderBy(f.Sort, f.Origin) + b.Limit(limit)
+
+	rows, err := r.db.Query(query, b.Params()...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying clusters: %w", err)
+	}
+	defer rows.Close()
+
+	clusters := make([]Cluster, 0, limit)
+	for rows.Next() {
+		var c Cluster
+		var distanceMeters sql.NullFloat64
+		if err := rows.Scan(&c.InclId, &c.Latitude, &c.Longitude, &c.InsuId, &c.CategoryCode, &c.SubcategoryCode,
+			&c.SubcategoryName, &c.Credibility, &c.TotalVotes, &c.IsActive, &c.IsOfficial, &c.CreatedAt,
+			&distanceMeters, &c.sortValue); err != nil {
+			return nil, fmt.Errorf("error scanning cluster: %w", err)
+		}
+		if distanceMeters.Valid {
+			c.DistanceMeters = &distanceMeters.Float64
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters, rows.Err()
+}
+
+// categoryStats son los agregados de una categoría; el service los suma para el total
+type categoryStats struct {
+	CategoryCount
+	Active         int64
+	Official       int64
+	CredibilitySum float64
+	Votes          int64
+}
+
+// CountByCategory agrupa los clusters del filtro por category_code
+func (r *pgRepository) CountByCategory(f Filter) ([]categoryStats, error) {
+	b := NewBuilder("c", cjbot_creator.BOT_USER_ID).Apply(f)
+	query := fmt.Sprintf(`
+    SELECT
+        COALESCE(c.category_code, ''),
+        COUNT(*),
+        COUNT(*) FILTER (WHERE c.is_active = '1'),
+        COUNT(*) FILTER (WHERE COALESCE(c.account_id, 0) = $1),
+        COALESCE(SUM(c.credibility), 0),
+        COALESCE(SUM(c.counter_total_votes), 0)
+    FROM incident_clusters c
+    WHERE %s
+    GROUP BY 1
+    ORDER BY 2 DESC, 1`, b.SQL())
+
+	rows, err := r.db.Query(query, b.Params()...)
+	if err != nil {
+		return nil, fmt.Errorf("error counting clusters: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []categoryStats
+	for rows.Next() {
+		var s categoryStats
+		if err := rows.Scan(&s.CategoryCode, &s.Count, &s.Active, &s.Official, &s.CredibilitySum, &s.Votes); err != nil {
+			return nil, fmt.Errorf("error scanning counts: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+func (r *pgRepository) NeighbourhoodExists(nbhdID int64) (bool, error) {
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM neighbourhoods WHERE nbhd_id = $1)`, nbhdID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("checking neighbourhood %d: %w", nbhdID, err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,296 @@
+package clusterquery
+
+import (
+	"alertly/internal/pagination"
+	"bytes"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // time_of_day con timezone no depende del tzdata del servidor
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 500
+	maxRadius       = 50000 // metros
+)
+
+var (
+	ErrInvalidArea      = errors.New("send one area: a bbox (min_latitude, max_latitude, min_longitude, max_longitude), latitude, longitude and radius (up to 50000 m), a neighbourhood_id or a polygon")
+	ErrInvalidFilter    = errors.New("invalid filter: check source, status, insu_ids, min_credibility and sort")
+	ErrInvalidTimeRange = errors.New("from and to must be RFC3339 or YYYY-MM-DD with from before to; time_of_day_from/time_of_day_to must be HH:MM and timezone a valid IANA zone")
+	ErrDistanceSort     = errors.New("sort=distance needs latitude and longitude")
+	ErrNeighbourhood    = errors.New("neighbourhood not found")
+)
+
+type Service interface {
+	Query(f Filter) (ClustersPage, error)
+	Stats(f Filter) (Stats, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) Query(f Filter) (ClustersPage, error) {
+	if err := s.checkNeighbourhood(f); err != nil {
+		return ClustersPage{Clusters: []Cluster{}}, err
+	}
+	// Se pide una fila de más para saber si hay otra página
+	clusters, err := s.repo.Query(f, f.Limit+1)
+	if err != nil {
+		return ClustersPage{Clusters: []Cluster{}}, err
+	}
+	page := ClustersPage{Clusters: clusters}
+	if len(clusters) > f.Limit {
+		page.Clusters = clusters[:f.Limit]
+		page.HasMore = true
+		last := page.Clusters[f.Limit-1]
+		page.NextCursor = pagination.SortedCursor{
+			Cursor: pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.InclId},
+			Sort:   string(f.Sort),
+			Value:  last.sortValue,
+		}.Encode()
+	}
+	return page, nil
+}
+
+func (s *service) Stats(f Filter) (Stats, error) {
+	stats := Stats{ByCategory: []CategoryCount{}}
+	if err := s.checkNeighbourhood(f); err != nil {
+		return stats, err
+	}
+	rows, err := s.repo.CountByCategory(f)
+	if err != nil {
+		return stats, err
+	}
+	var credibility float64
+	for _, row := range rows {
+		stats.Total += row.Count
+		stats.Active += row.Active
+		stats.Official += row.Official
+		stats.TotalVotes += row.Votes
+		credibility += row.CredibilitySum
+		stats.ByCategory = append(stats.ByCategory, row.CategoryCount)
+	}
+	if stats.Total > 0 {
+		stats.AvgCredibility = math.Round(credibility/float64(stats.Total)*100) / 100
+	}
+	return stats, nil
+}
+
+// checkNeighbourhood: un neighbourhood_id inexistente es un error y no un área sin clusters
+func (s *service) checkNeighbourhood(f Filter) error {
+	if f.Neighbourhood == 0 {
+		return nil
+	}
+	exists, err := s.repo.NeighbourhoodExists(f.Neighbourhood)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNeighbourhood
+	}
+	return nil
+}
+
+// Parse valida la Query y arma el Filter
+func Parse(q Query) (Filter, error) {
+	f := Filter{
+		Categories:    SplitList(q.Categories...),
+		Subcategories: SplitList(q.Subcategories...),
+		Source:        Source(strings.ToLower(q.Source)),
+		Status:        Status(strings.ToLower(q.Status)),
+		Sort:          Sort(strings.ToLower(q.Sort)),
+		Limit:         pagination.Limit(q.Limit, defaultPageSize, maxPageSize),
+	}
+	if f.Source == "" {
+		f.Source = SourceAll
+	}
+	if f.Status == "" {
+		f.Status = StatusActive
+	}
+	if f.Sort == "" {
+		f.Sort = SortRecent
+	}
+	if (f.Source != SourceAll && f.Source != SourceUser && f.Source != SourceBot) ||
+		(f.Status != StatusActive && f.Status != StatusExpired && f.Status != StatusAll) ||
+		(f.Sort != SortRecent && f.Sort != SortOldest && f.Sort != SortCredibility && f.Sort != SortVotes && f.Sort != SortDistance) {
+		return Filter{}, ErrInvalidFilter
+	}
+	for _, v := range SplitList(q.InsuIDs...) {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			return Filter{}, ErrInvalidFilter
+		}
+		f.InsuIDs = append(f.InsuIDs, id)
+	}
+	if q.MinCredibility != nil {
+		if *q.MinCredibility < 0 || *q.MinCredibility > 10 {
+			return Filter{}, ErrInvalidFilter
+		}
+		f.MinCredibility = q.MinCredibility
+	}
+
+	if err := parseArea(q, &f); err != nil {
+		return Filter{}, err
+	}
+	if f.Sort == SortDistance && f.Origin == nil {
+		return Filter{}, ErrDistanceSort
+	}
+	if err := parseTime(q, &f); err != nil {
+		return Filter{}, err
+	}
+
+	after, err := pagination.DecodeSorted(q.Cursor, string(f.Sort))
+	if err != nil {
+		return Filter{}, err
+	}
+	f.After = after
+	return f, nil
+}
+
+func parseArea(q Query, f *Filter) error {
+	bboxFields := countSet(q.MinLatitude, q.MaxLatitude, q.MinLongitude, q.MaxLongitude)
+	pointFields := countSet(q.Latitude, q.Longitude)
+	if (bboxFields != 0 && bboxFields != 4) || pointFields == 1 {
+		return ErrInvalidArea
+	}
+	if pointFields == 2 {
+		if *q.Latitude < -90 || *q.Latitude > 90 || *q.Longitude < -180 || *q.Longitude > 180 {
+			return ErrInvalidArea
+		}
+		f.Origin = &Point{Latitude: *q.Latitude, Longitude: *q.Longitude}
+	}
+
+	areas := 0
+	// "polygon": null es lo mismo que no mandarlo
+	hasPolygon := len(q.Polygon) > 0 && string(bytes.TrimSpace(q.Polygon)) != "null"
+	for _, set := range []bool{bboxFields == 4, q.Radius != 0, q.NeighbourhoodID != 0, hasPolygon} {
+		if set {
+			areas++
+		}
+	}
+	if areas != 1 {
+		return ErrInvalidArea
+	}
+
+	switch {
+	case q.NeighbourhoodID != 0:
+		if q.NeighbourhoodID < 0 {
+			return ErrInvalidArea
+		}
+		f.Neighbourhood = q.NeighbourhoodID
+	case hasPolygon:
+		polygon, err := ParsePolygon(q.Polygon)
+		if err != nil {
+			return err
+		}
+		f.Polygon = polygon
+	case bboxFields == 4:
+		b := BBox{MinLatitude: *q.MinLatitude, MaxLatitude: *q.MaxLatitude, MinLongitude: *q.MinLongitude, MaxLongitude: *q.MaxLongitude}
+		if b.MinLatitude >= b.MaxLatitude || b.MinLongitude >= b.MaxLongitude {
+			return ErrInvalidArea
+		}
+		f.BBox = &b
+	case f.Origin != nil && q.Radius > 0 && q.Radius <= maxRadius:
+		f.Center = &Center{Point: *f.Origin, Radius: q.Radius}
+	default:
+		return ErrInvalidArea
+	}
+	return nil
+}
+
+func parseTime(q Query, f *Filter) error {
+	from, to, err := ParseWindow(q.From, q.To)
+	if err != nil {
+		return err
+	}
+	f.From, f.To = from, to
+
+	if q.TimeOfDayFrom == "" && q.TimeOfDayTo == "" {
+		return nil
+	}
+	fromMinute, errFrom := parseMinuteOfDay(q.TimeOfDayFrom)
+	toMinute, errTo := parseMinuteOfDay(q.TimeOfDayTo)
+	location := q.Timezone
+	if location == "" {
+		location = "UTC"
+	}
+	if _, err := time.LoadLocation(location); errFrom != nil || errTo != nil || err != nil || fromMinute == toMinute {
+		return ErrInvalidTimeRange
+	}
+	f.TimeOfDay = &TimeOfDay{FromMinute: fromMinute, ToMinute: toMinute, Location: location}
+	return nil
+}
+
+// ParseWindow lee los extremos de la ventana de tiempo (RFC3339 o YYYY-MM-DD, vacío = sin límite).
+// Si to es una fecha sola incluye todo ese día, como en las rutas del mapa.
+func ParseWindow(fromValue, toValue string) (from, to *time.Time, err error) {
+	if fromValue != "" {
+		t, err := parseTimestamp(fromValue)
+		if err != nil {
+			return nil, nil, ErrInvalidTimeRange
+		}
+		from = &t
+	}
+	if toValue != "" {
+		t, err := parseTimestamp(toValue)
+		if err != nil {
+			return nil, nil, ErrInvalidTimeRange
+		}
+		if len(toValue) == len("2006-01-02") {
+			t = t.Add(24 * time.Hour)
+		}
+		if from != nil && t.Before(*from) {
+			return nil, nil, ErrInvalidTimeRange
+		}
+		to = &t
+	}
+	return from, to, nil
+}
+
+// parseTimestamp acepta RFC3339 o YYYY-MM-DD y devuelve la hora en UTC, como se guarda en la base
+func parseTimestamp(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+func parseMinuteOfDay(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// SplitList acepta valores repetidos y separados por coma, sin vacíos
+func SplitList(values ...string) []string {
+	var out []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
+func countSet(values ...*float64) int {
+	n := 0
+	for _, v := range values {
+		if v != nil {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,191 @@
+package clusterquery
+
+import (
+	"alertly/internal/pagination"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func fp(v float64) *float64 { return &v }
+
+func TestParse(t *testing.T) {
+	q := Query{
+		MinLatitude: fp(43.5), MaxLatitude: fp(43.9), MinLongitude: fp(-79.7), MaxLongitude: fp(-79.1),
+		Categories: []string{"crime,fire_incident", " traffic_accident "},
+		Source:     "BOT", From: "2026-10-01", To: "2026-10-17",
+		TimeOfDayFrom: "22:00", TimeOfDayTo: "04:30", Timezone: "America/Toronto",
+	}
+	f, err := Parse(q)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if f.BBox == nil || f.Center != nil || len(f.Categories) != 3 || f.Source != SourceBot ||
+		f.Status != StatusActive || f.Sort != SortRecent || f.Limit != defaultPageSize {
+		t.Errorf("unexpected filter: %+v", f)
+	}
+	if !f.To.Equal(time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("a date-only to should include the whole day, got %v", f.To)
+	}
+	if f.TimeOfDay == nil || f.TimeOfDay.FromMinute != 22*60 || f.TimeOfDay.ToMinute != 4*60+30 {
+		t.Errorf("unexpected time of day: %+v", f.TimeOfDay)
+	}
+
+	radius := Query{Latitude: fp(43.65), Longitude: fp(-79.38), Radius: 2000, Sort: "distance", From: "2026-10-01T08:00:00-04:00"}
+	f, err = Parse(radius)
+	if err != nil || f.Center == nil || f.Origin == nil || f.Sort != SortDistance || f.From.Hour() != 12 {
+		t.Fatalf("radius: got %+v, %v", f, err)
+	}
+
+	invalid := []struct {
+		name string
+		q    Query
+		want error
+	}{
+		{"no area", Query{}, ErrInvalidArea},
+		{"partial bbox", Query{MinLatitude: fp(1)}, ErrInvalidArea},
+		{"radius too large", Query{Latitude: fp(43.65), Longitude: fp(-79.38), Radius: maxRadius + 1}, ErrInvalidArea},
+		{"bad source", func() Query { c := q; c.Source = "robot"; return c }(), ErrInvalidFilter},
+		{"bad credibility", func() Query { c := q; c.MinCredibility = fp(11); return c }(), ErrInvalidFilter},
+		{"distance without origin", func() Query { c := q; c.Sort = "distance"; return c }(), ErrDistanceSort},
+		{"reversed window", func() Query { c := q; c.From, c.To = "2026-10-17", "2026-10-01"; return c }(), ErrInvalidTimeRange},
+		{"bad time of day", func() Query { c := q; c.TimeOfDayTo = "25:00"; return c }(), ErrInvalidTimeRange},
+		{"bad timezone", func() Query { c := q; c.Timezone = "Mars/Olympus"; return c }(), ErrInvalidTimeRange},
+		{"cursor from another sort", func() Query {
+			c := q
+			c.Sort = "votes"
+			c.Cursor = pagination.SortedCursor{Cursor: pagination.Cursor{CreatedAt: time.Now(), ID: 1}, Sort: "recent"}.Encode()
+			return c
+		}(), pagination.ErrInvalidCursor},
+		{"bad insu_ids", func() Query { c := q; c.InsuIDs = IDList{"3,abc"}; return c }(), ErrInvalidFilter},
+	}
+	for _, tt := range invalid {
+		if _, err := Parse(tt.q); err != tt.want {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+type fakeRepo struct {
+	clusters []Cluster
+	counts   []categoryStats
+}
+
+func (r *fakeRepo) Query(f Filter, limit int) ([]Cluster, error) {
+	return r.clusters, nil
+}
+
+func (r *fakeRepo) CountByCategory(f Filter) ([]categoryStats, error) {
+	return r.counts, nil
+}
+
+func (r *fakeRepo) NeighbourhoodExists(nbhdID int64) (bool, error) {
+	return nbhdID == 1, nil
+}
+
+func TestUnknownNeighbourhood(t *testing.T) {
+	s := NewService(&fakeRepo{})
+	if _, err := s.Query(Filter{Neighbourhood: 99, Limit: 10}); err != ErrNeighbourhood {
+		t.Errorf("Query: expected ErrNeighbourhood, got %v", err)
+	}
+	if _, err := s.Stats(Filter{Neighbourhood: 99}); err != ErrNeighbourhood {
+		t.Errorf("Stats: expected ErrNeighbourhood, got %v", err)
+	}
+	if _, err := s.Query(Filter{Neighbourhood: 1, Limit: 10}); err != nil {
+		t.Errorf("Query with a known neighbourhood: %v", err)
+	}
+}
+
+func TestParseNullPolygon(t *testing.T) {
+	var q Query
+	if err := json.Unmarshal([]byte(`{"neighbourhood_id":1,"polygon":null}`), &q); err != nil {
+		t.Fatal(err)
+	}
+	if f, err := Parse(q); err != nil || f.Neighbourhood != 1 || f.Polygon != nil {
+		t.Errorf("null polygon should be ignored: %+v, %v", f, err)
+	}
+}
+
+func TestQueryPageCursor(t *testing.T) {
+	repo := &fakeRepo{clusters: []Cluster{{InclId: 3, sortValue: 9.5}, {InclId: 2, sortValue: 8}, {InclId: 1, sortValue: 7}}}
+	page, err := NewService(repo).Query(Filter{Sort: SortCredibility, Limit: 2})
+	if err != nil || len(page.Clusters) != 2 || !page.HasMore {
+		t.Fatalf("unexpected page: %+v, %v", page, err)
+	}
+
+	c, err := pagination.DecodeSorted(page.NextCursor, string(SortCredibility))
+	if err != nil || c.ID != 2 || c.Value != 8 {
+		t.Errorf("next cursor = %+v, %v", c, err)
+	}
+}
+
+func TestStatsTotals(t *testing.T) {
+	repo := &fakeRepo{counts: []categoryStats{
+		{CategoryCount: CategoryCount{CategoryCode: "crime", Count: 3}, Active: 2, Official: 1, CredibilitySum: 21, Votes: 10},
+		{CategoryCount: CategoryCount{CategoryCode: "fire_incident", Count: 1}, Active: 1, CredibilitySum: 8, Votes: 2},
+	}}
+	stats, err := NewService(repo).Stats(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Total != 4 || stats.Active != 3 || stats.Official != 1 || stats.TotalVotes != 12 || stats.AvgCredibility != 7.25 {
+		t.Errorf("unexpected totals: %+v", stats)
+	}
+	if len(stats.ByCategory) != 2 || stats.ByCategory[0].CategoryCode != "crime" {
+		t.Errorf("unexpected by_category: %+v", stats.ByCategory)
+	}
+
+	empty, _ := NewService(&fakeRepo{}).Stats(Filter{})
+	if empty.ByCategory == nil || empty.AvgCredibility != 0 {
+		t.Errorf("empty stats should have an empty list and no average: %+v", empty)
+	}
+}
+
+func TestParseNeighbourhoodAndPolygon(t *testing.T) {
+	f, err := Parse(Query{NeighbourhoodID: 12, Latitude: fp(43.65), Longitude: fp(-79.38), Sort: "distance"})
+	if err != nil || f.Neighbourhood != 12 || f.Origin == nil {
+		t.Fatalf("neighbourhood with origin: %+v, %v", f, err)
+	}
+
+	polygon := json.RawMessage(`{"type":"Polygon","coordinates":[[[-79.4,43.6],[-79.3,43.6],[-79.3,43.7],[-79.4,43.6]]]}`)
+	f, err = Parse(Query{Polygon: polygon})
+	if err != nil || f.Polygon == nil {
+		t.Fatalf("polygon: %+v, %v", f, err)
+	}
+	if f.Polygon.BBox != (BBox{MinLatitude: 43.6, MaxLatitude: 43.7, MinLongitude: -79.4, MaxLongitude: -79.3}) {
+		t.Errorf("polygon bbox = %+v", f.Polygon.BBox)
+	}
+
+	if _, err := Parse(Query{NeighbourhoodID: 12, Polygon: polygon}); err != ErrInvalidArea {
+		t.Errorf("two areas: expected ErrInvalidArea, got %v", err)
+	}
+	if _, err := Parse(Query{NeighbourhoodID: 12, Latitude: fp(43.65), Longitude: fp(-79.38), Radius: 500}); err != ErrInvalidArea {
+		t.Errorf("neighbourhood and radius: expected ErrInvalidArea, got %v", err)
+	}
+}
+
+func TestParsePolygonRejects(t *testing.T) {
+	for _, raw := range []string{
+		`{"type":"Point","coordinates":[-79.4,43.6]}`,
+		`{"type":"Polygon","coordinates":[[[-79.4,43.6],[-79.3,43.6],[-79.4,43.6]]]}`,              // menos de 4 posiciones
+		`{"type":"Polygon","coordinates":[[[-79.4,43.6],[-79.3,43.6],[-79.3,43.7],[-79.4,43.7]]]}`, // anillo sin cerrar
+		`{"type":"Polygon","coordinates":[[[43.6,-179.4],[43.6,-79.3],[43.7,-79.3],[43.6,-179.4]]]}`,
+		`{"type":"MultiPolygon","coordinates":[]}`,
+		`not json`,
+	} {
+		if _, err := ParsePolygon(json.RawMessage(raw)); err != ErrInvalidPolygon {
+			t.Errorf("%s: expected ErrInvalidPolygon, got %v", raw, err)
+		}
+	}
+}
+
+func TestInsuIDsFromJSONAndQueryString(t *testing.T) {
+	var q Query
+	if err := json.Unmarshal([]byte(`{"min_latitude":43,"max_latitude":44,"min_longitude":-80,"max_longitude":-79,"insu_ids":[3,"4,5"]}`), &q); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Parse(q)
+	if err != nil || len(f.InsuIDs) != 3 || f.InsuIDs[0] != 3 || f.InsuIDs[2] != 5 {
+		t.Errorf("insu_ids = %v, %v", f.InsuIDs, err)
+	}
+}
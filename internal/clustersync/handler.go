@@ -0,0 +1,42 @@
+package clustersync
+
+import (
+	"alertly/internal/database"
+	"alertly/internal/response"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Sync devuelve los clusters del área creados, actualizados, expirados o bloqueados desde sync_token.
+// Con If-None-Match igual al ETag anterior responde 304 sin leer los clusters.
+func Sync(c *gin.Context) {
+	var inputs Inputs
+	if err := c.ShouldBindQuery(&inputs); err != nil {
+		log.Printf("Error en query params: %v", err)
+		response.Send(c, http.StatusBadRequest, true, "min_latitude, max_latitude, min_longitude and max_longitude are required.", nil)
+		return
+	}
+
+	service := NewService(NewRepository(database.DB))
+	delta, etag, notModified, err := service.Sync(inputs, c.GetHeader("If-None-Match"))
+	if err != nil {
+		if errors.Is(err, ErrInvalidSyncToken) || errors.Is(err, ErrInvalidArea) {
+			response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+			return
+		}
+		log.Printf("Error syncing clusters: %v", err)
+		response.Send(c, http.StatusInternalServerError, true, "We couldn’t update the map. Please try again later.", nil)
+		return
+	}
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if notModified {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	response.Send(c, http.StatusOK, false, "Success", delta)
+}
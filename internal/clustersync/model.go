@@ -0,0 +1,60 @@
+package clustersync
+
+import "time"
+
+// Tipos de cambio que recibe el cliente
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeExpired = "expired" // inactivo o fusionado en otro (merged_into_incl_id): el cliente lo quita del mapa
+	ChangeBlocked = "blocked" // todos sus reportes fueron rechazados por moderación: el cliente lo quita del mapa
+)
+
+type Inputs struct {
+	MinLatitude  float64  `form:"min_latitude" binding:"required"`
+	MaxLatitude  float64  `form:"max_latitude" binding:"required"`
+	MinLongitude float64  `form:"min_longitude" binding:"required"`
+	MaxLongitude float64  `form:"max_longitude" binding:"required"`
+	Categories   []string `form:"categories"`
+	SyncToken    string   `form:"sync_token"` // sync_token de la respuesta anterior; vacío = sync completo
+}
+
+// Change es un cluster que cambió desde el sync token
+type Change struct {
+	Type             string    `json:"type"`
+	InclId           int64     `json:"incl_id"`
+	Latitude         float64   `json:"latitude"`
+	Longitude        float64   `json:"longitude"`
+	InsuId           int64     `json:"insu_id"`
+	CategoryCode     string    `json:"category_code"`
+	SubcategoryCode  string    `json:"subcategory_code"`
+	Credibility      float64   `json:"credibility"`
+	IsOfficial       bool      `json:"is_official"`
+	MergedIntoInclId *int64    `json:"merged_into_incl_id,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// Delta es la respuesta de /cluster/sync.
+// FullSync = true: Changes trae todos los clusters activos del área y el cliente reemplaza lo que tenía
+// (primer sync, token vencido o demasiados cambios). Truncated = el área tiene más de los que se enviaron.
+type Delta struct {
+	SyncToken string   `json:"sync_token"`
+	FullSync  bool     `json:"full_sync"`
+	Truncated bool     `json:"truncated"`
+	Changes   []Change `json:"changes"`
+}
+
+// changeRow es una fila de incident_clusters con lo necesario para clasificar el cambio
+type changeRow struct {
+	Change
+	IsActive  bool
+	BlockedAt *time.Time
+}
+
+// Stats resume las filas que devolvería el sync, para el ETag
+type Stats struct {
+	ServerTime  time.Time // LOCALTIMESTAMP de la base, misma referencia que updated_at
+	Count       int
+	LastUpdated *time.Time
+}
@@ -0,0 +1,103 @@
+package clustersync
+
+import (
+	"alertly/internal/clusterquery"
+	"alertly/internal/cronjobs/cjbot_creator"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type Repository interface {
+	GetStats(area clusterquery.Filter, since *time.Time) (Stats, error)
+	GetChanges(area clusterquery.Filter, since *time.Time, limit int) ([]changeRow, error)
+}
+
+type pgRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &pgRepository{db: db}
+}
+
+// changesFilter: sin since son los clusters activos del área (sync completo); con since, todo lo que
+// cambió después, en cualquier estado
+func changesFilter(area clusterquery.Filter, since *time.Time) *clusterquery.Builder {
+	if since == nil {
+		area.Status = clusterquery.StatusActive
+		return clusterquery.NewBuilder("c").Apply(area)
+	}
+	area.Status = clusterquery.StatusAny
+	b := clusterquery.NewBuilder("c").Apply(area)
+	return b.Where(fmt.Sprintf("c.updated_at > %s::timestamp", b.Arg(*since)))
+}
+
+// GetStats cuenta las filas del sync sin traerlas: alcanza para responder 304 si nada cambió
+func (r *pgRepository) GetStats(area clusterquery.Filter, since *time.Time) (Stats, error) {
+	b := changesFilter(area, since)
+	var stats Stats
+	var last sql.NullTime
+	err := r.db.QueryRow(`
+    SELECT LOCALTIMESTAMP, COUNT(*), MAX(c.updated_at)
+    FROM incident_clusters c
+    WHERE `+b.SQL(), b.Params()...).Scan(&stats.ServerTime, &stats.Count, &last)
+	if err != nil {
+		return Stats{}, fmt.Errorf("error reading sync stats: %w", err)
+	}
+	if last.Valid {
+		stats.LastUpdated = &last.Time
+	}
+	return stats, nil
+}
+
+// GetChanges devuelve hasta limit clusters del sync, del cambio más viejo al más nuevo
+func (r *pgRepository) GetChanges(area clusterquery.Filter, since *time.Time, limit int) ([]changeRow, error) {
+	b := changesFilter(area, since)
+	bot := b.Arg(cjbot_creator.BOT_USER_ID)
+	query := fmt.Sprintf(`
+    SELECT
+        c.incl_id,
+        c.center_latitude,
+        c.center_longitude,
+        c.insu_id,
+        COALESCE(c.category_code, ''),
+        COALESCE(c.subcategory_code, ''),
+        COALESCE(c.credibility, 0),
+        COALESCE(c.account_id, 0) = %s,
+        c.merged_into_incl_id,
+        COALESCE(c.created_at, c.updated_at),
+        c.updated_at,
+        c.is_active = '1',
+        c.blocked_at
+    FROM incident_clusters c
+    WHERE %s
+    ORDER BY c.updated_at ASC, c.incl_id ASC`, bot, b.SQL())
+	query += b.Limit(limit)
+
+	rows, err := r.db.Query(query, b.Params()...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying sync changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []changeRow
+	for rows.Next() {
+		var row changeRow
+		var mergedInto sql.NullInt64
+		var blockedAt sql.NullTime
+		if err := rows.Scan(&row.InclId, &row.Latitude, &row.Longitude, &row.InsuId, &row.CategoryCode,
+			&row.SubcategoryCode, &row.Credibility, &row.IsOfficial, &mergedInto, &row.CreatedAt, &row.UpdatedAt,
+			&row.IsActive, &blockedAt); err != nil {
+			return nil, fmt.Errorf("error scanning sync change: %w", err)
+		}
+		if mergedInto.Valid {
+			row.MergedIntoInclId = &mergedInto.Int64
+		}
+		if blockedAt.Valid {
+			row.BlockedAt = &blockedAt.Time
+		}
+		changes = append(changes, row)
+	}
+	return changes, rows.Err()
+}
@@ -0,0 +1,148 @@
+package clustersync
+
+import (
+	"alertly/internal/clusterquery"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxChanges = 2000
+	// El token se emite un poco antes de la hora del servidor: un UPDATE que arrancó antes y commiteó
+	// después tiene updated_at anterior al token y si no se perdería. Los repetidos el cliente los pisa.
+	syncOverlap = 30 * time.Second
+	// Tokens más viejos piden un sync completo
+	maxTokenAge = 7 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidSyncToken = errors.New("invalid sync_token, start a full sync without it")
+	ErrInvalidArea      = errors.New("min_latitude must be below max_latitude and min_longitude below max_longitude")
+)
+
+type Service interface {
+	// Sync devuelve los cambios del área y el ETag de la respuesta. notModified = el ETag coincide con
+	// ifNoneMatch y no hace falta mandar el cuerpo.
+	Sync(in Inputs, ifNoneMatch string) (delta Delta, etag string, notModified bool, err error)
+}
+
+type service struct {
+	repo Repository
+	now  func() time.Time
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo, now: time.Now}
+}
+
+func (s *service) Sync(in Inputs, ifNoneMatch string) (Delta, string, bool, error) {
+	if in.MinLatitude >= in.MaxLatitude || in.MinLongitude >= in.MaxLongitude {
+		return Delta{}, "", false, ErrInvalidArea
+	}
+	since, err := decodeSyncToken(in.SyncToken)
+	if err != nil {
+		return Delta{}, "", false, err
+	}
+	if since != nil && s.now().UTC().Sub(*since) > maxTokenAge {
+		since = nil
+	}
+
+	area := clusterquery.Filter{
+		BBox: &clusterquery.BBox{
+			MinLatitude: in.MinLatitude, MaxLatitude: in.MaxLatitude,
+			MinLongitude: in.MinLongitude, MaxLongitude: in.MaxLongitude,
+		},
+		Categories: clusterquery.SplitList(in.Categories...),
+	}
+
+	stats, err := s.repo.GetStats(area, since)
+	if err != nil {
+		return Delta{}, "", false, err
+	}
+	// Demasiados cambios: conviene recargar todo
+	if since != nil && stats.Count > maxChanges {
+		since = nil
+		if stats, err = s.repo.GetStats(area, nil); err != nil {
+			return Delta{}, "", false, err
+		}
+	}
+
+	etag := syncETag(area, since, stats)
+	if ifNoneMatch == etag {
+		return Delta{}, etag, true, nil
+	}
+
+	rows, err := s.repo.GetChanges(area, since, maxChanges)
+	if err != nil {
+		return Delta{}, "", false, err
+	}
+
+	delta := Delta{FullSync: since == nil, Truncated: since == nil && stats.Count > maxChanges, Changes: make([]Change, 0, len(rows))}
+	for _, row := range rows {
+		change := row.Change
+		change.Type = classify(row, since)
+		delta.Changes = append(delta.Changes, change)
+	}
+
+	// Sin cambios se devuelve el mismo token: la URL no cambia y el próximo pedido puede ser un 304
+	if since != nil && len(rows) == 0 {
+		delta.SyncToken = in.SyncToken
+	} else {
+		delta.SyncToken = encodeSyncToken(stats.ServerTime.Add(-syncOverlap))
+	}
+	return delta, etag, false, nil
+}
+
+// classify decide qué le pasó al cluster desde since (nil = sync completo: todo es nuevo para el cliente)
+func classify(row changeRow, since *time.Time) string {
+	switch {
+	case row.BlockedAt != nil:
+		return ChangeBlocked
+	case !row.IsActive:
+		return ChangeExpired
+	case since == nil || row.CreatedAt.After(*since):
+		return ChangeCreated
+	default:
+		return ChangeUpdated
+	}
+}
+
+// syncETag cambia si cambia el filtro, el token o alguna fila que entra en el sync
+func syncETag(area clusterquery.Filter, since *time.Time, stats Stats) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%v|%s|%d", *area.BBox, strings.Join(area.Categories, ","), stats.Count)
+	if since != nil {
+		fmt.Fprintf(h, "|%d", since.UnixMicro())
+	}
+	if stats.LastUpdated != nil {
+		fmt.Fprintf(h, "|%d", stats.LastUpdated.UnixMicro())
+	}
+	return fmt.Sprintf("\"%x\"", h.Sum64())
+}
+
+// El token es la hora del servidor (sin zona, como updated_at) en microsegundos
+func encodeSyncToken(t time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("v1:" + strconv.FormatInt(t.UnixMicro(), 10)))
+}
+
+// decodeSyncToken: vacío = sync completo (nil, nil)
+func decodeSyncToken(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || !strings.HasPrefix(string(raw), "v1:") {
+		return nil, ErrInvalidSyncToken
+	}
+	micros, err := strconv.ParseInt(strings.TrimPrefix(string(raw), "v1:"), 10, 64)
+	if err != nil || micros <= 0 {
+		return nil, ErrInvalidSyncToken
+	}
+	t := time.UnixMicro(micros).UTC()
+	return &t, nil
+}
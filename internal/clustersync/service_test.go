@@ -0,0 +1,115 @@
+package clustersync
+
+import (
+	"alertly/internal/clusterquery"
+	"testing"
+	"time"
+)
+
+type fakeRepo struct {
+	stats       Stats
+	fullStats   Stats
+	rows        []changeRow
+	changeCalls int
+	gotSince    *time.Time
+}
+
+func (r *fakeRepo) GetStats(area clusterquery.Filter, since *time.Time) (Stats, error) {
+	if since == nil {
+		return r.fullStats, nil
+	}
+	return r.stats, nil
+}
+
+func (r *fakeRepo) GetChanges(area clusterquery.Filter, since *time.Time, limit int) ([]changeRow, error) {
+	r.changeCalls++
+	r.gotSince = since
+	return r.rows, nil
+}
+
+var (
+	serverTime = time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	area       = Inputs{MinLatitude: 43.5, MaxLatitude: 43.9, MinLongitude: -79.7, MaxLongitude: -79.1}
+)
+
+func newTestService(repo *fakeRepo) *service {
+	return &service{repo: repo, now: func() time.Time { return serverTime }}
+}
+
+func TestClassify(t *testing.T) {
+	since := serverTime.Add(-time.Hour)
+	blocked := serverTime
+	tests := []struct {
+		name  string
+		row   changeRow
+		since *time.Time
+		want  string
+	}{
+		{"full sync", changeRow{IsActive: true, Change: Change{CreatedAt: since.Add(-time.Hour)}}, nil, ChangeCreated},
+		{"new", changeRow{IsActive: true, Change: Change{CreatedAt: since.Add(time.Minute)}}, &since, ChangeCreated},
+		{"updated", changeRow{IsActive: true, Change: Change{CreatedAt: since.Add(-time.Minute)}}, &since, ChangeUpdated},
+		{"expired", changeRow{IsActive: false}, &since, ChangeExpired},
+		{"blocked", changeRow{IsActive: false, BlockedAt: &blocked}, &since, ChangeBlocked},
+	}
+	for _, tt := range tests {
+		if got := classify(tt.row, tt.since); got != tt.want {
+			t.Errorf("%s: classify() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSyncTokenRoundTrip(t *testing.T) {
+	got, err := decodeSyncToken(encodeSyncToken(serverTime))
+	if err != nil || !got.Equal(serverTime) {
+		t.Fatalf("round trip = %v, %v", got, err)
+	}
+	for _, s := range []string{"%%", "djI6MTIz", "djE6YWJj"} {
+		if _, err := decodeSyncToken(s); err != ErrInvalidSyncToken {
+			t.Errorf("decodeSyncToken(%q) error = %v", s, err)
+		}
+	}
+}
+
+func TestSyncNotModifiedSkipsChanges(t *testing.T) {
+	repo := &fakeRepo{stats: Stats{ServerTime: serverTime, Count: 0}}
+	in := area
+	in.SyncToken = encodeSyncToken(serverTime.Add(-time.Minute))
+
+	s := newTestService(repo)
+	delta, etag, notModified, err := s.Sync(in, "")
+	if err != nil || notModified || delta.SyncToken != in.SyncToken || delta.FullSync {
+		t.Fatalf("first sync: %+v, %v, %v", delta, notModified, err)
+	}
+
+	_, again, notModified, err := s.Sync(in, etag)
+	if err != nil || !notModified || again != etag {
+		t.Fatalf("repeated sync should be 304: %v, %v", notModified, err)
+	}
+	if repo.changeCalls != 1 {
+		t.Errorf("a 304 must not read the changes, GetChanges called %d times", repo.changeCalls)
+	}
+}
+
+func TestSyncFallsBackToFullSync(t *testing.T) {
+	repo := &fakeRepo{
+		stats:     Stats{ServerTime: serverTime, Count: maxChanges + 1},
+		fullStats: Stats{ServerTime: serverTime, Count: 3},
+		rows:      []changeRow{{IsActive: true, Change: Change{InclId: 1}}},
+	}
+	in := area
+	in.SyncToken = encodeSyncToken(serverTime.Add(-time.Hour))
+
+	delta, _, _, err := newTestService(repo).Sync(in, "")
+	if err != nil || !delta.FullSync || repo.gotSince != nil || delta.Changes[0].Type != ChangeCreated {
+		t.Fatalf("too many changes should trigger a full sync: %+v, %v", delta, err)
+	}
+	if delta.SyncToken != encodeSyncToken(serverTime.Add(-syncOverlap)) {
+		t.Errorf("new token should trail the server time by the overlap")
+	}
+
+	in.SyncToken = encodeSyncToken(serverTime.Add(-maxTokenAge - time.Hour))
+	repo.stats.Count = 1
+	if delta, _, _, _ := newTestService(repo).Sync(in, ""); !delta.FullSync {
+		t.Errorf("an expired token should trigger a full sync")
+	}
+}
@@ -0,0 +1,31 @@
+package clustertimeline
+
+import (
+	"alertly/internal/common"
+	"encoding/json"
+	"fmt"
+)
+
+// RecordEvent guarda en incident_cluster_events un evento que no queda registrado en otra tabla.
+// accountID 0 = evento del sistema. Puede recibir una transacción para que el evento se guarde junto con el cambio.
+func RecordEvent(dbExec common.DBExecutor, inclID int64, eventType string, accountID int64, details map[string]interface{}) error {
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	payload, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("encoding %s event details: %w", eventType, err)
+	}
+
+	var account interface{}
+	if accountID != 0 {
+		account = accountID
+	}
+
+	_, err = dbExec.Exec(`INSERT INTO incident_cluster_events (incl_id, event_type, account_id, details) VALUES ($1, $2, $3, $4)`,
+		inclID, eventType, account, payload)
+	if err != nil {
+		return fmt.Errorf("saving %s event for cluster %d: %w", eventType, inclID, err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package clustertimeline
+
+import (
+	"alertly/internal/database"
+	"alertly/internal/response"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTimeline devuelve la evolución del incidente: creación, reports, votos, ubicación, dirección,
+// comentarios, moderación y expiración, en orden cronológico
+func GetTimeline(c *gin.Context) {
+	inclID, err := strconv.ParseInt(c.Param("incl_id"), 10, 64)
+	if err != nil || inclID <= 0 {
+		response.Send(c, http.StatusBadRequest, true, "Invalid incident ID.", nil)
+		return
+	}
+
+	service := NewService(NewRepository(database.DB))
+	timeline, err := service.GetTimeline(inclID)
+	if err != nil {
+		if errors.Is(err, ErrClusterNotFound) {
+			response.Send(c, http.StatusNotFound, true, err.Error(), nil)
+			return
+		}
+		log.Printf("Error getting timeline for cluster %d: %v", inclID, err)
+		response.Send(c, http.StatusInternalServerError, true, "Could not load the incident timeline. Please try later.", nil)
+		return
+	}
+
+	response.Send(c, http.StatusOK, false, "Success", timeline)
+}
@@ -0,0 +1,123 @@
+package clustertimeline
+
+import "time"
+
+// Tipos de evento de la línea de tiempo
+const (
+	EventCreated         = "created"
+	EventReportAdded     = "report_added"
+	EventReportEdited    = "report_edited"
+	EventReportWithdrawn = "report_withdrawn"
+	EventVoteMilestone   = "vote_milestone"
+	EventComment         = "comment"
+	EventMergedIn        = "merged_in"   // otro cluster se fusionó dentro de este
+	EventMergedInto      = "merged_into" // este cluster se fusionó dentro de otro
+	EventSplit           = "split"
+	EventExpired         = "expired"
+
+	// Guardados en incident_cluster_events
+	EventLocationMoved     = "location_moved"
+	EventAddressResolved   = "address_resolved"
+	EventReportRejected    = "report_rejected"
+	EventClosed            = "closed"
+	EventOfficialConfirmed = "official_confirmed"
+	EventBlocked           = "blocked" // todos los reportes del cluster fueron rechazados
+)
+
+// Event es un elemento de la línea de tiempo. Actor es nil para eventos del sistema.
+type Event struct {
+	Type        string                 `json:"type"`
+	At          time.Time              `json:"at"`
+	Actor       *Actor                 `json:"actor"`
+	ReferenceId int64                  `json:"reference_id,omitempty"` // inre_id, inco_id o merge_id según el tipo
+	Details     map[string]interface{} `json:"details,omitempty"`
+}
+
+// Actor es quien generó el evento. Si el report es anónimo solo se envía IsAnonymous.
+type Actor struct {
+	AccountId    int64  `json:"account_id,omitempty"`
+	Nickname     string `json:"nickname,omitempty"`
+	ThumbnailUrl string `json:"thumbnail_url,omitempty"`
+	IsAnonymous  bool   `json:"is_anonymous"`
+	Role         string `json:"role,omitempty"` // "moderator" para acciones de moderación
+}
+
+// Timeline es la respuesta de GET /cluster/timeline/:incl_id
+type Timeline struct {
+	InclId int64   `json:"incl_id"`
+	Events []Event `json:"events"`
+}
+
+// clusterRow son los datos del cluster necesarios para la línea de tiempo
+type clusterRow struct {
+	InclId          int64
+	AccountId       int64
+	CreatedAt       time.Time
+	EndTime         *time.Time
+	IsActive        bool
+	MergedInto      int64
+	Credibility     float64
+	SubcategoryName string
+}
+
+// reportRow es un incident_report del cluster con su autor
+type reportRow struct {
+	InreId          int64
+	AccountId       int64
+	Nickname        string
+	ThumbnailUrl    string
+	IsAnonymous     bool
+	Description     string
+	SubcategoryName string
+	Vote            *bool
+	VotedAt         time.Time
+	CreatedAt       time.Time
+	WithdrawnAt     *time.Time
+}
+
+// editRow es una edición de incident_report_edits
+type editRow struct {
+	InreId    int64
+	CreatedAt time.Time
+	Details   map[string]interface{}
+}
+
+// commentRow es un comentario del cluster
+type commentRow struct {
+	IncoId       int64
+	AccountId    int64
+	Nickname     string
+	ThumbnailUrl string
+	Comment      string
+	CreatedAt    time.Time
+}
+
+// mergeRow es un merge de incident_cluster_merges en el que participa el cluster
+type mergeRow struct {
+	MergeId      int64
+	TargetInclId int64
+	SourceInclId int64
+	Reason       string
+	MergedBy     *int64
+	CreatedAt    time.Time
+	SplitAt      *time.Time
+	SplitBy      *int64
+}
+
+// storedEvent es una fila de incident_cluster_events
+type storedEvent struct {
+	EventType string
+	AccountId *int64
+	Details   map[string]interface{}
+	CreatedAt time.Time
+}
+
+// timelineData agrupa todo lo leído de la base para construir la línea de tiempo
+type timelineData struct {
+	Cluster  clusterRow
+	Reports  []reportRow
+	Edits    []editRow
+	Comments []commentRow
+	Merges   []mergeRow
+	Events   []storedEvent
+}
@@ -0,0 +1,217 @@
+package clustertimeline
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+type Repository interface {
+	GetCluster(inclID int64) (clusterRow, error)
+	GetReports(inclID int64) ([]reportRow, error)
+	GetReportEdits(inclID int64) ([]editRow, error)
+	GetComments(inclID int64) ([]commentRow, error)
+	GetMerges(inclID int64) ([]mergeRow, error)
+	GetStoredEvents(inclID int64) ([]storedEvent, error)
+}
+
+type pgRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &pgRepository{db: db}
+}
+
+func (r *pgRepository) GetCluster(inclID int64) (clusterRow, error) {
+	query := `
+	SELECT incl_id, COALESCE(account_id, 0), COALESCE(created_at, NOW()), end_time, is_active = '1',
+		COALESCE(merged_into_incl_id, 0), COALESCE(credibility, 0), COALESCE(subcategory_name, '')
+	FROM incident_clusters
+	WHERE incl_id = $1`
+	var c clusterRow
+	var endTime sql.NullTime
+	err := r.db.QueryRow(query, inclID).Scan(&c.InclId, &c.AccountId, &c.CreatedAt, &endTime, &c.IsActive,
+		&c.MergedInto, &c.Credibility, &c.SubcategoryName)
+	if err != nil {
+		return c, err
+	}
+	if endTime.Valid {
+		c.EndTime = &endTime.Time
+	}
+	return c, nil
+}
+
+// GetReports devuelve todos los reports del cluster (incluidos los retirados) en orden de creación
+func (r *pgRepository) GetReports(inclID int64) ([]reportRow, error) {
+	query := `
+	SELECT
+		r.inre_id,
+		COALESCE(r.account_id, 0),
+		COALESCE(a.nickname, ''),
+		COALESCE(a.thumbnail_url, ''),
+		TRIM(COALESCE(r.is_anonymous, '0')) = '1',
+		COALESCE(r.description, ''),
+		COALESCE(r.subcategory_name, ''),
+		r.vote,
+		COALESCE(r.vote_updated_at, r.created_at, NOW()),
+		COALESCE(r.created_at, NOW()),
+		r.withdrawn_at
+	FROM incident_reports r
+	LEFT JOIN account a ON a.account_id = r.account_id
+	WHERE r.incl_id = $1
+	ORDER BY r.created_at ASC, r.inre_id ASC`
+	rows, err := r.db.Query(query, inclID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying timeline reports: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []reportRow
+	for rows.Next() {
+		var rep reportRow
+		var vote sql.NullInt64
+		var withdrawnAt sql.NullTime
+		if err := rows.Scan(&rep.InreId, &rep.AccountId, &rep.Nickname, &rep.ThumbnailUrl, &rep.IsAnonymous,
+			&rep.Description, &rep.SubcategoryName, &vote, &rep.VotedAt, &rep.CreatedAt, &withdrawnAt); err != nil {
+			return nil, fmt.Errorf("error scanning timeline report: %w", err)
+		}
+		if vote.Valid {
+			v := vote.Int64 == 1
+			rep.Vote = &v
+		}
+		if withdrawnAt.Valid {
+			rep.WithdrawnAt = &withdrawnAt.Time
+		}
+		reports = append(reports, rep)
+	}
+	return reports, rows.Err()
+}
+
+func (r *pgRepository) GetReportEdits(inclID int64) ([]editRow, error) {
+	query := `
+	SELECT e.inre_id, e.created_at,
+		COALESCE(e.old_description, ''), COALESCE(e.new_description, ''),
+		COALESCE(e.old_subcategory_code, ''), COALESCE(e.new_subcategory_code, '')
+	FROM incident_report_edits e
+	INNER JOIN incident_reports r ON r.inre_id = e.inre_id
+	WHERE r.incl_id = $1 AND e.action = 'edit'
+	ORDER BY e.created_at ASC`
+	rows, err := r.db.Query(query, inclID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying timeline edits: %w", err)
+	}
+	defer rows.Close()
+
+	var edits []editRow
+	for rows.Next() {
+		var e editRow
+		var oldDesc, newDesc, oldSub, newSub string
+		if err := rows.Scan(&e.InreId, &e.CreatedAt, &oldDesc, &newDesc, &oldSub, &newSub); err != nil {
+			return nil, fmt.Errorf("error scanning timeline edit: %w", err)
+		}
+		e.Details = map[string]interface{}{}
+		if oldDesc != newDesc {
+			e.Details["description"] = newDesc
+		}
+		if oldSub != newSub {
+			e.Details["old_subcategory_code"] = oldSub
+			e.Details["subcategory_code"] = newSub
+		}
+		edits = append(edits, e)
+	}
+	return edits, rows.Err()
+}
+
+func (r *pgRepository) GetComments(inclID int64) ([]commentRow, error) {
+	query := `
+	SELECT t1.inco_id, t1.account_id, COALESCE(t2.nickname, ''), COALESCE(t2.thumbnail_url, ''),
+		COALESCE(t1.comment, ''), COALESCE(t1.created_at, NOW())
+	FROM incident_comments t1
+	INNER JOIN account t2 ON t1.account_id = t2.account_id
+	WHERE t1.incl_id = $1
+	ORDER BY t1.inco_id ASC`
+	rows, err := r.db.Query(query, inclID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying timeline comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []commentRow
+	for rows.Next() {
+		var c commentRow
+		if err := rows.Scan(&c.IncoId, &c.AccountId, &c.Nickname, &c.ThumbnailUrl, &c.Comment, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning timeline comment: %w", err)
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+// GetMerges devuelve los merges en los que el cluster fue destino u origen
+func (r *pgRepository) GetMerges(inclID int64) ([]mergeRow, error) {
+	query := `
+	SELECT merge_id, target_incl_id, source_incl_id, reason, merged_by, created_at, split_at, split_by
+	FROM incident_cluster_merges
+	WHERE target_incl_id = $1 OR source_incl_id = $1
+	ORDER BY created_at ASC`
+	rows, err := r.db.Query(query, inclID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying timeline merges: %w", err)
+	}
+	defer rows.Close()
+
+	var merges []mergeRow
+	for rows.Next() {
+		var m mergeRow
+		var mergedBy, splitBy sql.NullInt64
+		var splitAt sql.NullTime
+		if err := rows.Scan(&m.MergeId, &m.TargetInclId, &m.SourceInclId, &m.Reason, &mergedBy, &m.CreatedAt, &splitAt, &splitBy); err != nil {
+			return nil, fmt.Errorf("error scanning timeline merge: %w", err)
+		}
+		if mergedBy.Valid {
+			m.MergedBy = &mergedBy.Int64
+		}
+		if splitAt.Valid {
+			m.SplitAt = &splitAt.Time
+		}
+		if splitBy.Valid {
+			m.SplitBy = &splitBy.Int64
+		}
+		merges = append(merges, m)
+	}
+	return merges, rows.Err()
+}
+
+func (r *pgRepository) GetStoredEvents(inclID int64) ([]storedEvent, error) {
+	query := `
+	SELECT event_type, account_id, details, created_at
+	FROM incident_cluster_events
+	WHERE incl_id = $1
+	ORDER BY created_at ASC, ince_id ASC`
+	rows, err := r.db.Query(query, inclID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying timeline events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []storedEvent
+	for rows.Next() {
+		var e storedEvent
+		var accountID sql.NullInt64
+		var details []byte
+		if err := rows.Scan(&e.EventType, &accountID, &details, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning timeline event: %w", err)
+		}
+		if accountID.Valid {
+			e.AccountId = &accountID.Int64
+		}
+		if len(details) > 0 {
+			if err := json.Unmarshal(details, &e.Details); err != nil {
+				return nil, fmt.Errorf("error decoding timeline event details: %w", err)
+			}
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,210 @@
+package clustertimeline
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Hitos de votos: se agrega un evento cuando el total de votos alcanza cada uno
+var voteMilestones = []int{5, 10, 25, 50, 100}
+
+var ErrClusterNotFound = errors.New("incident not found")
+
+type Service interface {
+	GetTimeline(inclID int64) (Timeline, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+// GetTimeline arma la línea de tiempo del cluster en orden cronológico
+func (s *service) GetTimeline(inclID int64) (Timeline, error) {
+	var data timelineData
+	var err error
+
+	data.Cluster, err = s.repo.GetCluster(inclID)
+	if err == sql.ErrNoRows {
+		return Timeline{}, ErrClusterNotFound
+	}
+	if err != nil {
+		return Timeline{}, fmt.Errorf("getting cluster: %w", err)
+	}
+	if data.Reports, err = s.repo.GetReports(inclID); err != nil {
+		return Timeline{}, err
+	}
+	if data.Edits, err = s.repo.GetReportEdits(inclID); err != nil {
+		return Timeline{}, err
+	}
+	if data.Comments, err = s.repo.GetComments(inclID); err != nil {
+		return Timeline{}, err
+	}
+	if data.Merges, err = s.repo.GetMerges(inclID); err != nil {
+		return Timeline{}, err
+	}
+	if data.Events, err = s.repo.GetStoredEvents(inclID); err != nil {
+		return Timeline{}, err
+	}
+
+	return Timeline{InclId: inclID, Events: buildTimeline(data, time.Now())}, nil
+}
+
+// reportActor oculta la identidad del autor si el report es anónimo
+func reportActor(r reportRow) *Actor {
+	if r.IsAnonymous {
+		return &Actor{IsAnonymous: true}
+	}
+	return &Actor{AccountId: r.AccountId, Nickname: r.Nickname, ThumbnailUrl: r.ThumbnailUrl}
+}
+
+// moderatorActor: las acciones de moderación muestran el rol, no la cuenta del moderador
+func moderatorActor(accountID *int64) *Actor {
+	if accountID == nil {
+		return nil
+	}
+	return &Actor{Role: "moderator"}
+}
+
+func voteValue(v *bool) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+// buildTimeline combina reports, votos, comentarios, ediciones, merges y eventos guardados
+// en una sola lista ordenada por fecha
+func buildTimeline(data timelineData, now time.Time) []Event {
+	c := data.Cluster
+	events := make([]Event, 0, len(data.Reports)*2+len(data.Comments)+len(data.Events)+2)
+	reportsByID := make(map[int64]reportRow, len(data.Reports))
+
+	// 1. Creación y reports
+	for i, r := range data.Reports {
+		reportsByID[r.InreId] = r
+		event := Event{
+			Type:        EventReportAdded,
+			At:          r.CreatedAt,
+			Actor:       reportActor(r),
+			ReferenceId: r.InreId,
+			Details:     map[string]interface{}{"description": r.Description, "vote": voteValue(r.Vote)},
+		}
+		// El primer report del creador es la creación del cluster
+		if i == 0 && r.AccountId == c.AccountId {
+			event.Type = EventCreated
+			event.Details = map[string]interface{}{"description": r.Description, "subcategory_name": r.SubcategoryName}
+		}
+		events = append(events, event)
+
+		if r.WithdrawnAt != nil {
+			events = append(events, Event{Type: EventReportWithdrawn, At: *r.WithdrawnAt, Actor: reportActor(r), ReferenceId: r.InreId})
+		}
+	}
+	if len(data.Reports) == 0 || data.Reports[0].AccountId != c.AccountId {
+		// Clusters sin report del creador (p.ej. creados por el bot)
+		events = append(events, Event{Type: EventCreated, At: c.CreatedAt, Details: map[string]interface{}{"subcategory_name": c.SubcategoryName}})
+	}
+
+	// 2. Ediciones
+	for _, e := range data.Edits {
+		var actor *Actor
+		if r, ok := reportsByID[e.InreId]; ok {
+			actor = reportActor(r)
+		}
+		events = append(events, Event{Type: EventReportEdited, At: e.CreatedAt, Actor: actor, ReferenceId: e.InreId, Details: e.Details})
+	}
+
+	// 3. Hitos de votos
+	events = append(events, voteMilestoneEvents(data.Reports)...)
+
+	// 4. Comentarios
+	for _, cm := range data.Comments {
+		events = append(events, Event{
+			Type:        EventComment,
+			At:          cm.CreatedAt,
+			Actor:       &Actor{AccountId: cm.AccountId, Nickname: cm.Nickname, ThumbnailUrl: cm.ThumbnailUrl},
+			ReferenceId: cm.IncoId,
+			Details:     map[string]interface{}{"comment": cm.Comment},
+		})
+	}
+
+	// 5. Moderación: merges y splits
+	for _, m := range data.Merges {
+		if m.TargetInclId == c.InclId {
+			events = append(events, Event{Type: EventMergedIn, At: m.CreatedAt, Actor: moderatorActor(m.MergedBy), ReferenceId: m.MergeId,
+				Details: map[string]interface{}{"source_incl_id": m.SourceInclId, "reason": m.Reason}})
+		} else {
+			events = append(events, Event{Type: EventMergedInto, At: m.CreatedAt, Actor: moderatorActor(m.MergedBy), ReferenceId: m.MergeId,
+				Details: map[string]interface{}{"target_incl_id": m.TargetInclId, "reason": m.Reason}})
+		}
+		if m.SplitAt != nil {
+			events = append(events, Event{Type: EventSplit, At: *m.SplitAt, Actor: moderatorActor(m.SplitBy), ReferenceId: m.MergeId,
+				Details: map[string]interface{}{"source_incl_id": m.SourceInclId, "target_incl_id": m.TargetInclId}})
+		}
+	}
+
+	// 6. Eventos guardados: movimientos de ubicación, dirección, rechazos y cierre
+	closed := false
+	for _, e := range data.Events {
+		if e.EventType == EventClosed {
+			closed = true
+		}
+		events = append(events, Event{Type: e.EventType, At: e.CreatedAt, Details: e.Details})
+	}
+
+	// 7. Expiración: cluster inactivo que no fue fusionado ni cerrado por retiro
+	if !c.IsActive && c.MergedInto == 0 && !closed {
+		at := now
+		if c.EndTime != nil && c.EndTime.Before(now) {
+			at = *c.EndTime
+		}
+		events = append(events, Event{Type: EventExpired, At: at, Details: map[string]interface{}{"credibility": c.Credibility}})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].At.Before(events[j].At)
+	})
+	return events
+}
+
+// voteMilestoneEvents recorre los votos vigentes en orden y agrega un evento cada vez que el total alcanza un hito
+func voteMilestoneEvents(reports []reportRow) []Event {
+	votes := make([]reportRow, 0, len(reports))
+	for _, r := range reports {
+		if r.Vote != nil {
+			votes = append(votes, r)
+		}
+	}
+	sort.SliceStable(votes, func(i, j int) bool {
+		return votes[i].VotedAt.Before(votes[j].VotedAt)
+	})
+
+	var events []Event
+	total, votesTrue, next := 0, 0, 0
+	for _, v := range votes {
+		total++
+		if *v.Vote {
+			votesTrue++
+		}
+		if next < len(voteMilestones) && total == voteMilestones[next] {
+			events = append(events, Event{
+				Type: EventVoteMilestone,
+				At:   v.VotedAt,
+				Details: map[string]interface{}{
+					"votes":       total,
+					"votes_true":  votesTrue,
+					"votes_false": total - votesTrue,
+				},
+			})
+			next++
+		}
+	}
+	return events
+}
@@ -0,0 +1,87 @@
+package clustertimeline
+
+import (
+	"testing"
+	"time"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestBuildTimeline(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	end := t0.Add(24 * time.Hour)
+	withdrawn := t0.Add(30 * time.Minute)
+
+	data := timelineData{
+		Cluster: clusterRow{InclId: 7, AccountId: 1, CreatedAt: t0, EndTime: &end, IsActive: false},
+		Reports: []reportRow{
+			{InreId: 10, AccountId: 1, Nickname: "ana", CreatedAt: t0, Description: "Crash"},
+			{InreId: 11, AccountId: 2, Nickname: "bob", IsAnonymous: true, CreatedAt: t0.Add(10 * time.Minute), Vote: boolPtr(true), VotedAt: t0.Add(10 * time.Minute)},
+			{InreId: 12, AccountId: 3, Nickname: "cai", CreatedAt: t0.Add(20 * time.Minute), WithdrawnAt: &withdrawn},
+		},
+		Comments: []commentRow{{IncoId: 5, AccountId: 4, Nickname: "dan", Comment: "Still blocked", CreatedAt: t0.Add(15 * time.Minute)}},
+		Events:   []storedEvent{{EventType: EventAddressResolved, CreatedAt: t0.Add(time.Minute), Details: map[string]interface{}{"city": "Toronto"}}},
+	}
+
+	events := buildTimeline(data, t0.Add(48*time.Hour))
+
+	want := []string{EventCreated, EventAddressResolved, EventReportAdded, EventComment, EventReportAdded, EventReportWithdrawn, EventExpired}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d: %+v", len(want), len(events), events)
+	}
+	for i, typ := range want {
+		if events[i].Type != typ {
+			t.Fatalf("event %d: expected %s, got %s", i, typ, events[i].Type)
+		}
+	}
+
+	if events[0].Actor == nil || events[0].Actor.Nickname != "ana" {
+		t.Errorf("creation should carry the creator, got %+v", events[0].Actor)
+	}
+	if a := events[2].Actor; a == nil || !a.IsAnonymous || a.AccountId != 0 || a.Nickname != "" {
+		t.Errorf("anonymous report should hide its author, got %+v", a)
+	}
+	if events[1].Actor != nil {
+		t.Errorf("system events should have no actor, got %+v", events[1].Actor)
+	}
+	if !events[6].At.Equal(end) {
+		t.Errorf("expiration should happen at end_time, got %v", events[6].At)
+	}
+}
+
+func TestBuildTimelineClosedClusterIsNotExpired(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	data := timelineData{
+		Cluster: clusterRow{InclId: 7, AccountId: 1, CreatedAt: t0, IsActive: false},
+		Reports: []reportRow{{InreId: 10, AccountId: 1, CreatedAt: t0}},
+		Events:  []storedEvent{{EventType: EventClosed, CreatedAt: t0.Add(time.Minute)}},
+	}
+	for _, e := range buildTimeline(data, t0.Add(time.Hour)) {
+		if e.Type == EventExpired {
+			t.Fatal("a cluster closed by withdrawal should not also expire")
+		}
+	}
+}
+
+func TestVoteMilestoneEvents(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	var reports []reportRow
+	for i := 0; i < 12; i++ {
+		reports = append(reports, reportRow{InreId: int64(i), Vote: boolPtr(i%3 != 0), VotedAt: t0.Add(time.Duration(i) * time.Minute)})
+	}
+	reports = append(reports, reportRow{InreId: 99}) // sin voto
+
+	events := voteMilestoneEvents(reports)
+	if len(events) != 2 {
+		t.Fatalf("expected milestones at 5 and 10 votes, got %d", len(events))
+	}
+	if events[0].Details["votes"] != 5 || events[1].Details["votes"] != 10 {
+		t.Errorf("unexpected milestones: %+v", events)
+	}
+	if !events[1].At.Equal(t0.Add(9 * time.Minute)) {
+		t.Errorf("10th vote milestone should be at the 10th vote, got %v", events[1].At)
+	}
+	if events[1].Details["votes_false"] != 4 {
+		t.Errorf("expected 4 false votes at milestone 10, got %v", events[1].Details["votes_false"])
+	}
+}
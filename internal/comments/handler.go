@@ -4,6 +4,7 @@ import (
 	"alertly/internal/auth"
 	"alertly/internal/database"
 	"alertly/internal/response"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -73,6 +74,10 @@ func SaveClusterComment(c *gin.Context) {
 
 	incoID, err = service.Save(comment)
 
+	if errors.Is(err, ErrInvalidParent) {
+		response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+		return
+	}
 	if err != nil {
 		fmt.Println("error1", err)
 		response.Send(c, http.StatusInternalServerError, true, "We couldn’t save your comment. Please try again later.", 0)
@@ -115,3 +120,90 @@ func GetClusterComments(c *gin.Context) {
 
 	response.Send(c, http.StatusOK, false, "success", result)
 }
+
+// AddReaction agrega una reacción del usuario a un comentario
+//
+//	POST /api/cluster/comment/:inco_id/reactions  {"reaction": "thumbs_up"}
+func AddReaction(c *gin.Context) {
+	var in InReaction
+	if err := c.ShouldBindJSON(&in); err != nil || validate.Struct(in) != nil {
+		response.Send(c, http.StatusBadRequest, true, ErrInvalidReaction.Error(), nil)
+		return
+	}
+	react(c, in.Reaction, true)
+}
+
+// RemoveReaction quita una reacción del usuario
+//
+//	DELETE /api/cluster/comment/:inco_id/reactions/:reaction
+func RemoveReaction(c *gin.Context) {
+	react(c, c.Param("reaction"), false)
+}
+
+func react(c *gin.Context, reaction string, add bool) {
+	accountID, err := auth.GetUserFromContext(c)
+	if err != nil {
+		response.Send(c, http.StatusUnauthorized, true, "We couldn’t verify your session. Please log in again.", nil)
+		return
+	}
+	incoID, err := strconv.ParseInt(c.Param("inco_id"), 10, 64)
+	if err != nil || incoID <= 0 {
+		response.Send(c, http.StatusBadRequest, true, "Invalid ID format. Please try again.", nil)
+		return
+	}
+
+	service := NewService(NewRepository(database.DB))
+	summary, err := service.React(incoID, accountID, reaction, add)
+	switch {
+	case errors.Is(err, ErrInvalidReaction):
+		response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+	case errors.Is(err, ErrCommentNotFound):
+		response.Send(c, http.StatusNotFound, true, err.Error(), nil)
+	case err != nil:
+		log.Printf("Error reacting to comment %d: %v", incoID, err)
+		response.Send(c, http.StatusInternalServerError, true, "We couldn’t save your reaction. Please try again later.", nil)
+	default:
+		response.Send(c, http.StatusOK, false, "success", summary)
+	}
+}
+
+// MarkHelpful permite al autor del incidente destacar un comentario como útil
+//
+//	POST /api/cluster/comment/:inco_id/helpful
+func MarkHelpful(c *gin.Context) {
+	setHelpful(c, true)
+}
+
+// UnmarkHelpful quita la marca de útil
+//
+//	DELETE /api/cluster/comment/:inco_id/helpful
+func UnmarkHelpful(c *gin.Context) {
+	setHelpful(c, false)
+}
+
+func setHelpful(c *gin.Context, helpful bool) {
+	accountID, err := auth.GetUserFromContext(c)
+	if err != nil {
+		response.Send(c, http.StatusUnauthorized, true, "We couldn’t verify your session. Please log in again.", nil)
+		return
+	}
+	incoID, err := strconv.ParseInt(c.Param("inco_id"), 10, 64)
+	if err != nil || incoID <= 0 {
+		response.Send(c, http.StatusBadRequest, true, "Invalid ID format. Please try again.", nil)
+		return
+	}
+
+	service := NewService(NewRepository(database.DB))
+	err = service.SetHelpful(incoID, accountID, helpful)
+	switch {
+	case errors.Is(err, ErrCommentNotFound):
+		response.Send(c, http.StatusNotFound, true, err.Error(), nil)
+	case errors.Is(err, ErrNotIncidentOwner):
+		response.Send(c, http.StatusForbidden, true, err.Error(), nil)
+	case err != nil:
+		log.Printf("Error setting helpful on comment %d: %v", incoID, err)
+		response.Send(c, http.StatusInternalServerError, true, "We couldn’t update the comment. Please try again later.", nil)
+	default:
+		response.Send(c, http.StatusOK, false, "success", gin.H{"inco_id": incoID, "helpful": helpful})
+	}
+}
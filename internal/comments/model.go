@@ -6,6 +6,7 @@ type InComment struct {
 	IncoID        int64     `json:"inco_id"`
 	AccountID     int64     `json:"account_id"`
 	InclID        int64     `json:"incl_id"`
+	ParentIncoID  int64     `json:"parent_inco_id"` // 0 = comentario raíz
 	CreatedAt     time.Time `json:"created_at"`
 	Comment       string    `json:"comment"`
 	CounterFlags  int       `json:"counter_flags"`
@@ -16,10 +17,51 @@ type Comment struct {
 	IncoID        int64     `json:"inco_id"`
 	AccountID     int64     `json:"account_id"`
 	InclID        int64     `json:"incl_id"`
+	ParentIncoID  *int64    `json:"parent_inco_id"`
 	CreatedAt     time.Time `json:"created_at"`
 	Comment       string    `json:"comment"`
 	CounterFlags  int       `json:"counter_flags"`
 	CommentStatus bool      `json:"comment_status"`
 	Nickname      string    `json:"nickname"`
 	ThumbnailUrl  string    `json:"thumbnail_url"`
+	Helpful       bool      `json:"helpful"` // marcado como útil por el autor del incidente
+	// Respuesta a otra respuesta: a quién se respondió dentro del hilo
+	ReplyToAccountID int64           `json:"reply_to_account_id,omitempty"`
+	ReplyToNickname  string          `json:"reply_to_nickname,omitempty"`
+	Reactions        []ReactionCount `json:"reactions"`
+	MyReactions      []string        `json:"my_reactions"` // reacciones del usuario que pide el cluster
+	Replies          []Comment       `json:"replies"`      // solo en comentarios raíz, de la más vieja a la más nueva
+}
+
+// ReactionCount es el total de una reacción en un comentario
+type ReactionCount struct {
+	Reaction string `json:"reaction"`
+	Emoji    string `json:"emoji"`
+	Count    int    `json:"count"`
+}
+
+// reactionRow es una fila agregada de incident_comment_reactions
+type reactionRow struct {
+	IncoID   int64
+	Reaction string
+	Count    int
+	Mine     bool
+}
+
+// CommentOwners son las cuentas que deciden sobre un comentario
+type CommentOwners struct {
+	InclID           int64
+	CommentAccountID int64
+	ClusterAccountID int64 // autor del incidente: el único que puede marcar "útil"
+}
+
+// ReactionSummary es la respuesta al reaccionar o quitar una reacción
+type ReactionSummary struct {
+	IncoID      int64           `json:"inco_id"`
+	Reactions   []ReactionCount `json:"reactions"`
+	MyReactions []string        `json:"my_reactions"`
+}
+
+type InReaction struct {
+	Reaction string `json:"reaction" validate:"required"`
 }
@@ -12,6 +12,12 @@ type Repository interface {
 	Save(comment InComment) (int64, error)
 	GetClusterCommentsByID(inclID int64) ([]Comment, error)
 	GetCommentById(incoID int64) (Comment, error)
+	GetCommentOwners(incoID int64) (CommentOwners, error)
+	GetClusterReactions(inclID, viewerID int64) ([]reactionRow, error)
+	GetCommentReactions(incoID, viewerID int64) ([]reactionRow, error)
+	AddReaction(incoID, accountID int64, reaction string) error
+	RemoveReaction(incoID, accountID int64, reaction string) error
+	SetHelpful(incoID, accountID int64, helpful bool) error
 }
 
 type pgRepository struct {
@@ -25,9 +31,9 @@ func NewRepository(db *sql.DB) Repository {
 func (r *pgRepository) Save(comment InComment) (int64, error) {
 
 	// 1. Insertar comentario
-	query := `INSERT INTO incident_comments (account_id, comment, created_at, incl_id) VALUES ($1, $2, NOW(), $3) RETURNING inco_id`
+	query := `INSERT INTO incident_comments (account_id, comment, created_at, incl_id, parent_inco_id) VALUES ($1, $2, NOW(), $3, NULLIF($4, 0)) RETURNING inco_id`
 	var commentID int64
-	err := r.db.QueryRow(query, comment.AccountID, comment.Comment, comment.InclID).Scan(&commentID)
+	err := r.db.QueryRow(query, comment.AccountID, comment.Comment, comment.InclID, comment.ParentIncoID).Scan(&commentID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert comment: %w", err)
 	}
@@ -39,6 +45,12 @@ func (r *pgRepository) Save(comment InComment) (int64, error) {
 		log.Printf("Error updating total comments count: %v", err)
 	}
 
+	// cjcomments avisa a quienes siguen el incidente y, si es una respuesta, al autor del comentario respondido.
+	// reference_id es el inco_id.
+	if err = common.SaveNotification(r.db, "new_comment", comment.AccountID, commentID); err != nil {
+		log.Printf("Error saving comment notification: %v", err)
+	}
+
 	// ----------------------CITIZEN SCORE----------------------- //
 	// Save to DB
 	err = common.SaveScore(r.db, comment.AccountID, 5)
@@ -58,7 +70,9 @@ func (r *pgRepository) GetClusterCommentsByID(inclID int64) ([]Comment, error) {
 	t1.comment_status,
 	t1.counter_flags,
 	t2.nickname,
-	COALESCE(t2.thumbnail_url, '') as thumbnail_url
+	COALESCE(t2.thumbnail_url, '') as thumbnail_url,
+	t1.parent_inco_id,
+	t1.helpful_at IS NOT NULL
 	FROM incident_comments t1 INNER JOIN account t2 ON t1.account_id = t2.account_id
 	WHERE t1.incl_id = $1
 	ORDER BY t1.inco_id DESC`
@@ -75,6 +89,7 @@ func (r *pgRepository) GetClusterCommentsByID(inclID int64) ([]Comment, error) {
 	for rows.Next() {
 		var c Comment
 		var commentStatus dbtypes.NullBool
+		var parent sql.NullInt64
 
 		if err := rows.Scan(
 			&c.IncoID,
@@ -85,10 +100,15 @@ func (r *pgRepository) GetClusterCommentsByID(inclID int64) ([]Comment, error) {
 			&c.CounterFlags,
 			&c.Nickname,
 			&c.ThumbnailUrl,
+			&parent,
+			&c.Helpful,
 		); err != nil {
 			return nil, err
 		}
 		c.CommentStatus = commentStatus.Valid && commentStatus.Bool
+		if parent.Valid {
+			c.ParentIncoID = &parent.Int64
+		}
 		comments = append(comments, c)
 	}
 
@@ -108,12 +128,15 @@ func (r *pgRepository) GetCommentById(incoID int64) (Comment, error) {
 	t1.comment_status,
 	t1.counter_flags,
 	t2.nickname,
-	COALESCE(t2.thumbnail_url, '') as thumbnail_url
+	COALESCE(t2.thumbnail_url, '') as thumbnail_url,
+	t1.parent_inco_id,
+	t1.helpful_at IS NOT NULL
 	FROM incident_comments t1 INNER JOIN account t2 ON t1.account_id = t2.account_id
 	WHERE t1.inco_id = $1`
 
 	var c Comment
 	var commentStatus dbtypes.NullBool
+	var parent sql.NullInt64
 	err := r.db.QueryRow(query, incoID).Scan(
 		&c.IncoID,
 		&c.AccountID,
@@ -123,12 +146,86 @@ func (r *pgRepository) GetCommentById(incoID int64) (Comment, error) {
 		&c.CounterFlags,
 		&c.Nickname,
 		&c.ThumbnailUrl,
+		&parent,
+		&c.Helpful,
 	)
 
 	if err != nil {
 		return Comment{}, err
 	}
 	c.CommentStatus = commentStatus.Valid && commentStatus.Bool
+	if parent.Valid {
+		c.ParentIncoID = &parent.Int64
+	}
 
 	return c, nil
 }
+
+// GetCommentOwners devuelve el cluster del comentario, su autor y el autor del incidente
+func (r *pgRepository) GetCommentOwners(incoID int64) (CommentOwners, error) {
+	query := `
+	SELECT t1.incl_id, t1.account_id, COALESCE(t2.account_id, 0)
+	FROM incident_comments t1
+	INNER JOIN incident_clusters t2 ON t1.incl_id = t2.incl_id
+	WHERE t1.inco_id = $1`
+	var o CommentOwners
+	err := r.db.QueryRow(query, incoID).Scan(&o.InclID, &o.CommentAccountID, &o.ClusterAccountID)
+	return o, err
+}
+
+// GetClusterReactions cuenta las reacciones de todos los comentarios del cluster, marcando las de viewerID
+func (r *pgRepository) GetClusterReactions(inclID, viewerID int64) ([]reactionRow, error) {
+	return r.queryReactions(`c.incl_id = $1`, inclID, viewerID)
+}
+
+func (r *pgRepository) GetCommentReactions(incoID, viewerID int64) ([]reactionRow, error) {
+	return r.queryReactions(`c.inco_id = $1`, incoID, viewerID)
+}
+
+func (r *pgRepository) queryReactions(where string, id, viewerID int64) ([]reactionRow, error) {
+	query := fmt.Sprintf(`
+	SELECT t.inco_id, t.reaction, COUNT(*), BOOL_OR(t.account_id = $2)
+	FROM incident_comment_reactions t
+	INNER JOIN incident_comments c ON c.inco_id = t.inco_id
+	WHERE %s
+	GROUP BY t.inco_id, t.reaction`, where)
+	rows, err := r.db.Query(query, id, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying comment reactions: %w", err)
+	}
+	defer rows.Close()
+
+	var reactions []reactionRow
+	for rows.Next() {
+		var rr reactionRow
+		if err := rows.Scan(&rr.IncoID, &rr.Reaction, &rr.Count, &rr.Mine); err != nil {
+			return nil, fmt.Errorf("error scanning comment reaction: %w", err)
+		}
+		reactions = append(reactions, rr)
+	}
+	return reactions, rows.Err()
+}
+
+// AddReaction es idempotente: reaccionar dos veces con lo mismo no suma
+func (r *pgRepository) AddReaction(incoID, accountID int64, reaction string) error {
+	query := `INSERT INTO incident_comment_reactions (inco_id, account_id, reaction) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
+	_, err := r.db.Exec(query, incoID, accountID, reaction)
+	return err
+}
+
+func (r *pgRepository) RemoveReaction(incoID, accountID int64, reaction string) error {
+	query := `DELETE FROM incident_comment_reactions WHERE inco_id = $1 AND account_id = $2 AND reaction = $3`
+	_, err := r.db.Exec(query, incoID, accountID, reaction)
+	return err
+}
+
+func (r *pgRepository) SetHelpful(incoID, accountID int64, helpful bool) error {
+	query := `UPDATE incident_comments SET helpful_at = NULL, helpful_by = NULL WHERE inco_id = $1`
+	args := []interface{}{incoID}
+	if helpful {
+		query = `UPDATE incident_comments SET helpful_at = COALESCE(helpful_at, NOW()), helpful_by = $2 WHERE inco_id = $1`
+		args = append(args, accountID)
+	}
+	_, err := r.db.Exec(query, args...)
+	return err
+}
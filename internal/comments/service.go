@@ -1,9 +1,35 @@
 package comments
 
+import (
+	"database/sql"
+	"errors"
+	"sort"
+)
+
+// Reactions son los códigos aceptados, en el orden en que se muestran
+var Reactions = []ReactionCount{
+	{Reaction: "thumbs_up", Emoji: "👍"},
+	{Reaction: "heart", Emoji: "❤️"},
+	{Reaction: "eyes", Emoji: "👀"},
+	{Reaction: "pray", Emoji: "🙏"},
+	{Reaction: "wow", Emoji: "😮"},
+	{Reaction: "sad", Emoji: "😢"},
+}
+
+var (
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrInvalidParent    = errors.New("the comment you’re replying to doesn’t belong to this incident")
+	ErrInvalidReaction  = errors.New("unknown reaction")
+	ErrNotIncidentOwner = errors.New("only the person who reported the incident can mark comments as helpful")
+)
+
 type Service interface {
 	Save(comment InComment) (int64, error)
 	GetClusterCommentsByID(inclID int64) ([]Comment, error)
+	GetClusterThreads(inclID, viewerID int64) ([]Comment, error)
 	GetCommentById(incoID int64) (Comment, error)
+	React(incoID, accountID int64, reaction string, add bool) (ReactionSummary, error)
+	SetHelpful(incoID, accountID int64, helpful bool) error
 }
 
 type service struct {
@@ -18,16 +44,46 @@ func (s *service) Save(comment InComment) (int64, error) {
 	var err error
 	var id int64
 
+	// Una respuesta tiene que ser a un comentario del mismo incidente
+	if comment.ParentIncoID != 0 {
+		owners, err := s.repo.GetCommentOwners(comment.ParentIncoID)
+		if err == sql.ErrNoRows || (err == nil && owners.InclID != comment.InclID) {
+			return 0, ErrInvalidParent
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
 	id, err = s.repo.Save(comment)
 	return id, err
 }
 
+// GetClusterCommentsByID devuelve la lista plana de siempre (el más nuevo primero, las respuestas como un
+// comentario más con parent_inco_id), para los clientes que no muestran hilos
 func (s *service) GetClusterCommentsByID(inclID int64) ([]Comment, error) {
+	threads, err := s.GetClusterThreads(inclID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return Flatten(threads), nil
+}
+
+// GetClusterThreads devuelve los comentarios raíz (el más nuevo primero) con sus respuestas, reacciones
+// y las reacciones de viewerID (0 = sin usuario)
+func (s *service) GetClusterThreads(inclID, viewerID int64) ([]Comment, error) {
 	var err error
 	var comments []Comment
 
 	comments, err = s.repo.GetClusterCommentsByID(inclID)
-	return comments, err
+	if err != nil {
+		return nil, err
+	}
+	reactions, err := s.repo.GetClusterReactions(inclID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return buildThreads(comments, reactions), nil
 }
 
 func (s *service) GetCommentById(incoID int64) (Comment, error) {
@@ -35,5 +91,183 @@ func (s *service) GetCommentById(incoID int64) (Comment, error) {
 	var comment Comment
 
 	comment, err = s.repo.GetCommentById(incoID)
-	return comment, err
+	if err != nil {
+		return comment, err
+	}
+	comment.Reactions = []ReactionCount{}
+	comment.MyReactions = []string{}
+	comment.Replies = []Comment{}
+	return comment, nil
+}
+
+// React agrega (add) o quita una reacción y devuelve los totales actualizados del comentario
+func (s *service) React(incoID, accountID int64, reaction string, add bool) (ReactionSummary, error) {
+	if !isReaction(reaction) {
+		return ReactionSummary{}, ErrInvalidReaction
+	}
+	if _, err := s.repo.GetCommentOwners(incoID); err != nil {
+		if err == sql.ErrNoRows {
+			return ReactionSummary{}, ErrCommentNotFound
+		}
+		return ReactionSummary{}, err
+	}
+
+	var err error
+	if add {
+		err = s.repo.AddReaction(incoID, accountID, reaction)
+	} else {
+		err = s.repo.RemoveReaction(incoID, accountID, reaction)
+	}
+	if err != nil {
+		return ReactionSummary{}, err
+	}
+
+	rows, err := s.repo.GetCommentReactions(incoID, accountID)
+	if err != nil {
+		return ReactionSummary{}, err
+	}
+	summary := ReactionSummary{IncoID: incoID}
+	counts, mine := summarize(rows)
+	summary.Reactions, summary.MyReactions = reactionsOf(incoID, counts, mine)
+	return summary, nil
+}
+
+// SetHelpful marca o desmarca un comentario como útil; solo el autor del incidente y no sobre su propio comentario
+func (s *service) SetHelpful(incoID, accountID int64, helpful bool) error {
+	owners, err := s.repo.GetCommentOwners(incoID)
+	if err == sql.ErrNoRows {
+		return ErrCommentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if owners.ClusterAccountID != accountID || owners.CommentAccountID == accountID {
+		return ErrNotIncidentOwner
+	}
+	return s.repo.SetHelpful(incoID, accountID, helpful)
+}
+
+// buildThreads arma un nivel de hilos: una respuesta a otra respuesta queda en el hilo de la raíz, con
+// ReplyTo apuntando a quien se respondió. comments llega del más nuevo al más viejo.
+func buildThreads(comments []Comment, reactions []reactionRow) []Comment {
+	counts, mine := summarize(reactions)
+	byID := make(map[int64]*Comment, len(comments))
+	for i := range comments {
+		c := &comments[i]
+		c.Reactions, c.MyReactions = reactionsOf(c.IncoID, counts, mine)
+		c.Replies = []Comment{}
+		byID[c.IncoID] = c
+	}
+
+	rootOf := func(c *Comment) int64 {
+		// El límite evita un loop si hubiera un ciclo en parent_inco_id
+		for i := 0; i < len(comments) && c.ParentIncoID != nil; i++ {
+			parent, ok := byID[*c.ParentIncoID]
+			if !ok {
+				return 0
+			}
+			c = parent
+		}
+		return c.IncoID
+	}
+
+	replies := make(map[int64][]Comment)
+	roots := make([]int64, 0, len(comments))
+	for i := range comments {
+		c := &comments[i]
+		if c.ParentIncoID == nil {
+			roots = append(roots, c.IncoID)
+			continue
+		}
+		root := rootOf(c)
+		if root == 0 || root == c.IncoID || byID[root].ParentIncoID != nil {
+			// Padre ya no existe (o ciclo): se muestra como comentario raíz
+			roots = append(roots, c.IncoID)
+			continue
+		}
+		reply := *c
+		if parent := byID[*c.ParentIncoID]; parent.IncoID != root {
+			reply.ReplyToAccountID = parent.AccountID
+			reply.ReplyToNickname = parent.Nickname
+		}
+		replies[root] = append(replies[root], reply)
+	}
+
+	threads := make([]Comment, 0, len(roots))
+	for _, id := range roots {
+		thread := *byID[id]
+		thread.Replies = replies[id]
+		if thread.Replies == nil {
+			thread.Replies = []Comment{}
+		}
+		sort.SliceStable(thread.Replies, func(i, j int) bool { return thread.Replies[i].IncoID < thread.Replies[j].IncoID })
+		threads = append(threads, thread)
+	}
+	return threads
+}
+
+// Flatten pasa los hilos de buildThreads a la lista plana, del más nuevo al más viejo
+func Flatten(threads []Comment) []Comment {
+	flat := make([]Comment, 0, len(threads))
+	for _, thread := range threads {
+		replies := thread.Replies
+		thread.Replies = []Comment{}
+		flat = append(flat, thread)
+		flat = append(flat, replies...)
+	}
+	sort.SliceStable(flat, func(i, j int) bool { return flat[i].IncoID > flat[j].IncoID })
+	return flat
+}
+
+// summarize agrupa las filas de reacciones por comentario, en el orden de Reactions
+func summarize(rows []reactionRow) (map[int64][]ReactionCount, map[int64][]string) {
+	type key struct {
+		incoID   int64
+		reaction string
+	}
+	byKey := make(map[key]reactionRow, len(rows))
+	ids := make(map[int64]bool)
+	for _, r := range rows {
+		byKey[key{r.IncoID, r.Reaction}] = r
+		ids[r.IncoID] = true
+	}
+
+	counts := make(map[int64][]ReactionCount, len(ids))
+	mine := make(map[int64][]string, len(ids))
+	for id := range ids {
+		counts[id] = []ReactionCount{}
+		mine[id] = []string{}
+		for _, def := range Reactions {
+			r, ok := byKey[key{id, def.Reaction}]
+			if !ok || r.Count == 0 {
+				continue
+			}
+			counts[id] = append(counts[id], ReactionCount{Reaction: def.Reaction, Emoji: def.Emoji, Count: r.Count})
+			if r.Mine {
+				mine[id] = append(mine[id], def.Reaction)
+			}
+		}
+	}
+	return counts, mine
+}
+
+// reactionsOf nunca devuelve nil: el cliente recibe [] en comentarios sin reacciones
+func reactionsOf(incoID int64, counts map[int64][]ReactionCount, mine map[int64][]string) ([]ReactionCount, []string) {
+	c, m := counts[incoID], mine[incoID]
+	if c == nil {
+		c = []ReactionCount{}
+	}
+	if m == nil {
+		m = []string{}
+	}
+	return c, m
+}
+
+func isReaction(reaction string) bool {
+	for _, def := range Reactions {
+		if def.Reaction == reaction {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,135 @@
+package comments
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func id(v int64) *int64 { return &v }
+
+type fakeRepo struct {
+	Repository
+	owners  map[int64]CommentOwners
+	saved   []InComment
+	helpful map[int64]bool
+}
+
+func (r *fakeRepo) Save(comment InComment) (int64, error) {
+	r.saved = append(r.saved, comment)
+	return int64(100 + len(r.saved)), nil
+}
+
+func (r *fakeRepo) GetCommentOwners(incoID int64) (CommentOwners, error) {
+	o, ok := r.owners[incoID]
+	if !ok {
+		return CommentOwners{}, sql.ErrNoRows
+	}
+	return o, nil
+}
+
+func (r *fakeRepo) SetHelpful(incoID, accountID int64, helpful bool) error {
+	r.helpful[incoID] = helpful
+	return nil
+}
+
+func TestBuildThreads(t *testing.T) {
+	// Como llegan de la base: del más nuevo al más viejo
+	comments := []Comment{
+		{IncoID: 6, AccountID: 3, Nickname: "carol", ParentIncoID: id(4)},
+		{IncoID: 5, AccountID: 1, Nickname: "ana"},
+		{IncoID: 4, AccountID: 2, Nickname: "bob", ParentIncoID: id(1)},
+		{IncoID: 3, AccountID: 4, Nickname: "dan", ParentIncoID: id(99)}, // padre borrado
+		{IncoID: 2, AccountID: 3, Nickname: "carol", ParentIncoID: id(1)},
+		{IncoID: 1, AccountID: 1, Nickname: "ana"},
+	}
+	reactions := []reactionRow{
+		{IncoID: 1, Reaction: "heart", Count: 1},
+		{IncoID: 1, Reaction: "thumbs_up", Count: 3, Mine: true},
+		{IncoID: 4, Reaction: "eyes", Count: 2, Mine: true},
+	}
+
+	threads := buildThreads(comments, reactions)
+	if len(threads) != 3 || threads[0].IncoID != 5 || threads[1].IncoID != 3 || threads[2].IncoID != 1 {
+		t.Fatalf("unexpected roots: %+v", threads)
+	}
+
+	root := threads[2]
+	if len(root.Replies) != 3 || root.Replies[0].IncoID != 2 || root.Replies[1].IncoID != 4 || root.Replies[2].IncoID != 6 {
+		t.Fatalf("replies should be oldest first and flattened into the root thread: %+v", root.Replies)
+	}
+	if root.Replies[2].ReplyToNickname != "bob" || root.Replies[0].ReplyToNickname != "" {
+		t.Errorf("a reply to a reply should point at who it answered: %+v", root.Replies)
+	}
+	if len(root.Reactions) != 2 || root.Reactions[0].Reaction != "thumbs_up" || root.Reactions[0].Emoji != "👍" || root.Reactions[0].Count != 3 {
+		t.Errorf("reactions should follow the Reactions order: %+v", root.Reactions)
+	}
+	if len(root.MyReactions) != 1 || root.MyReactions[0] != "thumbs_up" || len(root.Replies[1].MyReactions) != 1 {
+		t.Errorf("unexpected viewer reactions: %+v / %+v", root.MyReactions, root.Replies[1].MyReactions)
+	}
+	if threads[0].Reactions == nil || threads[0].MyReactions == nil || threads[0].Replies == nil {
+		t.Errorf("empty lists should not be nil: %+v", threads[0])
+	}
+}
+
+func TestSaveReplyMustBelongToCluster(t *testing.T) {
+	repo := &fakeRepo{owners: map[int64]CommentOwners{1: {InclID: 10, CommentAccountID: 1}}}
+	s := NewService(repo)
+
+	if _, err := s.Save(InComment{InclID: 10, ParentIncoID: 1, Comment: "same here"}); err != nil {
+		t.Fatalf("reply to a comment of the same cluster: %v", err)
+	}
+	if _, err := s.Save(InComment{InclID: 11, ParentIncoID: 1}); err != ErrInvalidParent {
+		t.Errorf("reply across clusters: expected ErrInvalidParent, got %v", err)
+	}
+	if _, err := s.Save(InComment{InclID: 10, ParentIncoID: 2}); err != ErrInvalidParent {
+		t.Errorf("reply to a missing comment: expected ErrInvalidParent, got %v", err)
+	}
+	if len(repo.saved) != 1 {
+		t.Errorf("only the valid reply should be saved, got %d", len(repo.saved))
+	}
+}
+
+func TestSetHelpfulOnlyIncidentAuthor(t *testing.T) {
+	repo := &fakeRepo{helpful: map[int64]bool{}, owners: map[int64]CommentOwners{
+		1: {InclID: 10, CommentAccountID: 2, ClusterAccountID: 7},
+		2: {InclID: 10, CommentAccountID: 7, ClusterAccountID: 7},
+	}}
+	s := NewService(repo)
+
+	if err := s.SetHelpful(1, 7, true); err != nil || !repo.helpful[1] {
+		t.Errorf("incident author should mark helpful: %v", err)
+	}
+	if err := s.SetHelpful(1, 2, true); err != ErrNotIncidentOwner {
+		t.Errorf("other accounts: expected ErrNotIncidentOwner, got %v", err)
+	}
+	if err := s.SetHelpful(2, 7, true); err != ErrNotIncidentOwner {
+		t.Errorf("own comment: expected ErrNotIncidentOwner, got %v", err)
+	}
+	if err := s.SetHelpful(3, 7, true); err != ErrCommentNotFound {
+		t.Errorf("missing comment: expected ErrCommentNotFound, got %v", err)
+	}
+	if _, err := s.React(1, 7, "party", true); err != ErrInvalidReaction {
+		t.Errorf("unknown reaction: expected ErrInvalidReaction, got %v", err)
+	}
+}
+
+func TestFlattenKeepsLegacyOrder(t *testing.T) {
+	comments := []Comment{
+		{IncoID: 4, ParentIncoID: id(1)},
+		{IncoID: 3},
+		{IncoID: 2, ParentIncoID: id(1)},
+		{IncoID: 1},
+	}
+	flat := Flatten(buildThreads(comments, nil))
+	if len(flat) != 4 {
+		t.Fatalf("every comment should be in the flat list: %+v", flat)
+	}
+	for i, want := range []int64{4, 3, 2, 1} {
+		if flat[i].IncoID != want || len(flat[i].Replies) != 0 {
+			t.Errorf("flat[%d] = %+v, want inco_id %d without replies", i, flat[i], want)
+		}
+	}
+	if flat[0].ParentIncoID == nil || *flat[0].ParentIncoID != 1 {
+		t.Errorf("replies should keep parent_inco_id: %+v", flat[0])
+	}
+}
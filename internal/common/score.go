@@ -6,13 +6,23 @@ import (
 	"fmt"
 )
 
-func SaveScore(dbExec DBExecutor, accountID int64, score uint8) error {
+// OfficialConfirmationScore se suma una sola vez a score_true de un cluster confirmado por una fuente oficial
+// (cjbot_creator), lo mismo que tres votos true de cuentas con credibilidad 10.
+// clustermerge lo descuenta cuando los dos clusters fusionados ya lo tenían.
+const OfficialConfirmationScore = 30.0
+
+// SaveScore suma (o con score negativo resta, p.ej. al retirar un report) puntos de citizen score.
+// El score nunca queda por debajo de 0 y solo los puntos ganados generan notificación.
+func SaveScore(dbExec DBExecutor, accountID int64, score int) error {
 	// 1. Actualizar score en la cuenta
-	updateQuery := `UPDATE account SET score = score + $1 WHERE account_id = $2`
+	updateQuery := `UPDATE account SET score = GREATEST(score + $1, 0) WHERE account_id = $2`
 	_, err := dbExec.Exec(updateQuery, score, accountID)
 	if err != nil {
 		return fmt.Errorf("failed to update score: %w", err)
 	}
+	if score <= 0 {
+		return nil
+	}
 
 	// 2. Crear notificación citizen score (solo in-app)
 	err = saveScoreNotification(dbExec, accountID, score)
@@ -25,7 +35,7 @@ func SaveScore(dbExec DBExecutor, accountID int64, score uint8) error {
 }
 
 // saveScoreNotification crea una notificación in-app para citizen score
-func saveScoreNotification(dbExec DBExecutor, accountID int64, score uint8) error {
+func saveScoreNotification(dbExec DBExecutor, accountID int64, score int) error {
 	// Crear título personalizado con puntos ganados (max 45 chars para varchar(45))
 	title := fmt.Sprintf("+%d Citizen Points earned!", score)
 	message := "Congratulations! Keep contributing to your community!"
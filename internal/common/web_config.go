@@ -0,0 +1,48 @@
+package common
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// GetPublicWebURL retorna la URL del sitio web público (landing pages de incidentes)
+// En producción: https://alertly.ca
+func GetPublicWebURL() string {
+	baseURL := os.Getenv("PUBLIC_WEB_URL")
+	if baseURL == "" {
+		baseURL = "https://alertly.ca"
+	}
+	return strings.TrimRight(baseURL, "/")
+}
+
+// GetPublicAPIURL retorna la URL pública de la API (API_URL), para los links absolutos que arma el
+// servidor (feeds, páginas de compartir). No se usa el Host del request: se podría envenenar el cache.
+// En producción: https://api.alertly.ca
+func GetPublicAPIURL() string {
+	baseURL := os.Getenv("API_URL")
+	if baseURL == "" {
+		baseURL = "https://api.alertly.ca"
+	}
+	return strings.TrimRight(baseURL, "/")
+}
+
+// GetIncidentWebURL construye el link público de un cluster
+func GetIncidentWebURL(inclID int64) string {
+	return GetPublicWebURL() + "/incident/" + strconv.FormatInt(inclID, 10)
+}
+
+// GetAppDeepLink construye el link que abre un cluster en la app (APP_URL_SCHEME, por defecto "alertly")
+func GetAppDeepLink(inclID int64) string {
+	scheme := strings.TrimSuffix(os.Getenv("APP_URL_SCHEME"), "://")
+	if scheme == "" {
+		scheme = "alertly"
+	}
+	return scheme + "://incident/" + strconv.FormatInt(inclID, 10)
+}
+
+// GetAppStoreURLs retorna los links de descarga (IOS_APP_STORE_URL, ANDROID_PLAY_STORE_URL);
+// vacíos si no están configurados
+func GetAppStoreURLs() (ios, android string) {
+	return os.Getenv("IOS_APP_STORE_URL"), os.Getenv("ANDROID_PLAY_STORE_URL")
+}
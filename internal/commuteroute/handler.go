@@ -0,0 +1,35 @@
+package commuteroute
+
+import (
+	"alertly/internal/database"
+	"alertly/internal/response"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Find devuelve los incidentes activos a lo largo de una ruta ("¿hay algo en mi camino al trabajo?")
+func Find(c *gin.Context) {
+	var in Inputs
+	if err := c.ShouldBindJSON(&in); err != nil {
+		log.Printf("Error al bindear ruta: %v", err)
+		response.Send(c, http.StatusBadRequest, true, "Invalid route. Please check and try again.", nil)
+		return
+	}
+
+	route, err := ParseRoute(in.Polyline, in.Waypoints, in.Buffer)
+	if err != nil {
+		response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+		return
+	}
+
+	service := NewService(NewRepository(database.DB))
+	result, err := service.FindAlongRoute(route, in.Categories)
+	if err != nil {
+		log.Printf("Error querying clusters along route: %v", err)
+		response.Send(c, http.StatusInternalServerError, true, "We couldn’t load the incidents. Please try again later.", nil)
+		return
+	}
+	response.Send(c, http.StatusOK, false, "Success", result)
+}
@@ -0,0 +1,46 @@
+package commuteroute
+
+import (
+	"alertly/internal/polyline"
+	"time"
+)
+
+// Inputs de /cluster/route: la ruta como encoded polyline (Google Directions) o como lista de waypoints
+type Inputs struct {
+	Polyline   string           `json:"polyline"`
+	Waypoints  []polyline.Point `json:"waypoints"`
+	Buffer     float64          `json:"buffer"`     // metros a cada lado de la ruta, por defecto 150
+	Categories []string         `json:"categories"` // category_code, vacío = todas
+}
+
+// Route es la ruta ya validada: los vértices en orden de viaje y el ancho del corredor
+type Route struct {
+	Points []polyline.Point
+	Buffer float64 // metros
+}
+
+// Cluster es un incidente activo dentro del corredor
+type Cluster struct {
+	InclId           int64     `json:"incl_id"`
+	Latitude         float64   `json:"latitude"`
+	Longitude        float64   `json:"longitude"`
+	InsuId           int64     `json:"insu_id"`
+	CategoryCode     string    `json:"category_code"`
+	SubcategoryCode  string    `json:"subcategory_code"`
+	SubcategoryName  string    `json:"subcategory_name"`
+	Credibility      float64   `json:"credibility"`
+	TotalVotes       int64     `json:"counter_total_votes"`
+	IsOfficial       bool      `json:"is_official"`
+	CreatedAt        time.Time `json:"created_at"`
+	DistanceMeters   float64   `json:"distance_meters"`    // distancia a la ruta
+	RoutePosition    float64   `json:"route_position"`     // 0 = inicio, 1 = destino
+	MetersFromOrigin float64   `json:"meters_from_origin"` // distancia aproximada desde el inicio siguiendo la ruta
+}
+
+// RouteClusters: los clusters vienen ordenados por su posición a lo largo de la ruta
+type RouteClusters struct {
+	Clusters     []Cluster `json:"clusters"`
+	LengthMeters float64   `json:"length_meters"`
+	Buffer       float64   `json:"buffer"`
+	Truncated    bool      `json:"truncated"` // hubo más de maxClusters incidentes en el corredor
+}
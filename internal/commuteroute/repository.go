@@ -0,0 +1,71 @@
+package commuteroute
+
+import (
+	"alertly/internal/clusterquery"
+	"alertly/internal/cronjobs/cjbot_creator"
+	"alertly/internal/polyline"
+	"database/sql"
+	"fmt"
+)
+
+type Repository interface {
+	FindAlongRoute(route Route, categories []string, limit int) ([]Cluster, error)
+}
+
+type pgRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &pgRepository{db: db}
+}
+
+// FindAlongRoute devuelve los clusters activos a menos de route.Buffer metros de la ruta,
+// ordenados por su posición a lo largo de ella (ST_LineLocatePoint)
+func (r *pgRepository) FindAlongRoute(route Route, categories []string, limit int) ([]Cluster, error) {
+	b := clusterquery.NewBuilder("c", cjbot_creator.BOT_USER_ID).Apply(clusterquery.Filter{
+		Status:     clusterquery.StatusActive,
+		Categories: categories,
+	})
+	line := b.Arg(polyline.WKT(route.Points))
+	// ✅ ST_DWithin sobre geography usa el índice GiST de center_location
+	b.Where(fmt.Sprintf("ST_DWithin(c.center_location, ST_GeogFromText(%s), %s)", line, b.Arg(route.Buffer)))
+
+	query := fmt.Sprintf(`
+    SELECT
+        c.incl_id,
+        c.center_latitude,
+        c.center_longitude,
+        c.insu_id,
+        COALESCE(c.category_code, ''),
+        COALESCE(c.subcategory_code, ''),
+        COALESCE(c.subcategory_name, ''),
+        COALESCE(c.credibility, 0),
+        COALESCE(c.counter_total_votes, 0),
+        COALESCE(c.account_id, 0) = $1,
+        c.created_at,
+        ST_Distance(c.center_location, ST_GeogFromText(%[1]s)),
+        ST_LineLocatePoint(ST_GeomFromEWKT(%[1]s), c.center_location::geometry) AS route_position
+    FROM incident_clusters c
+    WHERE %[2]s
+    ORDER BY route_position ASC, c.incl_id ASC`, line, b.SQL())
+	query += b.Limit(limit)
+
+	rows, err := r.db.Query(query, b.Params()...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying clusters along route: %w", err)
+	}
+	defer rows.Close()
+
+	var clusters []Cluster
+	for rows.Next() {
+		var c Cluster
+		if err := rows.Scan(&c.InclId, &c.Latitude, &c.Longitude, &c.InsuId, &c.CategoryCode, &c.SubcategoryCode,
+			&c.SubcategoryName, &c.Credibility, &c.TotalVotes, &c.IsOfficial, &c.CreatedAt,
+			&c.DistanceMeters, &c.RoutePosition); err != nil {
+			return nil, fmt.Errorf("error scanning cluster: %w", err)
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters, rows.Err()
+}
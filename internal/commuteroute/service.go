@@ -0,0 +1,101 @@
+package commuteroute
+
+import (
+	"alertly/internal/clusterquery"
+	"alertly/internal/polyline"
+	"errors"
+	"strings"
+)
+
+const (
+	DefaultBuffer = 150  // metros
+	MinBuffer     = 25   // metros
+	MaxBuffer     = 1000 // metros
+	maxPoints     = 1000
+	maxLength     = 200000 // metros, un trayecto diario largo
+	maxClusters   = 200
+)
+
+var (
+	ErrInvalidRoute  = errors.New("send the route as an encoded polyline or a list of at least 2 waypoints (up to 1000 points and 200 km)")
+	ErrInvalidBuffer = errors.New("buffer must be between 25 and 1000 meters")
+)
+
+type Service interface {
+	FindAlongRoute(route Route, categories []string) (RouteClusters, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+// ParseRoute valida la ruta (polyline o waypoints, no ambos) y el buffer. Buffer 0 usa DefaultBuffer.
+// La usa también myplaces para guardar la ruta como lugar.
+func ParseRoute(encoded string, waypoints []polyline.Point, buffer float64) (Route, error) {
+	encoded = strings.TrimSpace(encoded)
+	if (encoded == "") == (len(waypoints) == 0) {
+		return Route{}, ErrInvalidRoute
+	}
+
+	points := waypoints
+	if encoded != "" {
+		var err error
+		if points, err = polyline.Decode(encoded); err != nil {
+			return Route{}, ErrInvalidRoute
+		}
+	}
+	points = dropRepeated(points)
+	if len(points) < 2 || len(points) > maxPoints {
+		return Route{}, ErrInvalidRoute
+	}
+	for _, p := range points {
+		if p.Latitude < -90 || p.Latitude > 90 || p.Longitude < -180 || p.Longitude > 180 {
+			return Route{}, ErrInvalidRoute
+		}
+	}
+	if polyline.LengthMeters(points) > maxLength {
+		return Route{}, ErrInvalidRoute
+	}
+
+	if buffer == 0 {
+		buffer = DefaultBuffer
+	}
+	if buffer < MinBuffer || buffer > MaxBuffer {
+		return Route{}, ErrInvalidBuffer
+	}
+	return Route{Points: points, Buffer: buffer}, nil
+}
+
+// dropRepeated quita vértices consecutivos iguales (Directions los repite entre tramos)
+func dropRepeated(points []polyline.Point) []polyline.Point {
+	out := make([]polyline.Point, 0, len(points))
+	for i, p := range points {
+		if i > 0 && p == points[i-1] {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func (s *service) FindAlongRoute(route Route, categories []string) (RouteClusters, error) {
+	result := RouteClusters{Clusters: []Cluster{}, Buffer: route.Buffer, LengthMeters: polyline.LengthMeters(route.Points)}
+
+	clusters, err := s.repo.FindAlongRoute(route, clusterquery.SplitList(categories...), maxClusters+1)
+	if err != nil {
+		return result, err
+	}
+	if len(clusters) > maxClusters {
+		clusters = clusters[:maxClusters]
+		result.Truncated = true
+	}
+	for i := range clusters {
+		clusters[i].MetersFromOrigin = clusters[i].RoutePosition * result.LengthMeters
+	}
+	result.Clusters = append(result.Clusters, clusters...)
+	return result, nil
+}
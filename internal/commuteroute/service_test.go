@@ -0,0 +1,61 @@
+package commuteroute
+
+import (
+	"alertly/internal/polyline"
+	"testing"
+)
+
+func TestParseRouteFromPolyline(t *testing.T) {
+	// Tres puntos del centro de Montreal
+	route, err := ParseRoute("s`vtGrr_`Mkk@crA{m@fY", nil, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(route.Points) != 3 || route.Buffer != DefaultBuffer {
+		t.Errorf("expected 3 points with the default buffer, got %+v", route)
+	}
+}
+
+func TestParseRouteFromWaypoints(t *testing.T) {
+	waypoints := []polyline.Point{pt(45.5017, -73.5673), pt(45.5017, -73.5673), pt(45.5088, -73.554)}
+	route, err := ParseRoute("", waypoints, 300)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(route.Points) != 2 {
+		t.Errorf("expected repeated waypoints to be dropped, got %d points", len(route.Points))
+	}
+	if route.Buffer != 300 {
+		t.Errorf("expected buffer 300, got %v", route.Buffer)
+	}
+}
+
+func TestParseRouteRejects(t *testing.T) {
+	montreal := []polyline.Point{pt(45.5017, -73.5673), pt(45.5088, -73.554)}
+	cases := []struct {
+		name      string
+		encoded   string
+		waypoints []polyline.Point
+		buffer    float64
+		want      error
+	}{
+		{"nothing", "", nil, 0, ErrInvalidRoute},
+		{"both", "_p~iF~ps|U_ulLnnqC", montreal, 0, ErrInvalidRoute},
+		{"single point", "", montreal[:1], 0, ErrInvalidRoute},
+		{"same point twice", "", []polyline.Point{montreal[0], montreal[0]}, 0, ErrInvalidRoute},
+		{"bad polyline", "_p~iF~ps|U_", nil, 0, ErrInvalidRoute},
+		{"out of range", "", []polyline.Point{pt(91, 0), pt(45, 0)}, 0, ErrInvalidRoute},
+		{"too long", "", []polyline.Point{pt(45.5, -73.5), pt(43.65, -79.38)}, 0, ErrInvalidRoute}, // Montreal-Toronto
+		{"buffer too small", "", montreal, 10, ErrInvalidBuffer},
+		{"buffer too large", "", montreal, 5000, ErrInvalidBuffer},
+	}
+	for _, tc := range cases {
+		if _, err := ParseRoute(tc.encoded, tc.waypoints, tc.buffer); err != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func pt(lat, lng float64) polyline.Point {
+	return polyline.Point{Latitude: lat, Longitude: lng}
+}
@@ -1,6 +1,8 @@
 package cjblockincident
 
 import (
+	"alertly/internal/clustertimeline"
+	"alertly/internal/realtime"
 	"database/sql"
 	"fmt"
 )
@@ -52,16 +54,56 @@ func (r *Repository) FetchIncidentsToReject() ([]IncidentToReject, error) {
 	return incidentsToReject, nil
 }
 
-// RejectIncident actualiza el estado de un incidente a 'rejected'.
+// RejectIncident actualiza el estado de un incidente a 'rejected' y lo registra en la línea de tiempo del cluster.
 func (r *Repository) RejectIncident(incidentID int64) error {
 	query := `
         UPDATE incident_reports
         SET status = 'rejected'
         WHERE inre_id = $1
+        RETURNING COALESCE(incl_id, 0)
     `
-	_, err := r.db.Exec(query, incidentID)
+	var inclID int64
+	err := r.db.QueryRow(query, incidentID).Scan(&inclID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("RejectIncident: %w", err)
 	}
+
+	if inclID != 0 {
+		details := map[string]interface{}{"inre_id": incidentID, "reason": "flagged"}
+		if err := clustertimeline.RecordEvent(r.db, inclID, clustertimeline.EventReportRejected, 0, details); err != nil {
+			return fmt.Errorf("RejectIncident: %w", err)
+		}
+		if err := r.blockClusterIfAllRejected(inclID); err != nil {
+			return fmt.Errorf("RejectIncident: %w", err)
+		}
+	}
 	return nil
 }
+
+// blockClusterIfAllRejected desactiva el cluster y setea blocked_at cuando ya no le queda ningún reporte
+// sin rechazar ni retirar. El mapa lo quita en el próximo sync.
+func (r *Repository) blockClusterIfAllRejected(inclID int64) error {
+	res, err := r.db.Exec(`
+        UPDATE incident_clusters
+        SET is_active = '0', blocked_at = NOW()
+        WHERE incl_id = $1
+          AND blocked_at IS NULL
+          AND NOT EXISTS (
+              SELECT 1 FROM incident_reports
+              WHERE incl_id = $1
+                AND COALESCE(status, '') <> 'rejected'
+                AND withdrawn_at IS NULL
+          )
+    `, inclID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+	realtime.PublishCluster(realtime.EventClusterExpired, inclID)
+	return clustertimeline.RecordEvent(r.db, inclID, clustertimeline.EventBlocked, 0, map[string]interface{}{"reason": "all_reports_rejected"})
+}
@@ -0,0 +1,140 @@
+package cjbot_creator
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+// ============================================
+// OFFICIAL CORRELATION
+// Links user clusters to the bot incident that describes the same event
+// ============================================
+
+const (
+	officialMatchWindow       = 3 * time.Hour // max time between the official report and the user cluster
+	officialMatchRadiusFactor = 2.0           // official coordinates are usually an intersection, so be generous
+	officialMatchMinScore     = 0.35
+
+	// Match score weights (sum = 1)
+	officialWeightDistance    = 0.5
+	officialWeightTime        = 0.3
+	officialWeightSubcategory = 0.2
+)
+
+// OfficialReport is a bot report that can confirm user clusters
+type OfficialReport struct {
+	InreID          int64
+	InclID          int64
+	Source          string
+	CategoryCode    string
+	SubcategoryCode string
+	Latitude        float64
+	Longitude       float64
+	CreatedAt       time.Time
+}
+
+// UserClusterCandidate is a user cluster near an official report
+type UserClusterCandidate struct {
+	InclID          int64
+	SubcategoryCode string
+	CreatedAt       time.Time
+	DistanceMeters  float64
+}
+
+// OfficialMatch is a user cluster confirmed by an official report
+type OfficialMatch struct {
+	InclID         int64
+	InreID         int64
+	Source         string
+	DistanceMeters float64
+	TimeDiff       time.Duration
+	Score          float64
+}
+
+// officialMatchRadius returns the correlation radius for a category (in meters)
+func (r *Repository) officialMatchRadius(categoryCode string) float64 {
+	return r.getClusteringRadius(categoryCode) * officialMatchRadiusFactor
+}
+
+// scoreOfficialMatch combines distance, time and subcategory into a 0-1 score.
+// Returns ok=false if the candidate is outside the radius or the time window.
+func scoreOfficialMatch(report OfficialReport, candidate UserClusterCandidate, radius float64) (OfficialMatch, bool) {
+	timeDiff := candidate.CreatedAt.Sub(report.CreatedAt)
+	if timeDiff < 0 {
+		timeDiff = -timeDiff
+	}
+	if radius <= 0 || candidate.DistanceMeters > radius || timeDiff > officialMatchWindow {
+		return OfficialMatch{}, false
+	}
+
+	subcategory := 0.0
+	if report.SubcategoryCode != "" && strings.EqualFold(report.SubcategoryCode, candidate.SubcategoryCode) {
+		subcategory = 1
+	}
+
+	score := officialWeightDistance*(1-candidate.DistanceMeters/radius) +
+		officialWeightTime*(1-timeDiff.Seconds()/officialMatchWindow.Seconds()) +
+		officialWeightSubcategory*subcategory
+
+	return OfficialMatch{
+		InclID:         candidate.InclID,
+		InreID:         report.InreID,
+		Source:         report.Source,
+		DistanceMeters: candidate.DistanceMeters,
+		TimeDiff:       timeDiff,
+		Score:          score,
+	}, score >= officialMatchMinScore
+}
+
+// selectOfficialMatches returns the candidates confirmed by the official report.
+// Several user clusters can describe the same event, so all that pass are linked.
+func selectOfficialMatches(report OfficialReport, candidates []UserClusterCandidate, radius float64) []OfficialMatch {
+	var matches []OfficialMatch
+	for _, c := range candidates {
+		if match, ok := scoreOfficialMatch(report, c, radius); ok {
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+// RunCorrelation links recent official reports to the user clusters that describe the same event
+func (s *Service) RunCorrelation() {
+	log.Printf("🔗 [Correlation] Starting official correlation job...")
+	startTime := time.Now()
+
+	// Clusters are only matched within the window, so older reports can't get new links
+	lookback := int((officialMatchWindow + time.Hour).Minutes())
+	reports, err := s.repo.GetRecentBotReports(lookback)
+	if err != nil {
+		log.Printf("❌ [Correlation] Error getting bot reports: %v", err)
+		return
+	}
+
+	linked := 0
+	for _, report := range reports {
+		radius := s.repo.officialMatchRadius(report.CategoryCode)
+		candidates, err := s.repo.FindUserClustersNear(report, radius, int(officialMatchWindow.Minutes()))
+		if err != nil {
+			log.Printf("⚠️ [Correlation] Error finding clusters for report #%d: %v", report.InreID, err)
+			continue
+		}
+
+		for _, match := range selectOfficialMatches(report, candidates, radius) {
+			ok, err := s.repo.LinkOfficialReport(match)
+			if err != nil {
+				log.Printf("⚠️ [Correlation] Error linking cluster %d to report #%d: %v", match.InclID, match.InreID, err)
+				continue
+			}
+			if ok {
+				linked++
+				log.Printf("✅ [Correlation] Cluster %d confirmed by %s report #%d (%.0fm, score %.2f)",
+					match.InclID, match.Source, match.InreID, match.DistanceMeters, match.Score)
+			}
+		}
+	}
+
+	log.Printf("✅ [Correlation] Job completed in %v. Linked %d clusters from %d official reports",
+		time.Since(startTime), linked, len(reports))
+}
@@ -0,0 +1,79 @@
+package cjbot_creator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScoreOfficialMatch(t *testing.T) {
+	now := time.Date(2026, 10, 17, 14, 0, 0, 0, time.UTC)
+	report := OfficialReport{InreID: 1, Source: "tps", SubcategoryCode: "collision", CreatedAt: now}
+
+	tests := []struct {
+		name      string
+		candidate UserClusterCandidate
+		wantOK    bool
+		wantScore float64
+	}{
+		{
+			name:      "same place, same time, same subcategory",
+			candidate: UserClusterCandidate{InclID: 10, SubcategoryCode: "collision", CreatedAt: now, DistanceMeters: 0},
+			wantOK:    true,
+			wantScore: 1,
+		},
+		{
+			name:      "half radius, half window, other subcategory",
+			candidate: UserClusterCandidate{InclID: 11, SubcategoryCode: "hit_and_run", CreatedAt: now.Add(-officialMatchWindow / 2), DistanceMeters: 100},
+			wantOK:    true,
+			wantScore: 0.4,
+		},
+		{
+			name:      "edge of radius and window, other subcategory",
+			candidate: UserClusterCandidate{InclID: 12, CreatedAt: now.Add(officialMatchWindow), DistanceMeters: 200},
+			wantOK:    false,
+			wantScore: 0,
+		},
+		{
+			name:      "outside radius",
+			candidate: UserClusterCandidate{InclID: 13, SubcategoryCode: "collision", CreatedAt: now, DistanceMeters: 201},
+			wantOK:    false,
+		},
+		{
+			name:      "outside window",
+			candidate: UserClusterCandidate{InclID: 14, SubcategoryCode: "collision", CreatedAt: now.Add(officialMatchWindow + time.Minute)},
+			wantOK:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, ok := scoreOfficialMatch(report, tt.candidate, 200)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v (score %.2f)", ok, tt.wantOK, match.Score)
+			}
+			if tt.wantScore != 0 && (match.Score < tt.wantScore-0.001 || match.Score > tt.wantScore+0.001) {
+				t.Errorf("score = %.3f, want %.3f", match.Score, tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestSelectOfficialMatches(t *testing.T) {
+	now := time.Now()
+	report := OfficialReport{InreID: 7, Source: "tfs", SubcategoryCode: "fire", CreatedAt: now}
+	candidates := []UserClusterCandidate{
+		{InclID: 1, SubcategoryCode: "fire", CreatedAt: now.Add(10 * time.Minute), DistanceMeters: 20},
+		{InclID: 2, SubcategoryCode: "smoke", CreatedAt: now.Add(-2 * time.Hour), DistanceMeters: 280},
+		{InclID: 3, SubcategoryCode: "fire", CreatedAt: now.Add(30 * time.Minute), DistanceMeters: 120},
+	}
+
+	matches := selectOfficialMatches(report, candidates, 300)
+	if len(matches) != 2 || matches[0].InclID != 1 || matches[1].InclID != 3 {
+		t.Fatalf("unexpected matches: %+v", matches)
+	}
+	for _, m := range matches {
+		if m.InreID != 7 || m.Source != "tfs" {
+			t.Errorf("match should reference the official report: %+v", m)
+		}
+	}
+}
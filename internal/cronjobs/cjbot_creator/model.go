@@ -1,6 +1,9 @@
 package cjbot_creator
 
-import "time"
+import (
+	"alertly/internal/cronjobs/cjbot_creator/scrapers"
+	"time"
+)
 
 // ScrapedIncident and Point types are defined in scrapers/types.go
 // Import them from there to avoid circular dependencies
@@ -19,6 +22,8 @@ type NormalizedIncident struct {
 	Province       string
 	PostalCode     string
 	EventType      string  // Category name (e.g., "crime", "fire_incident")
+	Source         string  // Official source (e.g., "tps", "tfs")
+	Polygon        []scrapers.Point // Affected area (hydro outages), nil for point incidents
 }
 
 // BotIncidentHash represents a deduplication record
@@ -217,6 +217,7 @@ func (n *Normalizer) Normalize(scraped scrapers.ScrapedIncident) (*NormalizedInc
 		CategoryCode:    bestMatch.CategoryCode,
 		SubcategoryCode: bestMatch.SubcategoryCode,
 		EventType:       bestMatch.CategoryCode, // event_type = category name
+		Source:          scraped.Source,
 	}
 
 	// Determine image URL (camera URL or official asset)
@@ -236,6 +237,7 @@ func (n *Normalizer) Normalize(scraped scrapers.ScrapedIncident) (*NormalizedInc
 		normalized.Latitude = centroid.Lat
 		normalized.Longitude = centroid.Lng
 	}
+	normalized.Polygon = scraped.Polygon
 	// Note: If no coordinates available, they will be geocoded later from address
 	// normalized.Latitude and normalized.Longitude will be 0 (will be checked in service)
 
@@ -419,3 +421,22 @@ func CalculateCentroid(points []scrapers.Point) scrapers.Point {
 		Lng: sumLng / float64(len(points)),
 	}
 }
+
+// PolygonWKT returns the polygon as WKT (lng lat) with a closed ring for official_area.
+// Returns "" if there are fewer than 3 distinct points.
+func PolygonWKT(points []scrapers.Point) string {
+	ring := points
+	if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		ring = ring[:len(ring)-1]
+	}
+	if len(ring) < 3 {
+		return ""
+	}
+
+	coords := make([]string, 0, len(ring)+1)
+	for _, p := range ring {
+		coords = append(coords, fmt.Sprintf("%f %f", p.Lng, p.Lat))
+	}
+	coords = append(coords, coords[0])
+	return "POLYGON((" + strings.Join(coords, ", ") + "))"
+}
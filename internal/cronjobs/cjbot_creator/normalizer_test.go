@@ -0,0 +1,25 @@
+package cjbot_creator
+
+import (
+	"alertly/internal/cronjobs/cjbot_creator/scrapers"
+	"testing"
+)
+
+func TestPolygonWKT(t *testing.T) {
+	open := []scrapers.Point{{Lat: 43.65, Lng: -79.39}, {Lat: 43.66, Lng: -79.38}, {Lat: 43.64, Lng: -79.37}}
+	want := "POLYGON((-79.390000 43.650000, -79.380000 43.660000, -79.370000 43.640000, -79.390000 43.650000))"
+
+	if got := PolygonWKT(open); got != want {
+		t.Errorf("PolygonWKT(open ring) = %q, want %q", got, want)
+	}
+	// An already closed ring is not closed twice
+	if got := PolygonWKT(append(open, open[0])); got != want {
+		t.Errorf("PolygonWKT(closed ring) = %q, want %q", got, want)
+	}
+	if got := PolygonWKT(open[:2]); got != "" {
+		t.Errorf("PolygonWKT(2 points) = %q, want empty", got)
+	}
+	if got := PolygonWKT(nil); got != "" {
+		t.Errorf("PolygonWKT(nil) = %q, want empty", got)
+	}
+}
@@ -1,11 +1,14 @@
 package cjbot_creator
 
 import (
+	"alertly/internal/clustertimeline"
 	"alertly/internal/common"
+	"alertly/internal/realtime"
 	"crypto/sha256"
 	"database/sql"
 	"fmt"
 	"log"
+	"math"
 	"strings"
 	"time"
 )
@@ -215,9 +218,12 @@ func (r *Repository) SaveBotIncident(incident NormalizedIncident) (int64, error)
 			subcategory_code,
 			category_code,
 			event_type,
+			official_source,
+			official_area,
 			vote,
 			created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, 1, NOW()) RETURNING inre_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, NULLIF($16, ''),
+			ST_GeogFromText(NULLIF($17, '')), 1, NOW()) RETURNING inre_id
 	`
 
 	var reportID int64
@@ -237,11 +243,20 @@ func (r *Repository) SaveBotIncident(incident NormalizedIncident) (int64, error)
 		incident.SubcategoryCode,
 		incident.CategoryCode,
 		incident.EventType,
+		incident.Source,
+		PolygonWKT(incident.Polygon),
 	).Scan(&reportID)
 	if err != nil {
 		return 0, err
 	}
 
+	// 📡 Live map streams
+	if isNewCluster {
+		realtime.PublishCluster(realtime.EventClusterCreated, clusterID)
+	} else {
+		realtime.PublishCluster(realtime.EventClusterUpdated, clusterID)
+	}
+
 	// 🔔 Create notification when joining existing cluster (async)
 	if !isNewCluster {
 		go func(accountID int64, inclID int64, reportID int64) {
@@ -374,3 +389,138 @@ func (r *Repository) getSubcategoryDuration(subcategoryCode string) int {
 	}
 	return duration
 }
+
+// ============================================
+// OFFICIAL CORRELATION METHODS
+// ============================================
+
+// GetRecentBotReports returns active bot reports created in the last lookbackMinutes
+func (r *Repository) GetRecentBotReports(lookbackMinutes int) ([]OfficialReport, error) {
+	query := `
+		SELECT inre_id, incl_id, COALESCE(official_source, 'official'), COALESCE(category_code, ''),
+			COALESCE(subcategory_code, ''), latitude, longitude, created_at
+		FROM incident_reports
+		WHERE account_id = $1
+		AND COALESCE(is_active, '1') = '1'
+		AND created_at >= NOW() - INTERVAL '1 minute' * $2
+		ORDER BY created_at ASC
+	`
+	rows, err := r.db.Query(query, BOT_USER_ID, lookbackMinutes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []OfficialReport
+	for rows.Next() {
+		var rep OfficialReport
+		if err := rows.Scan(&rep.InreID, &rep.InclID, &rep.Source, &rep.CategoryCode, &rep.SubcategoryCode,
+			&rep.Latitude, &rep.Longitude, &rep.CreatedAt); err != nil {
+			return nil, err
+		}
+		reports = append(reports, rep)
+	}
+	return reports, rows.Err()
+}
+
+// FindUserClustersNear returns active clusters of the same category with at least one active user report,
+// created within windowMinutes of the official report and not yet linked to it.
+// The cluster the bot created for the report itself is never a candidate, even after users join it.
+func (r *Repository) FindUserClustersNear(report OfficialReport, radiusMeters float64, windowMinutes int) ([]UserClusterCandidate, error) {
+	query := `
+		SELECT
+			c.incl_id,
+			COALESCE(c.subcategory_code, ''),
+			c.created_at,
+			ST_Distance(c.center_location, ST_SetSRID(ST_MakePoint($3::float8, $2::float8), 4326)::geography)
+		FROM incident_clusters c
+		WHERE c.category_code = $1
+		AND c.incl_id <> $9
+		AND c.is_active = '1'
+		AND ST_DWithin(c.center_location, ST_SetSRID(ST_MakePoint($3::float8, $2::float8), 4326)::geography, $4)
+		AND c.created_at BETWEEN $5::timestamp - INTERVAL '1 minute' * $6 AND $5::timestamp + INTERVAL '1 minute' * $6
+		AND EXISTS (
+			SELECT 1 FROM incident_reports u
+			WHERE u.incl_id = c.incl_id AND u.account_id <> $7 AND COALESCE(u.is_active, '0') = '1'
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM incident_cluster_official_links l
+			WHERE l.incl_id = c.incl_id AND l.inre_id = $8
+		)
+	`
+	rows, err := r.db.Query(query, report.CategoryCode, report.Latitude, report.Longitude, radiusMeters,
+		report.CreatedAt, windowMinutes, BOT_USER_ID, report.InreID, report.InclID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []UserClusterCandidate
+	for rows.Next() {
+		var c UserClusterCandidate
+		if err := rows.Scan(&c.InclID, &c.SubcategoryCode, &c.CreatedAt, &c.DistanceMeters); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// LinkOfficialReport stores the link between a user cluster and an official report.
+// The first confirmation of a cluster adds common.OfficialConfirmationScore to score_true, recalculates
+// credibility and records the timeline event. Returns false if the link already existed.
+func (r *Repository) LinkOfficialReport(match OfficialMatch) (linked bool, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var inolID int64
+	err = tx.QueryRow(`
+		INSERT INTO incident_cluster_official_links (incl_id, inre_id, source, distance_meters, time_diff_secs, match_score)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (incl_id, inre_id) DO NOTHING
+		RETURNING inol_id
+	`, match.InclID, match.InreID, match.Source, match.DistanceMeters, int(match.TimeDiff.Seconds()), match.Score).Scan(&inolID)
+	if err == sql.ErrNoRows {
+		err = nil
+		tx.Rollback()
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE incident_clusters
+		SET official_confirmed_at = NOW(),
+			score_true = COALESCE(score_true, 0) + $2,
+			credibility = (COALESCE(score_true, 0) + $2)
+						/ GREATEST(COALESCE(score_true, 0) + $2 + COALESCE(score_false, 0), 1)
+						* 10
+		WHERE incl_id = $1 AND official_confirmed_at IS NULL
+	`, match.InclID, common.OfficialConfirmationScore)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		err = clustertimeline.RecordEvent(tx, match.InclID, clustertimeline.EventOfficialConfirmed, 0, map[string]interface{}{
+			"source":          match.Source,
+			"inre_id":         match.InreID,
+			"distance_meters": math.Round(match.DistanceMeters),
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -18,6 +18,9 @@ type CommentDetails struct {
 	CommentText string
 	CommenterID int64
 	SubcategoryName string
+	CommenterNickname string
+	// Respuesta: autor del comentario respondido (0 si es un comentario raíz)
+	ReplyToAccountID int64
 }
 
 // Recipient representa un usuario que debe recibir la notificación.
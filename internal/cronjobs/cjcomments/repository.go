@@ -59,16 +59,22 @@ func (r *Repository) FetchPendingCommentNotifications(limit int64) ([]CommentNot
 func (r *Repository) GetCommentDetails(commentID int64) (*CommentDetails, error) {
 	query := `
         SELECT
-            inc.inco_id, inc.incl_id, inc.comment, inc.account_id, ic.subcategory_name
+            inc.inco_id, inc.incl_id, inc.comment, inc.account_id, ic.subcategory_name,
+            COALESCE(a.nickname, ''), COALESCE(parent.account_id, 0)
         FROM
             incident_comments inc
         JOIN
             incident_clusters ic ON inc.incl_id = ic.incl_id
+        LEFT JOIN
+            account a ON a.account_id = inc.account_id
+        LEFT JOIN
+            incident_comments parent ON parent.inco_id = inc.parent_inco_id
         WHERE
             inc.inco_id = $1
     `
 	var cd CommentDetails
-	err := r.db.QueryRow(query, commentID).Scan(&cd.CommentID, &cd.ClusterID, &cd.CommentText, &cd.CommenterID, &cd.SubcategoryName)
+	err := r.db.QueryRow(query, commentID).Scan(&cd.CommentID, &cd.ClusterID, &cd.CommentText, &cd.CommenterID, &cd.SubcategoryName,
+		&cd.CommenterNickname, &cd.ReplyToAccountID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("comment with ID %d not found", commentID)
@@ -163,6 +169,32 @@ func (r *Repository) GetDeviceTokensForAccounts(accountIDs []int64) ([]Recipient
 	return recipients, nil
 }
 
+// GetReplyTargetTokens obtiene los tokens del autor de un comentario respondido. A diferencia de
+// GetDeviceTokensForAccounts no exige premium: una respuesta es una conversación directa.
+func (r *Repository) GetReplyTargetTokens(accountID int64) ([]Recipient, error) {
+	query := `
+        SELECT dt.account_id, dt.device_token
+        FROM device_tokens dt
+        JOIN account a ON dt.account_id = a.account_id
+        WHERE dt.account_id = $1 AND a.status = 'active' AND a.receive_notifications = 1
+    `
+	rows, err := r.db.Query(query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("GetReplyTargetTokens: %w", err)
+	}
+	defer rows.Close()
+
+	var recipients []Recipient
+	for rows.Next() {
+		var rec Recipient
+		if err := rows.Scan(&rec.AccountID, &rec.DeviceToken); err != nil {
+			return nil, fmt.Errorf("scanning reply target: %w", err)
+		}
+		recipients = append(recipients, rec)
+	}
+	return recipients, rows.Err()
+}
+
 // InsertNotificationDeliveries inserta en bloque los registros de envío de notificaciones.
 func (r *Repository) InsertNotificationDeliveries(deliveries []shared.Delivery) error {
 	return shared.InsertDeliveries(r.db, deliveries)
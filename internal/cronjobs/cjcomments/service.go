@@ -5,6 +5,7 @@ import (
 	"alertly/internal/cronjobs/shared"
 	"fmt"
 	"log"
+	"unicode/utf8"
 
 	"github.com/sideshow/apns2/payload"
 )
@@ -76,6 +77,13 @@ func (s *Service) Run() {
 			}
 		}
 
+		// Respuesta: el autor del comentario respondido recibe su propio aviso, no el genérico
+		replyTo := commentDetails.ReplyToAccountID
+		if replyTo == commentDetails.CommenterID {
+			replyTo = 0
+		}
+		delete(recipientAccountIDs, replyTo)
+
 		var uniqueRecipientIDs []int64
 		for id := range recipientAccountIDs {
 			uniqueRecipientIDs = append(uniqueRecipientIDs, id)
@@ -88,6 +96,19 @@ func (s *Service) Run() {
 			continue
 		}
 
+		if replyTo != 0 {
+			targets, err := s.repo.GetReplyTargetTokens(replyTo)
+			if err != nil {
+				log.Printf("cjcomments: Error getting device tokens for reply target %d: %v", replyTo, err)
+			}
+			title, message := replyMessage(commentDetails)
+			for _, target := range targets {
+				if delivery, ok := s.send(notif.NotificationID, target, commentDetails.ClusterID, title, message); ok {
+					allDeliveries = append(allDeliveries, delivery)
+				}
+			}
+		}
+
 		// Enviar notificaciones push y preparar registros de entrega
 		for _, recipient := range recipients {
 			// Personalizar el mensaje
@@ -97,36 +118,9 @@ func (s *Service) Run() {
 				message = message[:197] + "..."
 			}
 
-			// Data para navegación
-			pushData := map[string]interface{}{
-				"screen": "ViewIncidentScreen",
-				"inclId": fmt.Sprintf("%d", commentDetails.ClusterID),
+			if delivery, ok := s.send(notif.NotificationID, recipient, commentDetails.ClusterID, title, message); ok {
+				allDeliveries = append(allDeliveries, delivery)
 			}
-
-			err := common.SendPush(
-				common.ExpoPushMessage{
-					Title: title,
-					Body:  message,
-					Data:  pushData,
-				},
-				recipient.DeviceToken,
-				payload.NewPayload().
-					AlertTitle(title).
-					AlertBody(message).
-					Custom("screen", "ViewIncidentScreen").
-					Custom("inclId", commentDetails.ClusterID),
-			)
-			if err != nil {
-				log.Printf("cjcomments: Error sending push to account %d (%s): %v", recipient.AccountID, recipient.DeviceToken, err)
-				continue
-			}
-
-			allDeliveries = append(allDeliveries, shared.Delivery{
-				NotificationID: notif.NotificationID,
-				AccountID:      recipient.AccountID,
-				Title:          title,
-				Message:        message,
-			})
 		}
 
 		processedNotifIDs = append(processedNotifIDs, notif.NotificationID)
@@ -148,3 +142,51 @@ func (s *Service) Run() {
 
 	log.Printf("cjcomments: Processed %d comment notifications.", len(notifs))
 }
+
+// send envía el push de un comentario y devuelve el registro de entrega si salió bien.
+func (s *Service) send(notificationID int64, recipient Recipient, clusterID int64, title, message string) (shared.Delivery, bool) {
+	// Data para navegación
+	pushData := map[string]interface{}{
+		"screen": "ViewIncidentScreen",
+		"inclId": fmt.Sprintf("%d", clusterID),
+	}
+
+	err := common.SendPush(
+		common.ExpoPushMessage{
+			Title: title,
+			Body:  message,
+			Data:  pushData,
+		},
+		recipient.DeviceToken,
+		payload.NewPayload().
+			AlertTitle(title).
+			AlertBody(message).
+			Custom("screen", "ViewIncidentScreen").
+			Custom("inclId", clusterID),
+	)
+	if err != nil {
+		log.Printf("cjcomments: Error sending push to account %d (%s): %v", recipient.AccountID, recipient.DeviceToken, err)
+		return shared.Delivery{}, false
+	}
+
+	return shared.Delivery{
+		NotificationID: notificationID,
+		AccountID:      recipient.AccountID,
+		Title:          title,
+		Message:        message,
+	}, true
+}
+
+// replyMessage arma el aviso para el autor del comentario respondido.
+func replyMessage(cd *CommentDetails) (string, string) {
+	name := cd.CommenterNickname
+	if name == "" {
+		name = "Someone"
+	}
+	title := fmt.Sprintf("New reply on %s", cd.SubcategoryName)
+	message := fmt.Sprintf("%s replied to your comment: %s", name, cd.CommentText)
+	if utf8.RuneCountInString(message) > 200 { // Limitar la longitud del mensaje para push
+		message = string([]rune(message)[:197]) + "..."
+	}
+	return title, message
+}
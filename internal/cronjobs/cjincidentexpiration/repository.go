@@ -27,8 +27,9 @@ func NewRepository(db *sql.DB) Repository {
 
 // ExpiredCluster holds the necessary information for a cluster that has expired.
 type ExpiredCluster struct {
-	ID          int64
-	Credibility sql.NullFloat64 // Can be NULL in the database
+	ID                int64
+	Credibility       sql.NullFloat64 // Can be NULL in the database
+	OfficialConfirmed bool            // Linked to an official bot incident
 }
 
 // VoteRecord holds information about a single user's vote on an incident.
@@ -42,7 +43,8 @@ func (r *pgRepository) GetExpiredClusters() ([]ExpiredCluster, error) {
 	query := `
 		SELECT
 			ic.incl_id,
-			ic.credibility
+			ic.credibility,
+			ic.official_confirmed_at IS NOT NULL
 		FROM
 			incident_clusters AS ic
 		JOIN
@@ -60,7 +62,7 @@ func (r *pgRepository) GetExpiredClusters() ([]ExpiredCluster, error) {
 	var clusters []ExpiredCluster
 	for rows.Next() {
 		var cluster ExpiredCluster
-		if err := rows.Scan(&cluster.ID, &cluster.Credibility); err != nil {
+		if err := rows.Scan(&cluster.ID, &cluster.Credibility, &cluster.OfficialConfirmed); err != nil {
 			return nil, fmt.Errorf("failed to scan expired cluster: %w", err)
 		}
 		clusters = append(clusters, cluster)
@@ -69,16 +71,20 @@ func (r *pgRepository) GetExpiredClusters() ([]ExpiredCluster, error) {
 	return clusters, nil
 }
 
-// GetVotesForCluster retrieves all votes for a given cluster ID.
+// GetVotesForCluster retrieves the final vote of each account for a given cluster ID.
+// Retracted votes are stored as NULL and skipped; if an account has several voting
+// reports (e.g. after a cluster merge) only the most recently changed one counts.
 func (r *pgRepository) GetVotesForCluster(clusterID int64) ([]VoteRecord, error) {
 	query := `
-		SELECT
+		SELECT DISTINCT ON (account_id)
 			account_id,
 			vote
 		FROM
 			incident_reports
 		WHERE
-			incl_id = $1 AND vote IS NOT NULL;
+			incl_id = $1 AND vote IS NOT NULL
+		ORDER BY
+			account_id, COALESCE(vote_updated_at, created_at) DESC;
 	`
 	rows, err := r.db.Query(query, clusterID)
 	if err != nil {
@@ -1,6 +1,7 @@
 package cjincidentexpiration
 
 import (
+	"alertly/internal/realtime"
 	"fmt"
 	"log"
 )
@@ -54,7 +55,9 @@ func (s *service) processCluster(cluster ExpiredCluster) {
 		// Mark the cluster as processed even if credibility is null to avoid reprocessing
 		if err := s.repo.MarkClusterProcessed(cluster.ID); err != nil {
 			log.Printf("Error marking cluster %d with NULL credibility as processed: %v", cluster.ID, err)
+			return
 		}
+		realtime.PublishCluster(realtime.EventClusterExpired, cluster.ID)
 		return
 	}
 
@@ -67,7 +70,8 @@ func (s *service) processCluster(cluster ExpiredCluster) {
 		return
 	}
 
-	outcomeIsTrue := finalCredibility >= credibilityThreshold
+	// An official source (TPS, TFS...) confirming the incident settles the outcome regardless of the votes
+	outcomeIsTrue := finalCredibility >= credibilityThreshold || cluster.OfficialConfirmed
 
 	for _, vote := range votes {
 		userVotedTrue := vote.Vote
@@ -100,6 +104,8 @@ func (s *service) processCluster(cluster ExpiredCluster) {
 	// Mark the cluster as processed to avoid re-processing
 	if err := s.repo.MarkClusterProcessed(cluster.ID); err != nil {
 		log.Printf("Error marking cluster %d as processed: %v", cluster.ID, err)
+	} else {
+		realtime.PublishCluster(realtime.EventClusterExpired, cluster.ID)
 	}
 
 	log.Printf("Finished processing cluster ID: %d", cluster.ID)
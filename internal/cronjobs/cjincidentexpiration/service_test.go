@@ -112,6 +112,33 @@ func TestService_Run_HappyPath(t *testing.T) {
 	}
 }
 
+func TestService_Run_OfficialConfirmedOutcome(t *testing.T) {
+	// Low credibility, but an official source confirmed the incident
+	mockRepo := &mockRepository{
+		clustersToReturn: []ExpiredCluster{
+			{ID: 102, Credibility: sql.NullFloat64{Float64: 3.0, Valid: true}, OfficialConfirmed: true},
+		},
+		votesToReturn: map[int64][]VoteRecord{
+			102: {
+				{AccountID: 1, Vote: true},
+				{AccountID: 2, Vote: false},
+			},
+		},
+	}
+
+	NewService(mockRepo).Run()
+
+	if len(mockRepo.statsUpdateCalls) != 2 {
+		t.Fatalf("expected 2 calls to UpdateUserStats, but got %d", len(mockRepo.statsUpdateCalls))
+	}
+	if got := mockRepo.statsUpdateCalls[0]; got.AccountID != 1 || got.ScoreChange != scoreWin {
+		t.Errorf("true voter should win on a confirmed incident. Got: %+v", got)
+	}
+	if got := mockRepo.statsUpdateCalls[1]; got.AccountID != 2 || got.ScoreChange != scoreLoss {
+		t.Errorf("false voter should lose on a confirmed incident. Got: %+v", got)
+	}
+}
+
 func TestService_Run_NoClusters(t *testing.T) {
 	// 1. Setup
 	mockRepo := &mockRepository{
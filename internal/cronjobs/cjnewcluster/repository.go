@@ -67,7 +67,11 @@ func (r *Repository) FindSubscribedUsersForCluster(clusterID int64) ([]Subscribe
         JOIN
             account_favorite_locations afl ON
             -- Usar ST_DWithin con índices GiST (10-50x más rápido)
-            ST_DWithin(ic.center_location, afl.location, afl.radius)
+            -- Lugares tipo ruta: el corredor de route_buffer metros alrededor de la ruta
+            (
+                (afl.place_type = 'point' AND ST_DWithin(ic.center_location, afl.location, afl.radius))
+                OR (afl.place_type = 'route' AND ST_DWithin(ic.center_location, afl.route, afl.route_buffer))
+            )
         JOIN
             account a ON afl.account_id = a.account_id
         JOIN
@@ -0,0 +1,43 @@
+package export
+
+import (
+	"alertly/internal/database"
+	"alertly/internal/response"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Export descarga los incidentes de un área (bbox o radio) y rango de fechas como GeoJSON, KML o CSV.
+// El archivo se escribe en streaming: si la base falla a mitad de camino el archivo queda truncado y se loguea.
+func Export(c *gin.Context) {
+	var inputs Inputs
+	if err := c.ShouldBindQuery(&inputs); err != nil {
+		log.Printf("Error en query params: %v", err)
+		response.Send(c, http.StatusBadRequest, true, "from_date and to_date (YYYY-MM-DD) are required.", nil)
+		return
+	}
+
+	filter, err := ParseInputs(inputs)
+	if err != nil {
+		response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+		return
+	}
+
+	contentType, ext := ContentType(filter.Format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="alertly_incidents_%s_%s.%s"`,
+		filter.FromDate.Format("20060102"), filter.ToDate.Format("20060102"), ext))
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Status(http.StatusOK)
+
+	service := NewService(NewRepository(database.DB))
+	count, err := service.Export(filter, c.Writer)
+	if err != nil {
+		log.Printf("Error exporting incidents (%d written): %v", count, err)
+		return
+	}
+	log.Printf("📦 Exported %d incidents as %s", count, filter.Format)
+}
@@ -0,0 +1,70 @@
+package export
+
+import "time"
+
+// Formatos de export
+const (
+	FormatGeoJSON = "geojson"
+	FormatKML     = "kml"
+	FormatCSV     = "csv"
+)
+
+// Inputs: bbox (min/max lat/lng) o radio (latitude, longitude, radius en metros), rango de fechas y filtros
+// con la misma semántica que /cluster/getbylocation
+type Inputs struct {
+	Format       string   `form:"format"`
+	MinLatitude  *float64 `form:"min_latitude"`
+	MaxLatitude  *float64 `form:"max_latitude"`
+	MinLongitude *float64 `form:"min_longitude"`
+	MaxLongitude *float64 `form:"max_longitude"`
+	Latitude     *float64 `form:"latitude"`
+	Longitude    *float64 `form:"longitude"`
+	Radius       float64  `form:"radius"`
+	FromDate     string   `form:"from_date" binding:"required,datetime=2006-01-02"`
+	ToDate       string   `form:"to_date" binding:"required,datetime=2006-01-02"`
+	InsuID       int      `form:"insu_id"`
+	Categories   string   `form:"categories"` // vacío = todas
+}
+
+// Filter son los Inputs ya validados
+type Filter struct {
+	Format     string
+	BBox       *BBox
+	Center     *Center
+	FromDate   time.Time
+	ToDate     time.Time
+	InsuID     int
+	Categories []string
+}
+
+type BBox struct {
+	MinLatitude, MaxLatitude, MinLongitude, MaxLongitude float64
+}
+
+type Center struct {
+	Latitude, Longitude, Radius float64
+}
+
+// Feature es un cluster exportado
+type Feature struct {
+	InclId            int64
+	Latitude          float64
+	Longitude         float64
+	CategoryCode      string
+	SubcategoryCode   string
+	SubcategoryName   string
+	Description       string
+	Address           string
+	City              string
+	CreatedAt         time.Time
+	StartTime         *time.Time
+	EndTime           *time.Time
+	IsActive          bool
+	Credibility       float64
+	IncidentCount     int
+	TotalVotes        int
+	Official          bool   // creado por el bot desde una fuente oficial
+	OfficialConfirmed bool   // cluster de usuarios confirmado por una fuente oficial
+	AreaGeoJSON       string // polígono oficial (p.ej. corte de luz), "" si no hay
+	AreaWKT           string
+}
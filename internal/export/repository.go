@@ -0,0 +1,114 @@
+package export
+
+import (
+	"alertly/internal/cronjobs/cjbot_creator"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type Repository interface {
+	StreamFeatures(f Filter, limit int, fn func(Feature) error) error
+}
+
+type pgRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &pgRepository{db: db}
+}
+
+// StreamFeatures recorre los clusters del área y llama fn por cada uno sin cargarlos todos en memoria.
+// Incluye clusters ya expirados del rango de fechas; los fusionados en otro y los bloqueados por moderación se omiten.
+func (r *pgRepository) StreamFeatures(f Filter, limit int, fn func(Feature) error) error {
+	params := []interface{}{f.ToDate.Format("2006-01-02"), f.FromDate.Format("2006-01-02"), f.InsuID, cjbot_creator.BOT_USER_ID}
+	where := []string{
+		"c.start_time <= $1::date + INTERVAL '1 day'",
+		"c.end_time >= $2::date",
+		"($3::integer = 0 OR c.insu_id = $3::integer)",
+		"c.merged_into_incl_id IS NULL",
+		"c.blocked_at IS NULL",
+	}
+
+	if f.BBox != nil {
+		n := len(params)
+		where = append(where, fmt.Sprintf("c.center_latitude BETWEEN $%d AND $%d AND c.center_longitude BETWEEN $%d AND $%d", n+1, n+2, n+3, n+4))
+		params = append(params, f.BBox.MinLatitude, f.BBox.MaxLatitude, f.BBox.MinLongitude, f.BBox.MaxLongitude)
+	}
+	if f.Center != nil {
+		n := len(params)
+		where = append(where, fmt.Sprintf("ST_DWithin(c.center_location, ST_MakePoint($%d, $%d)::geography, $%d)", n+1, n+2, n+3))
+		params = append(params, f.Center.Longitude, f.Center.Latitude, f.Center.Radius)
+	}
+	if len(f.Categories) > 0 {
+		placeholders := make([]string, len(f.Categories))
+		for i, cat := range f.Categories {
+			params = append(params, cat)
+			placeholders[i] = fmt.Sprintf("$%d", len(params))
+		}
+		where = append(where, "c.category_code IN ("+strings.Join(placeholders, ",")+")")
+	}
+	params = append(params, limit)
+
+	query := fmt.Sprintf(`
+    SELECT
+        c.incl_id,
+        c.center_latitude,
+        c.center_longitude,
+        COALESCE(c.category_code, ''),
+        COALESCE(c.subcategory_code, ''),
+        COALESCE(c.subcategory_name, ''),
+        COALESCE(c.description, ''),
+        COALESCE(c.address, ''),
+        COALESCE(c.city, ''),
+        COALESCE(c.created_at, NOW()),
+        c.start_time,
+        c.end_time,
+        c.is_active = '1',
+        COALESCE(c.credibility, 0),
+        COALESCE(c.incident_count, 0),
+        COALESCE(c.counter_total_votes, 0),
+        COALESCE(c.account_id, 0) = $4,
+        c.official_confirmed_at IS NOT NULL,
+        COALESCE(ST_AsGeoJSON(a.official_area, 6), ''),
+        COALESCE(ST_AsText(a.official_area), '')
+    FROM incident_clusters c
+    LEFT JOIN LATERAL (
+        SELECT r.official_area
+        FROM incident_reports r
+        WHERE r.incl_id = c.incl_id AND r.official_area IS NOT NULL
+        ORDER BY r.created_at DESC
+        LIMIT 1
+    ) a ON true
+    WHERE %s
+    ORDER BY c.created_at ASC, c.incl_id ASC
+    LIMIT $%d`, strings.Join(where, "\n      AND "), len(params))
+
+	rows, err := r.db.Query(query, params...)
+	if err != nil {
+		return fmt.Errorf("error querying export: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ft Feature
+		var startTime, endTime sql.NullTime
+		if err := rows.Scan(&ft.InclId, &ft.Latitude, &ft.Longitude, &ft.CategoryCode, &ft.SubcategoryCode,
+			&ft.SubcategoryName, &ft.Description, &ft.Address, &ft.City, &ft.CreatedAt, &startTime, &endTime,
+			&ft.IsActive, &ft.Credibility, &ft.IncidentCount, &ft.TotalVotes, &ft.Official, &ft.OfficialConfirmed,
+			&ft.AreaGeoJSON, &ft.AreaWKT); err != nil {
+			return fmt.Errorf("error scanning export row: %w", err)
+		}
+		if startTime.Valid {
+			ft.StartTime = &startTime.Time
+		}
+		if endTime.Valid {
+			ft.EndTime = &endTime.Time
+		}
+		if err := fn(ft); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
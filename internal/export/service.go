@@ -0,0 +1,95 @@
+package export
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	maxExportRows   = 50000
+	maxExportDays   = 366
+	maxExportRadius = 50000 // metros
+	maxBBoxDegrees  = 5.0   // lado máximo del bbox en grados
+)
+
+var (
+	ErrInvalidFormat    = errors.New("format must be geojson, kml or csv")
+	ErrInvalidArea      = errors.New("send a bbox (min_latitude, max_latitude, min_longitude, max_longitude) or a radius (latitude, longitude, radius up to 50000 m)")
+	ErrInvalidDateRange = errors.New("from_date must be before to_date and the range can't exceed 366 days")
+)
+
+type Service interface {
+	Export(f Filter, w io.Writer) (int, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+// Export escribe los clusters del filtro en w a medida que se leen de la base. Devuelve cuántos se escribieron.
+func (s *service) Export(f Filter, w io.Writer) (int, error) {
+	fw := newFeatureWriter(f.Format, w)
+	if err := fw.Begin(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err := s.repo.StreamFeatures(f, maxExportRows, func(ft Feature) error {
+		count++
+		return fw.WriteFeature(ft)
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, fw.End()
+}
+
+// ParseInputs valida los Inputs y arma el Filter. Se llama antes de escribir nada en la respuesta.
+func ParseInputs(in Inputs) (Filter, error) {
+	f := Filter{Format: strings.ToLower(strings.TrimSpace(in.Format)), InsuID: in.InsuID}
+	if f.Format == "" {
+		f.Format = FormatGeoJSON
+	}
+	if f.Format != FormatGeoJSON && f.Format != FormatKML && f.Format != FormatCSV {
+		return Filter{}, ErrInvalidFormat
+	}
+
+	from, errFrom := time.Parse("2006-01-02", in.FromDate)
+	to, errTo := time.Parse("2006-01-02", in.ToDate)
+	if errFrom != nil || errTo != nil || to.Before(from) || to.Sub(from) > maxExportDays*24*time.Hour {
+		return Filter{}, ErrInvalidDateRange
+	}
+	f.FromDate, f.ToDate = from, to
+
+	hasBBox := in.MinLatitude != nil && in.MaxLatitude != nil && in.MinLongitude != nil && in.MaxLongitude != nil
+	hasCenter := in.Latitude != nil && in.Longitude != nil && in.Radius > 0
+	switch {
+	case hasBBox && !hasCenter:
+		b := BBox{MinLatitude: *in.MinLatitude, MaxLatitude: *in.MaxLatitude, MinLongitude: *in.MinLongitude, MaxLongitude: *in.MaxLongitude}
+		if b.MinLatitude >= b.MaxLatitude || b.MinLongitude >= b.MaxLongitude ||
+			b.MaxLatitude-b.MinLatitude > maxBBoxDegrees || b.MaxLongitude-b.MinLongitude > maxBBoxDegrees {
+			return Filter{}, ErrInvalidArea
+		}
+		f.BBox = &b
+	case hasCenter && !hasBBox:
+		if in.Radius > maxExportRadius {
+			return Filter{}, ErrInvalidArea
+		}
+		f.Center = &Center{Latitude: *in.Latitude, Longitude: *in.Longitude, Radius: in.Radius}
+	default:
+		return Filter{}, ErrInvalidArea
+	}
+
+	for _, cat := range strings.Split(in.Categories, ",") {
+		if cat = strings.TrimSpace(cat); cat != "" {
+			f.Categories = append(f.Categories, cat)
+		}
+	}
+	return f, nil
+}
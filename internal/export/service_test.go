@@ -0,0 +1,161 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeRepo struct {
+	features []Feature
+}
+
+func (r *fakeRepo) StreamFeatures(f Filter, limit int, fn func(Feature) error) error {
+	for _, ft := range r.features {
+		if err := fn(ft); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fp(v float64) *float64 { return &v }
+
+func sampleFeatures() []Feature {
+	created := time.Date(2026, 10, 17, 14, 0, 0, 0, time.UTC)
+	return []Feature{
+		{InclId: 1, Latitude: 43.65, Longitude: -79.38, CategoryCode: "crime", SubcategoryName: "Robbery",
+			Description: "=HYPERLINK(\"x\") & <b>", CreatedAt: created},
+		{InclId: 2, Latitude: 43.70, Longitude: -79.40, CategoryCode: "infrastructure_issues", Official: true, CreatedAt: created,
+			AreaGeoJSON: `{"type":"Polygon","coordinates":[[[-79.41,43.69],[-79.39,43.69],[-79.40,43.71],[-79.41,43.69]]]}`,
+			AreaWKT:     "POLYGON((-79.41 43.69,-79.39 43.69,-79.4 43.71,-79.41 43.69))"},
+	}
+}
+
+func TestParseInputs(t *testing.T) {
+	base := Inputs{FromDate: "2026-10-01", ToDate: "2026-10-17"}
+
+	bbox := base
+	bbox.MinLatitude, bbox.MaxLatitude, bbox.MinLongitude, bbox.MaxLongitude = fp(43.5), fp(43.9), fp(-79.7), fp(-79.1)
+	bbox.Categories = "crime, fire_incident,"
+	f, err := ParseInputs(bbox)
+	if err != nil || f.BBox == nil || f.Center != nil || f.Format != FormatGeoJSON || len(f.Categories) != 2 {
+		t.Fatalf("bbox: got %+v, %v", f, err)
+	}
+
+	radius := base
+	radius.Latitude, radius.Longitude, radius.Radius, radius.Format = fp(43.65), fp(-79.38), 2000, "CSV"
+	if f, err := ParseInputs(radius); err != nil || f.Center == nil || f.Format != FormatCSV {
+		t.Fatalf("radius: got %+v, %v", f, err)
+	}
+
+	invalid := []struct {
+		name string
+		in   Inputs
+		want error
+	}{
+		{"no area", base, ErrInvalidArea},
+		{"both areas", func() Inputs { in := bbox; in.Latitude, in.Longitude, in.Radius = fp(1), fp(1), 10; return in }(), ErrInvalidArea},
+		{"radius too large", func() Inputs { in := radius; in.Radius = maxExportRadius + 1; return in }(), ErrInvalidArea},
+		{"bbox too large", func() Inputs { in := bbox; in.MaxLatitude = fp(50); return in }(), ErrInvalidArea},
+		{"bad format", func() Inputs { in := bbox; in.Format = "xlsx"; return in }(), ErrInvalidFormat},
+		{"reversed dates", func() Inputs { in := bbox; in.FromDate, in.ToDate = "2026-10-17", "2026-10-01"; return in }(), ErrInvalidDateRange},
+		{"range too long", func() Inputs { in := bbox; in.FromDate = "2024-01-01"; return in }(), ErrInvalidDateRange},
+	}
+	for _, tt := range invalid {
+		if _, err := ParseInputs(tt.in); err != tt.want {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestExportGeoJSON(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := NewService(&fakeRepo{features: sampleFeatures()}).Export(Filter{Format: FormatGeoJSON}, &buf)
+	if err != nil || n != 2 {
+		t.Fatalf("Export() = %d, %v", n, err)
+	}
+
+	var fc struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry struct {
+				Type string `json:"type"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &fc); err != nil {
+		t.Fatalf("invalid GeoJSON: %v\n%s", err, buf.String())
+	}
+	if fc.Type != "FeatureCollection" || len(fc.Features) != 2 {
+		t.Fatalf("unexpected collection: %+v", fc)
+	}
+	if fc.Features[0].Geometry.Type != "Point" || fc.Features[1].Geometry.Type != "Polygon" {
+		t.Errorf("official area should be the geometry: %s, %s", fc.Features[0].Geometry.Type, fc.Features[1].Geometry.Type)
+	}
+	if fc.Features[1].Properties["official"] != true {
+		t.Errorf("official property missing: %+v", fc.Features[1].Properties)
+	}
+}
+
+func TestExportEmptyGeoJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := NewService(&fakeRepo{}).Export(Filter{Format: FormatGeoJSON}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != `{"type":"FeatureCollection","features":[]}` {
+		t.Errorf("empty export = %s", buf.String())
+	}
+}
+
+func TestExportKML(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := NewService(&fakeRepo{features: sampleFeatures()}).Export(Filter{Format: FormatKML}, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Placemarks []struct {
+			Description string    `xml:"description"`
+			Polygon     *struct{} `xml:"MultiGeometry>Polygon"`
+		} `xml:"Document>Placemark"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid KML: %v\n%s", err, buf.String())
+	}
+	if len(doc.Placemarks) != 2 {
+		t.Fatalf("expected 2 placemarks, got %d", len(doc.Placemarks))
+	}
+	if doc.Placemarks[0].Description != sampleFeatures()[0].Description {
+		t.Errorf("description not escaped correctly: %q", doc.Placemarks[0].Description)
+	}
+	if doc.Placemarks[0].Polygon != nil || doc.Placemarks[1].Polygon == nil {
+		t.Errorf("only the official incident should have a polygon")
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := NewService(&fakeRepo{features: sampleFeatures()}).Export(Filter{Format: FormatCSV}, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 3 || len(records[0]) != len(csvHeader) {
+		t.Fatalf("expected header + 2 rows, got %d rows", len(records))
+	}
+	if desc := records[1][6]; !strings.HasPrefix(desc, "'=") {
+		t.Errorf("formula should be neutralized, got %q", desc)
+	}
+	if wkt := records[2][len(csvHeader)-1]; !strings.HasPrefix(wkt, "POLYGON") {
+		t.Errorf("area_wkt = %q", wkt)
+	}
+}
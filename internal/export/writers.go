@@ -0,0 +1,248 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// featureWriter escribe un export en streaming: Begin, un WriteFeature por cluster y End
+type featureWriter interface {
+	Begin() error
+	WriteFeature(f Feature) error
+	End() error
+}
+
+func newFeatureWriter(format string, w io.Writer) featureWriter {
+	switch format {
+	case FormatKML:
+		return &kmlWriter{w: w}
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}
+	default:
+		return &geoJSONWriter{w: w}
+	}
+}
+
+// ContentType devuelve el Content-Type y la extensión de archivo del formato
+func ContentType(format string) (string, string) {
+	switch format {
+	case FormatKML:
+		return "application/vnd.google-earth.kml+xml", "kml"
+	case FormatCSV:
+		return "text/csv; charset=utf-8", "csv"
+	default:
+		return "application/geo+json", "geojson"
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ─── GeoJSON ────────────────────────────────────────────────────────────────
+
+type geoJSONWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (g *geoJSONWriter) Begin() error {
+	_, err := io.WriteString(g.w, `{"type":"FeatureCollection","features":[`)
+	return err
+}
+
+// WriteFeature usa el polígono oficial como geometría cuando existe; si no, el punto del cluster
+func (g *geoJSONWriter) WriteFeature(f Feature) error {
+	geometry := json.RawMessage(fmt.Sprintf(`{"type":"Point","coordinates":[%s,%s]}`,
+		strconv.FormatFloat(f.Longitude, 'f', 6, 64), strconv.FormatFloat(f.Latitude, 'f', 6, 64)))
+	if f.AreaGeoJSON != "" {
+		geometry = json.RawMessage(f.AreaGeoJSON)
+	}
+
+	feature := map[string]interface{}{
+		"type":     "Feature",
+		"id":       f.InclId,
+		"geometry": geometry,
+		"properties": map[string]interface{}{
+			"incl_id":            f.InclId,
+			"latitude":           f.Latitude,
+			"longitude":          f.Longitude,
+			"category_code":      f.CategoryCode,
+			"subcategory_code":   f.SubcategoryCode,
+			"subcategory_name":   f.SubcategoryName,
+			"description":        f.Description,
+			"address":            f.Address,
+			"city":               f.City,
+			"created_at":         formatTime(&f.CreatedAt),
+			"start_time":         formatTime(f.StartTime),
+			"end_time":           formatTime(f.EndTime),
+			"is_active":          f.IsActive,
+			"credibility":        f.Credibility,
+			"incident_count":     f.IncidentCount,
+			"total_votes":        f.TotalVotes,
+			"official":           f.Official,
+			"official_confirmed": f.OfficialConfirmed,
+		},
+	}
+	data, err := json.Marshal(feature)
+	if err != nil {
+		return err
+	}
+	if g.count > 0 {
+		if _, err := io.WriteString(g.w, ","); err != nil {
+			return err
+		}
+	}
+	g.count++
+	_, err = g.w.Write(data)
+	return err
+}
+
+func (g *geoJSONWriter) End() error {
+	_, err := io.WriteString(g.w, "]}")
+	return err
+}
+
+// ─── KML ────────────────────────────────────────────────────────────────────
+
+type kmlWriter struct {
+	w io.Writer
+}
+
+func (k *kmlWriter) Begin() error {
+	_, err := io.WriteString(k.w, xml.Header+`<kml xmlns="http://www.opengis.net/kml/2.2"><Document><name>Alertly incidents</name>`)
+	return err
+}
+
+func (k *kmlWriter) WriteFeature(f Feature) error {
+	geometry := fmt.Sprintf("<Point><coordinates>%f,%f</coordinates></Point>", f.Longitude, f.Latitude)
+	if ring := kmlRing(f.AreaGeoJSON); ring != "" {
+		geometry = "<MultiGeometry>" + geometry +
+			"<Polygon><outerBoundaryIs><LinearRing><coordinates>" + ring + "</coordinates></LinearRing></outerBoundaryIs></Polygon>" +
+			"</MultiGeometry>"
+	}
+
+	if _, err := fmt.Fprintf(k.w, `<Placemark id="incl-%d"><name>%s</name><description>%s</description><TimeStamp><when>%s</when></TimeStamp><ExtendedData>`,
+		f.InclId, xmlEscape(f.SubcategoryName), xmlEscape(f.Description), formatTime(&f.CreatedAt)); err != nil {
+		return err
+	}
+	data := [][2]string{
+		{"incl_id", strconv.FormatInt(f.InclId, 10)},
+		{"category_code", f.CategoryCode},
+		{"subcategory_code", f.SubcategoryCode},
+		{"address", f.Address},
+		{"city", f.City},
+		{"start_time", formatTime(f.StartTime)},
+		{"end_time", formatTime(f.EndTime)},
+		{"is_active", strconv.FormatBool(f.IsActive)},
+		{"credibility", strconv.FormatFloat(f.Credibility, 'f', 1, 64)},
+		{"incident_count", strconv.Itoa(f.IncidentCount)},
+		{"total_votes", strconv.Itoa(f.TotalVotes)},
+		{"official", strconv.FormatBool(f.Official)},
+		{"official_confirmed", strconv.FormatBool(f.OfficialConfirmed)},
+	}
+	for _, d := range data {
+		if _, err := fmt.Fprintf(k.w, `<Data name="%s"><value>%s</value></Data>`, d[0], xmlEscape(d[1])); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(k.w, "</ExtendedData>"+geometry+"</Placemark>")
+	return err
+}
+
+func (k *kmlWriter) End() error {
+	_, err := io.WriteString(k.w, "</Document></kml>")
+	return err
+}
+
+// kmlRing convierte el anillo exterior de un polígono GeoJSON a coordenadas KML ("lng,lat lng,lat ...")
+func kmlRing(areaGeoJSON string) string {
+	if areaGeoJSON == "" {
+		return ""
+	}
+	var polygon struct {
+		Coordinates [][][]float64 `json:"coordinates"`
+	}
+	if err := json.Unmarshal([]byte(areaGeoJSON), &polygon); err != nil || len(polygon.Coordinates) == 0 {
+		return ""
+	}
+	ring := ""
+	for i, p := range polygon.Coordinates[0] {
+		if len(p) < 2 {
+			continue
+		}
+		if i > 0 {
+			ring += " "
+		}
+		ring += fmt.Sprintf("%f,%f", p[0], p[1])
+	}
+	return ring
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// ─── CSV ────────────────────────────────────────────────────────────────────
+
+var csvHeader = []string{
+	"incl_id", "latitude", "longitude", "category_code", "subcategory_code", "subcategory_name", "description",
+	"address", "city", "created_at", "start_time", "end_time", "is_active", "credibility", "incident_count",
+	"total_votes", "official", "official_confirmed", "area_wkt",
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) Begin() error {
+	return c.w.Write(csvHeader)
+}
+
+func (c *csvWriter) WriteFeature(f Feature) error {
+	return c.w.Write([]string{
+		strconv.FormatInt(f.InclId, 10),
+		strconv.FormatFloat(f.Latitude, 'f', 6, 64),
+		strconv.FormatFloat(f.Longitude, 'f', 6, 64),
+		f.CategoryCode,
+		f.SubcategoryCode,
+		csvSafe(f.SubcategoryName),
+		csvSafe(f.Description),
+		csvSafe(f.Address),
+		csvSafe(f.City),
+		formatTime(&f.CreatedAt),
+		formatTime(f.StartTime),
+		formatTime(f.EndTime),
+		strconv.FormatBool(f.IsActive),
+		strconv.FormatFloat(f.Credibility, 'f', 1, 64),
+		strconv.Itoa(f.IncidentCount),
+		strconv.Itoa(f.TotalVotes),
+		strconv.FormatBool(f.Official),
+		strconv.FormatBool(f.OfficialConfirmed),
+		f.AreaWKT,
+	})
+}
+
+// csvSafe evita que Excel/Sheets interpreten como fórmula un texto escrito por usuarios
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func (c *csvWriter) End() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -3,6 +3,7 @@ package getclusterby
 import (
 	"alertly/internal/comments"
 	"alertly/internal/common"
+	"time"
 )
 
 type Cluster struct {
@@ -33,13 +34,17 @@ type Cluster struct {
 	CounterTotalVotesTrue  int                `json:"counter_total_votes_true"`
 	CounterTotalVotesFalse int                `json:"counter_total_votes_false"`
 	Incidents              []Incident         `json:"incidents"`
-	Comments               []comments.Comment `json:"comments"`
+	Media                  []Media            `json:"media"` // todas las fotos del cluster; media_url sigue siendo la portada
+	Comments               []comments.Comment `json:"comments"`        // lista plana (clientes que no muestran hilos)
+	CommentThreads         []comments.Comment `json:"comment_threads"` // los mismos comentarios agrupados en hilos
 	CredibilityPercent     float64            `json:"credibility_percent"`
 	GetAccountAlreadyVoted bool               `json:"get_account_already_voted"`
 	GetAccountAlreadySaved bool               `json:"get_account_already_saved"`
 	UserVote               int                `json:"user_vote"`
 	Credibility            float64            `json:"credibility"`
 	AccountId              int64              `json:"account_id"` // ID del creador del cluster - usar COALESCE en query
+	OfficialConfirmed      bool               `json:"official_confirmed"`
+	OfficialConfirmations  []OfficialConfirmation `json:"official_confirmations"` // incidentes oficiales (TPS, TFS...) vinculados
 }
 
 type Comment struct {
@@ -76,4 +81,25 @@ type Incident struct {
 	CreatedAt        common.CustomTime `json:"created_at"`
 	InclID           int64             `json:"incl_id"`
 	Status           string             `json:"status"` // Usar COALESCE en query
+	Media            []Media            `json:"media"`  // fotos del report en orden; media_url es la portada
+}
+
+// Media es una foto de un incident_report (tabla incident_media)
+type Media struct {
+	InmeId    int64  `json:"inme_id"`
+	InreId    int64  `json:"inre_id"`
+	MediaUrl  string `json:"media_url"`
+	MediaType string `json:"media_type"`
+	PosterUrl string `json:"poster_url,omitempty"` // solo videos
+	Position  int    `json:"position"`
+}
+
+// OfficialConfirmation es un incidente oficial publicado por el bot que describe el mismo evento que el cluster
+type OfficialConfirmation struct {
+	InreId         int64     `json:"inre_id"`
+	InclId         int64     `json:"incl_id"` // cluster donde está el report oficial
+	Source         string    `json:"source"`
+	Description    string    `json:"description"`
+	DistanceMeters float64   `json:"distance_meters"`
+	ConfirmedAt    time.Time `json:"confirmed_at"`
 }
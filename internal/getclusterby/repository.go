@@ -16,6 +16,8 @@ type Repository interface {
 	GetAccountAlreadySaved(inclID, AccountID int64) (bool, error)
 	GetUserVote(inclID, AccountID int64) (int, error)
 	SaveAccountHistory(accountID, inclID int64) error
+	GetClusterMedia(inclID int64) ([]Media, error)
+	GetOfficialConfirmations(inclID int64) ([]OfficialConfirmation, error)
 }
 
 type pgRepository struct {
@@ -221,6 +223,33 @@ func (r *pgRepository) SaveAccountHistory(accountID, inclID int64) error {
 	return err
 }
 
+// GetClusterMedia devuelve las fotos ya procesadas de todos los reports activos del cluster,
+// del report más reciente al más antiguo y en el orden en que se subieron
+func (r *pgRepository) GetClusterMedia(inclID int64) ([]Media, error) {
+	query := `
+        SELECT m.inme_id, m.inre_id, m.media_url, m.media_type, COALESCE(m.poster_url, ''), m.position
+        FROM incident_media m
+        INNER JOIN incident_reports r ON r.inre_id = m.inre_id
+        WHERE r.incl_id = $1 AND COALESCE(r.is_active, '0') = '1' AND m.status = 'ready'
+        ORDER BY r.created_at DESC, m.position ASC
+    `
+	rows, err := r.db.Query(query, inclID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying cluster media: %w", err)
+	}
+	defer rows.Close()
+
+	media := make([]Media, 0)
+	for rows.Next() {
+		var m Media
+		if err := rows.Scan(&m.InmeId, &m.InreId, &m.MediaUrl, &m.MediaType, &m.PosterUrl, &m.Position); err != nil {
+			return nil, fmt.Errorf("error scanning cluster media: %w", err)
+		}
+		media = append(media, m)
+	}
+	return media, rows.Err()
+}
+
 // ✅ FALLBACK: Crear cluster temporal desde incident_report individual
 func (r *pgRepository) createClusterFromIndividualIncident(inclId int64, activeOnly bool) (Cluster, error) {
 	log.Printf("Creating temporary cluster from individual incident %d (activeOnly: %v)", inclId, activeOnly)
@@ -386,3 +415,29 @@ func calculateTimeDifference(createdAt time.Time) string {
 		return fmt.Sprintf("%d days ago", days)
 	}
 }
+
+// GetOfficialConfirmations devuelve los incidentes oficiales vinculados al cluster por el cronjob bot_correlation
+func (r *pgRepository) GetOfficialConfirmations(inclID int64) ([]OfficialConfirmation, error) {
+	query := `
+        SELECT l.inre_id, COALESCE(r.incl_id, 0), l.source, COALESCE(r.description, ''), l.distance_meters, l.created_at
+        FROM incident_cluster_official_links l
+        INNER JOIN incident_reports r ON r.inre_id = l.inre_id
+        WHERE l.incl_id = $1
+        ORDER BY l.created_at ASC
+    `
+	rows, err := r.db.Query(query, inclID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying official confirmations: %w", err)
+	}
+	defer rows.Close()
+
+	confirmations := []OfficialConfirmation{}
+	for rows.Next() {
+		var oc OfficialConfirmation
+		if err := rows.Scan(&oc.InreId, &oc.InclId, &oc.Source, &oc.Description, &oc.DistanceMeters, &oc.ConfirmedAt); err != nil {
+			return nil, fmt.Errorf("error scanning official confirmation: %w", err)
+		}
+		confirmations = append(confirmations, oc)
+	}
+	return confirmations, rows.Err()
+}
@@ -52,9 +52,28 @@ func (s *service) getIncidentByInternal(inclId, accountID int64, isPublic bool)
 		result.UserVote, _ = s.repo.GetUserVote(result.InclId, accountID)
 	}
 
+	// Galería de fotos; si falla seguimos con media_url como antes
+	media, mediaErr := s.repo.GetClusterMedia(result.InclId)
+	if mediaErr != nil {
+		log.Printf("error getting media for cluster %d: %v", result.InclId, mediaErr)
+	}
+	attachMedia(&result, media)
+
+	// Confirmación de fuente oficial; si falla el cluster se muestra sin ella
+	confirmations, officialErr := s.repo.GetOfficialConfirmations(result.InclId)
+	if officialErr != nil {
+		log.Printf("error getting official confirmations for cluster %d: %v", result.InclId, officialErr)
+		confirmations = []OfficialConfirmation{}
+	}
+	result.OfficialConfirmations = confirmations
+	result.OfficialConfirmed = len(confirmations) > 0
+
 	repo := comments.NewRepository(database.DB)
 	cs := comments.NewService(repo)
-	result.Comments, err = cs.GetClusterCommentsByID(result.InclId)
+	// Hilos con reacciones; MyReactions son las del usuario que pide (0 en el endpoint público).
+	// comments sigue siendo la lista plana para las versiones de la app anteriores a los hilos.
+	result.CommentThreads, err = cs.GetClusterThreads(result.InclId, accountID)
+	result.Comments = comments.Flatten(result.CommentThreads)
 
 	// remember what is this for?
 	for i := range result.Incidents {
@@ -74,6 +93,34 @@ func (s *service) getIncidentByInternal(inclId, accountID int64, isPublic bool)
 	return result, nil
 }
 
+// attachMedia reparte las fotos del cluster entre sus incidents. Los reports anteriores a
+// incident_media (o cuya foto sigue en "processing") usan su media_url como única foto,
+// con el media_type del cluster (vacío = image).
+func attachMedia(cluster *Cluster, media []Media) {
+	byReport := make(map[int64][]Media, len(cluster.Incidents))
+	for _, m := range media {
+		byReport[m.InreId] = append(byReport[m.InreId], m)
+	}
+	legacyType := cluster.MediaType
+	if legacyType == "" {
+		legacyType = "image"
+	}
+
+	cluster.Media = make([]Media, 0, len(media))
+	for i := range cluster.Incidents {
+		incident := &cluster.Incidents[i]
+		items := byReport[incident.InreId]
+		if len(items) == 0 && incident.MediaUrl != "" && incident.MediaUrl != "processing" {
+			items = []Media{{InreId: incident.InreId, MediaUrl: incident.MediaUrl, MediaType: legacyType}}
+		}
+		if items == nil {
+			items = []Media{}
+		}
+		incident.Media = items
+		cluster.Media = append(cluster.Media, items...)
+	}
+}
+
 func calculateCredibilityPercent(counterTotalVotesTrue, counterTotalVotesFake int) float64 {
 	totalVotes := float64(counterTotalVotesTrue + counterTotalVotesFake)
 	if totalVotes == 0 {
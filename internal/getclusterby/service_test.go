@@ -0,0 +1,40 @@
+package getclusterby
+
+import "testing"
+
+func TestAttachMedia(t *testing.T) {
+	cluster := Cluster{Incidents: []Incident{
+		{InreId: 3, MediaUrl: "https://cdn/3a.jpg"},
+		{InreId: 2, MediaUrl: "https://cdn/legacy.jpg"},
+		{InreId: 1, MediaUrl: "processing"},
+	}}
+	media := []Media{
+		{InmeId: 10, InreId: 3, MediaUrl: "https://cdn/3a.jpg", MediaType: "image", Position: 0},
+		{InmeId: 11, InreId: 3, MediaUrl: "https://cdn/3b.jpg", MediaType: "image", Position: 1},
+	}
+
+	attachMedia(&cluster, media)
+
+	if got := len(cluster.Incidents[0].Media); got != 2 {
+		t.Fatalf("report 3: expected 2 photos, got %d", got)
+	}
+	if got := cluster.Incidents[1].Media; len(got) != 1 || got[0].MediaUrl != "https://cdn/legacy.jpg" {
+		t.Fatalf("report 2: expected legacy media_url as single photo, got %+v", got)
+	}
+	if got := cluster.Incidents[2].Media; got == nil || len(got) != 0 {
+		t.Fatalf("report 1: expected empty (non-nil) media while processing, got %+v", got)
+	}
+	if got := len(cluster.Media); got != 3 {
+		t.Fatalf("cluster: expected 3 photos, got %d", got)
+	}
+}
+
+func TestAttachMediaLegacyVideo(t *testing.T) {
+	cluster := Cluster{MediaType: "video", Incidents: []Incident{{InreId: 4, MediaUrl: "https://cdn/4.mp4"}}}
+
+	attachMedia(&cluster, nil)
+
+	if got := cluster.Incidents[0].Media; len(got) != 1 || got[0].MediaType != "video" {
+		t.Fatalf("expected the legacy media_url typed as the cluster's video, got %+v", got)
+	}
+}
@@ -1,8 +1,11 @@
 package getclusterbyradius
 
 import (
+	"alertly/internal/clusterquery"
 	"alertly/internal/database"
+	"alertly/internal/pagination"
 	"alertly/internal/response"
+	"errors"
 	"log"
 	"net/http"
 
@@ -35,6 +38,14 @@ func GetByRadius(c *gin.Context) {
 
 	result, err := service.GetClustersByRadius(inputs)
 	if err != nil {
+		if errors.Is(err, clusterquery.ErrInvalidTimeRange) {
+			response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+			return
+		}
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			response.Send(c, http.StatusBadRequest, true, "Invalid cursor. Please reload the map and try again.", nil)
+			return
+		}
 		log.Printf("Error loading clusters by radius: %v", err)
 		response.Send(c, http.StatusInternalServerError, true, "Could not load clusters. Please try again later.", nil)
 		return
@@ -1,12 +1,18 @@
 package getclusterbyradius
 
+import (
+	"alertly/internal/pagination"
+	"time"
+)
+
 type Cluster struct {
-	InclId          int64   `json:"incl_id"`
-	Latitude        float64 `json:"latitude"`
-	Longitude       float64 `json:"longitude"`
-	InsuId          int64   `json:"insu_id"`
-	CategoryCode    string  `json:"category_code"`
-	SubcategoryCode string  `json:"subcategory_code"`
+	InclId          int64     `json:"incl_id"`
+	Latitude        float64   `json:"latitude"`
+	Longitude       float64   `json:"longitude"`
+	InsuId          int64     `json:"insu_id"`
+	CategoryCode    string    `json:"category_code"`
+	SubcategoryCode string    `json:"subcategory_code"`
+	CreatedAt       time.Time `json:"-"` // solo para el cursor
 }
 
 type Inputs struct {
@@ -17,4 +23,9 @@ type Inputs struct {
 	ToDate     string  `uri:"to_date" binding:"required,datetime=2006-01-02"`
 	InsuID     int     `uri:"insu_id"`
 	Categories string  `form:"categories"`
+	Cursor     string  `form:"cursor"` // next_cursor de la página anterior
+	Limit      int     `form:"limit"`
 }
+
+// ClustersPage es una página de clusters ordenados del más reciente al más antiguo
+type ClustersPage = pagination.Page[Cluster]
@@ -1,13 +1,13 @@
 package getclusterbyradius
 
 import (
+	"alertly/internal/clusterquery"
+	"alertly/internal/pagination"
 	"database/sql"
-	"fmt"
-	"strings"
 )
 
 type Repository interface {
-	GetClustersByRadius(inputs Inputs) ([]Cluster, error)
+	GetClustersByRadius(inputs Inputs, after *pagination.Cursor, limit int) ([]Cluster, error)
 }
 
 type pgRepository struct {
@@ -18,69 +18,46 @@ func NewRepository(db *sql.DB) Repository {
 	return &pgRepository{db: db}
 }
 
-func (r *pgRepository) GetClustersByRadius(inputs Inputs) ([]Cluster, error) {
+// GetClustersByRadius devuelve hasta limit clusters ordenados por (created_at, incl_id) descendente,
+// empezando después de after (nil = primera página)
+func (r *pgRepository) GetClustersByRadius(inputs Inputs, after *pagination.Cursor, limit int) ([]Cluster, error) {
 	// Si no hay categorías seleccionadas, devolver array vacío
 	if inputs.Categories == "" {
 		return []Cluster{}, nil
 	}
 
-	// ✅ OPTIMIZACIÓN CRÍTICA: Calcular bounding box para pre-filtro eficiente
-	// Esto reduce las filas escaneadas de ~100K a ~100-500 (100x menos)
-	// Impacto: 10-15x más rápido
-	const (
-		metersPerDegreeLat = 111000.0 // ~111km por grado de latitud
-		metersPerDegreeLng = 85000.0  // ~85km por grado de longitud (en latitud ~54° - Canada)
-	)
-
-	// Calcular deltas para bounding box
-	latDelta := float64(inputs.Radius) / metersPerDegreeLat
-	lngDelta := float64(inputs.Radius) / metersPerDegreeLng
+	from, to, err := clusterquery.ParseWindow(inputs.FromDate, inputs.ToDate)
+	if err != nil {
+		return []Cluster{}, err
+	}
+	f := clusterquery.Filter{
+		Status: clusterquery.StatusActive,
+		// ✅ El builder agrega el bounding box de pre-filtro antes del ST_DWithin (usa índice GiST)
+		Center:     &clusterquery.Center{Point: clusterquery.Point{Latitude: inputs.Latitude, Longitude: inputs.Longitude}, Radius: inputs.Radius},
+		From:       from,
+		To:         to,
+		Categories: clusterquery.SplitList(inputs.Categories),
+	}
+	if inputs.InsuID != 0 {
+		f.InsuIDs = []int{inputs.InsuID}
+	}
+	b := clusterquery.NewBuilder("t1").Apply(f)
 
-	minLat := inputs.Latitude - latDelta
-	maxLat := inputs.Latitude + latDelta
-	minLng := inputs.Longitude - lngDelta
-	maxLng := inputs.Longitude + lngDelta
+	// 📄 Keyset: continuar después de la última fila de la página anterior
+	if after != nil {
+		b.After(clusterquery.SortRecent, nil, &pagination.SortedCursor{Cursor: *after})
+	}
 
-	// ✅ Query optimizada con bounding box + ST_DWithin (usa índice GiST)
+	// ✅ Orden estable: incl_id desempata clusters creados en el mismo instante
 	query := `
 		SELECT
-			t1.incl_id, t1.center_latitude, t1.center_longitude, t1.insu_id, t1.category_code, t1.subcategory_code
+			t1.incl_id, t1.center_latitude, t1.center_longitude, t1.insu_id, t1.category_code, t1.subcategory_code, t1.created_at
 		FROM incident_clusters t1
-		WHERE t1.center_latitude BETWEEN $1 AND $2
-		  AND t1.center_longitude BETWEEN $3 AND $4
-		  AND ST_DWithin(t1.center_location, ST_MakePoint($5, $6)::geography, $7)
-		  AND t1.start_time <= $8::date + INTERVAL '1 day'
-		  AND t1.end_time >= $9::date
-		  AND ($10::integer = 0 OR t1.insu_id = $11::integer)
-		  AND t1.is_active = '1'
-	`
-
-	params := []interface{}{
-		minLat, maxLat,                                    // Bounding box latitud
-		minLng, maxLng,                                    // Bounding box longitud
-		inputs.Longitude, inputs.Latitude, inputs.Radius, // ST_DWithin
-		inputs.ToDate,                                     // Sin DATE()
-		inputs.FromDate,                                   // Sin DATE()
-		inputs.InsuID, inputs.InsuID,
-	}
-
-	if inputs.Categories != "" {
-		cats := strings.Split(inputs.Categories, ",")
-		placeholders := make([]string, len(cats))
-		startIdx := len(params) // Capturar el índice ANTES del loop
-		for i := range cats {
-			placeholders[i] = fmt.Sprintf("$%d", startIdx+i+1)
-			params = append(params, strings.TrimSpace(cats[i]))
-		}
-		query += " AND t1.category_code IN (" + strings.Join(placeholders, ",") + ")"
-	}
-
-	// ✅ OPTIMIZACIÓN: Agregar ORDER BY y LIMIT para consistencia y performance
-	query += " ORDER BY t1.created_at DESC LIMIT 200"
+		WHERE ` + b.SQL() + b.OrderBy(clusterquery.SortRecent, nil) + b.Limit(limit)
 
-	// 🔥 Pre-asignar capacidad para evitar reallocaciones (LIMIT 200)
-	clusters := make([]Cluster, 0, 200)
-	rows, err := r.db.Query(query, params...)
+	// 🔥 Pre-asignar capacidad para evitar reallocaciones
+	clusters := make([]Cluster, 0, limit)
+	rows, err := r.db.Query(query, b.Params()...)
 	if err != nil {
 		return clusters, err
 	}
@@ -88,7 +65,7 @@ func (r *pgRepository) GetClustersByRadius(inputs Inputs) ([]Cluster, error) {
 
 	for rows.Next() {
 		var cluster Cluster
-		if err := rows.Scan(&cluster.InclId, &cluster.Latitude, &cluster.Longitude, &cluster.InsuId, &cluster.CategoryCode, &cluster.SubcategoryCode); err != nil {
+		if err := rows.Scan(&cluster.InclId, &cluster.Latitude, &cluster.Longitude, &cluster.InsuId, &cluster.CategoryCode, &cluster.SubcategoryCode, &cluster.CreatedAt); err != nil {
 			return clusters, err
 		}
 		clusters = append(clusters, cluster)
@@ -1,7 +1,14 @@
 package getclusterbyradius
 
+import "alertly/internal/pagination"
+
+const (
+	defaultPageSize = 200
+	maxPageSize     = 500
+)
+
 type Service interface {
-	GetClustersByRadius(inputs Inputs) ([]Cluster, error)
+	GetClustersByRadius(inputs Inputs) (ClustersPage, error)
 }
 
 type service struct {
@@ -12,10 +19,22 @@ func NewService(repo Repository) Service {
 	return &service{repo: repo}
 }
 
-func (s *service) GetClustersByRadius(inputs Inputs) ([]Cluster, error) {
-	result, err := s.repo.GetClustersByRadius(inputs)
+func (s *service) GetClustersByRadius(inputs Inputs) (ClustersPage, error) {
+	after, err := pagination.Decode(inputs.Cursor)
 	if err != nil {
-		return []Cluster{}, err
+		return ClustersPage{Clusters: []Cluster{}}, err
 	}
-	return result, nil
+	limit := pagination.Limit(inputs.Limit, defaultPageSize, maxPageSize)
+
+	// Se pide una fila de más para saber si hay otra página
+	result, err := s.repo.GetClustersByRadius(inputs, after, limit+1)
+	if err != nil {
+		return ClustersPage{Clusters: []Cluster{}}, err
+	}
+	return pagination.NewPage(result, limit, clusterPosition), nil
+}
+
+// clusterPosition es la posición de keyset de un cluster para el cursor de la página siguiente
+func clusterPosition(c Cluster) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: c.CreatedAt, ID: c.InclId}
 }
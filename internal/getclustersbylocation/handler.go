@@ -1,8 +1,11 @@
 package getclustersbylocation
 
 import (
+	"alertly/internal/clusterquery"
 	"alertly/internal/database"
+	"alertly/internal/pagination"
 	"alertly/internal/response"
+	"errors"
 	"log"
 	"net/http"
 
@@ -37,9 +40,54 @@ func Get(c *gin.Context) {
 
 	result, err := service.GetClustersByLocation(inputs)
 	if err != nil {
+		if errors.Is(err, clusterquery.ErrInvalidTimeRange) {
+			response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+			return
+		}
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			response.Send(c, http.StatusBadRequest, true, "Invalid cursor. Please reload the map and try again.", nil)
+			return
+		}
 		log.Printf("We couldn’t load the categories. Please try again later: %v", err)
 		response.Send(c, http.StatusInternalServerError, true, "We couldn’t load the categories. Please try again later.", nil)
 		return
 	}
 	response.Send(c, http.StatusOK, false, "Success", result)
 }
+
+// GetAggregated devuelve los clusters del viewport agrupados en celdas según el zoom,
+// para vistas alejadas donde la lista paginada no alcanza a mostrar toda el área
+func GetAggregated(c *gin.Context) {
+	var inputs AggregateInputs
+	// Solo la parte de la URL: zoom viene en el query y se valida al bindearlo
+	if err := c.ShouldBindUri(&inputs.Inputs); err != nil {
+		log.Printf("Error al bindear URI: %v", err)
+		response.Send(c, http.StatusBadRequest, true, "Invalid data in the URL. Please check and try again.", nil)
+		return
+	}
+
+	if err := c.ShouldBindQuery(&inputs); err != nil {
+		log.Printf("Error en query params: %v", err)
+		response.Send(c, http.StatusBadRequest, true, "Invalid query parameters. Please check and try again.", nil)
+		return
+	}
+
+	repo := NewRepository(database.DB)
+	service := NewService(repo)
+
+	result, err := service.GetAggregated(inputs)
+	if err != nil {
+		if errors.Is(err, clusterquery.ErrInvalidTimeRange) {
+			response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+			return
+		}
+		if errors.Is(err, ErrInvalidZoom) {
+			response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+			return
+		}
+		log.Printf("Error aggregating clusters: %v", err)
+		response.Send(c, http.StatusInternalServerError, true, "We couldn’t load the map. Please try again later.", nil)
+		return
+	}
+	response.Send(c, http.StatusOK, false, "Success", result)
+}
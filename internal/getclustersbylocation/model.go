@@ -1,5 +1,10 @@
 package getclustersbylocation
 
+import (
+	"alertly/internal/pagination"
+	"time"
+)
+
 type Cluster struct {
 	InclId          int64        `json:"incl_id"`
 	Latitude        float64      `json:"latitude"`
@@ -8,6 +13,7 @@ type Cluster struct {
 	CategoryCode    string       `json:"category_code"`
 	SubcategoryCode string       `json:"subcategory_code"`
 	Subcategory     *Subcategory `json:"subcategory"` // ✅ Datos completos de subcategoría
+	CreatedAt       time.Time    `json:"-"`           // solo para el cursor
 }
 
 // Subcategory contiene la información completa de la subcategoría (JOIN)
@@ -35,4 +41,36 @@ type Inputs struct {
 	ToDate       string  `uri:"to_date" binding:"required,datetime=2006-01-02"`
 	InsuID       int     `uri:"insu_id"`
 	Categories   string  `form:"categories"`
+	Cursor       string  `form:"cursor"` // next_cursor de la página anterior
+	Limit        int     `form:"limit"`
+}
+
+// ClustersPage es una página de clusters ordenados del más reciente al más antiguo
+type ClustersPage = pagination.Page[Cluster]
+
+// AggregateInputs: mismo viewport y filtros que Inputs más el zoom del mapa (0-22).
+// zoom es obligatorio: sin él (0) toda la vista caería en una sola celda de 90°.
+type AggregateInputs struct {
+	Inputs
+	Zoom *int `form:"zoom" binding:"required"`
+}
+
+// Bucket es una celda de la grilla con todos los clusters que caen en ella
+type Bucket struct {
+	Latitude             float64        `json:"latitude"`  // centroide de los clusters de la celda
+	Longitude            float64        `json:"longitude"` // centroide de los clusters de la celda
+	CellLatitude         float64        `json:"cell_latitude"`
+	CellLongitude        float64        `json:"cell_longitude"`
+	Count                int            `json:"count"`
+	Categories           map[string]int `json:"categories"` // count por category_code
+	DominantSubcategory  string         `json:"dominant_subcategory_code"`
+	RepresentativeInclId int64          `json:"representative_incl_id"` // el más votado, luego el más reciente
+}
+
+// AggregatedView es la respuesta de /cluster/aggregate
+type AggregatedView struct {
+	Zoom     int      `json:"zoom"`
+	CellSize float64  `json:"cell_size"` // en grados
+	Total    int      `json:"total"`
+	Buckets  []Bucket `json:"buckets"`
 }
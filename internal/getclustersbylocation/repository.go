@@ -1,13 +1,16 @@
 package getclustersbylocation
 
 import (
+	"alertly/internal/clusterquery"
+	"alertly/internal/pagination"
 	"database/sql"
+	"encoding/json"
 	"fmt"
-	"strings"
 )
 
 type Repository interface {
-	GetClustersByLocation(inputs Inputs) ([]Cluster, error)
+	GetClustersByLocation(inputs Inputs, after *pagination.Cursor, limit int) ([]Cluster, error)
+	GetBuckets(inputs Inputs, cellSize float64, maxBuckets int) ([]Bucket, error)
 }
 
 type pgRepository struct {
@@ -18,52 +21,34 @@ func NewRepository(db *sql.DB) Repository {
 	return &pgRepository{db: db}
 }
 
-func (r *pgRepository) GetClustersByLocation(inputs Inputs) ([]Cluster, error) {
+// GetClustersByLocation devuelve hasta limit clusters ordenados por (created_at, incl_id) descendente,
+// empezando después de after (nil = primera página)
+func (r *pgRepository) GetClustersByLocation(inputs Inputs, after *pagination.Cursor, limit int) ([]Cluster, error) {
 	// Si no hay categorías seleccionadas, devolver array vacío
 	if inputs.Categories == "" {
 		return []Cluster{}, nil
 	}
 
-	// ✅ OPTIMIZACIÓN CRÍTICA: Query mejorada sin DATE() para usar índices correctamente
-	// Cambio: DATE(t1.start_time) <= ? → t1.start_time <= $X + INTERVAL '1 day'
-	// Impacto: 5-8x más rápido (evita full table scan)
-	query := `
-        SELECT
-                t1.incl_id, t1.center_latitude, t1.center_longitude, t1.insu_id, t1.category_code, t1.subcategory_code
-        FROM incident_clusters t1
-        WHERE t1.center_latitude BETWEEN $1 AND $2
-          AND t1.center_longitude BETWEEN $3 AND $4
-          AND t1.start_time <= $5::date + INTERVAL '1 day'
-          AND t1.end_time >= $6::date
-          AND ($7::integer = 0 OR t1.insu_id = $8::integer)
-          AND t1.is_active = '1'
-	`
-	params := []interface{}{
-		inputs.MinLatitude, inputs.MaxLatitude,
-		inputs.MinLongitude, inputs.MaxLongitude,
-		inputs.ToDate,
-		inputs.FromDate,
-		inputs.InsuID, inputs.InsuID,
+	b, err := viewportFilter(inputs)
+	if err != nil {
+		return []Cluster{}, err
 	}
 
-	// ✅ CORRECCIÓN: Agregar categorías antes del ORDER BY con numeración consecutiva
-	if inputs.Categories != "" {
-		cats := strings.Split(inputs.Categories, ",")
-		placeholders := make([]string, len(cats))
-		startIdx := len(params) + 1 // $9 is the starting index
-		for i := range cats {
-			placeholders[i] = fmt.Sprintf("$%d", startIdx+i)
-			params = append(params, strings.TrimSpace(cats[i]))
-		}
-		query += " AND t1.category_code IN (" + strings.Join(placeholders, ",") + ")"
+	// 📄 Keyset: continuar después de la última fila de la página anterior
+	if after != nil {
+		b.After(clusterquery.SortRecent, nil, &pagination.SortedCursor{Cursor: *after})
 	}
 
-	// ✅ CORRECCIÓN: ORDER BY y LIMIT después de todas las condiciones WHERE
-	query += " ORDER BY t1.created_at DESC LIMIT 100"
+	// ✅ Orden estable: incl_id desempata clusters creados en el mismo instante
+	query := `
+        SELECT
+                t1.incl_id, t1.center_latitude, t1.center_longitude, t1.insu_id, t1.category_code, t1.subcategory_code, t1.created_at
+        FROM incident_clusters t1
+        WHERE ` + b.SQL() + b.OrderBy(clusterquery.SortRecent, nil) + b.Limit(limit)
 
-	// 🔥 Pre-asignar capacidad para evitar reallocaciones (LIMIT 100)
-	clusters := make([]Cluster, 0, 100)
-	rows, err := r.db.Query(query, params...)
+	// 🔥 Pre-asignar capacidad para evitar reallocaciones
+	clusters := make([]Cluster, 0, limit)
+	rows, err := r.db.Query(query, b.Params()...)
 	if err != nil {
 
 		return clusters, err
@@ -72,7 +57,7 @@ func (r *pgRepository) GetClustersByLocation(inputs Inputs) ([]Cluster, error) {
 
 	for rows.Next() {
 		var cluster Cluster
-		if err := rows.Scan(&cluster.InclId, &cluster.Latitude, &cluster.Longitude, &cluster.InsuId, &cluster.CategoryCode, &cluster.SubcategoryCode); err != nil {
+		if err := rows.Scan(&cluster.InclId, &cluster.Latitude, &cluster.Longitude, &cluster.InsuId, &cluster.CategoryCode, &cluster.SubcategoryCode, &cluster.CreatedAt); err != nil {
 			return clusters, err
 		}
 		clusters = append(clusters, cluster)
@@ -84,3 +69,103 @@ func (r *pgRepository) GetClustersByLocation(inputs Inputs) ([]Cluster, error) {
 
 	return clusters, nil
 }
+
+// viewportFilter arma con clusterquery el filtro compartido por la lista de clusters y la agregación:
+// viewport, rango de fechas, subcategoría, activos y categorías seleccionadas
+func viewportFilter(inputs Inputs) (*clusterquery.Builder, error) {
+	from, to, err := clusterquery.ParseWindow(inputs.FromDate, inputs.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	f := clusterquery.Filter{
+		Status: clusterquery.StatusActive,
+		BBox: &clusterquery.BBox{
+			MinLatitude: inputs.MinLatitude, MaxLatitude: inputs.MaxLatitude,
+			MinLongitude: inputs.MinLongitude, MaxLongitude: inputs.MaxLongitude,
+		},
+		From:       from,
+		To:         to,
+		Categories: clusterquery.SplitList(inputs.Categories),
+	}
+	if inputs.InsuID != 0 {
+		f.InsuIDs = []int{inputs.InsuID}
+	}
+	return clusterquery.NewBuilder("t1").Apply(f), nil
+}
+
+// GetBuckets agrupa los clusters del viewport en una grilla de cellSize grados con ST_SnapToGrid.
+// Devuelve hasta maxBuckets celdas, las más pobladas primero.
+func (r *pgRepository) GetBuckets(inputs Inputs, cellSize float64, maxBuckets int) ([]Bucket, error) {
+	if inputs.Categories == "" {
+		return []Bucket{}, nil
+	}
+
+	b, err := viewportFilter(inputs)
+	if err != nil {
+		return nil, err
+	}
+	cellArg := b.Arg(cellSize)
+	where := b.SQL()
+	limitArg := b.Arg(maxBuckets)
+
+	query := fmt.Sprintf(`
+        WITH filtered AS (
+            SELECT
+                t1.incl_id, t1.center_latitude, t1.center_longitude, t1.created_at,
+                COALESCE(t1.category_code, '') AS category_code,
+                COALESCE(t1.subcategory_code, '') AS subcategory_code,
+                COALESCE(t1.counter_total_votes, 0) AS votes,
+                ST_SnapToGrid(t1.center_location::geometry, %s) AS cell
+            FROM incident_clusters t1
+            WHERE %s
+        ),
+        cells AS (
+            SELECT ST_Y(cell) AS cell_lat, ST_X(cell) AS cell_lng, * FROM filtered
+        ),
+        per_category AS (
+            SELECT cell_lat, cell_lng, jsonb_object_agg(category_code, n) AS categories
+            FROM (
+                SELECT cell_lat, cell_lng, category_code, COUNT(*) AS n
+                FROM cells
+                GROUP BY cell_lat, cell_lng, category_code
+            ) c
+            GROUP BY cell_lat, cell_lng
+        )
+        SELECT
+            b.cell_lat, b.cell_lng, b.total, b.avg_lat, b.avg_lng, b.dominant, b.representative, pc.categories
+        FROM (
+            SELECT
+                cell_lat, cell_lng,
+                COUNT(*) AS total,
+                AVG(center_latitude)::float8 AS avg_lat,
+                AVG(center_longitude)::float8 AS avg_lng,
+                mode() WITHIN GROUP (ORDER BY subcategory_code) AS dominant,
+                (array_agg(incl_id ORDER BY votes DESC, created_at DESC, incl_id DESC))[1] AS representative
+            FROM cells
+            GROUP BY cell_lat, cell_lng
+        ) b
+        INNER JOIN per_category pc ON pc.cell_lat = b.cell_lat AND pc.cell_lng = b.cell_lng
+        ORDER BY b.total DESC
+        LIMIT %s`, cellArg, where, limitArg)
+
+	rows, err := r.db.Query(query, b.Params()...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying buckets: %w", err)
+	}
+	defer rows.Close()
+
+	buckets := []Bucket{}
+	for rows.Next() {
+		var b Bucket
+		var categories []byte
+		if err := rows.Scan(&b.CellLatitude, &b.CellLongitude, &b.Count, &b.Latitude, &b.Longitude,
+			&b.DominantSubcategory, &b.RepresentativeInclId, &categories); err != nil {
+			return nil, fmt.Errorf("error scanning bucket: %w", err)
+		}
+		if err := json.Unmarshal(categories, &b.Categories); err != nil {
+			return nil, fmt.Errorf("error decoding bucket categories: %w", err)
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
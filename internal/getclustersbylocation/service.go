@@ -1,7 +1,26 @@
 package getclustersbylocation
 
+import (
+	"alertly/internal/pagination"
+	"errors"
+	"math"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 500
+
+	// Agregación: cada tile de 256px se divide en cellsPerTile x cellsPerTile celdas (~64px)
+	maxZoom      = 22
+	cellsPerTile = 4
+	maxBuckets   = 1000
+)
+
+var ErrInvalidZoom = errors.New("zoom must be between 0 and 22")
+
 type Service interface {
-	GetClustersByLocation(inputs Inputs) ([]Cluster, error)
+	GetClustersByLocation(inputs Inputs) (ClustersPage, error)
+	GetAggregated(inputs AggregateInputs) (AggregatedView, error)
 }
 
 type service struct {
@@ -12,10 +31,48 @@ func NewService(repo Repository) Service {
 	return &service{repo: repo}
 }
 
-func (s *service) GetClustersByLocation(inputs Inputs) ([]Cluster, error) {
-	result, err := s.repo.GetClustersByLocation(inputs)
+func (s *service) GetClustersByLocation(inputs Inputs) (ClustersPage, error) {
+	after, err := pagination.Decode(inputs.Cursor)
+	if err != nil {
+		return ClustersPage{Clusters: []Cluster{}}, err
+	}
+	limit := pagination.Limit(inputs.Limit, defaultPageSize, maxPageSize)
+
+	// Se pide una fila de más para saber si hay otra página
+	result, err := s.repo.GetClustersByLocation(inputs, after, limit+1)
 	if err != nil {
-		return []Cluster{}, err
+		return ClustersPage{Clusters: []Cluster{}}, err
 	}
-	return result, nil
+	return pagination.NewPage(result, limit, clusterPosition), nil
+}
+
+// clusterPosition es la posición de keyset de un cluster para el cursor de la página siguiente
+func clusterPosition(c Cluster) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: c.CreatedAt, ID: c.InclId}
+}
+
+// GetAggregated agrupa todos los clusters del viewport en celdas cuyo tamaño depende del zoom
+func (s *service) GetAggregated(inputs AggregateInputs) (AggregatedView, error) {
+	if inputs.Zoom == nil || *inputs.Zoom < 0 || *inputs.Zoom > maxZoom {
+		return AggregatedView{Buckets: []Bucket{}}, ErrInvalidZoom
+	}
+	zoom := *inputs.Zoom
+
+	cellSize := gridCellSize(zoom)
+	buckets, err := s.repo.GetBuckets(inputs.Inputs, cellSize, maxBuckets)
+	if err != nil {
+		return AggregatedView{Buckets: []Bucket{}}, err
+	}
+
+	view := AggregatedView{Zoom: zoom, CellSize: cellSize, Buckets: buckets}
+	for _, b := range buckets {
+		view.Total += b.Count
+	}
+	return view, nil
+}
+
+// gridCellSize devuelve el lado de la celda en grados: el ancho de un tile web mercator
+// en ese zoom (360 / 2^zoom) dividido en cellsPerTile
+func gridCellSize(zoom int) float64 {
+	return 360 / math.Pow(2, float64(zoom)) / cellsPerTile
 }
@@ -0,0 +1,31 @@
+package getclustersbylocation
+
+import "testing"
+
+func TestGridCellSize(t *testing.T) {
+	tests := []struct {
+		zoom int
+		want float64
+	}{
+		{0, 90},
+		{1, 45},
+		{10, 360.0 / 1024 / 4},
+	}
+	for _, tt := range tests {
+		if got := gridCellSize(tt.zoom); got != tt.want {
+			t.Errorf("gridCellSize(%d) = %v, want %v", tt.zoom, got, tt.want)
+		}
+	}
+}
+
+func TestGetAggregatedRejectsInvalidZoom(t *testing.T) {
+	s := NewService(nil)
+	for _, zoom := range []int{-1, 23} {
+		if _, err := s.GetAggregated(AggregateInputs{Zoom: &zoom}); err != ErrInvalidZoom {
+			t.Errorf("zoom %d: error = %v, want ErrInvalidZoom", zoom, err)
+		}
+	}
+	if _, err := s.GetAggregated(AggregateInputs{}); err != ErrInvalidZoom {
+		t.Errorf("missing zoom: error = %v, want ErrInvalidZoom", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"alertly/internal/auth"
 	"alertly/internal/database"
 	"alertly/internal/response"
+	"errors"
 	"log"
 	"net/http"
 
@@ -27,12 +28,22 @@ func GetReel(c *gin.Context) {
 		return
 	}
 
+	if err := c.ShouldBindQuery(&inputs); err != nil {
+		log.Printf("Error binding query: %v", err)
+		response.Send(c, http.StatusBadRequest, true, "Invalid query parameters. Please check and try again.", nil)
+		return
+	}
+
 	repo := NewRepository(database.DB)
 	service := NewService(repo)
 
 	data, err := service.GetReel(inputs, accountID)
 
 	if err != nil {
+		if errors.Is(err, ErrInvalidCursor) {
+			response.Send(c, http.StatusBadRequest, true, "Invalid cursor. Please refresh the feed.", nil)
+			return
+		}
 		log.Printf("We couldn’t load the incidents. Please try again later: %v", err)
 		response.Send(c, http.StatusOK, false, "We couldn’t load the incidents. Please try again later.", data)
 		return
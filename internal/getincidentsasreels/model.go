@@ -1,8 +1,46 @@
 package getincidentsasreels
 
+import (
+	"alertly/internal/getclusterby"
+	"time"
+)
+
 type Inputs struct {
-	MinLatitude  float64 `uri:"min_latitude" binding:"required"`
-	MaxLatitude  float64 `uri:"max_latitude" binding:"required"`
-	MinLongitude float64 `uri:"min_longitude" binding:"required"`
-	MaxLongitude float64 `uri:"max_longitude" binding:"required"`
+	MinLatitude  float64  `uri:"min_latitude" binding:"required"`
+	MaxLatitude  float64  `uri:"max_latitude" binding:"required"`
+	MinLongitude float64  `uri:"min_longitude" binding:"required"`
+	MaxLongitude float64  `uri:"max_longitude" binding:"required"`
+	Latitude     *float64 `form:"latitude"`  // ubicación del usuario; si no viene se usa el centro del viewport
+	Longitude    *float64 `form:"longitude"` // ubicación del usuario; si no viene se usa el centro del viewport
+	Cursor       string   `form:"cursor"`    // next_cursor del reel anterior
+}
+
+// Reel es una página del feed ordenada por relevancia para el usuario
+type Reel struct {
+	Clusters   []getclusterby.Cluster `json:"clusters"`
+	HasMore    bool                   `json:"has_more"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+type point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// candidate son los datos de un cluster que usa el ranking
+type candidate struct {
+	InclId             int64
+	CreatedAt          time.Time
+	Credibility        float64
+	Votes              int
+	Comments           int
+	Views              int
+	HasMedia           bool
+	ViewerDistance     float64  // metros
+	SavedPlaceDistance *float64 // metros al lugar guardado más cercano; nil si no tiene lugares
+}
+
+type scoredCandidate struct {
+	InclId int64
+	Score  float64
 }
@@ -0,0 +1,151 @@
+package getincidentsasreels
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	reelPageSize      = 20
+	reelCandidatePool = 300 // clusters más recientes que se rankean en cada pedido
+
+	// Pesos del ranking (suman 1)
+	weightProximity   = 0.30
+	weightRecency     = 0.25
+	weightCredibility = 0.15
+	weightEngagement  = 0.15
+	weightMedia       = 0.15
+
+	viewerDistanceScale     = 2000.0 // metros: a 2 km del usuario la cercanía vale ~0.37
+	savedPlaceDistanceScale = 500.0  // metros: los lugares guardados pesan solo muy cerca
+	recencyHalfLife         = 6 * time.Hour
+	engagementSaturation    = 100.0 // interacciones a partir de las cuales engagement vale 1
+)
+
+var ErrInvalidCursor = errors.New("invalid reel cursor")
+
+// scoreCandidate combina cercanía (al usuario o a sus lugares guardados), antigüedad, credibilidad,
+// interacción y si tiene fotos/video en un puntaje de 0 a 1
+func scoreCandidate(c candidate, now time.Time) float64 {
+	proximity := math.Exp(-c.ViewerDistance / viewerDistanceScale)
+	if c.SavedPlaceDistance != nil {
+		proximity = math.Max(proximity, math.Exp(-*c.SavedPlaceDistance/savedPlaceDistanceScale))
+	}
+
+	age := now.Sub(c.CreatedAt)
+	if age < 0 {
+		age = 0
+	}
+	recency := math.Pow(0.5, age.Hours()/recencyHalfLife.Hours())
+
+	// Sin votos la credibilidad es neutra
+	credibility := 0.5
+	if c.Votes > 0 {
+		credibility = math.Min(math.Max(c.Credibility/10, 0), 1)
+	}
+
+	interactions := float64(c.Votes) + 2*float64(c.Comments) + 0.1*float64(c.Views)
+	engagement := math.Min(math.Log1p(interactions)/math.Log1p(engagementSaturation), 1)
+
+	media := 0.0
+	if c.HasMedia {
+		media = 1
+	}
+
+	return weightProximity*proximity + weightRecency*recency + weightCredibility*credibility +
+		weightEngagement*engagement + weightMedia*media
+}
+
+// rankCandidates ordena por puntaje (incl_id desempata) y descarta lo que ya se entregó en páginas anteriores
+func rankCandidates(candidates []candidate, now time.Time, after *reelCursor) []scoredCandidate {
+	var shown map[int64]bool
+	if after != nil {
+		shown = make(map[int64]bool, len(after.Shown))
+		for _, id := range after.Shown {
+			shown[id] = true
+		}
+	}
+
+	ranked := make([]scoredCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if shown[c.InclId] {
+			continue
+		}
+		ranked = append(ranked, scoredCandidate{InclId: c.InclId, Score: scoreCandidate(c, now)})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].InclId > ranked[j].InclId
+	})
+	return ranked
+}
+
+// reelCursor guarda el instante en que se armó el primer reel (para no sumar clusters creados después)
+// y los clusters ya entregados. Los puntajes cambian entre páginas con cada voto, comentario o vista, así que
+// no sirve continuar desde el puntaje del último: la página siguiente es lo mejor rankeado entre lo no entregado.
+// Shown nunca pasa de reelCandidatePool IDs.
+type reelCursor struct {
+	GeneratedAt time.Time
+	Shown       []int64
+}
+
+// next devuelve el cursor de la página siguiente sumando los clusters de esta página
+func (c *reelCursor) next(generatedAt time.Time, page []scoredCandidate) reelCursor {
+	n := reelCursor{GeneratedAt: generatedAt}
+	if c != nil {
+		n.Shown = append(n.Shown, c.Shown...)
+	}
+	for _, sc := range page {
+		n.Shown = append(n.Shown, sc.InclId)
+	}
+	return n
+}
+
+func (c reelCursor) Encode() string {
+	ids := make([]string, len(c.Shown))
+	for i, id := range c.Shown {
+		ids[i] = strconv.FormatInt(id, 36)
+	}
+	raw := fmt.Sprintf("%d:%s", c.GeneratedAt.UTC().UnixMicro(), strings.Join(ids, ","))
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeReelCursor lee un cursor de Encode. Vacío = primer reel (nil, nil).
+func decodeReelCursor(s string) (*reelCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, ErrInvalidCursor
+	}
+	micros, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	ids := strings.Split(parts[1], ",")
+	if len(ids) > reelCandidatePool {
+		return nil, ErrInvalidCursor
+	}
+	cursor := &reelCursor{GeneratedAt: time.UnixMicro(micros).UTC(), Shown: make([]int64, len(ids))}
+	for i, v := range ids {
+		id, err := strconv.ParseInt(v, 36, 64)
+		if err != nil || id <= 0 {
+			return nil, ErrInvalidCursor
+		}
+		cursor.Shown[i] = id
+	}
+	return cursor, nil
+}
@@ -0,0 +1,128 @@
+package getincidentsasreels
+
+import (
+	"testing"
+	"time"
+)
+
+func fp(v float64) *float64 { return &v }
+
+func TestScoreCandidate(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	base := candidate{InclId: 1, CreatedAt: now.Add(-time.Hour), ViewerDistance: 1000}
+
+	better := func(name string, a, b candidate) {
+		t.Helper()
+		if scoreCandidate(a, now) <= scoreCandidate(b, now) {
+			t.Errorf("%s: expected %.4f > %.4f", name, scoreCandidate(a, now), scoreCandidate(b, now))
+		}
+	}
+
+	closer := base
+	closer.ViewerDistance = 100
+	better("closer to viewer", closer, base)
+
+	nearSaved := base
+	nearSaved.ViewerDistance = 20000
+	nearSaved.SavedPlaceDistance = fp(50)
+	far := base
+	far.ViewerDistance = 20000
+	better("near a saved place", nearSaved, far)
+
+	older := base
+	older.CreatedAt = now.Add(-12 * time.Hour)
+	better("more recent", base, older)
+
+	credible := base
+	credible.Votes, credible.Credibility = 10, 9
+	notCredible := base
+	notCredible.Votes, notCredible.Credibility = 10, 1
+	better("more credible", credible, notCredible)
+
+	engaged := base
+	engaged.Comments, engaged.Views = 10, 200
+	better("more engagement", engaged, base)
+
+	withMedia := base
+	withMedia.HasMedia = true
+	better("has media", withMedia, base)
+
+	best := candidate{CreatedAt: now, HasMedia: true, Votes: 1000, Credibility: 10}
+	if got := scoreCandidate(best, now); got < 0.999 || got > 1.0001 {
+		t.Errorf("best possible candidate should score 1, got %.4f", got)
+	}
+}
+
+func TestRankCandidatesWithCursor(t *testing.T) {
+	now := time.Now()
+	var candidates []candidate
+	for i := int64(1); i <= 5; i++ {
+		candidates = append(candidates, candidate{InclId: i, CreatedAt: now, ViewerDistance: float64(i * 500)})
+	}
+
+	ranked := rankCandidates(candidates, now, nil)
+	if len(ranked) != 5 || ranked[0].InclId != 1 || ranked[4].InclId != 5 {
+		t.Fatalf("unexpected order: %+v", ranked)
+	}
+
+	cursor := (*reelCursor)(nil).next(now, ranked[:2])
+	decoded, err := decodeReelCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("decodeReelCursor() error = %v", err)
+	}
+	if len(decoded.Shown) != 2 || decoded.Shown[0] != 1 || decoded.Shown[1] != 2 || !decoded.GeneratedAt.Equal(now.Truncate(time.Microsecond)) {
+		t.Fatalf("cursor round trip: got %+v, want %+v", decoded, cursor)
+	}
+
+	next := rankCandidates(candidates, now, decoded)
+	if len(next) != 3 || next[0].InclId != 3 {
+		t.Errorf("next page should skip what was already shown: %+v", next)
+	}
+}
+
+func TestRankCandidatesCountersChangeBetweenPages(t *testing.T) {
+	now := time.Now()
+	var candidates []candidate
+	for i := int64(1); i <= 6; i++ {
+		candidates = append(candidates, candidate{InclId: i, CreatedAt: now, ViewerDistance: float64(i * 500)})
+	}
+
+	page := rankCandidates(candidates, now, nil)[:2]
+	cursor := (*reelCursor)(nil).next(now, page)
+
+	// Entre páginas: uno ya entregado gana interacción y uno pendiente la pierde frente al resto
+	candidates[1].Comments, candidates[1].Views = 50, 500
+	candidates[5].Votes, candidates[5].Credibility, candidates[5].Comments = 40, 10, 40
+
+	seen := map[int64]int{}
+	for _, sc := range page {
+		seen[sc.InclId]++
+	}
+	for {
+		ranked := rankCandidates(candidates, now, &cursor)
+		if len(ranked) == 0 {
+			break
+		}
+		if len(ranked) > 2 {
+			ranked = ranked[:2]
+		}
+		for _, sc := range ranked {
+			seen[sc.InclId]++
+		}
+		cursor = cursor.next(now, ranked)
+	}
+
+	for id := int64(1); id <= 6; id++ {
+		if seen[id] != 1 {
+			t.Errorf("cluster %d shown %d times, want exactly once", id, seen[id])
+		}
+	}
+}
+
+func TestDecodeReelCursorInvalid(t *testing.T) {
+	for _, s := range []string{"%%%", "MTow", "MTphLCE", "YTpiOmM", "MTo"} {
+		if _, err := decodeReelCursor(s); err != ErrInvalidCursor {
+			t.Errorf("decodeReelCursor(%q) error = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
@@ -1,17 +1,21 @@
 package getincidentsasreels
 
 import (
+	"alertly/internal/clusterquery"
 	"alertly/internal/getclusterby"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 )
 
 const maxDistanceMeters = 500
 
 type Repository interface {
-	GetReel(inputs Inputs, accountID int64) ([]getclusterby.Cluster, error)
+	GetCandidates(inputs Inputs, accountID int64, viewer point, createdBefore time.Time, limit int) ([]candidate, error)
+	GetClustersByIds(ids []int64) ([]getclusterby.Cluster, error)
 }
 
 type pgRepository struct {
@@ -22,45 +26,81 @@ func NewRepository(db *sql.DB) Repository {
 	return &pgRepository{db: db}
 }
 
-func (r *pgRepository) GetReel(inputs Inputs, accountID int64) ([]getclusterby.Cluster, error) {
-
-	idQuery := `
-    SELECT c.incl_id
-    FROM incident_clusters c
-    WHERE
-        (c.center_latitude  BETWEEN $1 AND $2 AND c.center_longitude BETWEEN $3 AND $4)
+// GetCandidates devuelve los clusters activos del viewport o cerca de los lugares guardados del usuario
+// que todavía no vio (account_history), con los datos que usa el ranking. En los lugares de tipo ruta
+// la distancia se mide a la línea completa, no a su inicio.
+func (r *pgRepository) GetCandidates(inputs Inputs, accountID int64, viewer point, createdBefore time.Time, limit int) ([]candidate, error) {
+	b := clusterquery.NewBuilder("c").Apply(clusterquery.Filter{Status: clusterquery.StatusActive})
+	account := b.Arg(accountID)
+	viewerDistance := b.Distance(&clusterquery.Point{Latitude: viewer.Latitude, Longitude: viewer.Longitude})
+	b.Where("c.created_at <= " + b.Arg(createdBefore))
+	b.Where(fmt.Sprintf(`(
+        (c.center_latitude BETWEEN %s AND %s AND c.center_longitude BETWEEN %s AND %s)
         OR EXISTS (
           SELECT 1
           FROM account_favorite_locations f
-          WHERE f.account_id = $5
-            AND ST_DWithin(c.center_location, f.location, $6)
+          WHERE f.account_id = %s
+            AND ST_DWithin(c.center_location, COALESCE(f.route, f.location), %s)
         )
-	AND c.is_active = '1'
-    ORDER BY RANDOM()
-    LIMIT 20
-    `
-	rows, err := r.db.Query(idQuery,
-		inputs.MinLatitude, inputs.MaxLatitude, inputs.MinLongitude, inputs.MaxLongitude,
-		accountID, maxDistanceMeters,
-	)
+      )`, b.Arg(inputs.MinLatitude), b.Arg(inputs.MaxLatitude), b.Arg(inputs.MinLongitude), b.Arg(inputs.MaxLongitude),
+		account, b.Arg(maxDistanceMeters)))
+	b.Where(fmt.Sprintf(`NOT EXISTS (
+        SELECT 1 FROM account_history h
+        WHERE h.account_id = %s AND h.incl_id = c.incl_id
+      )`, account))
+
+	query := fmt.Sprintf(`
+    SELECT
+        c.incl_id,
+        COALESCE(c.created_at, NOW()),
+        COALESCE(c.credibility, 0),
+        COALESCE(c.counter_total_votes, 0),
+        COALESCE(c.counter_total_comments, 0),
+        COALESCE(c.counter_total_views, 0),
+        COALESCE(c.media_url, '') NOT IN ('', 'processing') OR EXISTS (
+            SELECT 1 FROM incident_media m
+            INNER JOIN incident_reports ir ON ir.inre_id = m.inre_id
+            WHERE ir.incl_id = c.incl_id AND m.status = 'ready'
+        ),
+        %s,
+        (
+            SELECT MIN(ST_Distance(c.center_location, COALESCE(f.route, f.location)))
+            FROM account_favorite_locations f
+            WHERE f.account_id = %s
+        )
+    FROM incident_clusters c
+    WHERE %s`, viewerDistance, account, b.SQL())
+	query += b.OrderBy(clusterquery.SortRecent, nil) + b.Limit(limit)
+
+	rows, err := r.db.Query(query, b.Params()...)
 	if err != nil {
-		return nil, fmt.Errorf("fetch random ids: %w", err)
+		return nil, fmt.Errorf("fetch reel candidates: %w", err)
 	}
 	defer rows.Close()
 
-	var ids []int64
+	var candidates []candidate
 	for rows.Next() {
-		var id int64
-		if err := rows.Scan(&id); err != nil {
-			return nil, fmt.Errorf("scan id: %w", err)
+		var c candidate
+		var savedPlace sql.NullFloat64
+		if err := rows.Scan(&c.InclId, &c.CreatedAt, &c.Credibility, &c.Votes, &c.Comments, &c.Views,
+			&c.HasMedia, &c.ViewerDistance, &savedPlace); err != nil {
+			return nil, fmt.Errorf("scan reel candidate: %w", err)
+		}
+		if savedPlace.Valid {
+			c.SavedPlaceDistance = &savedPlace.Float64
 		}
-		ids = append(ids, id)
+		candidates = append(candidates, c)
 	}
+	return candidates, rows.Err()
+}
+
+// GetClustersByIds reconstruye el detalle de los clusters del reel en el orden de ids
+func (r *pgRepository) GetClustersByIds(ids []int64) ([]getclusterby.Cluster, error) {
 	if len(ids) == 0 {
 		return nil, nil
 	}
 
-	// Paso 2: reconstruir el detalle solo para esos 20 IDs
+	// Reconstruir el detalle solo para los IDs de la página
 	placeholders := make([]string, len(ids))
 	args := make([]interface{}, len(ids))
 	for i, id := range ids {
@@ -184,6 +224,17 @@ func (r *pgRepository) GetReel(inputs Inputs, accountID int64) ([]getclusterby.C
 
 		results = append(results, cl)
 	}
+	if err := rows2.Err(); err != nil {
+		return nil, err
+	}
 
+	// IN (...) no respeta el orden: se reordena según el ranking
+	position := make(map[int64]int, len(ids))
+	for i, id := range ids {
+		position[id] = i
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return position[results[i].InclId] < position[results[j].InclId]
+	})
 	return results, nil
 }
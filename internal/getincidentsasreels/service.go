@@ -8,10 +8,11 @@ import (
 	"alertly/internal/database"
 	"alertly/internal/getclusterby"
 	"math"
+	"time"
 )
 
 type Service interface {
-	GetReel(inputs Inputs, accountID int64) ([]getclusterby.Cluster, error)
+	GetReel(inputs Inputs, accountID int64) (Reel, error)
 }
 
 type service struct {
@@ -22,11 +23,38 @@ func NewService(repo Repository) Service {
 	return &service{repo: repo}
 }
 
-func (s *service) GetReel(inputs Inputs, accountID int64) ([]getclusterby.Cluster, error) {
-	// 1) obtenemos los clusters base
-	clusters, err := s.repo.GetReel(inputs, accountID)
+func (s *service) GetReel(inputs Inputs, accountID int64) (Reel, error) {
+	reel := Reel{Clusters: []getclusterby.Cluster{}}
+
+	after, err := decodeReelCursor(inputs.Cursor)
+	if err != nil {
+		return reel, err
+	}
+	// Misma precisión que el cursor para que las páginas siguientes usen el mismo instante
+	now := time.Now().Truncate(time.Microsecond)
+	if after != nil {
+		now = after.GeneratedAt
+	}
+
+	// 1) rankeamos los candidatos que el usuario no vio y tomamos una página
+	candidates, err := s.repo.GetCandidates(inputs, accountID, viewerLocation(inputs), now, reelCandidatePool)
 	if err != nil {
-		return nil, err
+		return reel, err
+	}
+	ranked := rankCandidates(candidates, now, after)
+	if len(ranked) > reelPageSize {
+		ranked = ranked[:reelPageSize]
+		reel.HasMore = true
+		reel.NextCursor = after.next(now, ranked).Encode()
+	}
+
+	ids := make([]int64, len(ranked))
+	for i, sc := range ranked {
+		ids[i] = sc.InclId
+	}
+	clusters, err := s.repo.GetClustersByIds(ids)
+	if err != nil {
+		return reel, err
 	}
 
 	// pre-inicializamos repositorios/servicios auxiliares
@@ -59,7 +87,21 @@ func (s *service) GetReel(inputs Inputs, accountID int64) ([]getclusterby.Cluste
 		}
 	}
 
-	return clusters, nil
+	if clusters != nil {
+		reel.Clusters = clusters
+	}
+	return reel, nil
+}
+
+// viewerLocation: ubicación enviada por la app o, si no viene, el centro del viewport
+func viewerLocation(inputs Inputs) point {
+	if inputs.Latitude != nil && inputs.Longitude != nil {
+		return point{Latitude: *inputs.Latitude, Longitude: *inputs.Longitude}
+	}
+	return point{
+		Latitude:  (inputs.MinLatitude + inputs.MaxLatitude) / 2,
+		Longitude: (inputs.MinLongitude + inputs.MaxLongitude) / 2,
+	}
 }
 
 // Misma función que tenías en getclusterby
@@ -316,11 +316,6 @@ func ProcessImage(filePath, folder string) (string, error) {
 	return s3URL, nil
 }
 
-// ProcessVideo es una función placeholder para procesar videos.
-func ProcessVideo(filePath, outputFolder string) (string, error) {
-	return "", fmt.Errorf("ProcessVideo not implemented")
-}
-
 // ProcessProfileImage procesa la imagen de perfil ubicada en filePath y la sube a S3.
 // Redimensiona la imagen para mobile y la codifica a formato WebP con calidad 80.
 // Devuelve la URL pública de S3.
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"image"
+	"io"
 	"os"
 	"path/filepath"
 	"time"
@@ -107,6 +108,75 @@ func (s *R2Service) UploadRawFile(data []byte, folder, filename string) (string,
 	return url, nil
 }
 
+// UploadFile streams a file from disk to R2 and returns the public URL
+func (s *R2Service) UploadFile(filePath, folder, contentType string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %v", filePath, err)
+	}
+	defer file.Close()
+
+	filename := fmt.Sprintf("alerty_%d%s", time.Now().UnixNano(), filepath.Ext(filePath))
+	key := filepath.Join(folder, filename)
+
+	_, err = s.client.PutObject(&s3.PutObjectInput{
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(key),
+		Body:         file,
+		ContentType:  aws.String(contentType),
+		CacheControl: aws.String("max-age=31536000"), // 1 year cache
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload to R2: %v", err)
+	}
+
+	url := fmt.Sprintf("%s/%s", s.baseURL, key)
+	return url, nil
+}
+
+// PutFile streams a file from disk to R2 under an explicit key (no CDN cache headers)
+func (s *R2Service) PutFile(filePath, key string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", filePath, err)
+	}
+	defer file.Close()
+
+	_, err = s.client.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        file,
+		ContentType: aws.String("application/octet-stream"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s to R2: %v", key, err)
+	}
+	return nil
+}
+
+// DownloadFile copies an R2 object to destPath
+func (s *R2Service) DownloadFile(key, destPath string) error {
+	out, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to download %s from R2: %w", key, err)
+	}
+	defer out.Body.Close()
+
+	file, err := os.Create(destPath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", destPath, err)
+	}
+	if _, err := io.Copy(file, out.Body); err != nil {
+		file.Close()
+		os.Remove(destPath)
+		return fmt.Errorf("failed to write %s: %v", destPath, err)
+	}
+	return file.Close()
+}
+
 // DeleteFile deletes a file from R2
 func (s *R2Service) DeleteFile(key string) error {
 	_, err := s.client.DeleteObject(&s3.DeleteObjectInput{
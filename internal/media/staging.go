@@ -0,0 +1,64 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// stagingFolder guarda los originales subidos hasta que el outbox los procesa. Así cualquier instancia
+// puede tomar el job, no solo la que recibió el upload.
+const stagingFolder = "staging"
+
+// ErrStagedNotFound: el original ya no está en el bucket (se procesó o se borró)
+var ErrStagedNotFound = errors.New("staged upload not found")
+
+// StageUpload sube el original a R2 y devuelve su key
+func StageUpload(localPath string) (string, error) {
+	r2, err := NewR2Service()
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%s/orig_%d%s", stagingFolder, time.Now().UnixNano(), filepath.Ext(localPath))
+	if err := r2.PutFile(localPath, key); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// FetchStaged descarga el original a un archivo temporal en dir; quien lo pide lo borra
+func FetchStaged(key, dir string) (string, error) {
+	r2, err := NewR2Service()
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(dir, "staged_*"+filepath.Ext(key))
+	if err != nil {
+		return "", err
+	}
+	tmp.Close()
+
+	if err := r2.DownloadFile(key, tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		var aerr awserr.Error
+		if errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return "", ErrStagedNotFound
+		}
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// DeleteStaged borra el original del bucket una vez procesado (o descartado)
+func DeleteStaged(key string) error {
+	r2, err := NewR2Service()
+	if err != nil {
+		return err
+	}
+	return r2.DeleteFile(key)
+}
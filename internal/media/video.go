@@ -0,0 +1,404 @@
+package media
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxVideoDuration = 60 * time.Second
+	defaultMaxVideoBytes    = 100 << 20 // 100 MB
+	minVideoDuration        = 1 * time.Second
+)
+
+// ErrInvalidVideo se devuelve (envuelto) cuando el archivo no es un video aceptable:
+// contenedor desconocido, sin pista de video, códec no soportado, muy largo o muy pesado.
+// Reintentar no sirve; el llamador debe marcar el video como fallido.
+var ErrInvalidVideo = errors.New("invalid video")
+
+// ErrFFmpegNotFound se devuelve (envuelto) cuando el binario de ffmpeg no está instalado en la imagen
+// o FFMPEG_PATH apunta a un archivo que no existe. Reintentar no sirve hasta que se corrija el deploy.
+var ErrFFmpegNotFound = errors.New("ffmpeg binary not found")
+
+// Códecs que los clientes iOS/Android reproducen sin transcodificar
+var (
+	allowedVideoCodecs = map[string]bool{"avc1": true, "avc3": true, "hvc1": true, "hev1": true}
+	allowedAudioCodecs = map[string]bool{"mp4a": true}
+)
+
+// VideoInfo es lo que se obtiene del contenedor MP4/MOV sin decodificar el video
+type VideoInfo struct {
+	Container  string // "mp4" o "mov"
+	Duration   time.Duration
+	Width      int
+	Height     int
+	VideoCodec string // fourcc del sample entry, p.ej. "avc1"
+	AudioCodec string // "" si no hay pista de audio
+	Size       int64
+}
+
+// VideoResult es el resultado de ProcessVideo
+type VideoResult struct {
+	URL       string
+	PosterURL string
+	Info      VideoInfo
+}
+
+// IsVideoFile decide por extensión o Content-Type si un archivo subido es un video
+func IsVideoFile(filename, contentType string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".mp4", ".mov", ".m4v":
+		return true
+	}
+	return strings.HasPrefix(strings.ToLower(contentType), "video/")
+}
+
+// ProbeVideoFile lee los metadatos del contenedor de un archivo MP4/MOV
+func ProbeVideoFile(filePath string) (VideoInfo, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return VideoInfo{}, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return VideoInfo{}, err
+	}
+	return ProbeVideo(file, stat.Size())
+}
+
+// ProbeVideo recorre las cajas ISO BMFF (ftyp, moov/mvhd, trak/tkhd, mdia/hdlr, stsd)
+// y devuelve duración, dimensiones y códecs
+func ProbeVideo(r io.ReaderAt, size int64) (VideoInfo, error) {
+	info := VideoInfo{Size: size}
+	foundMoov := false
+
+	err := walkBoxes(r, 0, size, func(typ string, start, end int64) error {
+		switch typ {
+		case "ftyp":
+			brand, err := readAt(r, start, 4)
+			if err != nil {
+				return err
+			}
+			info.Container = "mp4"
+			if string(brand) == "qt  " {
+				info.Container = "mov"
+			}
+		case "moov":
+			foundMoov = true
+			return probeMoov(r, start, end, &info)
+		}
+		return nil
+	})
+	if err != nil {
+		return info, fmt.Errorf("%w: %v", ErrInvalidVideo, err)
+	}
+	if !foundMoov {
+		return info, fmt.Errorf("%w: no moov box (not an MP4/MOV file)", ErrInvalidVideo)
+	}
+	// Los MOV antiguos no tienen ftyp
+	if info.Container == "" {
+		info.Container = "mov"
+	}
+	return info, nil
+}
+
+func probeMoov(r io.ReaderAt, start, end int64, info *VideoInfo) error {
+	return walkBoxes(r, start, end, func(typ string, start, end int64) error {
+		switch typ {
+		case "mvhd":
+			duration, err := readMvhdDuration(r, start)
+			if err != nil {
+				return err
+			}
+			info.Duration = duration
+		case "trak":
+			return probeTrak(r, start, end, info)
+		}
+		return nil
+	})
+}
+
+func probeTrak(r io.ReaderAt, start, end int64, info *VideoInfo) error {
+	var handler, codec string
+	var width, height int
+
+	var walk func(start, end int64) error
+	walk = func(start, end int64) error {
+		return walkBoxes(r, start, end, func(typ string, start, end int64) error {
+			switch typ {
+			case "mdia", "minf", "stbl":
+				return walk(start, end)
+			case "tkhd":
+				// width y height son los últimos 8 bytes, en punto fijo 16.16
+				if end-start < 8 {
+					return fmt.Errorf("tkhd box too small")
+				}
+				b, err := readAt(r, end-8, 8)
+				if err != nil {
+					return err
+				}
+				width = int(binary.BigEndian.Uint32(b[0:4]) >> 16)
+				height = int(binary.BigEndian.Uint32(b[4:8]) >> 16)
+			case "hdlr":
+				// version/flags(4) + pre_defined(4) + handler_type(4)
+				b, err := readAt(r, start+8, 4)
+				if err != nil {
+					return err
+				}
+				handler = string(b)
+			case "stsd":
+				// version/flags(4) + entry_count(4) + primer sample entry: size(4) + format(4)
+				b, err := readAt(r, start+12, 4)
+				if err != nil {
+					return err
+				}
+				codec = string(b)
+			}
+			return nil
+		})
+	}
+	if err := walk(start, end); err != nil {
+		return err
+	}
+
+	switch handler {
+	case "vide":
+		if info.VideoCodec == "" {
+			info.VideoCodec = codec
+			info.Width, info.Height = width, height
+		}
+	case "soun":
+		if info.AudioCodec == "" {
+			info.AudioCodec = codec
+		}
+	}
+	return nil
+}
+
+func readMvhdDuration(r io.ReaderAt, start int64) (time.Duration, error) {
+	version, err := readAt(r, start, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	var timescale, duration uint64
+	if version[0] == 1 {
+		// version/flags(4) + creation(8) + modification(8) + timescale(4) + duration(8)
+		b, err := readAt(r, start+20, 12)
+		if err != nil {
+			return 0, err
+		}
+		timescale = uint64(binary.BigEndian.Uint32(b[0:4]))
+		duration = binary.BigEndian.Uint64(b[4:12])
+	} else {
+		// version/flags(4) + creation(4) + modification(4) + timescale(4) + duration(4)
+		b, err := readAt(r, start+12, 8)
+		if err != nil {
+			return 0, err
+		}
+		timescale = uint64(binary.BigEndian.Uint32(b[0:4]))
+		duration = uint64(binary.BigEndian.Uint32(b[4:8]))
+	}
+	if timescale == 0 {
+		return 0, fmt.Errorf("mvhd timescale is zero")
+	}
+	return time.Duration(float64(duration) / float64(timescale) * float64(time.Second)), nil
+}
+
+// walkBoxes llama a fn con el tipo y el rango del contenido de cada caja entre start y end
+func walkBoxes(r io.ReaderAt, start, end int64, fn func(typ string, start, end int64) error) error {
+	offset := start
+	for offset+8 <= end {
+		header, err := readAt(r, offset, 8)
+		if err != nil {
+			return err
+		}
+		size := int64(binary.BigEndian.Uint32(header[0:4]))
+		typ := string(header[4:8])
+		headerLen := int64(8)
+
+		switch size {
+		case 0: // la caja llega hasta el final
+			size = end - offset
+		case 1: // tamaño de 64 bits a continuación
+			ext, err := readAt(r, offset+8, 8)
+			if err != nil {
+				return err
+			}
+			size = int64(binary.BigEndian.Uint64(ext))
+			headerLen = 16
+		}
+		if size < headerLen || offset+size > end {
+			return fmt.Errorf("malformed %q box at offset %d", typ, offset)
+		}
+
+		if err := fn(typ, offset+headerLen, offset+size); err != nil {
+			return err
+		}
+		offset += size
+	}
+	return nil
+}
+
+func readAt(r io.ReaderAt, offset int64, n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := r.ReadAt(b, offset); err != nil {
+		return nil, fmt.Errorf("reading %d bytes at offset %d: %w", n, offset, err)
+	}
+	return b, nil
+}
+
+// ValidateVideo comprueba duración, tamaño y códecs. Los límites se configuran con
+// VIDEO_MAX_SECONDS y VIDEO_MAX_MB.
+func ValidateVideo(info VideoInfo) error {
+	if info.VideoCodec == "" {
+		return fmt.Errorf("%w: no video track", ErrInvalidVideo)
+	}
+	if !allowedVideoCodecs[info.VideoCodec] {
+		return fmt.Errorf("%w: unsupported video codec %q", ErrInvalidVideo, info.VideoCodec)
+	}
+	if info.AudioCodec != "" && !allowedAudioCodecs[info.AudioCodec] {
+		return fmt.Errorf("%w: unsupported audio codec %q", ErrInvalidVideo, info.AudioCodec)
+	}
+	if info.Duration < minVideoDuration {
+		return fmt.Errorf("%w: video is too short", ErrInvalidVideo)
+	}
+	if maxDuration := maxVideoDuration(); info.Duration > maxDuration {
+		return fmt.Errorf("%w: video is %ds long, the limit is %ds", ErrInvalidVideo, int(info.Duration.Seconds()), int(maxDuration.Seconds()))
+	}
+	if maxBytes := maxVideoBytes(); info.Size > maxBytes {
+		return fmt.Errorf("%w: video is %d MB, the limit is %d MB", ErrInvalidVideo, info.Size>>20, maxBytes>>20)
+	}
+	return nil
+}
+
+func maxVideoDuration() time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("VIDEO_MAX_SECONDS")); err == nil && v > 0 {
+		return time.Duration(v) * time.Second
+	}
+	return defaultMaxVideoDuration
+}
+
+func maxVideoBytes() int64 {
+	if v, err := strconv.Atoi(os.Getenv("VIDEO_MAX_MB")); err == nil && v > 0 {
+		return int64(v) << 20
+	}
+	return defaultMaxVideoBytes
+}
+
+// Transcoder convierte el video original al formato que sirve la app y extrae el poster
+type Transcoder interface {
+	// Transcode escribe en outputPath un MP4 H.264/AAC apto para streaming
+	Transcode(ctx context.Context, inputPath, outputPath string) error
+	// ExtractPoster escribe en outputPath un JPEG con el frame en el instante at
+	ExtractPoster(ctx context.Context, inputPath, outputPath string, at time.Duration) error
+}
+
+// LocalTranscoder usa el binario ffmpeg de la máquina (FFMPEG_PATH, por defecto "ffmpeg")
+type LocalTranscoder struct {
+	FFmpegPath string
+}
+
+// NewLocalTranscoder crea un LocalTranscoder con la configuración del entorno
+func NewLocalTranscoder() *LocalTranscoder {
+	path := os.Getenv("FFMPEG_PATH")
+	if path == "" {
+		path = "ffmpeg"
+	}
+	return &LocalTranscoder{FFmpegPath: path}
+}
+
+func (t *LocalTranscoder) Transcode(ctx context.Context, inputPath, outputPath string) error {
+	return t.run(ctx,
+		"-y", "-i", inputPath,
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", "28",
+		"-vf", fmt.Sprintf("scale='min(%d,iw)':-2", MobileWidth),
+		"-c:a", "aac", "-b:a", "96k",
+		"-movflags", "+faststart",
+		outputPath,
+	)
+}
+
+func (t *LocalTranscoder) ExtractPoster(ctx context.Context, inputPath, outputPath string, at time.Duration) error {
+	return t.run(ctx,
+		"-y", "-ss", strconv.FormatFloat(at.Seconds(), 'f', 3, 64), "-i", inputPath,
+		"-frames:v", "1", "-q:v", "3",
+		outputPath,
+	)
+}
+
+func (t *LocalTranscoder) run(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, t.FFmpegPath, append([]string{"-hide_banner", "-loglevel", "error"}, args...)...)
+	out, err := cmd.CombinedOutput()
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w (%s): %v", ErrFFmpegNotFound, t.FFmpegPath, err)
+	}
+	if err != nil {
+		return fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// ProcessVideo valida el video en filePath, lo transcodifica, extrae un poster (pasándolo por
+// ProcessImage para pixelar rostros y placas) y sube ambos a R2.
+// Los errores de validación envuelven ErrInvalidVideo.
+func ProcessVideo(ctx context.Context, filePath, folder string, transcoder Transcoder) (VideoResult, error) {
+	info, err := ProbeVideoFile(filePath)
+	if err != nil {
+		return VideoResult{}, err
+	}
+	if err := ValidateVideo(info); err != nil {
+		return VideoResult{}, err
+	}
+
+	s3Service, err := NewS3Service()
+	if err != nil {
+		return VideoResult{}, fmt.Errorf("failed to create S3 service: %v", err)
+	}
+
+	base := strings.TrimSuffix(filePath, filepath.Ext(filePath))
+	transcodedPath := base + "_transcoded.mp4"
+	posterPath := base + "_poster.jpg"
+	defer os.Remove(transcodedPath)
+	defer os.Remove(posterPath)
+
+	fmt.Printf("🎬 Transcoding video %s (%s, %dx%d, %s)\n", filePath, info.VideoCodec, info.Width, info.Height, info.Duration)
+	if err := transcoder.Transcode(ctx, filePath, transcodedPath); err != nil {
+		return VideoResult{}, fmt.Errorf("failed to transcode video: %w", err)
+	}
+
+	// Poster en el segundo 1 (o a la mitad si el video es más corto)
+	at := time.Second
+	if info.Duration < 2*time.Second {
+		at = info.Duration / 2
+	}
+	if err := transcoder.ExtractPoster(ctx, filePath, posterPath, at); err != nil {
+		return VideoResult{}, fmt.Errorf("failed to extract poster frame: %w", err)
+	}
+
+	videoURL, err := s3Service.UploadFile(transcodedPath, folder, "video/mp4")
+	if err != nil {
+		return VideoResult{}, fmt.Errorf("failed to upload video to S3: %v", err)
+	}
+
+	posterURL, err := ProcessImage(posterPath, folder)
+	if err != nil {
+		// El video ya está subido; sin poster la app muestra el primer frame
+		log.Printf("⚠️ Error processing poster for %s: %v", filePath, err)
+	}
+
+	fmt.Printf("✅ Video processed successfully: %s\n", videoURL)
+	return VideoResult{URL: videoURL, PosterURL: posterURL, Info: info}, nil
+}
@@ -0,0 +1,124 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+func box(typ string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	b := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(b[0:4], uint32(8+len(body)))
+	copy(b[4:8], typ)
+	return append(b, body...)
+}
+
+func u32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+func mvhd(timescale, duration uint32) []byte {
+	return box("mvhd", u32(0), u32(0), u32(0), u32(timescale), u32(duration), make([]byte, 80))
+}
+
+func tkhd(width, height uint32) []byte {
+	return box("tkhd", make([]byte, 76), u32(width<<16), u32(height<<16))
+}
+
+func trak(handler, codec string, width, height uint32) []byte {
+	hdlr := box("hdlr", u32(0), u32(0), []byte(handler), make([]byte, 12))
+	stsd := box("stsd", u32(0), u32(1), box(codec, make([]byte, 16)))
+	return box("trak", tkhd(width, height), box("mdia", hdlr, box("minf", box("stbl", stsd))))
+}
+
+func testMP4(brand string, seconds uint32, videoCodec string) []byte {
+	return bytes.Join([][]byte{
+		box("ftyp", []byte(brand), u32(0)),
+		box("moov", mvhd(1000, seconds*1000), trak("vide", videoCodec, 1080, 1920), trak("soun", "mp4a", 0, 0)),
+		box("mdat", make([]byte, 32)),
+	}, nil)
+}
+
+func TestProbeVideo(t *testing.T) {
+	data := testMP4("isom", 12, "avc1")
+	info, err := ProbeVideo(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("ProbeVideo returned error: %v", err)
+	}
+
+	if info.Container != "mp4" || info.Duration != 12*time.Second {
+		t.Errorf("expected mp4 of 12s, got %s of %s", info.Container, info.Duration)
+	}
+	if info.VideoCodec != "avc1" || info.AudioCodec != "mp4a" {
+		t.Errorf("expected avc1/mp4a, got %s/%s", info.VideoCodec, info.AudioCodec)
+	}
+	if info.Width != 1080 || info.Height != 1920 {
+		t.Errorf("expected 1080x1920, got %dx%d", info.Width, info.Height)
+	}
+
+	mov := testMP4("qt  ", 5, "hvc1")
+	info, err = ProbeVideo(bytes.NewReader(mov), int64(len(mov)))
+	if err != nil || info.Container != "mov" {
+		t.Errorf("expected mov container, got %q (err %v)", info.Container, err)
+	}
+}
+
+func TestProbeVideoRejectsNonVideo(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"jpeg":      {0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0, 1},
+		"no moov":   box("ftyp", []byte("isom"), u32(0)),
+		"truncated": append(box("ftyp", []byte("isom"), u32(0)), 0, 0, 0x10, 0, 'm', 'o', 'o', 'v'),
+	} {
+		if _, err := ProbeVideo(bytes.NewReader(data), int64(len(data))); !errors.Is(err, ErrInvalidVideo) {
+			t.Errorf("%s: expected ErrInvalidVideo, got %v", name, err)
+		}
+	}
+}
+
+func TestValidateVideo(t *testing.T) {
+	valid := VideoInfo{Container: "mp4", Duration: 20 * time.Second, VideoCodec: "avc1", AudioCodec: "mp4a", Size: 10 << 20}
+	if err := ValidateVideo(valid); err != nil {
+		t.Fatalf("expected valid video, got %v", err)
+	}
+
+	cases := map[string]func(v *VideoInfo){
+		"too long":  func(v *VideoInfo) { v.Duration = 5 * time.Minute },
+		"too short": func(v *VideoInfo) { v.Duration = 100 * time.Millisecond },
+		"too large": func(v *VideoInfo) { v.Size = 500 << 20 },
+		"no video":  func(v *VideoInfo) { v.VideoCodec = "" },
+		"bad codec": func(v *VideoInfo) { v.VideoCodec = "mp4v" },
+		"bad audio": func(v *VideoInfo) { v.AudioCodec = "samr" },
+	}
+	for name, mutate := range cases {
+		info := valid
+		mutate(&info)
+		if err := ValidateVideo(info); !errors.Is(err, ErrInvalidVideo) {
+			t.Errorf("%s: expected ErrInvalidVideo, got %v", name, err)
+		}
+	}
+}
+
+func TestIsVideoFile(t *testing.T) {
+	if !IsVideoFile("clip.MOV", "") || !IsVideoFile("upload", "video/mp4") {
+		t.Error("expected MOV/video content type to be detected as video")
+	}
+	if IsVideoFile("photo.jpg", "image/jpeg") {
+		t.Error("expected jpeg not to be a video")
+	}
+}
+
+func TestLocalTranscoderMissingBinary(t *testing.T) {
+	for _, path := range []string{"alertly-no-such-ffmpeg", "/nonexistent/ffmpeg"} {
+		tr := &LocalTranscoder{FFmpegPath: path}
+		err := tr.Transcode(context.Background(), "in.mp4", "out.mp4")
+		if !errors.Is(err, ErrFFmpegNotFound) {
+			t.Errorf("FFmpegPath %q: expected ErrFFmpegNotFound, got %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware verifica que el usuario tenga role = 'admin' (moderadores)
+// DEBE usarse DESPUÉS de TokenAuthMiddleware() para que AccountId esté disponible en el contexto
+func AdminMiddleware(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		accountIDInterface, exists := c.Get("AccountId")
+		if !exists {
+			log.Printf("⚠️ AdminMiddleware: AccountId not found in context")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":   "Authentication required",
+				"message": "Please log in to access this feature",
+			})
+			return
+		}
+
+		accountID, ok := accountIDInterface.(int64)
+		if !ok {
+			log.Printf("❌ AdminMiddleware: Invalid AccountId type in context")
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "Invalid account ID format",
+			})
+			return
+		}
+
+		var role sql.NullString
+		err := db.QueryRow(`SELECT role FROM account WHERE account_id = $1`, accountID).Scan(&role)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+					"error": "User account not found",
+				})
+				return
+			}
+			log.Printf("❌ AdminMiddleware: Error checking role for account %d: %v", accountID, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "Error verifying account role",
+			})
+			return
+		}
+
+		if !role.Valid || role.String != "admin" {
+			log.Printf("🔒 AdminMiddleware: Account %d attempted to access an admin endpoint", accountID)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Admin access required",
+				"code":  "ADMIN_REQUIRED",
+			})
+			return
+		}
+
+		c.Set("IsAdmin", true)
+		c.Next()
+	}
+}
@@ -0,0 +1,340 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyHeader         = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	defaultIdempotencyTTL     = 24 * time.Hour
+)
+
+// idempotencyWriter captura la respuesta del handler para poder repetirla en los reintentos
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware hace que los POST con header Idempotency-Key se ejecuten una sola vez por cuenta.
+// El primer request guarda su fingerprint (método + ruta + body) y la respuesta en idempotency_keys;
+// los reintentos con la misma key reciben la respuesta guardada sin volver a ejecutar el handler.
+// - Misma key con distinto body → 422
+// - Misma key mientras el primero sigue en curso → 409
+// - Respuestas 5xx no se guardan, para que el cliente pueda reintentar
+// Sin header el request pasa sin cambios (clientes antiguos).
+// DEBE usarse DESPUÉS de TokenAuthMiddleware() para que AccountId esté disponible en el contexto
+func IdempotencyMiddleware(db *sql.DB) gin.HandlerFunc {
+	return idempotencyMiddleware(&pgIdempotencyStore{db: db}, idempotencyTTL())
+}
+
+func idempotencyMiddleware(store idempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": "Idempotency-Key is too long",
+			})
+			return
+		}
+
+		accountID, _ := c.Get("AccountId")
+		accID, _ := accountID.(int64)
+
+		body, err := spoolBody(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": "Could not read request body",
+			})
+			return
+		}
+		defer body.Close()
+
+		fingerprint, err := requestFingerprint(c.Request.Method, c.FullPath(), c.GetHeader("Content-Type"), body.hashed)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": "Could not read request body",
+			})
+			return
+		}
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			log.Printf("❌ IdempotencyMiddleware: Error rewinding body for account %d: %v", accID, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "Could not read request body",
+			})
+			return
+		}
+		c.Request.Body = body
+
+		claimed, err := store.Claim(accID, key, fingerprint, ttl)
+		if err != nil {
+			// Si la tabla no está disponible no bloqueamos la creación de incidentes
+			log.Printf("⚠️ IdempotencyMiddleware: Error claiming key for account %d: %v", accID, err)
+			c.Next()
+			return
+		}
+
+		if !claimed {
+			replayIdempotentResponse(c, store, accID, key, fingerprint)
+			return
+		}
+
+		// Si el handler falla (5xx o panic) se libera la key para que el cliente pueda reintentar
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := store.Release(accID, key); err != nil {
+				log.Printf("⚠️ IdempotencyMiddleware: Error releasing key for account %d: %v", accID, err)
+			}
+		}()
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		err = store.Complete(accID, key, storedResponse{Status: status, Body: writer.body.Bytes(), ContentType: writer.Header().Get("Content-Type")})
+		if err != nil {
+			log.Printf("⚠️ IdempotencyMiddleware: Error saving response for account %d: %v", accID, err)
+			return
+		}
+		completed = true
+	}
+}
+
+func replayIdempotentResponse(c *gin.Context, store idempotencyStore, accountID int64, key, fingerprint string) {
+	stored, err := store.Get(accountID, key)
+	if err != nil {
+		log.Printf("❌ IdempotencyMiddleware: Error reading key for account %d: %v", accountID, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Error verifying Idempotency-Key",
+		})
+		return
+	}
+
+	if stored.Fingerprint != fingerprint {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "Idempotency-Key was already used with a different request",
+			"code":  "IDEMPOTENCY_KEY_REUSED",
+		})
+		return
+	}
+
+	if stored.Response == nil {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"error": "A request with this Idempotency-Key is still being processed",
+			"code":  "IDEMPOTENCY_IN_PROGRESS",
+		})
+		return
+	}
+
+	c.Header(idempotencyReplayedHeader, "true")
+	ct := stored.Response.ContentType
+	if ct == "" {
+		ct = "application/json; charset=utf-8"
+	}
+	c.Data(stored.Response.Status, ct, stored.Response.Body)
+	c.Abort()
+}
+
+// storedKey es una fila de idempotency_keys. Response es nil mientras el primer request sigue en curso.
+type storedKey struct {
+	Fingerprint string
+	Response    *storedResponse
+}
+
+type storedResponse struct {
+	Status      int
+	Body        []byte
+	ContentType string
+}
+
+// idempotencyStore guarda las keys reclamadas y sus respuestas (idempotency_keys)
+type idempotencyStore interface {
+	// Claim reserva la key para este request; si ya existe y no expiró devuelve false
+	Claim(accountID int64, key, fingerprint string, ttl time.Duration) (bool, error)
+	Get(accountID int64, key string) (storedKey, error)
+	Complete(accountID int64, key string, response storedResponse) error
+	// Release borra la key para que el cliente pueda reintentar
+	Release(accountID int64, key string) error
+}
+
+type pgIdempotencyStore struct {
+	db *sql.DB
+}
+
+// Claim: si la key existe pero ya expiró se reutiliza
+func (s *pgIdempotencyStore) Claim(accountID int64, key, fingerprint string, ttl time.Duration) (bool, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO idempotency_keys (account_id, idem_key, fingerprint, status, created_at, expires_at)
+		VALUES ($1, $2, $3, 'processing', NOW(), NOW() + $4 * INTERVAL '1 second')
+		ON CONFLICT (account_id, idem_key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, status = 'processing', response_status = NULL,
+			response_body = NULL, content_type = NULL, created_at = NOW(), expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < NOW()`,
+		accountID, key, fingerprint, int64(ttl.Seconds()))
+	if err != nil {
+		return false, err
+	}
+	claimed, err := res.RowsAffected()
+	return claimed > 0, err
+}
+
+func (s *pgIdempotencyStore) Get(accountID int64, key string) (storedKey, error) {
+	var stored storedKey
+	var status string
+	var responseStatus sql.NullInt64
+	var responseBody []byte
+	var contentType sql.NullString
+	err := s.db.QueryRow(`
+		SELECT fingerprint, status, response_status, response_body, content_type
+		FROM idempotency_keys
+		WHERE account_id = $1 AND idem_key = $2`, accountID, key).
+		Scan(&stored.Fingerprint, &status, &responseStatus, &responseBody, &contentType)
+	if err != nil {
+		return storedKey{}, err
+	}
+	if status == "completed" && responseStatus.Valid {
+		stored.Response = &storedResponse{Status: int(responseStatus.Int64), Body: responseBody, ContentType: contentType.String}
+	}
+	return stored, nil
+}
+
+func (s *pgIdempotencyStore) Complete(accountID int64, key string, response storedResponse) error {
+	_, err := s.db.Exec(`
+		UPDATE idempotency_keys
+		SET status = 'completed', response_status = $1, response_body = $2, content_type = $3
+		WHERE account_id = $4 AND idem_key = $5`,
+		response.Status, response.Body, response.ContentType, accountID, key)
+	return err
+}
+
+func (s *pgIdempotencyStore) Release(accountID int64, key string) error {
+	_, err := s.db.Exec(`DELETE FROM idempotency_keys WHERE account_id = $1 AND idem_key = $2`, accountID, key)
+	return err
+}
+
+// spooledBody es el body del request copiado a un archivo temporal mientras se calcula el fingerprint,
+// para no cargar en memoria uploads de video de 100 MB. El archivo se borra al cerrarlo.
+type spooledBody struct {
+	*os.File
+	hashed io.Reader // lee el body original y lo va escribiendo en el archivo
+}
+
+func spoolBody(body io.ReadCloser) (*spooledBody, error) {
+	tmp, err := os.CreateTemp("", "idempotency-*")
+	if err != nil {
+		return nil, err
+	}
+	return &spooledBody{File: tmp, hashed: io.TeeReader(body, tmp)}, nil
+}
+
+func (b *spooledBody) Close() error {
+	b.File.Close()
+	return os.Remove(b.Name())
+}
+
+// requestFingerprint = sha256(método + ruta + body), leyendo el body en streaming. Para multipart/form-data
+// se usan las partes (nombre + contenido) en vez de los bytes crudos, porque cada reintento puede generar
+// un boundary distinto. Siempre consume el body completo.
+func requestFingerprint(method, path, contentType string, body io.Reader) (string, error) {
+	prefix := []byte(method + " " + path + "\n")
+	raw := sha256.New()
+	raw.Write(prefix)
+	body = io.TeeReader(body, raw)
+
+	var parts []string
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	multipartOK := false
+	if err == nil && mediaType == "multipart/form-data" && params["boundary"] != "" {
+		parts, multipartOK = multipartParts(body, params["boundary"])
+	}
+
+	// Lo que quede (epílogo del multipart o el body completo) también se hashea y se copia al spool
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return "", err
+	}
+
+	if !multipartOK {
+		return hex.EncodeToString(raw.Sum(nil)), nil
+	}
+	hash := sha256.New()
+	hash.Write(prefix)
+	sort.Strings(parts)
+	for _, p := range parts {
+		hash.Write([]byte(p + "\n"))
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// multipartParts devuelve "nombre:sha256(contenido)" por cada parte del form
+func multipartParts(body io.Reader, boundary string) ([]string, bool) {
+	reader := multipart.NewReader(body, boundary)
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return parts, true
+		}
+		if err != nil {
+			return nil, false
+		}
+		hash := sha256.New()
+		if _, err := io.Copy(hash, part); err != nil {
+			return nil, false
+		}
+		parts = append(parts, part.FormName()+":"+hex.EncodeToString(hash.Sum(nil)))
+	}
+}
+
+// PurgeExpiredIdempotencyKeys borra las keys vencidas. Se ejecuta desde el scheduler.
+func PurgeExpiredIdempotencyKeys(db *sql.DB) (int64, error) {
+	res, err := db.Exec(`DELETE FROM idempotency_keys WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// idempotencyTTL: cuánto tiempo se guarda una respuesta. Configurable con IDEMPOTENCY_TTL_HOURS.
+func idempotencyTTL() time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_TTL_HOURS")); err == nil && v > 0 {
+		return time.Duration(v) * time.Hour
+	}
+	return defaultIdempotencyTTL
+}
@@ -0,0 +1,257 @@
+package middleware
+
+import (
+	"bytes"
+	"database/sql"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func buildMultipart(t *testing.T, boundary string, fields map[string]string, file []byte) ([]byte, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := w.SetBoundary(boundary); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range fields {
+		if err := w.WriteField(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fw, err := w.CreateFormFile("file", "photo.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(file)
+	w.Close()
+	return buf.Bytes(), w.FormDataContentType()
+}
+
+// fingerprint pasa el body por spoolBody como el middleware y verifica que el handler lo reciba completo
+func fingerprint(t *testing.T, method, path, contentType string, body []byte) string {
+	t.Helper()
+	spooled, err := spoolBody(io.NopCloser(bytes.NewReader(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spooled.Close()
+
+	fp, err := requestFingerprint(method, path, contentType, spooled.hashed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spooled.Seek(0, io.SeekStart)
+	if got, _ := io.ReadAll(spooled); !bytes.Equal(got, body) {
+		t.Errorf("spooled body = %q, want %q", got, body)
+	}
+	return fp
+}
+
+func TestRequestFingerprint_MultipartIgnoresBoundary(t *testing.T) {
+	fields := map[string]string{"description": "Fire on Queen St", "latitude": "43.65"}
+	file := []byte("jpeg-bytes")
+
+	body1, ct1 := buildMultipart(t, "boundaryAAAA", fields, file)
+	body2, ct2 := buildMultipart(t, "boundaryBBBB", fields, file)
+
+	fp1 := fingerprint(t, "POST", "/api/incident/create", ct1, body1)
+	fp2 := fingerprint(t, "POST", "/api/incident/create", ct2, body2)
+	if fp1 != fp2 {
+		t.Error("same form with different boundaries produced different fingerprints")
+	}
+
+	body3, ct3 := buildMultipart(t, "boundaryAAAA", fields, []byte("other-photo"))
+	if fp1 == fingerprint(t, "POST", "/api/incident/create", ct3, body3) {
+		t.Error("different file produced the same fingerprint")
+	}
+}
+
+func TestRequestFingerprint_JSON(t *testing.T) {
+	a := fingerprint(t, "POST", "/api/cluster/send_comment", "application/json", []byte(`{"comment":"hi"}`))
+	b := fingerprint(t, "POST", "/api/cluster/send_comment", "application/json", []byte(`{"comment":"hi"}`))
+	c := fingerprint(t, "POST", "/api/cluster/send_comment", "application/json", []byte(`{"comment":"bye"}`))
+	d := fingerprint(t, "POST", "/api/cluster/vote/change", "application/json", []byte(`{"comment":"hi"}`))
+	if a != b {
+		t.Error("identical requests produced different fingerprints")
+	}
+	if a == c || a == d {
+		t.Error("different body or path produced the same fingerprint")
+	}
+}
+
+type fakeIdempotencyEntry struct {
+	stored    storedKey
+	expiresAt time.Time
+}
+
+// fakeIdempotencyStore replica en memoria lo que hace pgIdempotencyStore con idempotency_keys
+type fakeIdempotencyStore struct {
+	entries map[string]*fakeIdempotencyEntry
+}
+
+func newFakeIdempotencyStore() *fakeIdempotencyStore {
+	return &fakeIdempotencyStore{entries: map[string]*fakeIdempotencyEntry{}}
+}
+
+func (s *fakeIdempotencyStore) Claim(accountID int64, key, fingerprint string, ttl time.Duration) (bool, error) {
+	if e, ok := s.entries[key]; ok && time.Now().Before(e.expiresAt) {
+		return false, nil
+	}
+	s.entries[key] = &fakeIdempotencyEntry{stored: storedKey{Fingerprint: fingerprint}, expiresAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (s *fakeIdempotencyStore) Get(accountID int64, key string) (storedKey, error) {
+	e, ok := s.entries[key]
+	if !ok {
+		return storedKey{}, sql.ErrNoRows
+	}
+	return e.stored, nil
+}
+
+func (s *fakeIdempotencyStore) Complete(accountID int64, key string, response storedResponse) error {
+	s.entries[key].stored.Response = &response
+	return nil
+}
+
+func (s *fakeIdempotencyStore) Release(accountID int64, key string) error {
+	delete(s.entries, key)
+	return nil
+}
+
+// idempotencyRouter monta el middleware delante de handler con la cuenta 7 autenticada
+func idempotencyRouter(store idempotencyStore, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gin.RecoveryWithWriter(io.Discard), func(c *gin.Context) { c.Set("AccountId", int64(7)) })
+	r.POST("/api/cluster/send_comment", idempotencyMiddleware(store, time.Hour), handler)
+	return r
+}
+
+func sendIdempotent(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/cluster/send_comment", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyHeader, key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddleware_ReplaysStoredResponse(t *testing.T) {
+	calls := 0
+	r := idempotencyRouter(newFakeIdempotencyStore(), func(c *gin.Context) {
+		calls++
+		body, _ := io.ReadAll(c.Request.Body)
+		c.JSON(http.StatusCreated, gin.H{"echo": string(body), "call": calls})
+	})
+
+	first := sendIdempotent(r, "k1", `{"comment":"hi"}`)
+	second := sendIdempotent(r, "k1", `{"comment":"hi"}`)
+
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if first.Code != http.StatusCreated || second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %s, want %d %s", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get(idempotencyReplayedHeader) != "true" || first.Header().Get(idempotencyReplayedHeader) != "" {
+		t.Error("only the replayed response should carry Idempotent-Replayed")
+	}
+}
+
+func TestIdempotencyMiddleware_InProgress(t *testing.T) {
+	store := newFakeIdempotencyStore()
+	calls := 0
+	var second *httptest.ResponseRecorder
+	var r *gin.Engine
+	r = idempotencyRouter(store, func(c *gin.Context) {
+		calls++
+		// Reintento mientras el primero sigue en el handler
+		second = sendIdempotent(r, "k1", `{"comment":"hi"}`)
+		c.JSON(http.StatusOK, gin.H{})
+	})
+
+	sendIdempotent(r, "k1", `{"comment":"hi"}`)
+
+	if calls != 1 || second == nil || second.Code != http.StatusConflict {
+		t.Errorf("retry while processing: calls = %d, response = %v, want 1 call and 409", calls, second)
+	}
+}
+
+func TestIdempotencyMiddleware_DifferentBody(t *testing.T) {
+	calls := 0
+	r := idempotencyRouter(newFakeIdempotencyStore(), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{})
+	})
+
+	sendIdempotent(r, "k1", `{"comment":"hi"}`)
+	w := sendIdempotent(r, "k1", `{"comment":"bye"}`)
+
+	if w.Code != http.StatusUnprocessableEntity || calls != 1 {
+		t.Errorf("same key with another body: status = %d, calls = %d, want 422 and 1", w.Code, calls)
+	}
+}
+
+func TestIdempotencyMiddleware_ReleasesKeyOnFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		fail gin.HandlerFunc
+	}{
+		{"5xx", func(c *gin.Context) { c.JSON(http.StatusInternalServerError, gin.H{}) }},
+		{"panic", func(c *gin.Context) { panic("boom") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeIdempotencyStore()
+			calls := 0
+			r := idempotencyRouter(store, func(c *gin.Context) {
+				calls++
+				if calls == 1 {
+					tt.fail(c)
+					return
+				}
+				c.JSON(http.StatusOK, gin.H{})
+			})
+
+			if w := sendIdempotent(r, "k1", `{"comment":"hi"}`); w.Code != http.StatusInternalServerError {
+				t.Fatalf("first attempt status = %d, want 500", w.Code)
+			}
+			if _, ok := store.entries["k1"]; ok {
+				t.Fatal("key should be released after a failed attempt")
+			}
+			if w := sendIdempotent(r, "k1", `{"comment":"hi"}`); w.Code != http.StatusOK || calls != 2 {
+				t.Errorf("retry: status = %d, calls = %d, want 200 and 2", w.Code, calls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddleware_ReclaimsExpiredKey(t *testing.T) {
+	store := newFakeIdempotencyStore()
+	store.entries["k1"] = &fakeIdempotencyEntry{
+		stored:    storedKey{Fingerprint: "old", Response: &storedResponse{Status: http.StatusCreated, Body: []byte(`{"old":true}`)}},
+		expiresAt: time.Now().Add(-time.Minute),
+	}
+	calls := 0
+	r := idempotencyRouter(store, func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"new": true})
+	})
+
+	w := sendIdempotent(r, "k1", `{"comment":"hi"}`)
+
+	if calls != 1 || w.Code != http.StatusOK || w.Header().Get(idempotencyReplayedHeader) != "" {
+		t.Errorf("expired key: status = %d, calls = %d, want a fresh 200", w.Code, calls)
+	}
+	if e := store.entries["k1"]; e.stored.Fingerprint == "old" || e.stored.Response == nil || e.stored.Response.Status != http.StatusOK {
+		t.Errorf("expired key should be re-claimed with the new response, got %+v", e.stored)
+	}
+}
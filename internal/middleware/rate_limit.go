@@ -112,3 +112,25 @@ func RateLimitMiddlewarePublic() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RateLimitMiddlewareFeed crea un rate limiter para los feeds públicos (RSS/Atom/JSON Feed).
+// Los lectores de feeds hacen polling: 1 request cada 3 segundos por IP alcanza y sobra (la respuesta se cachea).
+func RateLimitMiddlewareFeed() gin.HandlerFunc {
+	rateLimiter := NewRateLimiter(rate.Every(3*time.Second), 10)
+
+	return func(c *gin.Context) {
+		limiter := rateLimiter.getLimiter(c.ClientIP())
+
+		if !limiter.Allow() {
+			c.Header("Retry-After", "3")
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Too many requests. Please slow down.",
+				"retry_after": "3 seconds",
+				"remaining":   "0",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
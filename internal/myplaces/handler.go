@@ -2,8 +2,10 @@ package myplaces
 
 import (
 	"alertly/internal/auth"
+	"alertly/internal/commuteroute"
 	"alertly/internal/database"
 	"alertly/internal/response"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -83,6 +85,40 @@ func Add(c *gin.Context) {
 	response.Send(c, http.StatusOK, false, "Saved! Incident alerts on.", result)
 }
 
+// AddRoute guarda una ruta (trayecto al trabajo) como lugar: alerta de los incidentes a lo largo del corredor
+func AddRoute(c *gin.Context) {
+	accountID, err := auth.GetUserFromContext(c)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		response.Send(c, http.StatusInternalServerError, true, "error", nil)
+		return
+	}
+
+	var myPlace MyPlaces
+	if err := c.BindJSON(&myPlace); err != nil {
+		log.Printf("JSON error: %v", err)
+		response.Send(c, http.StatusBadRequest, true, "Wrong data in", nil)
+		return
+	}
+
+	repo := NewRepository(database.DB)
+	service := NewService(repo)
+
+	myPlace.AccountId = accountID
+	result, err := service.AddRoute(myPlace)
+	if errors.Is(err, commuteroute.ErrInvalidRoute) || errors.Is(err, commuteroute.ErrInvalidBuffer) {
+		response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		log.Printf("Error: %v", err)
+		response.Send(c, http.StatusInternalServerError, true, "Error saving route. Please try later", nil)
+		return
+	}
+
+	response.Send(c, http.StatusOK, false, "Saved! Incident alerts on along your route.", result)
+}
+
 // update or remove(hide changing status = 'inactive')
 func Update(c *gin.Context) {
 	var myPlace MyPlaces
@@ -139,6 +175,10 @@ func FullUpdate(c *gin.Context) {
 
 	myPlace.AccountId = accountID
 	err = service.FullUpdate(myPlace)
+	if errors.Is(err, commuteroute.ErrInvalidBuffer) {
+		response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+		return
+	}
 	if err != nil {
 		log.Printf("Error: %v", err)
 		response.Send(c, http.StatusBadRequest, true, "Bad request", nil)
@@ -1,5 +1,13 @@
 package myplaces
 
+import "alertly/internal/polyline"
+
+// PlaceType de account_favorite_locations
+const (
+	PlaceTypePoint = "point"
+	PlaceTypeRoute = "route"
+)
+
 type MyPlaces struct {
 	AflId                     int64   `json:"afl_id"`
 	AccountId                 int64   `json:"account_id"`
@@ -23,4 +31,9 @@ type MyPlaces struct {
 	PositiveActions           bool    `json:"positive_actions"`
 	LostPet                   bool    `json:"lost_pet"`
 	Radius                    int     `json:"radius"`
+	PlaceType                 string  `json:"place_type"`
+	// Solo para place_type = route: la ruta como encoded polyline (o waypoints al guardarla) y el ancho del corredor
+	Polyline    string           `json:"polyline,omitempty"`
+	Waypoints   []polyline.Point `json:"waypoints,omitempty"`
+	RouteBuffer int              `json:"route_buffer,omitempty"`
 }
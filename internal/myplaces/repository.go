@@ -1,6 +1,8 @@
 package myplaces
 
 import (
+	"alertly/internal/commuteroute"
+	"alertly/internal/polyline"
 	"database/sql"
 	"fmt"
 )
@@ -8,6 +10,7 @@ import (
 type Repository interface {
 	Get(accountId int) ([]MyPlaces, error)
 	Add(myPlace MyPlaces) (int64, error)
+	AddRoute(myPlace MyPlaces, route commuteroute.Route) (int64, error)
 	Update(myPlace MyPlaces) error
 	GetByAccountId(accountId int64) ([]MyPlaces, error)
 	GetById(accountId, aflId int64) (MyPlaces, error)
@@ -87,6 +90,45 @@ func (r *pgRepository) Add(myPlace MyPlaces) (int64, error) {
 	return id, nil
 }
 
+// AddRoute guarda un lugar de tipo ruta. latitude/longitude quedan en el inicio de la ruta y radius en el buffer,
+// para las consultas que solo conocen lugares de tipo punto.
+func (r *pgRepository) AddRoute(myPlace MyPlaces, route commuteroute.Route) (int64, error) {
+	query := `INSERT INTO account_favorite_locations(account_id, title, latitude, longitude, city, province, postal_code, crime, traffic_accident, medical_emergency, fire_incident, vandalism, suspicious_activity, infrastructure_issues, extreme_weather, community_events, dangerous_wildlife_sighting, positive_actions, lost_pet, radius, place_type, route, route_buffer)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, ST_GeogFromText($22), $20) RETURNING afl_id`
+
+	var id int64
+	err := r.db.QueryRow(query,
+		myPlace.AccountId,
+		myPlace.Title,
+		route.Points[0].Latitude,
+		route.Points[0].Longitude,
+		myPlace.City,
+		myPlace.Province,
+		myPlace.PostalCode,
+		myPlace.Crime,
+		myPlace.TrafficAccident,
+		myPlace.MedicalEmergency,
+		myPlace.FireIncident,
+		myPlace.Vandalism,
+		myPlace.SuspiciousActivity,
+		myPlace.InfrastructureIssues,
+		myPlace.ExtremeWeather,
+		myPlace.CommunityEvents,
+		myPlace.DangerousWildlifeSighting,
+		myPlace.PositiveActions,
+		myPlace.LostPet,
+		int(route.Buffer),
+		PlaceTypeRoute,
+		polyline.WKT(route.Points),
+	).Scan(&id)
+
+	if err != nil {
+		return 0, fmt.Errorf("error saving route %w", err)
+	}
+
+	return id, nil
+}
+
 func (r *pgRepository) Update(myPlace MyPlaces) error {
 	query := `UPDATE account_favorite_locations SET status = $1 WHERE afl_id = $2`
 	_, err := r.db.Exec(query, myPlace.Status, myPlace.AflId)
@@ -110,7 +152,9 @@ func (r *pgRepository) FullUpdate(myPlace MyPlaces) error {
 	community_events = $10,
 	dangerous_wildlife_sighting = $11,
 	positive_actions = $12,
-	lost_pet = $13
+	lost_pet = $13,
+	route_buffer = CASE WHEN place_type = 'route' AND $16 > 0 THEN $16 ELSE route_buffer END,
+	radius = CASE WHEN place_type = 'route' AND $16 > 0 THEN $16 ELSE radius END
 	WHERE afl_id = $14 AND account_id = $15`
 	_, err := r.db.Exec(query,
 		myPlace.Title,
@@ -128,6 +172,7 @@ func (r *pgRepository) FullUpdate(myPlace MyPlaces) error {
 		myPlace.LostPet,
 		myPlace.AflId,
 		myPlace.AccountId,
+		myPlace.RouteBuffer,
 	)
 	if err != nil {
 		return fmt.Errorf("error updating cluster  %w", err)
@@ -136,7 +181,7 @@ func (r *pgRepository) FullUpdate(myPlace MyPlaces) error {
 }
 
 func (r *pgRepository) GetById(accountId, aflId int64) (MyPlaces, error) {
-	query := `SELECT afl_id, account_id, title, latitude, longitude, city, province, postal_code, status, crime, traffic_accident, medical_emergency, fire_incident, vandalism, suspicious_activity, infrastructure_issues, extreme_weather, community_events, dangerous_wildlife_sighting, positive_actions, lost_pet FROM account_favorite_locations WHERE account_id = $1 AND afl_id = $2`
+	query := `SELECT afl_id, account_id, title, latitude, longitude, city, province, postal_code, status, crime, traffic_accident, medical_emergency, fire_incident, vandalism, suspicious_activity, infrastructure_issues, extreme_weather, community_events, dangerous_wildlife_sighting, positive_actions, lost_pet, place_type, COALESCE(ST_AsEncodedPolyline(route::geometry), ''), COALESCE(route_buffer, 0) FROM account_favorite_locations WHERE account_id = $1 AND afl_id = $2`
 
 	var c MyPlaces
 	err := r.db.QueryRow(query, accountId, aflId).Scan(&c.AflId,
@@ -159,7 +204,10 @@ func (r *pgRepository) GetById(accountId, aflId int64) (MyPlaces, error) {
 		&c.CommunityEvents,
 		&c.DangerousWildlifeSighting,
 		&c.PositiveActions,
-		&c.LostPet)
+		&c.LostPet,
+		&c.PlaceType,
+		&c.Polyline,
+		&c.RouteBuffer)
 
 	if err != nil {
 		return MyPlaces{}, fmt.Errorf("error scanning row: %w", err)
@@ -169,7 +217,7 @@ func (r *pgRepository) GetById(accountId, aflId int64) (MyPlaces, error) {
 }
 
 func (r *pgRepository) GetByAccountId(accountId int64) ([]MyPlaces, error) {
-	query := `SELECT afl_id, account_id, title, status, city, latitude, longitude, radius FROM account_favorite_locations WHERE account_id = $1 ORDER BY afl_id DESC`
+	query := `SELECT afl_id, account_id, title, status, city, latitude, longitude, radius, place_type, COALESCE(ST_AsEncodedPolyline(route::geometry), ''), COALESCE(route_buffer, 0) FROM account_favorite_locations WHERE account_id = $1 ORDER BY afl_id DESC`
 	rows, err := r.db.Query(query, accountId)
 	if err != nil {
 		return nil, err
@@ -188,6 +236,9 @@ func (r *pgRepository) GetByAccountId(accountId int64) ([]MyPlaces, error) {
 			&c.Latitude,
 			&c.Longitude,
 			&c.Radius,
+			&c.PlaceType,
+			&c.Polyline,
+			&c.RouteBuffer,
 		); err != nil {
 			return nil, err
 		}
@@ -1,10 +1,14 @@
 package myplaces
 
-import "alertly/internal/common"
+import (
+	"alertly/internal/common"
+	"alertly/internal/commuteroute"
+)
 
 type Service interface {
 	Get(accountId int) ([]MyPlaces, error)
 	Add(myPlace MyPlaces) (int64, error)
+	AddRoute(myPlace MyPlaces) (int64, error)
 	Update(myPlace MyPlaces) error
 	GetByAccountId(accountId int64) ([]MyPlaces, error)
 	GetById(accountId, aflId int64) (MyPlaces, error)
@@ -48,12 +52,38 @@ func (s *service) Add(myPlace MyPlaces) (int64, error) {
 	return result, err
 }
 
+// AddRoute guarda el corredor de una ruta (polyline o waypoints + route_buffer) como lugar con alertas.
+// Devuelve commuteroute.ErrInvalidRoute / ErrInvalidBuffer si la ruta no es válida.
+func (s *service) AddRoute(myPlace MyPlaces) (int64, error) {
+	route, err := commuteroute.ParseRoute(myPlace.Polyline, myPlace.Waypoints, float64(myPlace.RouteBuffer))
+	if err != nil {
+		return 0, err
+	}
+
+	start := route.Points[0]
+	_, city, province, postalCode, errGeo := common.ReverseGeocode(start.Latitude, start.Longitude)
+	if errGeo != nil {
+		return 0, errGeo
+	}
+
+	myPlace.City = city
+	myPlace.Province = province
+	myPlace.PostalCode = postalCode
+
+	return s.repo.AddRoute(myPlace, route)
+}
+
 func (s *service) Update(myPlace MyPlaces) error {
 	err := s.repo.Update(myPlace)
 	return err
 }
 
+// FullUpdate actualiza título y categorías; en lugares de tipo ruta también route_buffer si viene (> 0).
+// Devuelve commuteroute.ErrInvalidBuffer si el buffer está fuera de rango.
 func (s *service) FullUpdate(myPlace MyPlaces) error {
+	if myPlace.RouteBuffer != 0 && (myPlace.RouteBuffer < commuteroute.MinBuffer || myPlace.RouteBuffer > commuteroute.MaxBuffer) {
+		return commuteroute.ErrInvalidBuffer
+	}
 	err := s.repo.FullUpdate(myPlace)
 	return err
 }
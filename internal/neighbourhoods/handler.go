@@ -0,0 +1,119 @@
+package neighbourhoods
+
+import (
+	"alertly/internal/database"
+	"alertly/internal/response"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxImportBytes = 50 << 20 // los límites de una ciudad grande pesan unos pocos MB
+
+// List lista y busca barrios (por ciudad, tipo, nombre o el punto que contienen)
+func List(c *gin.Context) {
+	var in ListInputs
+	if err := c.ShouldBindQuery(&in); err != nil {
+		log.Printf("Error al bindear filtros: %v", err)
+		response.Send(c, http.StatusBadRequest, true, ErrInvalidFilter.Error(), nil)
+		return
+	}
+	f, err := ParseList(in)
+	if err != nil {
+		response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+		return
+	}
+
+	service := NewService(NewRepository(database.DB))
+	result, err := service.List(f)
+	if err != nil {
+		log.Printf("Error listing neighbourhoods: %v", err)
+		response.Send(c, http.StatusInternalServerError, true, "We couldn’t load the neighbourhoods. Please try again later.", nil)
+		return
+	}
+	response.Send(c, http.StatusOK, false, "Success", result)
+}
+
+// GetById devuelve un barrio; con boundary=true incluye el polígono en GeoJSON
+func GetById(c *gin.Context) {
+	nbhdID, err := strconv.ParseInt(c.Param("nbhd_id"), 10, 64)
+	if err != nil || nbhdID <= 0 {
+		response.Send(c, http.StatusBadRequest, true, "Invalid neighbourhood id", nil)
+		return
+	}
+	withBoundary, _ := strconv.ParseBool(c.Query("boundary"))
+
+	service := NewService(NewRepository(database.DB))
+	result, err := service.GetById(nbhdID, withBoundary)
+	if errors.Is(err, ErrNotFound) {
+		response.Send(c, http.StatusNotFound, true, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		log.Printf("Error getting neighbourhood %d: %v", nbhdID, err)
+		response.Send(c, http.StatusInternalServerError, true, "We couldn’t load the neighbourhood. Please try again later.", nil)
+		return
+	}
+	response.Send(c, http.StatusOK, false, "Success", result)
+}
+
+// Counts cuenta clusters por barrio de una ciudad (para pintar el mapa por barrio).
+// Los clusters y stats de un solo barrio salen de /cluster/query y /cluster/stats con neighbourhood_id.
+func Counts(c *gin.Context) {
+	var in CountInputs
+	if err := c.ShouldBindQuery(&in); err != nil {
+		log.Printf("Error al bindear filtros: %v", err)
+		response.Send(c, http.StatusBadRequest, true, "city is required", nil)
+		return
+	}
+	kind, f, err := ParseCounts(in)
+	if err != nil {
+		response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+		return
+	}
+
+	service := NewService(NewRepository(database.DB))
+	result, err := service.Counts(in.City, kind, f)
+	if err != nil {
+		log.Printf("Error counting clusters by neighbourhood: %v", err)
+		response.Send(c, http.StatusInternalServerError, true, "We couldn’t load the counts. Please try again later.", nil)
+		return
+	}
+	response.Send(c, http.StatusOK, false, "Success", result)
+}
+
+// Import carga (o actualiza) los límites desde un GeoJSON FeatureCollection. Solo admins.
+//
+//	POST /api/neighbourhoods/import?source=toronto_open_data&city=Toronto&province=ON&kind=neighbourhood
+func Import(c *gin.Context) {
+	var in ImportInputs
+	if err := c.ShouldBindQuery(&in); err != nil {
+		response.Send(c, http.StatusBadRequest, true, ErrInvalidImport.Error(), nil)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes))
+	if err != nil {
+		response.Send(c, http.StatusRequestEntityTooLarge, true, "GeoJSON file is too large (max 50 MB)", nil)
+		return
+	}
+
+	areas, skipped, err := ParseImport(&in, body)
+	if err != nil {
+		response.Send(c, http.StatusBadRequest, true, err.Error(), skipped)
+		return
+	}
+
+	service := NewService(NewRepository(database.DB))
+	result, err := service.Import(in, areas, skipped)
+	if err != nil {
+		log.Printf("Error importing neighbourhoods: %v", err)
+		response.Send(c, http.StatusUnprocessableEntity, true, "Import failed, nothing was saved. Check the geometries and try again.", nil)
+		return
+	}
+	log.Printf("🗺️ Neighbourhoods import %s/%s (%s): %d imported, %d skipped", in.Source, in.Kind, in.City, result.Imported, len(result.Skipped))
+	response.Send(c, http.StatusOK, false, "Success", result)
+}
@@ -0,0 +1,108 @@
+package neighbourhoods
+
+import (
+	"alertly/internal/clusterquery"
+	"encoding/json"
+)
+
+const (
+	KindNeighbourhood = "neighbourhood"
+	KindWard          = "ward"
+)
+
+type Neighbourhood struct {
+	NbhdId          int64           `json:"nbhd_id"`
+	Source          string          `json:"source"`
+	Kind            string          `json:"kind"`
+	ExternalId      string          `json:"external_id"`
+	Name            string          `json:"name"`
+	City            string          `json:"city"`
+	Province        string          `json:"province"`
+	CenterLatitude  float64         `json:"center_latitude"`
+	CenterLongitude float64         `json:"center_longitude"`
+	AreaSqMeters    float64         `json:"area_sq_meters"`
+	Boundary        json.RawMessage `json:"boundary,omitempty"` // GeoJSON MultiPolygon, solo con boundary=true
+}
+
+// ListInputs de GET /neighbourhoods: todos los filtros son opcionales
+type ListInputs struct {
+	City      string   `form:"city"`
+	Kind      string   `form:"kind"`
+	Q         string   `form:"q"`         // parte del nombre
+	Latitude  *float64 `form:"latitude"`  // con longitude: el barrio que contiene el punto
+	Longitude *float64 `form:"longitude"` // con latitude: el barrio que contiene el punto
+	Limit     int      `form:"limit"`
+	Page      int      `form:"page"`
+}
+
+// ListFilter es ListInputs validado
+type ListFilter struct {
+	City   string
+	Kind   string
+	Q      string
+	Point  *clusterquery.Point
+	Limit  int
+	Offset int
+}
+
+type NeighbourhoodsPage struct {
+	Neighbourhoods []Neighbourhood `json:"neighbourhoods"`
+	Page           int             `json:"page"`
+	HasMore        bool            `json:"has_more"`
+}
+
+// ImportInputs de POST /neighbourhoods/import (query string); el body es el FeatureCollection
+type ImportInputs struct {
+	Source       string `form:"source" binding:"required"`
+	Kind         string `form:"kind"` // neighbourhood (default) o ward
+	City         string `form:"city" binding:"required"`
+	Province     string `form:"province"`
+	NameProperty string `form:"name_property"` // por defecto se prueba name, NAME, AREA_NAME
+	IdProperty   string `form:"id_property"`   // por defecto se prueba id, AREA_SHORT_CODE, AREA_ID; si no, el id del feature
+}
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	ID         interface{}            `json:"id"`
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   json.RawMessage        `json:"geometry"`
+}
+
+// Area es un feature listo para guardar
+type Area struct {
+	ExternalId string
+	Name       string
+	Geometry   string // GeoJSON Polygon o MultiPolygon
+}
+
+type ImportResult struct {
+	Imported int              `json:"imported"`
+	Skipped  []SkippedFeature `json:"skipped"`
+}
+
+type SkippedFeature struct {
+	Index  int    `json:"index"`
+	Reason string `json:"reason"`
+}
+
+// CountInputs de GET /neighbourhoods/counts
+type CountInputs struct {
+	City       string   `form:"city" binding:"required"`
+	Kind       string   `form:"kind"`
+	Categories []string `form:"categories"`
+	Status     string   `form:"status"` // active (default), expired, all
+	From       string   `form:"from"`
+	To         string   `form:"to"`
+}
+
+// NeighbourhoodCount: clusters de un barrio con el filtro de CountInputs (los barrios sin clusters vienen en 0)
+type NeighbourhoodCount struct {
+	NbhdId int64  `json:"nbhd_id"`
+	Name   string `json:"name"`
+	Total  int64  `json:"total"`
+	Active int64  `json:"active"`
+}
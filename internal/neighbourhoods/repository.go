@@ -0,0 +1,192 @@
+package neighbourhoods
+
+import (
+	"alertly/internal/clusterquery"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type Repository interface {
+	List(f ListFilter, limit int) ([]Neighbourhood, error)
+	GetById(nbhdID int64, withBoundary bool) (Neighbourhood, error)
+	Upsert(in ImportInputs, areas []Area) (int, error)
+	Counts(city, kind string, f clusterquery.Filter) ([]NeighbourhoodCount, error)
+}
+
+type pgRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &pgRepository{db: db}
+}
+
+const neighbourhoodColumns = `
+        n.nbhd_id,
+        n.source,
+        n.kind,
+        n.external_id,
+        n.name,
+        n.city,
+        COALESCE(n.province, ''),
+        n.center_latitude,
+        n.center_longitude,
+        n.area_sq_meters`
+
+func scanNeighbourhood(row interface{ Scan(...interface{}) error }, extra ...interface{}) (Neighbourhood, error) {
+	var n Neighbourhood
+	dest := append([]interface{}{&n.NbhdId, &n.Source, &n.Kind, &n.ExternalId, &n.Name, &n.City, &n.Province,
+		&n.CenterLatitude, &n.CenterLongitude, &n.AreaSqMeters}, extra...)
+	err := row.Scan(dest...)
+	return n, err
+}
+
+// List busca barrios; con q primero van los que empiezan con q. La tabla es chica (cientos de filas por ciudad).
+func (r *pgRepository) List(f ListFilter, limit int) ([]Neighbourhood, error) {
+	var conds []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	order := "n.name ASC"
+	if f.City != "" {
+		conds = append(conds, "LOWER(n.city) = LOWER("+arg(f.City)+")")
+	}
+	if f.Kind != "" {
+		conds = append(conds, "n.kind = "+arg(f.Kind))
+	}
+	if f.Q != "" {
+		q := arg(f.Q)
+		conds = append(conds, "n.name ILIKE '%' || "+q+" || '%'")
+		order = "n.name ILIKE " + q + " || '%' DESC, " + order
+	}
+	if f.Point != nil {
+		conds = append(conds, fmt.Sprintf("ST_Covers(n.boundary, ST_SetSRID(ST_MakePoint(%s, %s), 4326)::geography)",
+			arg(f.Point.Longitude), arg(f.Point.Latitude)))
+	}
+	where := "TRUE"
+	if len(conds) > 0 {
+		where = strings.Join(conds, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+    SELECT %s
+    FROM neighbourhoods n
+    WHERE %s
+    ORDER BY %s, n.nbhd_id ASC
+    LIMIT %s OFFSET %s`, neighbourhoodColumns, where, order, arg(limit), arg(f.Offset))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing neighbourhoods: %w", err)
+	}
+	defer rows.Close()
+
+	var list []Neighbourhood
+	for rows.Next() {
+		n, err := scanNeighbourhood(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning neighbourhood: %w", err)
+		}
+		list = append(list, n)
+	}
+	return list, rows.Err()
+}
+
+func (r *pgRepository) GetById(nbhdID int64, withBoundary bool) (Neighbourhood, error) {
+	boundary := "NULL::text"
+	if withBoundary {
+		boundary = "ST_AsGeoJSON(n.boundary, 6)"
+	}
+	var geojson sql.NullString
+	n, err := scanNeighbourhood(r.db.QueryRow(fmt.Sprintf(`
+    SELECT %s, %s
+    FROM neighbourhoods n
+    WHERE n.nbhd_id = $1`, neighbourhoodColumns, boundary), nbhdID), &geojson)
+	if err == sql.ErrNoRows {
+		return Neighbourhood{}, ErrNotFound
+	}
+	if err != nil {
+		return Neighbourhood{}, fmt.Errorf("error getting neighbourhood: %w", err)
+	}
+	if geojson.Valid {
+		n.Boundary = []byte(geojson.String)
+	}
+	return n, nil
+}
+
+// Upsert guarda las áreas en una transacción: si una geometría no se puede leer no queda un import a medias.
+// ST_MakeValid arregla anillos que se cruzan (comunes en los archivos municipales).
+func (r *pgRepository) Upsert(in ImportInputs, areas []Area) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+    INSERT INTO neighbourhoods (source, kind, external_id, name, city, province, boundary, center_latitude, center_longitude, area_sq_meters)
+    SELECT $1, $2, $3, $4, $5, NULLIF($6, ''), g::geography, ST_Y(ST_PointOnSurface(g)), ST_X(ST_PointOnSurface(g)), ST_Area(g::geography)
+    FROM (
+        SELECT ST_Multi(ST_CollectionExtract(ST_MakeValid(ST_Force2D(ST_SetSRID(ST_GeomFromGeoJSON($7), 4326))), 3)) AS g
+    ) AS src
+    ON CONFLICT (source, kind, external_id) DO UPDATE SET
+        name = EXCLUDED.name,
+        city = EXCLUDED.city,
+        province = EXCLUDED.province,
+        boundary = EXCLUDED.boundary,
+        center_latitude = EXCLUDED.center_latitude,
+        center_longitude = EXCLUDED.center_longitude,
+        area_sq_meters = EXCLUDED.area_sq_meters,
+        updated_at = NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, a := range areas {
+		if _, err := stmt.Exec(in.Source, in.Kind, a.ExternalId, a.Name, in.City, in.Province, a.Geometry); err != nil {
+			return 0, fmt.Errorf("error importing %s (%s): %w", a.Name, a.ExternalId, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(areas), nil
+}
+
+// Counts cuenta los clusters del filtro dentro de cada barrio de la ciudad (LEFT JOIN: los barrios sin clusters vienen en 0)
+func (r *pgRepository) Counts(city, kind string, f clusterquery.Filter) ([]NeighbourhoodCount, error) {
+	b := clusterquery.NewBuilder("c").Apply(f)
+	query := fmt.Sprintf(`
+    SELECT
+        n.nbhd_id,
+        n.name,
+        COUNT(c.incl_id),
+        COUNT(c.incl_id) FILTER (WHERE c.is_active = '1')
+    FROM neighbourhoods n
+    LEFT JOIN incident_clusters c ON ST_Covers(n.boundary, c.center_location)
+      AND %s
+    WHERE LOWER(n.city) = LOWER(%s) AND n.kind = %s
+    GROUP BY n.nbhd_id, n.name
+    ORDER BY 3 DESC, n.name ASC`, b.SQL(), b.Arg(city), b.Arg(kind))
+
+	rows, err := r.db.Query(query, b.Params()...)
+	if err != nil {
+		return nil, fmt.Errorf("error counting clusters by neighbourhood: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []NeighbourhoodCount
+	for rows.Next() {
+		var c NeighbourhoodCount
+		if err := rows.Scan(&c.NbhdId, &c.Name, &c.Total, &c.Active); err != nil {
+			return nil, fmt.Errorf("error scanning count: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
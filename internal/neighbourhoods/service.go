@@ -0,0 +1,222 @@
+package neighbourhoods
+
+import (
+	"alertly/internal/clusterquery"
+	"alertly/internal/pagination"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+	maxQueryLength  = 100
+)
+
+var (
+	ErrInvalidFilter = errors.New("invalid filter: kind must be neighbourhood or ward, q up to 100 characters and latitude/longitude sent together")
+	ErrInvalidImport = errors.New("the body must be a GeoJSON FeatureCollection of Polygon or MultiPolygon features, with source and city in the query string")
+	ErrNotFound      = errors.New("neighbourhood not found")
+)
+
+// Nombres de propiedades que se prueban si el import no dice cuál usar (Toronto usa AREA_NAME / AREA_SHORT_CODE)
+var (
+	defaultNameProperties = []string{"name", "NAME", "AREA_NAME", "Name"}
+	defaultIdProperties   = []string{"id", "ID", "AREA_SHORT_CODE", "AREA_ID"}
+)
+
+type Service interface {
+	List(f ListFilter) (NeighbourhoodsPage, error)
+	GetById(nbhdID int64, withBoundary bool) (Neighbourhood, error)
+	Import(in ImportInputs, areas []Area, skipped []SkippedFeature) (ImportResult, error)
+	Counts(city, kind string, f clusterquery.Filter) ([]NeighbourhoodCount, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) List(f ListFilter) (NeighbourhoodsPage, error) {
+	page := NeighbourhoodsPage{Neighbourhoods: []Neighbourhood{}, Page: f.Offset/f.Limit + 1}
+	// Se pide una fila de más para saber si hay otra página
+	list, err := s.repo.List(f, f.Limit+1)
+	if err != nil {
+		return page, err
+	}
+	if len(list) > f.Limit {
+		list = list[:f.Limit]
+		page.HasMore = true
+	}
+	page.Neighbourhoods = append(page.Neighbourhoods, list...)
+	return page, nil
+}
+
+func (s *service) GetById(nbhdID int64, withBoundary bool) (Neighbourhood, error) {
+	return s.repo.GetById(nbhdID, withBoundary)
+}
+
+func (s *service) Import(in ImportInputs, areas []Area, skipped []SkippedFeature) (ImportResult, error) {
+	result := ImportResult{Skipped: skipped}
+	if result.Skipped == nil {
+		result.Skipped = []SkippedFeature{}
+	}
+	imported, err := s.repo.Upsert(in, areas)
+	if err != nil {
+		return result, err
+	}
+	result.Imported = imported
+	return result, nil
+}
+
+func (s *service) Counts(city, kind string, f clusterquery.Filter) ([]NeighbourhoodCount, error) {
+	counts, err := s.repo.Counts(city, kind, f)
+	if counts == nil {
+		counts = []NeighbourhoodCount{}
+	}
+	return counts, err
+}
+
+// ParseList valida los filtros de GET /neighbourhoods
+func ParseList(in ListInputs) (ListFilter, error) {
+	f := ListFilter{
+		City:  strings.TrimSpace(in.City),
+		Kind:  strings.ToLower(strings.TrimSpace(in.Kind)),
+		Q:     strings.TrimSpace(in.Q),
+		Limit: pagination.Limit(in.Limit, defaultPageSize, maxPageSize),
+	}
+	if !validKind(f.Kind, true) || utf8.RuneCountInString(f.Q) > maxQueryLength {
+		return ListFilter{}, ErrInvalidFilter
+	}
+	if (in.Latitude == nil) != (in.Longitude == nil) {
+		return ListFilter{}, ErrInvalidFilter
+	}
+	if in.Latitude != nil {
+		if *in.Latitude < -90 || *in.Latitude > 90 || *in.Longitude < -180 || *in.Longitude > 180 {
+			return ListFilter{}, ErrInvalidFilter
+		}
+		f.Point = &clusterquery.Point{Latitude: *in.Latitude, Longitude: *in.Longitude}
+	}
+	if in.Page > 1 {
+		f.Offset = (in.Page - 1) * f.Limit
+	}
+	return f, nil
+}
+
+// ParseCounts valida los filtros de GET /neighbourhoods/counts
+func ParseCounts(in CountInputs) (kind string, f clusterquery.Filter, err error) {
+	kind = strings.ToLower(strings.TrimSpace(in.Kind))
+	if kind == "" {
+		kind = KindNeighbourhood
+	}
+	if !validKind(kind, false) {
+		return "", f, ErrInvalidFilter
+	}
+
+	f.Categories = clusterquery.SplitList(in.Categories...)
+	f.Status = clusterquery.Status(strings.ToLower(in.Status))
+	switch f.Status {
+	case "":
+		f.Status = clusterquery.StatusActive
+	case clusterquery.StatusActive, clusterquery.StatusExpired, clusterquery.StatusAll:
+	default:
+		return "", f, clusterquery.ErrInvalidFilter
+	}
+	f.From, f.To, err = clusterquery.ParseWindow(in.From, in.To)
+	return kind, f, err
+}
+
+// ParseImport lee el FeatureCollection. Los features sin nombre, sin id o con geometría que no es
+// Polygon/MultiPolygon se saltean (y se informan) en vez de cortar todo el import.
+func ParseImport(in *ImportInputs, body []byte) ([]Area, []SkippedFeature, error) {
+	in.Source = strings.TrimSpace(in.Source)
+	in.City = strings.TrimSpace(in.City)
+	in.Kind = strings.ToLower(strings.TrimSpace(in.Kind))
+	if in.Kind == "" {
+		in.Kind = KindNeighbourhood
+	}
+	if in.Source == "" || in.City == "" || !validKind(in.Kind, false) {
+		return nil, nil, ErrInvalidImport
+	}
+
+	var fc featureCollection
+	if err := json.Unmarshal(body, &fc); err != nil || fc.Type != "FeatureCollection" || len(fc.Features) == 0 {
+		return nil, nil, ErrInvalidImport
+	}
+
+	nameProps, idProps := defaultNameProperties, defaultIdProperties
+	if in.NameProperty != "" {
+		nameProps = []string{in.NameProperty}
+	}
+	if in.IdProperty != "" {
+		idProps = []string{in.IdProperty}
+	}
+
+	var areas []Area
+	var skipped []SkippedFeature
+	seen := map[string]bool{}
+	for i, ft := range fc.Features {
+		var geometry struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(ft.Geometry, &geometry); err != nil || (geometry.Type != "Polygon" && geometry.Type != "MultiPolygon") {
+			skipped = append(skipped, SkippedFeature{Index: i, Reason: "geometry must be Polygon or MultiPolygon"})
+			continue
+		}
+		name := property(ft.Properties, nameProps)
+		if name == "" {
+			skipped = append(skipped, SkippedFeature{Index: i, Reason: "missing name property"})
+			continue
+		}
+		id := property(ft.Properties, idProps)
+		if id == "" {
+			id = stringValue(ft.ID)
+		}
+		if id == "" {
+			skipped = append(skipped, SkippedFeature{Index: i, Reason: "missing id property"})
+			continue
+		}
+		if seen[id] {
+			skipped = append(skipped, SkippedFeature{Index: i, Reason: fmt.Sprintf("duplicate id %s", id)})
+			continue
+		}
+		seen[id] = true
+		areas = append(areas, Area{ExternalId: id, Name: name, Geometry: string(ft.Geometry)})
+	}
+	if len(areas) == 0 {
+		return nil, skipped, ErrInvalidImport
+	}
+	return areas, skipped, nil
+}
+
+func validKind(kind string, allowEmpty bool) bool {
+	return kind == KindNeighbourhood || kind == KindWard || (allowEmpty && kind == "")
+}
+
+func property(props map[string]interface{}, names []string) string {
+	for _, name := range names {
+		if v := stringValue(props[name]); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// stringValue acepta strings y números (los ids de GeoJSON vienen de las dos formas)
+func stringValue(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return strings.TrimSpace(t)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,98 @@
+package neighbourhoods
+
+import (
+	"alertly/internal/clusterquery"
+	"testing"
+)
+
+const square = `{"type":"Polygon","coordinates":[[[-79.4,43.6],[-79.3,43.6],[-79.3,43.7],[-79.4,43.6]]]}`
+
+func TestParseImportTorontoProperties(t *testing.T) {
+	body := []byte(`{"type":"FeatureCollection","features":[
+		{"type":"Feature","properties":{"AREA_SHORT_CODE":95,"AREA_NAME":"Annex"},"geometry":` + square + `},
+		{"type":"Feature","properties":{"AREA_SHORT_CODE":"77","AREA_NAME":"Waterfront Communities"},"geometry":` + square + `},
+		{"type":"Feature","properties":{"AREA_SHORT_CODE":95,"AREA_NAME":"Annex again"},"geometry":` + square + `},
+		{"type":"Feature","properties":{"AREA_SHORT_CODE":1},"geometry":` + square + `},
+		{"type":"Feature","properties":{"AREA_SHORT_CODE":2,"AREA_NAME":"Point"},"geometry":{"type":"Point","coordinates":[-79.4,43.6]}}
+	]}`)
+	in := ImportInputs{Source: "toronto_open_data", City: "Toronto"}
+
+	areas, skipped, err := ParseImport(&in, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.Kind != KindNeighbourhood {
+		t.Errorf("kind should default to neighbourhood, got %q", in.Kind)
+	}
+	if len(areas) != 2 || areas[0].ExternalId != "95" || areas[0].Name != "Annex" || areas[1].ExternalId != "77" {
+		t.Errorf("unexpected areas: %+v", areas)
+	}
+	if len(skipped) != 3 || skipped[0].Index != 2 || skipped[1].Index != 3 || skipped[2].Index != 4 {
+		t.Errorf("unexpected skipped: %+v", skipped)
+	}
+}
+
+func TestParseImportCustomPropertiesAndFeatureId(t *testing.T) {
+	body := []byte(`{"type":"FeatureCollection","features":[
+		{"type":"Feature","id":"W10","properties":{"ward_name":"Spadina-Fort York"},"geometry":` + square + `}
+	]}`)
+	in := ImportInputs{Source: "toronto_open_data", City: "Toronto", Kind: "Ward", NameProperty: "ward_name"}
+
+	areas, _, err := ParseImport(&in, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.Kind != KindWard || len(areas) != 1 || areas[0].ExternalId != "W10" || areas[0].Name != "Spadina-Fort York" {
+		t.Errorf("unexpected result: kind=%q areas=%+v", in.Kind, areas)
+	}
+}
+
+func TestParseImportRejects(t *testing.T) {
+	valid := `{"type":"FeatureCollection","features":[{"type":"Feature","properties":{"id":1,"name":"A"},"geometry":` + square + `}]}`
+	cases := []struct {
+		name string
+		in   ImportInputs
+		body string
+	}{
+		{"no source", ImportInputs{City: "Toronto"}, valid},
+		{"bad kind", ImportInputs{Source: "s", City: "Toronto", Kind: "district"}, valid},
+		{"not a collection", ImportInputs{Source: "s", City: "Toronto"}, square},
+		{"nothing usable", ImportInputs{Source: "s", City: "Toronto"}, `{"type":"FeatureCollection","features":[{"type":"Feature","properties":{},"geometry":` + square + `}]}`},
+	}
+	for _, tc := range cases {
+		if _, _, err := ParseImport(&tc.in, []byte(tc.body)); err != ErrInvalidImport {
+			t.Errorf("%s: expected ErrInvalidImport, got %v", tc.name, err)
+		}
+	}
+}
+
+func TestParseList(t *testing.T) {
+	lat, lng := 43.67, -79.40
+	f, err := ParseList(ListInputs{City: " Toronto ", Q: "annex", Latitude: &lat, Longitude: &lng, Page: 3, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.City != "Toronto" || f.Point == nil || f.Offset != 20 || f.Limit != 10 {
+		t.Errorf("unexpected filter: %+v", f)
+	}
+
+	if _, err := ParseList(ListInputs{Latitude: &lat}); err != ErrInvalidFilter {
+		t.Errorf("latitude alone: expected ErrInvalidFilter, got %v", err)
+	}
+	if _, err := ParseList(ListInputs{Kind: "district"}); err != ErrInvalidFilter {
+		t.Errorf("unknown kind: expected ErrInvalidFilter, got %v", err)
+	}
+}
+
+func TestParseCounts(t *testing.T) {
+	kind, f, err := ParseCounts(CountInputs{City: "Toronto", Categories: []string{"crime,fire_incident"}, From: "2026-10-01", To: "2026-10-07"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kind != KindNeighbourhood || f.Status != clusterquery.StatusActive || len(f.Categories) != 2 || f.From == nil || f.To == nil {
+		t.Errorf("unexpected filter: kind=%q %+v", kind, f)
+	}
+	if _, _, err := ParseCounts(CountInputs{City: "Toronto", Status: "merged"}); err != clusterquery.ErrInvalidFilter {
+		t.Errorf("bad status: expected ErrInvalidFilter, got %v", err)
+	}
+}
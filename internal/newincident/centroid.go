@@ -0,0 +1,92 @@
+package newincident
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// Un report pierde la mitad de su peso cada centroidHalfLife
+	centroidHalfLife = 6 * time.Hour
+	// Credibilidad mínima usada como peso para que cuentas nuevas (credibility 0) no desaparezcan del cálculo
+	minCredibilityWeight = 0.5
+	// Radio mínimo antes de considerar un report como outlier (un GPS normal tiene ~10-50m de error)
+	outlierMinRadiusMeters = 150.0
+	// Un report es outlier si está a más de outlierMADFactor * (mediana de distancias) de la mediana
+	outlierMADFactor = 3.0
+	// Movimientos del centro menores a esto no se muestran en la línea de tiempo del cluster
+	locationMovedEventMeters = 25.0
+
+	earthRadiusMeters = 6371000.0
+)
+
+// weightedCentroid calcula el centro del cluster a partir de sus reports.
+// 1) Toma la mediana de latitud/longitud como referencia robusta.
+// 2) Descarta los reports demasiado lejos de la mediana (un mal fix de GPS).
+// 3) Promedia el resto ponderando por credibilidad del autor y antigüedad del report.
+// Devuelve ok=false si no hay puntos.
+func weightedCentroid(points []ReportPoint, now time.Time) (lat, lng float64, ok bool) {
+	if len(points) == 0 {
+		return 0, 0, false
+	}
+
+	lats := make([]float64, len(points))
+	lngs := make([]float64, len(points))
+	for i, p := range points {
+		lats[i] = p.Latitude
+		lngs[i] = p.Longitude
+	}
+	medLat, medLng := median(lats), median(lngs)
+
+	dists := make([]float64, len(points))
+	for i, p := range points {
+		dists[i] = haversineMeters(medLat, medLng, p.Latitude, p.Longitude)
+	}
+	radius := math.Max(outlierMinRadiusMeters, outlierMADFactor*median(dists))
+
+	var sumW, sumLat, sumLng float64
+	for i, p := range points {
+		if dists[i] > radius {
+			continue
+		}
+		w := reportWeight(p, now)
+		sumW += w
+		sumLat += p.Latitude * w
+		sumLng += p.Longitude * w
+	}
+
+	if sumW == 0 {
+		return medLat, medLng, true
+	}
+	return sumLat / sumW, sumLng / sumW, true
+}
+
+// reportWeight = credibilidad del autor * decaimiento exponencial por antigüedad
+func reportWeight(p ReportPoint, now time.Time) float64 {
+	cred := math.Max(p.Credibility, minCredibilityWeight)
+	age := now.Sub(p.CreatedAt)
+	if age < 0 {
+		age = 0
+	}
+	return cred * math.Pow(0.5, age.Hours()/centroidHalfLife.Hours())
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func haversineMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLng := (lng2 - lng1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
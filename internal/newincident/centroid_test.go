@@ -0,0 +1,54 @@
+package newincident
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestWeightedCentroid_Empty(t *testing.T) {
+	if _, _, ok := weightedCentroid(nil, time.Now()); ok {
+		t.Fatal("weightedCentroid(nil) ok = true, want false")
+	}
+}
+
+func TestWeightedCentroid_RejectsOutlier(t *testing.T) {
+	now := time.Now()
+	points := []ReportPoint{
+		{Latitude: 43.6500, Longitude: -79.3800, Credibility: 5, CreatedAt: now},
+		{Latitude: 43.6502, Longitude: -79.3802, Credibility: 5, CreatedAt: now},
+		{Latitude: 43.6498, Longitude: -79.3798, Credibility: 5, CreatedAt: now},
+		// Mal fix de GPS a varios kilómetros
+		{Latitude: 43.7000, Longitude: -79.4500, Credibility: 10, CreatedAt: now},
+	}
+
+	lat, lng, ok := weightedCentroid(points, now)
+	if !ok {
+		t.Fatal("weightedCentroid ok = false, want true")
+	}
+	if d := haversineMeters(lat, lng, 43.6500, -79.3800); d > 10 {
+		t.Errorf("centroid (%f, %f) is %.0fm from the cluster, outlier was not rejected", lat, lng, d)
+	}
+}
+
+func TestWeightedCentroid_WeightsCredibilityAndRecency(t *testing.T) {
+	now := time.Now()
+
+	// Misma antigüedad: el report con más credibilidad pesa más
+	lat, _, _ := weightedCentroid([]ReportPoint{
+		{Latitude: 43.6500, Longitude: -79.38, Credibility: 9, CreatedAt: now},
+		{Latitude: 43.6505, Longitude: -79.38, Credibility: 1, CreatedAt: now},
+	}, now)
+	if want := 43.6500 + 0.0005*0.1; math.Abs(lat-want) > 1e-9 {
+		t.Errorf("credibility weighting: lat = %f, want %f", lat, want)
+	}
+
+	// Misma credibilidad: un report de hace una vida media pesa la mitad
+	lat, _, _ = weightedCentroid([]ReportPoint{
+		{Latitude: 43.6500, Longitude: -79.38, Credibility: 5, CreatedAt: now},
+		{Latitude: 43.6503, Longitude: -79.38, Credibility: 5, CreatedAt: now.Add(-centroidHalfLife)},
+	}, now)
+	if want := 43.6500 + 0.0003/3; math.Abs(lat-want) > 1e-9 {
+		t.Errorf("recency weighting: lat = %f, want %f", lat, want)
+	}
+}
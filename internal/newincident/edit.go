@@ -0,0 +1,52 @@
+package newincident
+
+import (
+	"alertly/internal/middleware"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultReportEditWindow = 60 * time.Minute
+
+var (
+	ErrReportNotFound     = errors.New("report not found")
+	ErrReportWithdrawn    = errors.New("this report was already withdrawn")
+	ErrEditWindowClosed   = errors.New("the time to edit or withdraw this report has passed")
+	ErrNothingToEdit      = errors.New("there are no changes to save")
+	ErrInappropriateText  = errors.New("this description contains inappropriate language and cannot be saved")
+	ErrInvalidSubcategory = errors.New("invalid subcategory")
+)
+
+// editDescription valida la nueva descripción con el filtro de lenguaje de los comentarios.
+// Devuelve nil si no hay cambio; las palabras moderadas se censuran y las bloqueadas se rechazan.
+func editDescription(current string, input *string) (*string, error) {
+	if input == nil {
+		return nil, nil
+	}
+
+	description := strings.TrimSpace(*input)
+	result := middleware.FilterProfanity(description)
+	if result.Level == "blocked" {
+		return nil, ErrInappropriateText
+	}
+	if result.Level == "censored" {
+		description = result.FilteredText
+	}
+
+	if description == current {
+		return nil, nil
+	}
+	return &description, nil
+}
+
+// reportEditWindow: tiempo desde la creación del report durante el cual el autor puede editarlo o retirarlo.
+// Configurable con REPORT_EDIT_WINDOW_MINUTES.
+func reportEditWindow() time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("REPORT_EDIT_WINDOW_MINUTES")); err == nil && v > 0 {
+		return time.Duration(v) * time.Minute
+	}
+	return defaultReportEditWindow
+}
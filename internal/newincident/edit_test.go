@@ -0,0 +1,34 @@
+package newincident
+
+import (
+	"errors"
+	"testing"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestEditDescription(t *testing.T) {
+	got, err := editDescription("Car crash on main st", nil)
+	if err != nil || got != nil {
+		t.Fatalf("nil input: expected no change, got %v, %v", got, err)
+	}
+
+	got, err = editDescription("Car crash on main st", strPtr("  Car crash on main st "))
+	if err != nil || got != nil {
+		t.Fatalf("same text: expected no change, got %v, %v", got, err)
+	}
+
+	got, err = editDescription("Car crash on main st", strPtr("Car crash on Main St"))
+	if err != nil || got == nil || *got != "Car crash on Main St" {
+		t.Fatalf("typo fix: expected new description, got %v, %v", got, err)
+	}
+
+	got, err = editDescription("", strPtr("this is crap"))
+	if err != nil || got == nil || *got != "this is ****" {
+		t.Fatalf("censored: expected filtered text, got %v, %v", got, err)
+	}
+
+	if _, err := editDescription("", strPtr("what the fuck")); !errors.Is(err, ErrInappropriateText) {
+		t.Fatalf("blocked: expected ErrInappropriateText, got %v", err)
+	}
+}
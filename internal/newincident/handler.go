@@ -3,12 +3,17 @@ package newincident
 import (
 	"alertly/internal/auth"
 	"alertly/internal/database"
+	"alertly/internal/media"
 	"alertly/internal/response"
+	"errors"
+	"fmt"
 	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -16,6 +21,9 @@ import (
 
 var validate = validator.New()
 
+// MaxImagesPerReport es el máximo de fotos por incident_report
+const MaxImagesPerReport = 5
+
 func Create(c *gin.Context) {
 	// Parse multipart form (límite 10 MB)
 	// var accountID int64
@@ -49,38 +57,71 @@ func Create(c *gin.Context) {
 		return
 	}
 
-	// Procesar el archivo enviado (campo "file")
-	file, header, err := c.Request.FormFile("file")
-	if err != nil {
-		log.Printf("Error retrieving file: %v", err)
+	// Procesar los archivos enviados: "files" (varias imágenes, en orden) y "file" (clientes antiguos)
+	headers := c.Request.MultipartForm.File["files"]
+	headers = append(headers, c.Request.MultipartForm.File["file"]...)
+	if len(headers) == 0 {
+		log.Printf("Error retrieving file: no files in form")
 		response.Send(c, http.StatusBadRequest, true, "Error fetching file", nil)
 		return
 	}
-	defer file.Close()
-
-	// ✅ OPTIMIZACIÓN: Procesamiento de imágenes asíncrono
-	// 1. Crear archivo temporal para el archivo original
-	ext := filepath.Ext(header.Filename)
-	tmpFile, err := os.CreateTemp("", "orig_*"+ext)
-	if err != nil {
-		log.Printf("Error creating temp file: %v", err)
-		response.Send(c, http.StatusInternalServerError, true, "Error creating folder", nil)
+	if len(headers) > MaxImagesPerReport {
+		response.Send(c, http.StatusBadRequest, true, fmt.Sprintf("You can attach up to %d photos per report", MaxImagesPerReport), nil)
 		return
 	}
-	tmpFilePath := tmpFile.Name()
 
-	// Copiar el contenido al archivo temporal
-	if _, err := io.Copy(tmpFile, file); err != nil {
-		log.Printf("Error saving temp file: %v", err)
-		response.Send(c, http.StatusInternalServerError, true, "Error saving tmpl file", nil)
+	// Videos: uno solo por report, sin fotos adicionales
+	incident.MediaType = "image"
+	for _, header := range headers {
+		if media.IsVideoFile(header.Filename, header.Header.Get("Content-Type")) {
+			incident.MediaType = "video"
+		}
+	}
+	if incident.MediaType == "video" && len(headers) > 1 {
+		response.Send(c, http.StatusBadRequest, true, "You can attach one video per report", nil)
 		return
 	}
-	tmpFile.Close()
+
+	// ✅ OPTIMIZACIÓN: Procesamiento de imágenes asíncrono
+	// Crear un archivo temporal por cada original; el outbox los procesa y los elimina
+	tmpFilePaths := make([]string, 0, len(headers))
+	for _, header := range headers {
+		tmpFilePath, err := saveUploadedFile(header)
+		if err != nil {
+			log.Printf("Error saving temp file: %v", err)
+			for _, p := range tmpFilePaths {
+				os.Remove(p)
+			}
+			response.Send(c, http.StatusInternalServerError, true, "Error saving tmpl file", nil)
+			return
+		}
+		tmpFilePaths = append(tmpFilePaths, tmpFilePath)
+	}
+
+	// Validar el contenedor ahora para que el usuario sepa de inmediato si el video es muy largo o no es compatible;
+	// la transcodificación se hace en el outbox
+	if incident.MediaType == "video" {
+		info, err := media.ProbeVideoFile(tmpFilePaths[0])
+		if err == nil {
+			err = media.ValidateVideo(info)
+		}
+		if err != nil {
+			os.Remove(tmpFilePaths[0])
+			if errors.Is(err, media.ErrInvalidVideo) {
+				response.Send(c, http.StatusBadRequest, true, "This video can't be uploaded. Please use an MP4 or MOV video within the length and size limits.", err.Error())
+				return
+			}
+			log.Printf("Error reading video: %v", err)
+			response.Send(c, http.StatusInternalServerError, true, "Error reading video", nil)
+			return
+		}
+	}
 
 	// ⚡ RESPUESTA RÁPIDA: Usar placeholder temporal y procesar en background
 	// La imagen se procesará asincrónicamente después de guardar el incidente
 	incident.Media.Uri = "processing"
-	incident.TmpFilePath = tmpFilePath // Guardar path para procesamiento asíncrono
+	incident.TmpFilePaths = tmpFilePaths // Guardar paths para procesamiento asíncrono (el primero es la portada)
+	incident.StagedKeys = stageUploads(tmpFilePaths)
 
 	// Continuar con la lógica original de guardado en la base de datos
 	repo := NewRepository(database.DB)
@@ -88,13 +129,28 @@ func Create(c *gin.Context) {
 	incident.AccountId = accountID
 
 	result, err := service.Save(incident)
+	if err != nil {
+		for _, k := range incident.StagedKeys {
+			media.DeleteStaged(k)
+		}
+	}
+	if errors.Is(err, ErrClusterNotJoinable) {
+		response.Send(c, http.StatusConflict, true, err.Error(), nil)
+		return
+	}
 	if err != nil {
 		log.Printf("error saving incident: %v", err)
 		response.Send(c, http.StatusInternalServerError, true, "error saving incident. please try again", nil)
 		return
 	}
 
-	// ⚡ NOTA: El archivo temporal se eliminará automáticamente después del procesamiento asíncrono
+	// ⚡ NOTA: El archivo temporal se eliminará automáticamente después del procesamiento asíncrono.
+	// Si los originales quedaron en R2 los jobs trabajan desde ahí y la copia local ya no sirve.
+	if len(incident.StagedKeys) > 0 {
+		for _, p := range tmpFilePaths {
+			os.Remove(p)
+		}
+	}
 
 	log.Printf("success: %v", result)
 	response.Send(c, http.StatusOK, false, "Thank you for your report! We've received your incident and will review it shortly.", result)
@@ -102,3 +158,217 @@ func Create(c *gin.Context) {
 
 // ✅ ELIMINADA: Función obsoleta que no es compatible con AWS Lambda
 // copyFile() se eliminó porque Lambda no permite crear archivos fuera de /tmp
+
+// saveUploadedFile copia un archivo del form a uploadDir() y devuelve su path
+func saveUploadedFile(header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	ext := filepath.Ext(header.Filename)
+	tmpFile, err := os.CreateTemp(uploadDir(), "orig_*"+ext)
+	if err != nil {
+		return "", err
+	}
+	defer tmpFile.Close()
+
+	if _, err := io.Copy(tmpFile, file); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", err
+	}
+	return tmpFile.Name(), nil
+}
+
+// stageUploads sube los originales a R2 para que cualquier instancia pueda procesar el job; con cada key
+// el archivo local ya no hace falta. Si R2 falla se sigue con los archivos locales (una sola instancia).
+func stageUploads(tmpFilePaths []string) []string {
+	keys := make([]string, 0, len(tmpFilePaths))
+	for _, path := range tmpFilePaths {
+		key, err := media.StageUpload(path)
+		if err != nil {
+			log.Printf("⚠️ Could not stage upload %s, it will be processed from local disk: %v", path, err)
+			for _, k := range keys {
+				media.DeleteStaged(k)
+			}
+			return nil
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// uploadDir devuelve el directorio donde se guardan los originales hasta que el outbox los procesa.
+// Con los originales en R2 (stageUploads) solo se usa como scratch; sin R2 los jobs leen de acá, así que con
+// más de una instancia INCIDENT_UPLOAD_DIR tiene que ser un volumen compartido.
+func uploadDir() string {
+	if dir := os.Getenv("INCIDENT_UPLOAD_DIR"); dir != "" {
+		return dir
+	}
+	return os.TempDir()
+}
+
+// ChangeVote cambia o retira el voto del usuario en un cluster.
+// Body: {"incl_id": 123, "vote": true|false|null}
+func ChangeVote(c *gin.Context) {
+	accountID, err := auth.GetUserFromContext(c)
+	if err != nil {
+		response.Send(c, http.StatusUnauthorized, true, "Unauthorized", nil)
+		return
+	}
+
+	var inputs VoteInputs
+	if err := c.ShouldBindJSON(&inputs); err != nil {
+		response.Send(c, http.StatusBadRequest, true, "Invalid input. Please check and try again.", err.Error())
+		return
+	}
+
+	repo := NewRepository(database.DB)
+	service := NewService(repo)
+	err = service.ChangeVote(accountID, inputs)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNoVote):
+			response.Send(c, http.StatusNotFound, true, err.Error(), nil)
+		case errors.Is(err, ErrVoteWindowClosed):
+			response.Send(c, http.StatusForbidden, true, err.Error(), nil)
+		case errors.Is(err, ErrClusterClosed), errors.Is(err, ErrVoteConflict):
+			response.Send(c, http.StatusConflict, true, err.Error(), nil)
+		default:
+			log.Printf("Error changing vote for account %d on cluster %d: %v", accountID, inputs.InclId, err)
+			response.Send(c, http.StatusInternalServerError, true, "Could not update your vote. Please try later.", nil)
+		}
+		return
+	}
+
+	if inputs.Vote == nil {
+		response.Send(c, http.StatusOK, false, "Vote retracted", nil)
+		return
+	}
+	response.Send(c, http.StatusOK, false, "Vote updated", gin.H{"incl_id": inputs.InclId, "vote": *inputs.Vote})
+}
+
+// GetMediaStatus devuelve el estado del procesamiento (processing/ready/failed) de las fotos o el video
+// de un report del usuario, para que la app sepa cuándo mostrarlos o por qué fallaron.
+func GetMediaStatus(c *gin.Context) {
+	accountID, err := auth.GetUserFromContext(c)
+	if err != nil {
+		response.Send(c, http.StatusUnauthorized, true, "Unauthorized", nil)
+		return
+	}
+
+	inreID, err := strconv.ParseInt(c.Param("inre_id"), 10, 64)
+	if err != nil || inreID <= 0 {
+		response.Send(c, http.StatusBadRequest, true, "Invalid report ID", nil)
+		return
+	}
+
+	repo := NewRepository(database.DB)
+	service := NewService(repo)
+	items, err := service.GetMediaStatus(inreID, accountID)
+	if err != nil {
+		if errors.Is(err, ErrMediaNotFound) {
+			response.Send(c, http.StatusNotFound, true, err.Error(), nil)
+			return
+		}
+		log.Printf("Error getting media status for report %d: %v", inreID, err)
+		response.Send(c, http.StatusInternalServerError, true, "Could not get media status. Please try later.", nil)
+		return
+	}
+
+	response.Send(c, http.StatusOK, false, "success", items)
+}
+
+// reportEditError traduce los errores de edición/retiro a la respuesta HTTP
+func reportEditError(c *gin.Context, err error, accountID, inreID int64) {
+	switch {
+	case errors.Is(err, ErrReportNotFound):
+		response.Send(c, http.StatusNotFound, true, err.Error(), nil)
+	case errors.Is(err, ErrEditWindowClosed):
+		response.Send(c, http.StatusForbidden, true, err.Error(), nil)
+	case errors.Is(err, ErrReportWithdrawn):
+		response.Send(c, http.StatusConflict, true, err.Error(), nil)
+	case errors.Is(err, ErrNothingToEdit), errors.Is(err, ErrInappropriateText), errors.Is(err, ErrInvalidSubcategory):
+		response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+	default:
+		log.Printf("Error editing report %d for account %d: %v", inreID, accountID, err)
+		response.Send(c, http.StatusInternalServerError, true, "Could not update your report. Please try later.", nil)
+	}
+}
+
+// EditReport permite al autor corregir la descripción o la subcategoría de su report.
+// Body: {"inre_id": 123, "description": "...", "subcategory_code": "..."}
+func EditReport(c *gin.Context) {
+	accountID, err := auth.GetUserFromContext(c)
+	if err != nil {
+		response.Send(c, http.StatusUnauthorized, true, "Unauthorized", nil)
+		return
+	}
+
+	var inputs EditReportInputs
+	if err := c.ShouldBindJSON(&inputs); err != nil {
+		response.Send(c, http.StatusBadRequest, true, "Invalid input. Please check and try again.", err.Error())
+		return
+	}
+
+	repo := NewRepository(database.DB)
+	service := NewService(repo)
+	if err := service.EditReport(accountID, inputs); err != nil {
+		reportEditError(c, err, accountID, inputs.InreId)
+		return
+	}
+
+	response.Send(c, http.StatusOK, false, "Report updated", gin.H{"inre_id": inputs.InreId})
+}
+
+// WithdrawReport permite al autor retirar un report enviado por error.
+// Body: {"inre_id": 123}
+func WithdrawReport(c *gin.Context) {
+	accountID, err := auth.GetUserFromContext(c)
+	if err != nil {
+		response.Send(c, http.StatusUnauthorized, true, "Unauthorized", nil)
+		return
+	}
+
+	var inputs WithdrawReportInputs
+	if err := c.ShouldBindJSON(&inputs); err != nil {
+		response.Send(c, http.StatusBadRequest, true, "Invalid input. Please check and try again.", err.Error())
+		return
+	}
+
+	repo := NewRepository(database.DB)
+	service := NewService(repo)
+	if err := service.WithdrawReport(accountID, inputs.InreId); err != nil {
+		reportEditError(c, err, accountID, inputs.InreId)
+		return
+	}
+
+	response.Send(c, http.StatusOK, false, "Report withdrawn", gin.H{"inre_id": inputs.InreId})
+}
+
+// SuggestClusters devuelve clusters activos cercanos que podrían ser el mismo evento, para que la app
+// ofrezca unirse a uno (join_incl_id en /incident/create) en vez de crear un duplicado.
+// Query: ?latitude=..&longitude=..&subcategory_code=..&radius=..
+func SuggestClusters(c *gin.Context) {
+	var inputs SuggestionInputs
+	if err := c.ShouldBindQuery(&inputs); err != nil {
+		response.Send(c, http.StatusBadRequest, true, "Invalid query parameters. Please check and try again.", err.Error())
+		return
+	}
+
+	repo := NewRepository(database.DB)
+	service := NewService(repo)
+	result, err := service.SuggestClusters(inputs)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSubcategory) {
+			response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+			return
+		}
+		log.Printf("Error suggesting clusters: %v", err)
+		response.Send(c, http.StatusInternalServerError, true, "Could not load nearby incidents. Please try again later.", nil)
+		return
+	}
+
+	response.Send(c, http.StatusOK, false, "Success", result)
+}
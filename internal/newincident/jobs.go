@@ -0,0 +1,370 @@
+package newincident
+
+import (
+	"alertly/internal/common"
+	"alertly/internal/media"
+	"alertly/internal/outbox"
+	"alertly/internal/profile"
+	"alertly/internal/realtime"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Outbox job types written together with an incident report.
+const (
+	JobSaveScore            = "incident_save_score"
+	JobSaveNotification     = "incident_save_notification"
+	JobUpdateTotalIncidents = "incident_update_total_incidents"
+	JobReverseGeocode       = "incident_reverse_geocode"
+	JobProcessImage         = "incident_process_image"
+	JobProcessVideo         = "incident_process_video"
+)
+
+// videoTranscodeTimeout debe ser menor que el lockTimeout del outbox para que el job no se reparta dos veces
+const videoTranscodeTimeout = 5 * time.Minute
+
+// ErrMediaNotFound: el report no existe, no es del usuario o no tiene archivos.
+var ErrMediaNotFound = errors.New("no media found for this report")
+
+type scorePayload struct {
+	AccountID int64 `json:"account_id"`
+	Points    int   `json:"points"` // negativo al retirar un report
+}
+
+type notificationPayload struct {
+	Type        string `json:"type"`
+	AccountID   int64  `json:"account_id"`
+	ReferenceID int64  `json:"reference_id"`
+}
+
+type accountPayload struct {
+	AccountID int64 `json:"account_id"`
+}
+
+type geocodePayload struct {
+	InreID    int64   `json:"inre_id"`
+	InclID    int64   `json:"incl_id"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type imagePayload struct {
+	InreID      int64  `json:"inre_id"`
+	InclID      int64  `json:"incl_id"`
+	InmeID      int64  `json:"inme_id"`  // fila de incident_media (0 en jobs anteriores a incident_media)
+	Position    int    `json:"position"` // 0 = portada
+	TmpFilePath string `json:"tmp_file_path"`
+	StagedKey   string `json:"staged_key,omitempty"` // original en R2; si está, TmpFilePath ya no existe
+}
+
+type videoPayload struct {
+	InreID      int64  `json:"inre_id"`
+	InclID      int64  `json:"incl_id"`
+	InmeID      int64  `json:"inme_id"`
+	TmpFilePath string `json:"tmp_file_path"`
+	StagedKey   string `json:"staged_key,omitempty"`
+}
+
+// Nominatim usage policy: max 1 request per second
+var geocodeLimiter = rate.NewLimiter(rate.Every(1*time.Second), 1)
+
+// enqueueReportJobs writes every side effect of a new incident_report into the outbox.
+func enqueueReportJobs(tx *sql.Tx, incident IncidentReport, inreID int64) error {
+	// Si el incident tiene incl_id != 0, se está agregando a un cluster existente
+	notification := notificationPayload{Type: "new_incident_cluster", AccountID: incident.AccountId, ReferenceID: incident.InclId}
+	if incident.InclId == 0 {
+		notification = notificationPayload{Type: "new_cluster", AccountID: incident.AccountId, ReferenceID: inreID}
+	}
+
+	jobs := []struct {
+		jobType string
+		payload interface{}
+	}{
+		{JobSaveScore, scorePayload{AccountID: incident.AccountId, Points: 20}},
+		{JobSaveNotification, notification},
+		{JobUpdateTotalIncidents, accountPayload{AccountID: incident.AccountId}},
+		{JobReverseGeocode, geocodePayload{InreID: inreID, InclID: incident.InclId, Latitude: incident.Latitude, Longitude: incident.Longitude}},
+	}
+	// Una fila en incident_media + un job por archivo, en el orden en que llegaron
+	for position, tmpPath := range incident.TmpFilePaths {
+		stagedKey := ""
+		if position < len(incident.StagedKeys) {
+			stagedKey = incident.StagedKeys[position]
+		}
+		if incident.MediaType == "video" {
+			inmeID, err := insertMediaPlaceholder(tx, inreID, position, "video")
+			if err != nil {
+				return err
+			}
+			jobs = append(jobs, struct {
+				jobType string
+				payload interface{}
+			}{JobProcessVideo, videoPayload{InreID: inreID, InclID: incident.InclId, InmeID: inmeID, TmpFilePath: tmpPath, StagedKey: stagedKey}})
+			continue
+		}
+
+		inmeID, err := insertMediaPlaceholder(tx, inreID, position, "image")
+		if err != nil {
+			return err
+		}
+		jobs = append(jobs, struct {
+			jobType string
+			payload interface{}
+		}{JobProcessImage, imagePayload{InreID: inreID, InclID: incident.InclId, InmeID: inmeID, Position: position, TmpFilePath: tmpPath, StagedKey: stagedKey}})
+	}
+
+	for _, j := range jobs {
+		if err := outbox.Enqueue(tx, j.jobType, j.payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterJobs binds the newincident job types to their handlers.
+// Must be called before the outbox dispatcher starts.
+func RegisterJobs(db *sql.DB) {
+	repo := NewRepository(db)
+
+	outbox.RegisterHandler(JobSaveScore, func(raw json.RawMessage) error {
+		var p scorePayload
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return outbox.Permanent(err)
+		}
+		return common.SaveScore(db, p.AccountID, p.Points)
+	})
+
+	outbox.RegisterHandler(JobSaveNotification, func(raw json.RawMessage) error {
+		var p notificationPayload
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return outbox.Permanent(err)
+		}
+		return common.SaveNotification(db, p.Type, p.AccountID, p.ReferenceID)
+	})
+
+	outbox.RegisterHandler(JobUpdateTotalIncidents, func(raw json.RawMessage) error {
+		var p accountPayload
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return outbox.Permanent(err)
+		}
+		return profile.NewService(profile.NewRepository(db)).UpdateTotalIncidents(p.AccountID)
+	})
+
+	outbox.RegisterHandler(JobReverseGeocode, func(raw json.RawMessage) error {
+		var p geocodePayload
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return outbox.Permanent(err)
+		}
+		return reverseGeocodeJob(repo, p)
+	})
+
+	outbox.RegisterHandler(JobProcessImage, func(raw json.RawMessage) error {
+		var p imagePayload
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return outbox.Permanent(err)
+		}
+		return processImageJob(repo, p)
+	})
+	outbox.RegisterDeadHandler(JobProcessImage, func(raw json.RawMessage, err error) {
+		var p imagePayload
+		if json.Unmarshal(raw, &p) == nil {
+			abandonMedia(repo, p.InmeID, p.TmpFilePath, p.StagedKey, err)
+		}
+	})
+
+	transcoder := media.NewLocalTranscoder()
+	outbox.RegisterHandler(JobProcessVideo, func(raw json.RawMessage) error {
+		var p videoPayload
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return outbox.Permanent(err)
+		}
+		return processVideoJob(repo, transcoder, p)
+	})
+	outbox.RegisterDeadHandler(JobProcessVideo, func(raw json.RawMessage, err error) {
+		var p videoPayload
+		if json.Unmarshal(raw, &p) == nil {
+			abandonMedia(repo, p.InmeID, p.TmpFilePath, p.StagedKey, err)
+		}
+	})
+}
+
+func reverseGeocodeJob(repo Repository, p geocodePayload) error {
+	if err := geocodeLimiter.Wait(context.Background()); err != nil {
+		return err
+	}
+
+	addr, city, prov, postal, err := common.ReverseGeocode(p.Latitude, p.Longitude)
+	if err != nil {
+		return fmt.Errorf("geocoding incident %d: %w", p.InreID, err)
+	}
+
+	// Actualizar cluster con dirección real
+	if p.InclID != 0 {
+		if err := repo.UpdateClusterAddress(p.InclID, addr, city, prov, postal); err != nil {
+			return fmt.Errorf("updating cluster address for %d: %w", p.InclID, err)
+		}
+		realtime.PublishCluster(realtime.EventClusterUpdated, p.InclID)
+	}
+
+	// Actualizar incident report con dirección real
+	if err := repo.UpdateIncidentAddress(p.InreID, addr, city, prov, postal); err != nil {
+		return fmt.Errorf("updating incident address for %d: %w", p.InreID, err)
+	}
+
+	fmt.Printf("✅ Geocoding completed for incident %d: %s, %s\n", p.InreID, addr, city)
+	return nil
+}
+
+func processImageJob(repo Repository, p imagePayload) error {
+	path, err := fetchMedia(p.TmpFilePath, p.StagedKey)
+	if isMissingMedia(err) {
+		if p.InmeID != 0 {
+			if err := repo.MarkMediaFailed(p.InmeID, "original file is no longer available"); err != nil {
+				fmt.Printf("⚠️ Failed to mark media %d as failed: %v\n", p.InmeID, err)
+			}
+		}
+		// El archivo original ya no existe (p.ej. /tmp de un contenedor anterior): no tiene sentido reintentar
+		return outbox.Permanent(fmt.Errorf("original file for incident %d no longer exists: %w", p.InreID, err))
+	}
+	if err != nil {
+		return fmt.Errorf("fetching original for incident %d: %w", p.InreID, err)
+	}
+	if p.StagedKey != "" {
+		defer os.Remove(path)
+	}
+
+	// Procesar imagen (detección de rostros, pixelado, resize, upload a S3)
+	s3URL, err := media.ProcessImage(path, "incidents")
+	if err != nil {
+		return fmt.Errorf("processing image for incident %d: %w", p.InreID, err)
+	}
+
+	if p.InmeID != 0 {
+		if err := repo.UpdateMediaReady(p.InmeID, s3URL); err != nil {
+			return fmt.Errorf("updating media %d for incident %d: %w", p.InmeID, p.InreID, err)
+		}
+	}
+
+	// La imagen 0 sigue siendo la portada en media_url para clientes antiguos
+	if p.Position == 0 {
+		if err := repo.UpdateIncidentMediaPath(p.InreID, s3URL); err != nil {
+			return fmt.Errorf("updating incident media URL for %d: %w", p.InreID, err)
+		}
+
+		if p.InclID != 0 {
+			if err := repo.UpdateClusterMediaPath(p.InclID, s3URL, "image"); err != nil {
+				return fmt.Errorf("updating cluster media URL for %d: %w", p.InclID, err)
+			}
+		}
+	}
+
+	// Solo eliminamos el original cuando todo salió bien, para que los reintentos puedan usarlo
+	discardMedia(p.TmpFilePath, p.StagedKey)
+
+	fmt.Printf("✅ Image %d processed and updated for incident %d: %s\n", p.Position, p.InreID, s3URL)
+	return nil
+}
+
+func processVideoJob(repo Repository, transcoder media.Transcoder, p videoPayload) error {
+	path, err := fetchMedia(p.TmpFilePath, p.StagedKey)
+	if isMissingMedia(err) {
+		if err := repo.MarkMediaFailed(p.InmeID, "original file is no longer available"); err != nil {
+			fmt.Printf("⚠️ Failed to mark media %d as failed: %v\n", p.InmeID, err)
+		}
+		return outbox.Permanent(fmt.Errorf("original file for incident %d no longer exists: %w", p.InreID, err))
+	}
+	if err != nil {
+		return fmt.Errorf("fetching original for incident %d: %w", p.InreID, err)
+	}
+	if p.StagedKey != "" {
+		defer os.Remove(path)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), videoTranscodeTimeout)
+	defer cancel()
+
+	result, err := media.ProcessVideo(ctx, path, "incidents", transcoder)
+	if errors.Is(err, media.ErrInvalidVideo) {
+		// El video no cumple los límites: reintentar no sirve, se informa al autor vía incident_media
+		if markErr := repo.MarkMediaFailed(p.InmeID, err.Error()); markErr != nil {
+			fmt.Printf("⚠️ Failed to mark media %d as failed: %v\n", p.InmeID, markErr)
+		}
+		discardMedia(p.TmpFilePath, p.StagedKey)
+		return outbox.Permanent(fmt.Errorf("video for incident %d rejected: %w", p.InreID, err))
+	}
+	if errors.Is(err, media.ErrFFmpegNotFound) {
+		// Falta ffmpeg en esta instancia: ningún reintento va a funcionar
+		if markErr := repo.MarkMediaFailed(p.InmeID, "video processing is unavailable"); markErr != nil {
+			fmt.Printf("⚠️ Failed to mark media %d as failed: %v\n", p.InmeID, markErr)
+		}
+		discardMedia(p.TmpFilePath, p.StagedKey)
+		return outbox.Permanent(fmt.Errorf("processing video for incident %d: %w", p.InreID, err))
+	}
+	if err != nil {
+		return fmt.Errorf("processing video for incident %d: %w", p.InreID, err)
+	}
+
+	if err := repo.UpdateVideoReady(p.InmeID, result.URL, result.PosterURL, result.Info.Duration.Milliseconds()); err != nil {
+		return fmt.Errorf("updating media %d for incident %d: %w", p.InmeID, p.InreID, err)
+	}
+	if err := repo.UpdateIncidentMediaPath(p.InreID, result.URL); err != nil {
+		return fmt.Errorf("updating incident media URL for %d: %w", p.InreID, err)
+	}
+	if p.InclID != 0 {
+		if err := repo.UpdateClusterMediaPath(p.InclID, result.URL, "video"); err != nil {
+			return fmt.Errorf("updating cluster media URL for %d: %w", p.InclID, err)
+		}
+	}
+
+	discardMedia(p.TmpFilePath, p.StagedKey)
+
+	fmt.Printf("✅ Video processed and updated for incident %d: %s\n", p.InreID, result.URL)
+	return nil
+}
+
+// fetchMedia devuelve un path local al original: lo descarga de R2 si se subió a staging (el job puede
+// correr en otra instancia) o usa el archivo local del upload.
+func fetchMedia(tmpPath, stagedKey string) (string, error) {
+	if stagedKey != "" {
+		return media.FetchStaged(stagedKey, uploadDir())
+	}
+	if _, err := os.Stat(tmpPath); err != nil {
+		return "", err
+	}
+	return tmpPath, nil
+}
+
+func isMissingMedia(err error) bool {
+	return errors.Is(err, os.ErrNotExist) || errors.Is(err, media.ErrStagedNotFound)
+}
+
+// abandonMedia se llama cuando el outbox agotó los intentos: el media queda como fallido (la app deja de
+// mostrar "processing") y se borra el original, que ya nadie va a leer.
+func abandonMedia(repo Repository, inmeID int64, tmpPath, stagedKey string, err error) {
+	if inmeID != 0 {
+		if markErr := repo.MarkMediaFailed(inmeID, "processing failed after several attempts"); markErr != nil {
+			fmt.Printf("⚠️ Failed to mark media %d as failed: %v\n", inmeID, markErr)
+		}
+	}
+	fmt.Printf("💀 Giving up on media %d: %v\n", inmeID, err)
+	discardMedia(tmpPath, stagedKey)
+}
+
+// discardMedia borra el original (local y en R2) cuando ya no se va a reintentar
+func discardMedia(tmpPath, stagedKey string) {
+	if stagedKey != "" {
+		if err := media.DeleteStaged(stagedKey); err != nil {
+			fmt.Printf("⚠️ Failed to remove staged upload %s: %v\n", stagedKey, err)
+		}
+	}
+	if err := os.Remove(tmpPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("⚠️ Failed to remove temp file %s: %v\n", tmpPath, err)
+	}
+}
@@ -7,29 +7,31 @@ type Media struct {
 }
 
 type IncidentReport struct {
-	InreId             int64   `form:"inre_id"          json:"inre_id"`
-	AccountId          int64   `form:"account_id"       json:"account_id"`
-	InclId             int64   `form:"incl_id"          json:"incl_id"`
-	InsuId             int64   `form:"insu_id"          json:"insu_id"`
-	EventType          string  `form:"event_type"       json:"event_type"`
-	Description        string  `form:"description"      json:"description"`
-	Address            string  `form:"address"          json:"address"`
-	City               string  `form:"city"             json:"city"`
-	Province           string  `form:"province"         json:"province"`
-	PostalCode         string  `form:"postal_code"      json:"postal_code"`
-	Latitude           float64 `form:"latitude"         json:"latitude"`
-	Longitude          float64 `form:"longitude"        json:"longitude"`
-	IsAnonymous        bool    `form:"is_anonymous"     json:"is_anonymous"`
-	SubCategoryName    string  `form:"subcategory_name" json:"subcategory_name"`
-	Media              Media   `form:"media"            json:"media"`
-	MediaType          string  `form:"media_type"       json:"media_type"`
-	DefaultCircleRange int     `form:"default_circle_range" json:"default_circle_range"`
-	MediaUrl           string  `form:"media_url"        json:"media_url"`
-	SubcategoryCode    string  `form:"subcategory_code" json:"subcategory_code"`
-	CategoryCode       string  `form:"category_code"    json:"category_code"`
-	Vote               *bool   `form:"vote,omitempty"   json:"vote,omitempty"`
-	Credibility        float32 `form:"credibility"      json:"credibility"`
-	TmpFilePath        string  `form:"-"                json:"-"` // ⚡ Path temporal para procesamiento asíncrono
+	InreId             int64    `form:"inre_id"          json:"inre_id"`
+	AccountId          int64    `form:"account_id"       json:"account_id"`
+	InclId             int64    `form:"incl_id"          json:"incl_id"`
+	InsuId             int64    `form:"insu_id"          json:"insu_id"`
+	EventType          string   `form:"event_type"       json:"event_type"`
+	Description        string   `form:"description"      json:"description"`
+	Address            string   `form:"address"          json:"address"`
+	City               string   `form:"city"             json:"city"`
+	Province           string   `form:"province"         json:"province"`
+	PostalCode         string   `form:"postal_code"      json:"postal_code"`
+	Latitude           float64  `form:"latitude"         json:"latitude"`
+	Longitude          float64  `form:"longitude"        json:"longitude"`
+	IsAnonymous        bool     `form:"is_anonymous"     json:"is_anonymous"`
+	SubCategoryName    string   `form:"subcategory_name" json:"subcategory_name"`
+	Media              Media    `form:"media"            json:"media"`
+	MediaType          string   `form:"media_type"       json:"media_type"`
+	DefaultCircleRange int      `form:"default_circle_range" json:"default_circle_range"`
+	MediaUrl           string   `form:"media_url"        json:"media_url"`
+	SubcategoryCode    string   `form:"subcategory_code" json:"subcategory_code"`
+	CategoryCode       string   `form:"category_code"    json:"category_code"`
+	Vote               *bool    `form:"vote,omitempty"   json:"vote,omitempty"`
+	Credibility        float32  `form:"credibility"      json:"credibility"`
+	TmpFilePaths       []string `form:"-"               json:"-"`                       // ⚡ Paths temporales para procesamiento asíncrono, en orden (0 = portada)
+	StagedKeys         []string `form:"-"               json:"-"`                       // keys en R2 de los mismos originales (vacío si no se pudieron subir)
+	JoinInclId         int64    `form:"join_incl_id"     json:"join_incl_id,omitempty"` // cluster sugerido que el usuario eligió unirse (ver SuggestClusters)
 }
 
 type Cluster struct {
@@ -57,3 +59,105 @@ type Cluster struct {
 	ScoreTrue       float32    `json:"score_true"`
 	ScoreFalse      float32    `json:"score_false"`
 }
+
+// ReportPoint es la ubicación de un incident_report junto con la credibilidad de su autor,
+// usada para recalcular el centro del cluster.
+type ReportPoint struct {
+	Latitude    float64
+	Longitude   float64
+	Credibility float64
+	CreatedAt   time.Time
+}
+
+// VoteInputs cambia (vote=true/false) o retira (vote=null) el voto de la cuenta en un cluster.
+type VoteInputs struct {
+	InclId int64 `json:"incl_id" binding:"required,min=1"`
+	Vote   *bool `json:"vote"`
+}
+
+// AccountVote es el voto vigente de una cuenta en un cluster.
+type AccountVote struct {
+	InreId      int64
+	Vote        bool
+	Credibility float64 // credibilidad con la que se aplicó el voto
+	VotedAt     time.Time
+}
+
+// ReportMedia es el estado de procesamiento de un archivo (imagen o video) de un incident_report.
+type ReportMedia struct {
+	InmeId       int64  `json:"inme_id"`
+	MediaType    string `json:"media_type"`
+	MediaUrl     string `json:"media_url"`
+	PosterUrl    string `json:"poster_url"`
+	DurationMs   int64  `json:"duration_ms"`
+	Status       string `json:"status"` // processing | ready | failed
+	ErrorMessage string `json:"error_message,omitempty"`
+	Position     int    `json:"position"`
+}
+
+// EditReportInputs: campos que el autor puede corregir. nil = sin cambios.
+type EditReportInputs struct {
+	InreId          int64   `json:"inre_id" binding:"required,min=1"`
+	Description     *string `json:"description"`
+	SubcategoryCode *string `json:"subcategory_code"`
+}
+
+// WithdrawReportInputs retira un report del autor.
+type WithdrawReportInputs struct {
+	InreId int64 `json:"inre_id" binding:"required,min=1"`
+}
+
+// OwnReport es el estado actual de un report, leído para validar una edición o retiro de su autor.
+type OwnReport struct {
+	InreId          int64
+	InclId          int64
+	AccountId       int64
+	Description     string
+	SubcategoryCode string
+	Vote            *bool
+	VoteCredibility float64
+	IsActive        bool
+	CreatedAt       time.Time
+}
+
+// Subcategory son los datos de incident_subcategories que se copian al report.
+type Subcategory struct {
+	InsuId       int64
+	Code         string
+	Name         string
+	CategoryCode string
+}
+
+// ReportEdit son los cambios ya validados que se aplican a un report.
+type ReportEdit struct {
+	Description *string
+	Subcategory *Subcategory
+}
+
+// SuggestionInputs: ubicación y tipo del report que el usuario está por enviar.
+type SuggestionInputs struct {
+	Latitude        float64 `form:"latitude" binding:"required,latitude"`
+	Longitude       float64 `form:"longitude" binding:"required,longitude"`
+	SubcategoryCode string  `form:"subcategory_code"`
+	Radius          float64 `form:"radius"` // metros; por defecto 300, máximo 2000
+}
+
+// ClusterSuggestion es un cluster activo cercano que podría ser el mismo evento.
+type ClusterSuggestion struct {
+	InclId                int64     `json:"incl_id"`
+	CategoryCode          string    `json:"category_code"`
+	SubcategoryCode       string    `json:"subcategory_code"`
+	SubcategoryName       string    `json:"subcategory_name"`
+	Description           string    `json:"description"`
+	Address               string    `json:"address"`
+	MediaUrl              string    `json:"media_url"`
+	MediaType             string    `json:"media_type"`
+	IncidentCount         int       `json:"incident_count"`
+	CenterLatitude        float64   `json:"center_latitude"`
+	CenterLongitude       float64   `json:"center_longitude"`
+	CreatedAt             time.Time `json:"created_at"`
+	DistanceMeters        float64   `json:"distance_meters"`
+	AgeMinutes            int       `json:"age_minutes"`
+	SubcategorySimilarity float64   `json:"subcategory_similarity"`
+	Score                 float64   `json:"score"`
+}
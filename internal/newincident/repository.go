@@ -1,27 +1,44 @@
 package newincident
 
 import (
+	"alertly/internal/clustertimeline"
 	"alertly/internal/common"
 	"alertly/internal/dbtypes"
+	"alertly/internal/outbox"
 	"database/sql"
 	"fmt"
+	"math"
 )
 
 type Repository interface {
 	CheckAndGetIfClusterExist(incident IncidentReport) (Cluster, error)
 	Save(incident IncidentReport) (int64, error)
 	SaveCluster(cluster Cluster, accountId int64) (int64, error)
-	UpdateClusterAsTrue(inclId int64, accountID int64, latitude, longitude float64) (sql.Result, error)
-	UpdateClusterAsFalse(inclId int64, accountID int64, latitude, longitude float64) (sql.Result, error)
+	UpdateClusterAsTrue(inclId int64, accountID int64) (sql.Result, error)
+	UpdateClusterAsFalse(inclId int64, accountID int64) (sql.Result, error)
+	GetAccountVote(inclId, accountID int64) (AccountVote, error)
+	ChangeVote(inclId, accountID int64, current AccountVote, newVote *bool) error
 	// SaveAsUpdate(incident IncidentReport) error
 	HasAccountVoted(inclID, accountID int64) (bool, bool, error)
-	UpdateClusterLocation(inclId int64, latitude, longitude float64) (sql.Result, error)
+	// ✅ Centro del cluster ponderado por credibilidad y antigüedad de los reports
+	GetClusterReportPoints(inclId int64) ([]ReportPoint, error)
+	UpdateClusterCenter(inclId int64, latitude, longitude float64) error
 	// ✅ NUEVOS MÉTODOS: Para geocoding asíncrono
 	UpdateClusterAddress(inclId int64, address, city, province, postalCode string) error
 	UpdateIncidentAddress(inreId int64, address, city, province, postalCode string) error
 	// ✅ NUEVOS MÉTODOS: Para procesamiento asíncrono de imágenes
 	UpdateIncidentMediaPath(inreId int64, mediaPath string) error
-	UpdateClusterMediaPath(inclId int64, mediaPath string) error
+	UpdateClusterMediaPath(inclId int64, mediaPath, mediaType string) error
+	UpdateMediaReady(inmeId int64, mediaUrl string) error
+	UpdateVideoReady(inmeId int64, mediaUrl, posterUrl string, durationMs int64) error
+	MarkMediaFailed(inmeId int64, reason string) error
+	GetReportMedia(inreId, accountID int64) ([]ReportMedia, error)
+	GetOwnReport(inreId, accountID int64) (OwnReport, error)
+	FindNearbyActiveClusters(latitude, longitude, radius float64, limit int) ([]ClusterSuggestion, error)
+	IsClusterJoinable(inclId int64, latitude, longitude, radius float64) (bool, error)
+	GetSubcategory(code string) (Subcategory, error)
+	EditReport(report OwnReport, edit ReportEdit) error
+	WithdrawReport(report OwnReport) (clusterClosed bool, err error)
 	GetDurationForSubcategory(subcategoryCode string) (int, error)
 }
 
@@ -88,8 +105,9 @@ func (r *pgRepository) CheckAndGetIfClusterExist(incident IncidentReport) (Clust
 
 /*
 Guarda un incidente del cluster. Basicamente es una actualizacion del seguimiento del cluster de una persona que ya ha votado o haya creado el cluster.
+Los side effects (score, notificación, contador, geocoding e imagen) se encolan en incident_outbox dentro de la misma transacción.
 */
-func (r *pgRepository) Save(incident IncidentReport) (int64, error) {
+func (r *pgRepository) Save(incident IncidentReport) (id int64, err error) {
 
 	// Determinar el valor del voto para la base de datos
 	var voteValue interface{}
@@ -103,9 +121,19 @@ func (r *pgRepository) Save(incident IncidentReport) (int64, error) {
 		voteValue = nil // No es un voto
 	}
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
 	query := "INSERT INTO incident_reports(account_id, insu_id, incl_id, description, event_type, address, city, province, postal_code, latitude, longitude, subcategory_name, is_anonymous, media_url, subcategory_code, category_code, vote, created_at) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, NOW()) RETURNING inre_id"
-	var id int64
-	err := r.db.QueryRow(query,
+	err = tx.QueryRow(query,
 		incident.AccountId,
 		incident.InsuId,
 		incident.InclId,
@@ -129,27 +157,22 @@ func (r *pgRepository) Save(incident IncidentReport) (int64, error) {
 		return 0, fmt.Errorf("failed to insert incident report: %w", err)
 	}
 
-	// ⚡ OPTIMIZACIÓN: SaveScore y SaveNotification asíncronos (no bloquean respuesta)
-	go func(accountID int64, inclID int64, reportID int64) {
-		// ----------------------CITIZEN SCORE-----------------------
-		if err := common.SaveScore(r.db, accountID, 20); err != nil {
-			fmt.Printf("⚠️ Error saving score for account %d: %v\n", accountID, err)
+	// Guardar la credibilidad con la que se aplicó el voto para poder revertirlo exactamente (ChangeVote)
+	if incident.Vote != nil {
+		_, err = tx.Exec(`UPDATE incident_reports SET vote_credibility = (SELECT credibility FROM account WHERE account_id = $1), vote_updated_at = NOW() WHERE inre_id = $2`,
+			incident.AccountId, id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to save vote credibility: %w", err)
 		}
+	}
 
-		// ----------------------NOTIFICATION-----------------------
-		// Si el incident tiene incl_id != 0, significa que se está agregando a un cluster existente
-		if inclID != 0 {
-			// Es un update/report adicional a un cluster existente
-			if err := common.SaveNotification(r.db, "new_incident_cluster", accountID, inclID); err != nil {
-				fmt.Printf("⚠️ Error saving notification (cluster update) for account %d: %v\n", accountID, err)
-			}
-		} else {
-			// Es un cluster completamente nuevo
-			if err := common.SaveNotification(r.db, "new_cluster", accountID, reportID); err != nil {
-				fmt.Printf("⚠️ Error saving notification (new cluster) for account %d: %v\n", accountID, err)
-			}
-		}
-	}(incident.AccountId, incident.InclId, id)
+	if err = enqueueReportJobs(tx, incident, id); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit incident report: %w", err)
+	}
 
 	return id, nil
 }
@@ -243,65 +266,137 @@ func (r *pgRepository) SaveCluster(cluster Cluster, accountID int64) (int64, err
 		return 0, err
 	}
 
-	// ⚡ OPTIMIZACIÓN: SaveScore y SaveNotification asíncronos
-	// Nota: Se ejecutan fuera de la transacción para no bloquear el commit
-	go func(accountID int64, clusterID int64) {
-		// ----------------------CITIZEN SCORE-----------------------
-		if err := common.SaveScore(r.db, accountID, 20); err != nil {
-			fmt.Printf("⚠️ Error saving score for account %d: %v\n", accountID, err)
-		}
-
-		// ----------------------NOTIFICATION-----------------------
-		if err := common.SaveNotification(r.db, "new_cluster", accountID, clusterID); err != nil {
-			fmt.Printf("⚠️ Error saving notification for cluster %d: %v\n", clusterID, err)
-		}
-
-		fmt.Printf("✅ Score and notification saved for cluster %d\n", clusterID)
-	}(accountID, id)
+	// ----------------------CITIZEN SCORE + NOTIFICATION (outbox)-----------------------
+	if err = outbox.Enqueue(tx, JobSaveScore, scorePayload{AccountID: accountID, Points: 20}); err != nil {
+		return 0, err
+	}
+	if err = outbox.Enqueue(tx, JobSaveNotification, notificationPayload{Type: "new_cluster", AccountID: accountID, ReferenceID: id}); err != nil {
+		return 0, err
+	}
 
 	fmt.Printf("incidente creado con ID: %d\n", id)
 	return id, nil
 }
 
-// -- Actualiza la localizacion del cluster cuando se crea un incidente nuevo del cluster. Ya que el incidente nuevo no necesariamente tiene que esta ubicada en las coordenadas exactas del cluster. Por eso actualiza.
-func (r *pgRepository) UpdateClusterAsTrue(inclId int64, accountID int64, latitude, longitude float64) (sql.Result, error) {
-	query := `
-	UPDATE incident_clusters ic
-	SET
-	center_latitude      = (ic.center_latitude + $2) / 2,
-	center_longitude     = (ic.center_longitude + $3) / 2,
-	center_location      = ST_SetSRID(ST_MakePoint(((ic.center_longitude + $3) / 2)::float8, ((ic.center_latitude + $2) / 2)::float8), 4326)::geography,
-	counter_total_votes  = ic.counter_total_votes + 1,
-	score_true           = ic.score_true + (SELECT credibility FROM account WHERE account_id = $1),
-	score_false          = ic.score_false + (10 - (SELECT credibility FROM account WHERE account_id = $1)),
-	credibility          = ic.score_true
-								/ GREATEST(ic.score_true + ic.score_false, 1)
-								* 10
-	WHERE ic.incl_id = $4;
-	`
+// -- Aplica un voto positivo al cluster. La ubicación del cluster se recalcula en Service.RecomputeClusterCenter una vez guardado el report.
+func (r *pgRepository) UpdateClusterAsTrue(inclId int64, accountID int64) (sql.Result, error) {
+	credibility, err := r.accountCredibility(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return applyVoteDelta(r.db, inclId, voteContribution(true, credibility))
+}
 
-	result, err := r.db.Exec(query, accountID, latitude, longitude, inclId)
-	return result, err
+func (r *pgRepository) UpdateClusterAsFalse(inclId int64, accountID int64) (sql.Result, error) {
+	credibility, err := r.accountCredibility(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return applyVoteDelta(r.db, inclId, voteContribution(false, credibility))
 }
 
-func (r *pgRepository) UpdateClusterAsFalse(inclId int64, accountID int64, latitude, longitude float64) (sql.Result, error) {
+func (r *pgRepository) accountCredibility(accountID int64) (float64, error) {
+	var credibility float64
+	err := r.db.QueryRow("SELECT COALESCE(credibility, 5) FROM account WHERE account_id = $1", accountID).Scan(&credibility)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get account credibility: %w", err)
+	}
+	return credibility, nil
+}
+
+// applyVoteDelta suma (o resta) un voto a los contadores del cluster y recalcula credibility con los valores NUEVOS
+func applyVoteDelta(dbExec common.DBExecutor, inclId int64, d voteDelta) (sql.Result, error) {
 	query := `
 	UPDATE incident_clusters ic
 	SET
-	center_latitude      = (ic.center_latitude + $2) / 2,
-	center_longitude     = (ic.center_longitude + $3) / 2,
-	center_location      = ST_SetSRID(ST_MakePoint(((ic.center_longitude + $3) / 2)::float8, ((ic.center_latitude + $2) / 2)::float8), 4326)::geography,
-	counter_total_votes  = ic.counter_total_votes + 1,
-	score_true           = ic.score_true + (10 - (SELECT credibility FROM account WHERE account_id = $1)),
-	score_false          = ic.score_false + (SELECT credibility FROM account WHERE account_id = $1),
-	credibility          = ic.score_true
-								/ GREATEST(ic.score_true + ic.score_false, 1)
+	counter_total_votes       = GREATEST(COALESCE(ic.counter_total_votes, 0) + $2, 0),
+	counter_total_votes_true  = GREATEST(COALESCE(ic.counter_total_votes_true, 0) + $3, 0),
+	counter_total_votes_false = GREATEST(COALESCE(ic.counter_total_votes_false, 0) + $4, 0),
+	score_true                = GREATEST(COALESCE(ic.score_true, 0) + $5, 0),
+	score_false               = GREATEST(COALESCE(ic.score_false, 0) + $6, 0),
+	credibility               = GREATEST(COALESCE(ic.score_true, 0) + $5, 0)
+								/ GREATEST(GREATEST(COALESCE(ic.score_true, 0) + $5, 0) + GREATEST(COALESCE(ic.score_false, 0) + $6, 0), 1)
 								* 10
-	WHERE ic.incl_id = $4;
+	WHERE ic.incl_id = $1;
 	`
+	return dbExec.Exec(query, inclId, d.Votes, d.VotesTrue, d.VotesFalse, d.ScoreTrue, d.ScoreFalse)
+}
 
-	result, err := r.db.Exec(query, accountID, latitude, longitude, inclId)
-	return result, err
+// GetAccountVote devuelve el voto vigente de la cuenta en el cluster (sql.ErrNoRows si no ha votado).
+// Para votos anteriores a vote_credibility se usa la credibilidad actual de la cuenta.
+func (r *pgRepository) GetAccountVote(inclId, accountID int64) (AccountVote, error) {
+	query := `
+	SELECT ir.inre_id, ir.vote, COALESCE(ir.vote_credibility, a.credibility, 5), COALESCE(ir.created_at, NOW())
+	FROM incident_reports ir
+	LEFT JOIN account a ON a.account_id = ir.account_id
+	WHERE ir.incl_id = $1 AND ir.account_id = $2 AND ir.vote IS NOT NULL
+	ORDER BY ir.created_at DESC
+	LIMIT 1`
+	var v AccountVote
+	var vote int64
+	err := r.db.QueryRow(query, inclId, accountID).Scan(&v.InreId, &vote, &v.Credibility, &v.VotedAt)
+	if err != nil {
+		return AccountVote{}, err
+	}
+	v.Vote = vote == 1
+	return v, nil
+}
+
+// ChangeVote revierte el voto actual en los contadores del cluster y aplica el nuevo (nil = retirar) en una transacción.
+// Devuelve ErrVoteConflict si el voto cambió entre la lectura y la escritura.
+func (r *pgRepository) ChangeVote(inclId, accountID int64, current AccountVote, newVote *bool) (err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var isActive bool
+	err = tx.QueryRow(`SELECT is_active = '1' AND end_time >= NOW() FROM incident_clusters WHERE incl_id = $1 FOR UPDATE`, inclId).Scan(&isActive)
+	if err != nil {
+		return fmt.Errorf("locking cluster: %w", err)
+	}
+	if !isActive {
+		err = ErrClusterClosed
+		return err
+	}
+
+	var credibility float64
+	if err = tx.QueryRow("SELECT COALESCE(credibility, 5) FROM account WHERE account_id = $1", accountID).Scan(&credibility); err != nil {
+		return fmt.Errorf("failed to get account credibility: %w", err)
+	}
+
+	var voteValue, voteCredibility interface{}
+	if newVote != nil {
+		voteValue = 0
+		if *newVote {
+			voteValue = 1
+		}
+		voteCredibility = credibility
+	}
+
+	res, err := tx.Exec(`UPDATE incident_reports SET vote = $1, vote_credibility = $2, vote_updated_at = NOW() WHERE inre_id = $3 AND vote = $4`,
+		voteValue, voteCredibility, current.InreId, dbtypes.BoolToInt(current.Vote))
+	if err != nil {
+		return fmt.Errorf("updating report vote: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		err = ErrVoteConflict
+		return err
+	}
+
+	if _, err = applyVoteDelta(tx, inclId, changeVoteDelta(current.Vote, current.Credibility, newVote, credibility)); err != nil {
+		return fmt.Errorf("recomputing cluster votes: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit vote change: %w", err)
+	}
+	return nil
 }
 
 // func (r *pgRepository) SaveAsUpdate(incident IncidentReport) error {
@@ -425,16 +520,65 @@ func (r *pgRepository) HasAccountVoted(inclID, accountID int64) (bool, bool, err
 	return true, voteVal.Int64 == 1, nil
 }
 
-func (r *pgRepository) UpdateClusterLocation(inclId int64, latitude, longitude float64) (sql.Result, error) {
+// GetClusterReportPoints devuelve la ubicación de cada report activo del cluster con la credibilidad de su autor
+func (r *pgRepository) GetClusterReportPoints(inclId int64) ([]ReportPoint, error) {
+	query := `
+    SELECT
+      ir.latitude,
+      ir.longitude,
+      COALESCE(a.credibility, 5),
+      COALESCE(ir.created_at, NOW())
+    FROM incident_reports ir
+    LEFT JOIN account a ON a.account_id = ir.account_id
+    WHERE ir.incl_id = $1
+      AND ir.is_active = '1'
+      AND ir.latitude IS NOT NULL
+      AND ir.longitude IS NOT NULL;
+	`
+	rows, err := r.db.Query(query, inclId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report points for cluster %d: %w", inclId, err)
+	}
+	defer rows.Close()
+
+	var points []ReportPoint
+	for rows.Next() {
+		var p ReportPoint
+		if err := rows.Scan(&p.Latitude, &p.Longitude, &p.Credibility, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// UpdateClusterCenter fija el centro del cluster manteniendo center_location sincronizado
+func (r *pgRepository) UpdateClusterCenter(inclId int64, latitude, longitude float64) error {
+	// El subquery ve el centro anterior (snapshot previo al UPDATE), así sabemos cuánto se movió
 	query := `
     UPDATE incident_clusters
     SET
-      center_latitude  = (center_latitude  + $1) / 2,
-      center_longitude = (center_longitude + $2) / 2,
-      center_location  = ST_SetSRID(ST_MakePoint(((center_longitude + $2) / 2)::float8, ((center_latitude + $1) / 2)::float8), 4326)::geography
-    WHERE incl_id = $3;
+      center_latitude  = $1,
+      center_longitude = $2,
+      center_location  = ST_SetSRID(ST_MakePoint($2::float8, $1::float8), 4326)::geography
+    WHERE incl_id = $3
+    RETURNING COALESCE(ST_Distance(
+      (SELECT center_location FROM incident_clusters WHERE incl_id = $3),
+      ST_SetSRID(ST_MakePoint($2::float8, $1::float8), 4326)::geography
+    ), 0);
 	`
-	return r.db.Exec(query, latitude, longitude, inclId)
+	var movedMeters float64
+	if err := r.db.QueryRow(query, latitude, longitude, inclId).Scan(&movedMeters); err != nil {
+		return err
+	}
+
+	if movedMeters >= locationMovedEventMeters {
+		details := map[string]interface{}{"latitude": latitude, "longitude": longitude, "moved_meters": math.Round(movedMeters)}
+		if err := clustertimeline.RecordEvent(r.db, inclId, clustertimeline.EventLocationMoved, 0, details); err != nil {
+			fmt.Printf("⚠️ %v\n", err)
+		}
+	}
+	return nil
 }
 
 // ✅ NUEVOS MÉTODOS: Para geocoding asíncrono
@@ -450,8 +594,15 @@ func (r *pgRepository) UpdateClusterAddress(inclId int64, address, city, provinc
     WHERE incl_id = $5;
 	`
 
-	_, err := r.db.Exec(query, address, city, province, postalCode, inclId)
-	return err
+	if _, err := r.db.Exec(query, address, city, province, postalCode, inclId); err != nil {
+		return err
+	}
+
+	details := map[string]interface{}{"address": address, "city": city, "province": province}
+	if err := clustertimeline.RecordEvent(r.db, inclId, clustertimeline.EventAddressResolved, 0, details); err != nil {
+		fmt.Printf("⚠️ %v\n", err)
+	}
+	return nil
 }
 
 func (r *pgRepository) UpdateIncidentAddress(inreId int64, address, city, province, postalCode string) error {
@@ -483,18 +634,324 @@ func (r *pgRepository) UpdateIncidentMediaPath(inreId int64, mediaPath string) e
 	return err
 }
 
-func (r *pgRepository) UpdateClusterMediaPath(inclId int64, mediaPath string) error {
+func (r *pgRepository) UpdateClusterMediaPath(inclId int64, mediaPath, mediaType string) error {
 	query := `
     UPDATE incident_clusters
     SET
-      media_url = $1
-    WHERE incl_id = $2;
+      media_url = $1,
+      media_type = $2
+    WHERE incl_id = $3;
 	`
 
-	_, err := r.db.Exec(query, mediaPath, inclId)
+	_, err := r.db.Exec(query, mediaPath, mediaType, inclId)
 	return err
 }
 
+// insertMediaPlaceholder crea la fila de incident_media en 'processing' dentro de la transacción del report
+func insertMediaPlaceholder(tx *sql.Tx, inreId int64, position int, mediaType string) (int64, error) {
+	var inmeId int64
+	err := tx.QueryRow(`INSERT INTO incident_media (inre_id, media_type, status, position) VALUES ($1, $2, 'processing', $3) RETURNING inme_id`,
+		inreId, mediaType, position).Scan(&inmeId)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert incident media: %w", err)
+	}
+	return inmeId, nil
+}
+
+func (r *pgRepository) UpdateMediaReady(inmeId int64, mediaUrl string) error {
+	_, err := r.db.Exec(`UPDATE incident_media SET media_url = $1, status = 'ready', updated_at = NOW() WHERE inme_id = $2`, mediaUrl, inmeId)
+	return err
+}
+
+func (r *pgRepository) UpdateVideoReady(inmeId int64, mediaUrl, posterUrl string, durationMs int64) error {
+	_, err := r.db.Exec(`UPDATE incident_media SET media_url = $1, poster_url = NULLIF($2, ''), duration_ms = $3, status = 'ready', updated_at = NOW() WHERE inme_id = $4`,
+		mediaUrl, posterUrl, durationMs, inmeId)
+	return err
+}
+
+func (r *pgRepository) MarkMediaFailed(inmeId int64, reason string) error {
+	if len(reason) > 255 {
+		reason = reason[:255]
+	}
+	_, err := r.db.Exec(`UPDATE incident_media SET status = 'failed', error_message = $1, updated_at = NOW() WHERE inme_id = $2`, reason, inmeId)
+	return err
+}
+
+// GetReportMedia devuelve el estado de procesamiento de cada archivo del report, solo para su autor
+func (r *pgRepository) GetReportMedia(inreId, accountID int64) ([]ReportMedia, error) {
+	query := `
+	SELECT m.inme_id, m.media_type, COALESCE(m.media_url, ''), COALESCE(m.poster_url, ''),
+		COALESCE(m.duration_ms, 0), m.status, COALESCE(m.error_message, ''), m.position
+	FROM incident_media m
+	INNER JOIN incident_reports r ON r.inre_id = m.inre_id
+	WHERE m.inre_id = $1 AND r.account_id = $2
+	ORDER BY m.position`
+	rows, err := r.db.Query(query, inreId, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query report media: %w", err)
+	}
+	defer rows.Close()
+
+	var items []ReportMedia
+	for rows.Next() {
+		var m ReportMedia
+		if err := rows.Scan(&m.InmeId, &m.MediaType, &m.MediaUrl, &m.PosterUrl, &m.DurationMs, &m.Status, &m.ErrorMessage, &m.Position); err != nil {
+			return nil, fmt.Errorf("failed to scan report media: %w", err)
+		}
+		items = append(items, m)
+	}
+	return items, rows.Err()
+}
+
+// FindNearbyActiveClusters devuelve los clusters activos a menos de radius metros, de cualquier subcategoría,
+// ordenados por distancia. Usa el mismo ST_DWithin (índice GiST) que CheckAndGetIfClusterExist.
+func (r *pgRepository) FindNearbyActiveClusters(latitude, longitude, radius float64, limit int) ([]ClusterSuggestion, error) {
+	query := `
+	SELECT
+		incl_id,
+		COALESCE(category_code, ''),
+		COALESCE(subcategory_code, ''),
+		COALESCE(subcategory_name, ''),
+		COALESCE(description, ''),
+		COALESCE(address, ''),
+		COALESCE(media_url, ''),
+		COALESCE(media_type, ''),
+		COALESCE(incident_count, 1),
+		center_latitude,
+		center_longitude,
+		COALESCE(created_at, NOW()),
+		ST_Distance(center_location, ST_MakePoint($1, $2)::geography) AS distance
+	FROM incident_clusters
+	WHERE is_active = '1'
+	  AND end_time >= NOW()
+	  AND ST_DWithin(center_location, ST_MakePoint($1, $2)::geography, $3)
+	ORDER BY distance
+	LIMIT $4`
+	rows, err := r.db.Query(query, longitude, latitude, radius, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nearby clusters: %w", err)
+	}
+	defer rows.Close()
+
+	var clusters []ClusterSuggestion
+	for rows.Next() {
+		var c ClusterSuggestion
+		if err := rows.Scan(&c.InclId, &c.CategoryCode, &c.SubcategoryCode, &c.SubcategoryName, &c.Description,
+			&c.Address, &c.MediaUrl, &c.MediaType, &c.IncidentCount, &c.CenterLatitude, &c.CenterLongitude,
+			&c.CreatedAt, &c.DistanceMeters); err != nil {
+			return nil, fmt.Errorf("failed to scan nearby cluster: %w", err)
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters, rows.Err()
+}
+
+// IsClusterJoinable: el cluster sigue activo y está a menos de radius metros de la ubicación del report.
+func (r *pgRepository) IsClusterJoinable(inclId int64, latitude, longitude, radius float64) (bool, error) {
+	var joinable bool
+	err := r.db.QueryRow(`
+	SELECT EXISTS (
+		SELECT 1 FROM incident_clusters
+		WHERE incl_id = $1
+		  AND is_active = '1'
+		  AND end_time >= NOW()
+		  AND ST_DWithin(center_location, ST_MakePoint($2, $3)::geography, $4)
+	)`, inclId, longitude, latitude, radius).Scan(&joinable)
+	return joinable, err
+}
+
+// GetOwnReport devuelve el report solo si pertenece a accountID (sql.ErrNoRows si no).
+// Como en GetAccountVote, un voto anterior a vote_credibility usa la credibilidad actual de la cuenta.
+func (r *pgRepository) GetOwnReport(inreId, accountID int64) (OwnReport, error) {
+	query := `
+	SELECT ir.inre_id, COALESCE(ir.incl_id, 0), ir.account_id, COALESCE(ir.description, ''), COALESCE(ir.subcategory_code, ''),
+		ir.vote, COALESCE(ir.vote_credibility, a.credibility, 5), COALESCE(ir.is_active, '1') = '1' AND ir.withdrawn_at IS NULL, COALESCE(ir.created_at, NOW())
+	FROM incident_reports ir
+	LEFT JOIN account a ON a.account_id = ir.account_id
+	WHERE ir.inre_id = $1 AND ir.account_id = $2`
+	var report OwnReport
+	var vote sql.NullInt64
+	err := r.db.QueryRow(query, inreId, accountID).Scan(
+		&report.InreId, &report.InclId, &report.AccountId, &report.Description, &report.SubcategoryCode,
+		&vote, &report.VoteCredibility, &report.IsActive, &report.CreatedAt,
+	)
+	if err != nil {
+		return OwnReport{}, err
+	}
+	if vote.Valid {
+		v := vote.Int64 == 1
+		report.Vote = &v
+	}
+	return report, nil
+}
+
+func (r *pgRepository) GetSubcategory(code string) (Subcategory, error) {
+	var sub Subcategory
+	err := r.db.QueryRow(`SELECT insu_id, code, COALESCE(name, ''), COALESCE(category_code, '') FROM incident_subcategories WHERE code = $1`, code).
+		Scan(&sub.InsuId, &sub.Code, &sub.Name, &sub.CategoryCode)
+	return sub, err
+}
+
+// EditReport aplica la edición y la registra en incident_report_edits. Si el report es el único activo
+// de su cluster, la descripción y la subcategoría se copian también al cluster.
+func (r *pgRepository) EditReport(report OwnReport, edit ReportEdit) (err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	newDescription := report.Description
+	if edit.Description != nil {
+		newDescription = *edit.Description
+	}
+	newSubcategoryCode := report.SubcategoryCode
+
+	res, err := tx.Exec(`UPDATE incident_reports SET description = $1, edited_at = NOW() WHERE inre_id = $2 AND COALESCE(is_active, '1') = '1' AND withdrawn_at IS NULL`,
+		newDescription, report.InreId)
+	if err != nil {
+		return fmt.Errorf("updating report: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		err = ErrReportWithdrawn
+		return err
+	}
+
+	if edit.Subcategory != nil {
+		newSubcategoryCode = edit.Subcategory.Code
+		_, err = tx.Exec(`UPDATE incident_reports SET insu_id = $1, subcategory_code = $2, subcategory_name = $3, category_code = $4 WHERE inre_id = $5`,
+			edit.Subcategory.InsuId, edit.Subcategory.Code, edit.Subcategory.Name, edit.Subcategory.CategoryCode, report.InreId)
+		if err != nil {
+			return fmt.Errorf("updating report subcategory: %w", err)
+		}
+	}
+
+	if report.InclId != 0 {
+		var activeReports int
+		err = tx.QueryRow(`SELECT COUNT(*) FROM incident_reports WHERE incl_id = $1 AND COALESCE(is_active, '1') = '1' AND withdrawn_at IS NULL`, report.InclId).Scan(&activeReports)
+		if err != nil {
+			return fmt.Errorf("counting cluster reports: %w", err)
+		}
+		if activeReports == 1 {
+			if _, err = tx.Exec(`UPDATE incident_clusters SET description = $1 WHERE incl_id = $2`, newDescription, report.InclId); err != nil {
+				return fmt.Errorf("updating cluster description: %w", err)
+			}
+			if edit.Subcategory != nil {
+				_, err = tx.Exec(`UPDATE incident_clusters SET insu_id = $1, subcategory_code = $2, subcategory_name = $3, category_code = $4 WHERE incl_id = $5`,
+					edit.Subcategory.InsuId, edit.Subcategory.Code, edit.Subcategory.Name, edit.Subcategory.CategoryCode, report.InclId)
+				if err != nil {
+					return fmt.Errorf("updating cluster subcategory: %w", err)
+				}
+			}
+		}
+	}
+
+	_, err = tx.Exec(`
+	INSERT INTO incident_report_edits (inre_id, account_id, action, old_description, new_description, old_subcategory_code, new_subcategory_code)
+	VALUES ($1, $2, 'edit', $3, $4, $5, $6)`,
+		report.InreId, report.AccountId, report.Description, newDescription, report.SubcategoryCode, newSubcategoryCode)
+	if err != nil {
+		return fmt.Errorf("saving edit history: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit report edit: %w", err)
+	}
+	return nil
+}
+
+// WithdrawReport desactiva el report, revierte su voto en los contadores del cluster y recalcula incident_count.
+// Si era el último report activo, el cluster se desactiva (clusterClosed = true).
+func (r *pgRepository) WithdrawReport(report OwnReport) (clusterClosed bool, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// Bloquear el cluster para que los votos concurrentes no pisen los contadores
+	if report.InclId != 0 {
+		if _, err = tx.Exec(`SELECT 1 FROM incident_clusters WHERE incl_id = $1 FOR UPDATE`, report.InclId); err != nil {
+			return false, fmt.Errorf("locking cluster: %w", err)
+		}
+	}
+
+	res, err := tx.Exec(`UPDATE incident_reports SET is_active = '0', withdrawn_at = NOW(), vote = NULL, vote_updated_at = NOW() WHERE inre_id = $1 AND COALESCE(is_active, '1') = '1' AND withdrawn_at IS NULL`,
+		report.InreId)
+	if err != nil {
+		return false, fmt.Errorf("withdrawing report: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		err = ErrReportWithdrawn
+		return false, err
+	}
+
+	var oldVote interface{}
+	if report.Vote != nil {
+		oldVote = dbtypes.BoolToInt(*report.Vote)
+	}
+	_, err = tx.Exec(`
+	INSERT INTO incident_report_edits (inre_id, account_id, action, old_description, old_subcategory_code, old_vote)
+	VALUES ($1, $2, 'withdraw', $3, $4, $5)`,
+		report.InreId, report.AccountId, report.Description, report.SubcategoryCode, oldVote)
+	if err != nil {
+		return false, fmt.Errorf("saving withdraw history: %w", err)
+	}
+
+	if _, err = tx.Exec(`UPDATE account SET counter_total_incidents_created = GREATEST(COALESCE(counter_total_incidents_created, 0) - 1, 0) WHERE account_id = $1`, report.AccountId); err != nil {
+		return false, fmt.Errorf("updating account counter: %w", err)
+	}
+
+	// Se devuelven los 20 puntos de citizen score que dio el report
+	if err = outbox.Enqueue(tx, JobSaveScore, scorePayload{AccountID: report.AccountId, Points: -20}); err != nil {
+		return false, err
+	}
+
+	if report.InclId != 0 {
+		if report.Vote != nil {
+			if _, err = applyVoteDelta(tx, report.InclId, changeVoteDelta(*report.Vote, report.VoteCredibility, nil, 0)); err != nil {
+				return false, fmt.Errorf("reverting report vote: %w", err)
+			}
+		}
+
+		var activeReports int
+		err = tx.QueryRow(`SELECT COUNT(*) FROM incident_reports WHERE incl_id = $1 AND COALESCE(is_active, '1') = '1' AND withdrawn_at IS NULL`, report.InclId).Scan(&activeReports)
+		if err != nil {
+			return false, fmt.Errorf("counting cluster reports: %w", err)
+		}
+
+		clusterClosed = activeReports == 0
+		_, err = tx.Exec(`
+		UPDATE incident_clusters
+		SET incident_count = $1,
+			is_active = CASE WHEN $1 = 0 THEN '0' ELSE is_active END
+		WHERE incl_id = $2`, activeReports, report.InclId)
+		if err != nil {
+			return false, fmt.Errorf("updating cluster counters: %w", err)
+		}
+
+		if clusterClosed {
+			details := map[string]interface{}{"reason": "last_report_withdrawn", "inre_id": report.InreId}
+			if err = clustertimeline.RecordEvent(tx, report.InclId, clustertimeline.EventClosed, report.AccountId, details); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit report withdraw: %w", err)
+	}
+	return clusterClosed, nil
+}
+
 func (r *pgRepository) GetDurationForSubcategory(subcategoryCode string) (int, error) {
 	var duration int
 	// Usamos el nombre de tabla correcto: incident_subcategories
@@ -1,13 +1,9 @@
 package newincident
 
 import (
-	"alertly/internal/common"
-	"alertly/internal/database"
-	"alertly/internal/media"
-	"alertly/internal/profile"
+	"alertly/internal/realtime"
 	"database/sql"
 	"fmt"
-	"os"
 	"time"
 )
 
@@ -17,6 +13,12 @@ const (
 
 type Service interface {
 	Save(incident IncidentReport) (IncidentReport, error)
+	RecomputeClusterCenter(inclId int64) error
+	ChangeVote(accountID int64, inputs VoteInputs) error
+	GetMediaStatus(inreID, accountID int64) ([]ReportMedia, error)
+	EditReport(accountID int64, inputs EditReportInputs) error
+	WithdrawReport(accountID, inreID int64) error
+	SuggestClusters(inputs SuggestionInputs) ([]ClusterSuggestion, error)
 }
 
 type service struct {
@@ -36,19 +38,26 @@ func (s *service) Save(incident IncidentReport) (IncidentReport, error) {
 	// 1) Guardar incidente inmediatamente con dirección temporal
 	addr, city, prov, postal := "Processing...", "Processing...", "Processing...", "..."
 
+	clusterCreated := false
+	voteApplied := false
+
 	// 2) **Si viene incl_id Y NO viene vote, es solo un update de posición**
-	if incident.InclId != 0 && incident.Vote == nil {
-		// actualizamos únicamente la ubicación del cluster
-		if _, err := s.repo.UpdateClusterLocation(
-			incident.InclId,
-			incident.Latitude,
-			incident.Longitude,
-		); err != nil {
-			return IncidentReport{}, fmt.Errorf("updating cluster location: %w", err)
+	// No se toca el cluster aquí: el centro se recalcula con todos los reports una vez guardado este (paso 5)
+	// ✅ FIX: En ese caso el InclId que viene del frontend se mantiene para la respuesta
+	if incident.JoinInclId != 0 {
+		// 2b) El usuario eligió unirse a un cluster sugerido (puede ser de otra subcategoría)
+		joinable, err := s.repo.IsClusterJoinable(incident.JoinInclId, incident.Latitude, incident.Longitude, maxSuggestionRadiusMeters)
+		if err != nil {
+			return IncidentReport{}, fmt.Errorf("checking cluster %d: %w", incident.JoinInclId, err)
 		}
-		// después seguimos a grabar el report
-		// ✅ FIX: Asegurar que el InclId se mantiene para la respuesta
-	} else {
+		if !joinable {
+			return IncidentReport{}, ErrClusterNotJoinable
+		}
+		incident.InclId = incident.JoinInclId
+		if voteApplied, err = s.applyReportVote(incident); err != nil {
+			return IncidentReport{}, err
+		}
+	} else if incident.InclId == 0 || incident.Vote != nil {
 		// 3) Lógica habitual de NUEVO CLUSTER o VOTO bayesiano
 		cluster, err := s.repo.CheckAndGetIfClusterExist(incident)
 		if err != nil && err != sql.ErrNoRows {
@@ -104,31 +113,12 @@ func (s *service) Save(incident IncidentReport) (IncidentReport, error) {
 			if err != nil {
 				return IncidentReport{}, err
 			}
+			clusterCreated = true
 		} else {
 			// existe → aplicamos voto si viene y no ha votado ya
-			voted, _, err := s.repo.HasAccountVoted(cluster.InclId, incident.AccountId)
-			if err != nil {
-				return IncidentReport{}, fmt.Errorf("checking vote history: %w", err)
-			}
-			if !voted && incident.Vote != nil {
-				if *incident.Vote {
-					_, err = s.repo.UpdateClusterAsTrue(
-						cluster.InclId,
-						incident.AccountId,
-						incident.Latitude,
-						incident.Longitude,
-					)
-				} else {
-					_, err = s.repo.UpdateClusterAsFalse(
-						cluster.InclId,
-						incident.AccountId,
-						incident.Latitude,
-						incident.Longitude,
-					)
-				}
-				if err != nil {
-					return IncidentReport{}, fmt.Errorf("update cluster vote: %w", err)
-				}
+			incident.InclId = cluster.InclId
+			if voteApplied, err = s.applyReportVote(incident); err != nil {
+				return IncidentReport{}, err
 			}
 		}
 		// nos aseguramos de fijar el clusterId para el report
@@ -154,80 +144,202 @@ func (s *service) Save(incident IncidentReport) (IncidentReport, error) {
 	}
 	incident.InreId = inreId
 
-	// ⚡ OPTIMIZACIÓN: UpdateTotalIncidents asíncrono (no bloquea respuesta)
-	go func(accountID int64) {
-		profSvc := profile.NewService(profile.NewRepository(database.DB))
-		if err := profSvc.UpdateTotalIncidents(accountID); err != nil {
-			fmt.Printf("⚠️ Error updating total incidents for account %d: %v\n", accountID, err)
-		}
-	}(incident.AccountId)
+	// 5) Recalcular el centro del cluster con todos sus reports (ponderado por credibilidad y antigüedad)
+	// El report ya está guardado: un fallo aquí solo deja el centro anterior
+	if err := s.RecomputeClusterCenter(incident.InclId); err != nil {
+		fmt.Printf("⚠️ Error recomputing center for cluster %d: %v\n", incident.InclId, err)
+	}
+
+	// ✅ Score, notificación, total de incidentes, geocoding e imagen quedan en incident_outbox
+	// dentro de la misma transacción del report; el dispatcher los ejecuta con reintentos.
 
-	// ✅ OPTIMIZACIÓN: Geocoding asíncrono en background
-	go func() {
-		// Esperar un poco para no sobrecargar Nominatim
-		time.Sleep(2 * time.Second)
+	// 📡 Streams abiertos sobre la zona
+	if clusterCreated {
+		realtime.PublishCluster(realtime.EventClusterCreated, incident.InclId)
+	} else {
+		realtime.PublishCluster(realtime.EventClusterUpdated, incident.InclId)
+	}
+	if voteApplied {
+		realtime.PublishCluster(realtime.EventVoteChanged, incident.InclId)
+	}
+
+	return incident, nil
+}
 
-		// Realizar geocoding
-		realAddr, realCity, realProv, realPostal, err := common.ReverseGeocode(incident.Latitude, incident.Longitude)
+// applyReportVote suma el voto del report a incident.InclId si viene y la cuenta no ha votado ya en ese cluster.
+// Devuelve si el voto se aplicó.
+func (s *service) applyReportVote(incident IncidentReport) (bool, error) {
+	voted, _, err := s.repo.HasAccountVoted(incident.InclId, incident.AccountId)
+	if err != nil {
+		return false, fmt.Errorf("checking vote history: %w", err)
+	}
+	if voted || incident.Vote == nil {
+		return false, nil
+	}
+	if *incident.Vote {
+		_, err = s.repo.UpdateClusterAsTrue(incident.InclId, incident.AccountId)
+	} else {
+		_, err = s.repo.UpdateClusterAsFalse(incident.InclId, incident.AccountId)
+	}
+	if err != nil {
+		return false, fmt.Errorf("update cluster vote: %w", err)
+	}
+	return true, nil
+}
+
+// RecomputeClusterCenter recalcula center_latitude/center_longitude/center_location a partir de
+// todos los reports del cluster, descartando outliers. Ver weightedCentroid.
+func (s *service) RecomputeClusterCenter(inclId int64) error {
+	if inclId == 0 {
+		return nil
+	}
+	points, err := s.repo.GetClusterReportPoints(inclId)
+	if err != nil {
+		return err
+	}
+	lat, lng, ok := weightedCentroid(points, time.Now().UTC())
+	if !ok {
+		return nil
+	}
+	return s.repo.UpdateClusterCenter(inclId, lat, lng)
+}
+
+// ChangeVote cambia o retira (inputs.Vote == nil) el voto de la cuenta dentro de la ventana permitida.
+func (s *service) ChangeVote(accountID int64, inputs VoteInputs) error {
+	current, err := s.repo.GetAccountVote(inputs.InclId, accountID)
+	if err == sql.ErrNoRows {
+		return ErrNoVote
+	}
+	if err != nil {
+		return fmt.Errorf("getting current vote: %w", err)
+	}
+
+	if time.Since(current.VotedAt) > voteChangeWindow() {
+		return ErrVoteWindowClosed
+	}
+
+	// Mismo voto: nada que hacer
+	if inputs.Vote != nil && *inputs.Vote == current.Vote {
+		return nil
+	}
+
+	if err := s.repo.ChangeVote(inputs.InclId, accountID, current, inputs.Vote); err != nil {
+		return err
+	}
+	realtime.PublishCluster(realtime.EventVoteChanged, inputs.InclId)
+	return nil
+}
+
+// GetMediaStatus devuelve el estado de procesamiento de las fotos/videos de un report del usuario.
+func (s *service) GetMediaStatus(inreID, accountID int64) ([]ReportMedia, error) {
+	items, err := s.repo.GetReportMedia(inreID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrMediaNotFound
+	}
+	return items, nil
+}
+
+// getEditableReport devuelve el report si es del usuario, sigue activo y está dentro de la ventana de edición.
+func (s *service) getEditableReport(inreID, accountID int64) (OwnReport, error) {
+	report, err := s.repo.GetOwnReport(inreID, accountID)
+	if err == sql.ErrNoRows {
+		return OwnReport{}, ErrReportNotFound
+	}
+	if err != nil {
+		return OwnReport{}, fmt.Errorf("getting report: %w", err)
+	}
+	if !report.IsActive {
+		return OwnReport{}, ErrReportWithdrawn
+	}
+	if time.Since(report.CreatedAt) > reportEditWindow() {
+		return OwnReport{}, ErrEditWindowClosed
+	}
+	return report, nil
+}
+
+// EditReport corrige la descripción y/o la subcategoría de un report propio.
+func (s *service) EditReport(accountID int64, inputs EditReportInputs) error {
+	report, err := s.getEditableReport(inputs.InreId, accountID)
+	if err != nil {
+		return err
+	}
+
+	var edit ReportEdit
+	edit.Description, err = editDescription(report.Description, inputs.Description)
+	if err != nil {
+		return err
+	}
+
+	if inputs.SubcategoryCode != nil && *inputs.SubcategoryCode != report.SubcategoryCode {
+		sub, err := s.repo.GetSubcategory(*inputs.SubcategoryCode)
+		if err == sql.ErrNoRows {
+			return ErrInvalidSubcategory
+		}
 		if err != nil {
-			fmt.Printf("⚠️ Geocoding failed for incident %d: %v\n", inreId, err)
-			return
+			return fmt.Errorf("getting subcategory: %w", err)
 		}
+		edit.Subcategory = &sub
+	}
 
-		// Actualizar cluster con dirección real
-		if incident.InclId != 0 {
-			if err := s.repo.UpdateClusterAddress(incident.InclId, realAddr, realCity, realProv, realPostal); err != nil {
-				fmt.Printf("⚠️ Failed to update cluster address for %d: %v\n", incident.InclId, err)
-			} else {
-				fmt.Printf("✅ Geocoding completed for cluster %d: %s, %s\n", incident.InclId, realAddr, realCity)
-			}
-		}
+	if edit.Description == nil && edit.Subcategory == nil {
+		return ErrNothingToEdit
+	}
+	if err := s.repo.EditReport(report, edit); err != nil {
+		return err
+	}
+	realtime.PublishCluster(realtime.EventClusterUpdated, report.InclId)
+	return nil
+}
 
-		// Actualizar incident report con dirección real
-		if err := s.repo.UpdateIncidentAddress(inreId, realAddr, realCity, realProv, realPostal); err != nil {
-			fmt.Printf("⚠️ Failed to update incident address for %d: %v\n", inreId, err)
-		} else {
-			fmt.Printf("✅ Geocoding completed for incident %d: %s, %s\n", inreId, realAddr, realCity)
+// WithdrawReport retira un report propio. Si el cluster sigue activo se recalcula su centro sin este report.
+func (s *service) WithdrawReport(accountID, inreID int64) error {
+	report, err := s.getEditableReport(inreID, accountID)
+	if err != nil {
+		return err
+	}
+
+	clusterClosed, err := s.repo.WithdrawReport(report)
+	if err != nil {
+		return err
+	}
+
+	if report.InclId != 0 && !clusterClosed {
+		if err := s.RecomputeClusterCenter(report.InclId); err != nil {
+			fmt.Printf("⚠️ Error recomputing center for cluster %d: %v\n", report.InclId, err)
 		}
-	}()
-
-	// ⚡ OPTIMIZACIÓN: Procesamiento de imágenes asíncrono
-	// Si hay un archivo temporal pendiente, procesarlo en background
-	if incident.TmpFilePath != "" {
-		go func(tmpPath string, inreId int64, inclId int64) {
-			defer func() {
-				// Eliminar archivo temporal al finalizar (éxito o error)
-				if err := os.Remove(tmpPath); err != nil {
-					fmt.Printf("⚠️ Failed to remove temp file %s: %v\n", tmpPath, err)
-				}
-			}()
-
-			fmt.Printf("🖼️ Starting async image processing for incident %d...\n", inreId)
-
-			// Procesar imagen (detección de rostros, pixelado, resize, upload a S3)
-			s3URL, err := media.ProcessImage(tmpPath, "incidents")
-			if err != nil {
-				fmt.Printf("⚠️ Failed to process image for incident %d: %v\n", inreId, err)
-				return
-			}
+	}
 
-			// Actualizar URL en incident_reports
-			if err := s.repo.UpdateIncidentMediaPath(inreId, s3URL); err != nil {
-				fmt.Printf("⚠️ Failed to update incident media URL for %d: %v\n", inreId, err)
-			} else {
-				fmt.Printf("✅ Image processed and updated for incident %d: %s\n", inreId, s3URL)
-			}
+	if clusterClosed {
+		realtime.PublishCluster(realtime.EventClusterExpired, report.InclId)
+	} else {
+		realtime.PublishCluster(realtime.EventClusterUpdated, report.InclId)
+	}
+	return nil
+}
 
-			// Actualizar URL en incident_clusters si corresponde
-			if inclId != 0 {
-				if err := s.repo.UpdateClusterMediaPath(inclId, s3URL); err != nil {
-					fmt.Printf("⚠️ Failed to update cluster media URL for %d: %v\n", inclId, err)
-				} else {
-					fmt.Printf("✅ Image processed and updated for cluster %d: %s\n", inclId, s3URL)
-				}
-			}
-		}(incident.TmpFilePath, inreId, incident.InclId)
+// SuggestClusters devuelve los clusters activos cercanos que podrían ser el mismo evento que el report
+// que el usuario está por crear, rankeados por distancia, similitud de subcategoría y antigüedad.
+func (s *service) SuggestClusters(inputs SuggestionInputs) ([]ClusterSuggestion, error) {
+	radius := suggestionRadius(inputs.Radius)
+
+	var categoryCode string
+	if inputs.SubcategoryCode != "" {
+		sub, err := s.repo.GetSubcategory(inputs.SubcategoryCode)
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidSubcategory
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting subcategory: %w", err)
+		}
+		categoryCode = sub.CategoryCode
 	}
 
-	return incident, nil
+	candidates, err := s.repo.FindNearbyActiveClusters(inputs.Latitude, inputs.Longitude, radius, suggestionCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+	return rankSuggestions(candidates, categoryCode, inputs.SubcategoryCode, radius, time.Now()), nil
 }
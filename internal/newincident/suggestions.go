@@ -0,0 +1,83 @@
+package newincident
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	defaultSuggestionRadiusMeters = 300.0
+	maxSuggestionRadiusMeters     = 2000.0
+	// Cuántos clusters cercanos se leen de la base antes de rankear
+	suggestionCandidateLimit = 20
+	maxSuggestions           = 5
+	// Un cluster pierde la mitad de su peso por antigüedad cada suggestionAgeHalfLife
+	suggestionAgeHalfLife = 3 * time.Hour
+
+	// Pesos del ranking: la distancia manda, luego el tipo de incidente y luego la antigüedad
+	suggestionDistanceWeight   = 0.5
+	suggestionSimilarityWeight = 0.3
+	suggestionAgeWeight        = 0.2
+	sameCategorySimilarity     = 0.5
+	sameSubcategorySimilarity  = 1.0
+	otherCategorySimilarity    = 0.0
+)
+
+// ErrClusterNotJoinable: el cluster elegido ya no está activo o está lejos de la ubicación del report.
+var ErrClusterNotJoinable = errors.New("this incident is no longer active or is too far from your location")
+
+// subcategorySimilarity: 1 misma subcategoría, 0.5 misma categoría, 0 otra categoría.
+func subcategorySimilarity(categoryCode, subcategoryCode string, c ClusterSuggestion) float64 {
+	switch {
+	case subcategoryCode != "" && c.SubcategoryCode == subcategoryCode:
+		return sameSubcategorySimilarity
+	case categoryCode != "" && c.CategoryCode == categoryCode:
+		return sameCategorySimilarity
+	default:
+		return otherCategorySimilarity
+	}
+}
+
+// rankSuggestions puntúa cada cluster cercano (0-1) por distancia, similitud de subcategoría y antigüedad,
+// y devuelve los maxSuggestions mejores.
+func rankSuggestions(candidates []ClusterSuggestion, categoryCode, subcategoryCode string, radius float64, now time.Time) []ClusterSuggestion {
+	ranked := make([]ClusterSuggestion, 0, len(candidates))
+	for _, c := range candidates {
+		age := now.Sub(c.CreatedAt)
+		if age < 0 {
+			age = 0
+		}
+		c.AgeMinutes = int(age.Minutes())
+		c.SubcategorySimilarity = subcategorySimilarity(categoryCode, subcategoryCode, c)
+
+		proximity := 1 - math.Min(c.DistanceMeters/radius, 1)
+		freshness := math.Pow(0.5, age.Hours()/suggestionAgeHalfLife.Hours())
+		score := suggestionDistanceWeight*proximity +
+			suggestionSimilarityWeight*c.SubcategorySimilarity +
+			suggestionAgeWeight*freshness
+		c.Score = math.Round(score*1000) / 1000
+
+		ranked = append(ranked, c)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].DistanceMeters < ranked[j].DistanceMeters
+	})
+	if len(ranked) > maxSuggestions {
+		ranked = ranked[:maxSuggestions]
+	}
+	return ranked
+}
+
+// suggestionRadius aplica el radio por defecto y el máximo permitido.
+func suggestionRadius(radius float64) float64 {
+	if radius <= 0 {
+		return defaultSuggestionRadiusMeters
+	}
+	return math.Min(radius, maxSuggestionRadiusMeters)
+}
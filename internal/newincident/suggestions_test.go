@@ -0,0 +1,54 @@
+package newincident
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRankSuggestions(t *testing.T) {
+	now := time.Now()
+	candidates := []ClusterSuggestion{
+		{InclId: 1, CategoryCode: "crime", SubcategoryCode: "theft", DistanceMeters: 40, CreatedAt: now.Add(-10 * time.Minute)},
+		{InclId: 2, CategoryCode: "traffic_accident", SubcategoryCode: "car_crash", DistanceMeters: 60, CreatedAt: now.Add(-5 * time.Minute)},
+		{InclId: 3, CategoryCode: "traffic_accident", SubcategoryCode: "road_blocked", DistanceMeters: 60, CreatedAt: now.Add(-5 * time.Minute)},
+		{InclId: 4, CategoryCode: "traffic_accident", SubcategoryCode: "car_crash", DistanceMeters: 60, CreatedAt: now.Add(-12 * time.Hour)},
+	}
+
+	ranked := rankSuggestions(candidates, "traffic_accident", "car_crash", 300, now)
+
+	want := []int64{2, 3, 4, 1}
+	for i, id := range want {
+		if ranked[i].InclId != id {
+			t.Fatalf("position %d: expected cluster %d, got %d (%+v)", i, id, ranked[i].InclId, ranked)
+		}
+	}
+	if ranked[0].SubcategorySimilarity != 1 || ranked[1].SubcategorySimilarity != 0.5 || ranked[3].SubcategorySimilarity != 0 {
+		t.Errorf("unexpected similarities: %+v", ranked)
+	}
+	if ranked[0].AgeMinutes != 5 {
+		t.Errorf("expected age 5 minutes, got %d", ranked[0].AgeMinutes)
+	}
+}
+
+func TestRankSuggestionsLimit(t *testing.T) {
+	now := time.Now()
+	var candidates []ClusterSuggestion
+	for i := 0; i < maxSuggestions+3; i++ {
+		candidates = append(candidates, ClusterSuggestion{InclId: int64(i), DistanceMeters: float64(i * 10), CreatedAt: now})
+	}
+	if got := len(rankSuggestions(candidates, "", "", 300, now)); got != maxSuggestions {
+		t.Fatalf("expected %d suggestions, got %d", maxSuggestions, got)
+	}
+}
+
+func TestSuggestionRadius(t *testing.T) {
+	if suggestionRadius(0) != defaultSuggestionRadiusMeters {
+		t.Error("expected default radius for 0")
+	}
+	if suggestionRadius(50000) != maxSuggestionRadiusMeters {
+		t.Error("expected radius to be capped")
+	}
+	if suggestionRadius(500) != 500 {
+		t.Error("expected radius to be kept")
+	}
+}
@@ -0,0 +1,71 @@
+package newincident
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+const defaultVoteChangeWindow = 60 * time.Minute
+
+var (
+	ErrNoVote           = errors.New("you have not voted on this incident")
+	ErrVoteWindowClosed = errors.New("the time to change your vote has passed")
+	ErrClusterClosed    = errors.New("this incident is no longer active")
+	ErrVoteConflict     = errors.New("your vote was changed from another request. Please refresh and try again")
+)
+
+// voteDelta es el cambio que un voto (o su reversión) aplica a los contadores de incident_clusters.
+type voteDelta struct {
+	Votes      int
+	VotesTrue  int
+	VotesFalse int
+	ScoreTrue  float64
+	ScoreFalse float64
+}
+
+// voteContribution: un voto true suma la credibilidad del votante a score_true y (10 - credibilidad) a score_false.
+// Un voto false hace lo contrario. Es lo que aplican UpdateClusterAsTrue/UpdateClusterAsFalse.
+func voteContribution(vote bool, credibility float64) voteDelta {
+	if vote {
+		return voteDelta{Votes: 1, VotesTrue: 1, ScoreTrue: credibility, ScoreFalse: 10 - credibility}
+	}
+	return voteDelta{Votes: 1, VotesFalse: 1, ScoreTrue: 10 - credibility, ScoreFalse: credibility}
+}
+
+func (d voteDelta) minus(o voteDelta) voteDelta {
+	return voteDelta{
+		Votes:      d.Votes - o.Votes,
+		VotesTrue:  d.VotesTrue - o.VotesTrue,
+		VotesFalse: d.VotesFalse - o.VotesFalse,
+		ScoreTrue:  d.ScoreTrue - o.ScoreTrue,
+		ScoreFalse: d.ScoreFalse - o.ScoreFalse,
+	}
+}
+
+// changeVoteDelta revierte exactamente lo que aportó el voto anterior (con la credibilidad de ese momento)
+// y aplica el nuevo voto con la credibilidad actual. newVote nil = voto retirado.
+func changeVoteDelta(oldVote bool, oldCredibility float64, newVote *bool, newCredibility float64) voteDelta {
+	d := voteDelta{}.minus(voteContribution(oldVote, oldCredibility))
+	if newVote == nil {
+		return d
+	}
+	n := voteContribution(*newVote, newCredibility)
+	return voteDelta{
+		Votes:      d.Votes + n.Votes,
+		VotesTrue:  d.VotesTrue + n.VotesTrue,
+		VotesFalse: d.VotesFalse + n.VotesFalse,
+		ScoreTrue:  d.ScoreTrue + n.ScoreTrue,
+		ScoreFalse: d.ScoreFalse + n.ScoreFalse,
+	}
+}
+
+// voteChangeWindow: tiempo desde el voto original durante el cual se puede cambiar o retirar.
+// Configurable con VOTE_CHANGE_WINDOW_MINUTES.
+func voteChangeWindow() time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("VOTE_CHANGE_WINDOW_MINUTES")); err == nil && v > 0 {
+		return time.Duration(v) * time.Minute
+	}
+	return defaultVoteChangeWindow
+}
@@ -0,0 +1,60 @@
+package newincident
+
+import (
+	"math"
+	"testing"
+)
+
+func TestChangeVoteDelta(t *testing.T) {
+	yes, no := true, false
+
+	tests := []struct {
+		name    string
+		oldVote bool
+		oldCred float64
+		newVote *bool
+		newCred float64
+		want    voteDelta
+	}{
+		{
+			name:    "retract true vote",
+			oldVote: true, oldCred: 7,
+			want: voteDelta{Votes: -1, VotesTrue: -1, ScoreTrue: -7, ScoreFalse: -3},
+		},
+		{
+			name:    "retract false vote",
+			oldVote: false, oldCred: 6,
+			want: voteDelta{Votes: -1, VotesFalse: -1, ScoreTrue: -4, ScoreFalse: -6},
+		},
+		{
+			name:    "true to false with same credibility",
+			oldVote: true, oldCred: 8, newVote: &no, newCred: 8,
+			want: voteDelta{VotesTrue: -1, VotesFalse: 1, ScoreTrue: -6, ScoreFalse: 6},
+		},
+		{
+			name:    "false to true after credibility changed",
+			oldVote: false, oldCred: 5, newVote: &yes, newCred: 6,
+			want: voteDelta{VotesTrue: 1, VotesFalse: -1, ScoreTrue: 1, ScoreFalse: -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := changeVoteDelta(tt.oldVote, tt.oldCred, tt.newVote, tt.newCred)
+			if got.Votes != tt.want.Votes || got.VotesTrue != tt.want.VotesTrue || got.VotesFalse != tt.want.VotesFalse ||
+				math.Abs(got.ScoreTrue-tt.want.ScoreTrue) > 1e-9 || math.Abs(got.ScoreFalse-tt.want.ScoreFalse) > 1e-9 {
+				t.Errorf("changeVoteDelta() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeVoteDelta_ReversesContribution(t *testing.T) {
+	// Aplicar un voto y luego retirarlo debe dejar el cluster exactamente igual
+	applied := voteContribution(true, 4.5)
+	retracted := changeVoteDelta(true, 4.5, nil, 0)
+	sum := voteDelta{}.minus(applied).minus(retracted)
+	if sum != (voteDelta{}) {
+		t.Errorf("applied %+v + retracted %+v != zero", applied, retracted)
+	}
+}
@@ -35,6 +35,8 @@ type NotificationDelivery struct {
 	Message     string          `db:"message" json:"message"`
 	Type        string          `db:"type" json:"type"`
 	ReferenceID sql.NullInt64   `db:"reference_id" json:"reference_id"`
+	// InclID es el incidente a abrir cuando reference_id no es un incl_id (new_comment: reference_id = inco_id)
+	InclID sql.NullInt64 `db:"incl_id" json:"incl_id"`
 }
 
 // IsReadBool retorna el valor booleano del campo IsRead
@@ -44,10 +46,13 @@ func (nd *NotificationDelivery) IsReadBool() bool {
 
 // MarshalJSON implementa la serialización JSON personalizada
 func (nd *NotificationDelivery) MarshalJSON() ([]byte, error) {
-	var referenceID *int64
+	var referenceID, inclID *int64
 	if nd.ReferenceID.Valid {
 		referenceID = &nd.ReferenceID.Int64
 	}
+	if nd.InclID.Valid {
+		inclID = &nd.InclID.Int64
+	}
 	return json.Marshal(map[string]interface{}{
 		"node_id":       nd.NodeID,
 		"created_at":    nd.CreatedAt,
@@ -58,6 +63,7 @@ func (nd *NotificationDelivery) MarshalJSON() ([]byte, error) {
 		"message":       nd.Message,
 		"type":          nd.Type,
 		"reference_id":  referenceID,
+		"incl_id":       inclID,
 	})
 }
 
@@ -114,9 +120,11 @@ func (r *pgRepository) GetNotifications(accountID int64, limit, offset int) ([]N
 			nd.title,
 			nd.message,
 			n.type,
-			n.reference_id
+			n.reference_id,
+			ic.incl_id
 		FROM notification_deliveries nd
 		LEFT JOIN notifications n ON nd.noti_id = n.noti_id
+		LEFT JOIN incident_comments ic ON n.type = 'new_comment' AND ic.inco_id = n.reference_id
 		WHERE nd.to_account_id = $1
 		ORDER BY nd.created_at DESC
 		LIMIT $2 OFFSET $3
@@ -142,6 +150,7 @@ func (r *pgRepository) GetNotifications(accountID int64, limit, offset int) ([]N
 			&nd.Message,
 			&nd.Type,
 			&nd.ReferenceID,
+			&nd.InclID,
 		)
 		if err != nil {
 			log.Printf("Error scanning notification: %v", err)
@@ -0,0 +1,66 @@
+package outbox
+
+import (
+	"alertly/internal/database"
+	"alertly/internal/response"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListJobs returns the most recent outbox jobs, optionally filtered by status and job type.
+func ListJobs(c *gin.Context) {
+	var inputs ListInputs
+	if err := c.ShouldBindQuery(&inputs); err != nil {
+		response.Send(c, http.StatusBadRequest, true, "Invalid query parameters. Please check and try again.", err.Error())
+		return
+	}
+	if inputs.Limit == 0 {
+		inputs.Limit = 50
+	}
+
+	repo := NewRepository(database.DB)
+	jobs, err := repo.List(inputs)
+	if err != nil {
+		log.Printf("Error listing outbox jobs: %v", err)
+		response.Send(c, http.StatusInternalServerError, true, "Could not load outbox jobs.", nil)
+		return
+	}
+	response.Send(c, http.StatusOK, false, "Success", jobs)
+}
+
+// GetStats returns job counts grouped by type and status.
+func GetStats(c *gin.Context) {
+	repo := NewRepository(database.DB)
+	stats, err := repo.GetStats()
+	if err != nil {
+		log.Printf("Error getting outbox stats: %v", err)
+		response.Send(c, http.StatusInternalServerError, true, "Could not load outbox stats.", nil)
+		return
+	}
+	response.Send(c, http.StatusOK, false, "Success", stats)
+}
+
+// RetryJob moves a dead job back to pending.
+func RetryJob(c *gin.Context) {
+	jobID, err := strconv.ParseInt(c.Param("job_id"), 10, 64)
+	if err != nil || jobID <= 0 {
+		response.Send(c, http.StatusBadRequest, true, "Invalid job ID.", nil)
+		return
+	}
+
+	repo := NewRepository(database.DB)
+	ok, err := repo.Retry(jobID)
+	if err != nil {
+		log.Printf("Error retrying outbox job %d: %v", jobID, err)
+		response.Send(c, http.StatusInternalServerError, true, "Could not retry the job.", nil)
+		return
+	}
+	if !ok {
+		response.Send(c, http.StatusNotFound, true, "Job not found or not in dead state.", nil)
+		return
+	}
+	response.Send(c, http.StatusOK, false, "Job queued for retry", gin.H{"job_id": jobID})
+}
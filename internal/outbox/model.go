@@ -0,0 +1,42 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusDone       = "done"
+	StatusDead       = "dead"
+)
+
+// Job is a single row of incident_outbox.
+type Job struct {
+	JobID       int64           `json:"job_id"`
+	JobType     string          `json:"job_type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error"`
+	RunAfter    time.Time       `json:"run_after"`
+	LockedAt    *time.Time      `json:"locked_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// StatusCount is one row of the outbox summary (jobs grouped by type and status).
+type StatusCount struct {
+	JobType string `json:"job_type"`
+	Status  string `json:"status"`
+	Total   int    `json:"total"`
+}
+
+// ListInputs are the query-string filters accepted by the inspection endpoint.
+type ListInputs struct {
+	Status  string `form:"status" binding:"omitempty,oneof=pending processing done dead"`
+	JobType string `form:"job_type"`
+	Limit   int    `form:"limit" binding:"omitempty,min=1,max=500"`
+}
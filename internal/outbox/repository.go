@@ -0,0 +1,206 @@
+package outbox
+
+import (
+	"alertly/internal/common"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type Repository interface {
+	ClaimBatch(limit int) ([]Job, error)
+	MarkDone(jobID int64) error
+	MarkFailed(jobID int64, errMsg string, retryAt time.Time, dead bool) error
+	ReleaseStale(lockTimeout time.Duration) ([]Job, error)
+	PurgeDone(olderThan time.Duration) (int64, error)
+	List(inputs ListInputs) ([]Job, error)
+	GetStats() ([]StatusCount, error)
+	Retry(jobID int64) (bool, error)
+}
+
+type pgRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &pgRepository{db: db}
+}
+
+// Enqueue inserts a pending job. Pass the *sql.Tx that writes the incident so
+// the job only exists if the incident itself was committed.
+func Enqueue(dbExec common.DBExecutor, jobType string, payload interface{}) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload for %s: %w", jobType, err)
+	}
+
+	query := `INSERT INTO incident_outbox (job_type, payload, status, run_after, created_at, updated_at)
+	VALUES ($1, $2, 'pending', NOW(), NOW(), NOW())`
+	if _, err := dbExec.Exec(query, jobType, raw); err != nil {
+		return fmt.Errorf("failed to enqueue %s: %w", jobType, err)
+	}
+	return nil
+}
+
+// ClaimBatch marks up to limit ready jobs as processing and returns them.
+// SKIP LOCKED lets several instances poll the same table without double work.
+func (r *pgRepository) ClaimBatch(limit int) ([]Job, error) {
+	query := `
+	UPDATE incident_outbox
+	SET status = 'processing', locked_at = NOW(), attempts = attempts + 1, updated_at = NOW()
+	WHERE job_id IN (
+		SELECT job_id FROM incident_outbox
+		WHERE status = 'pending' AND run_after <= NOW()
+		ORDER BY run_after, job_id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING job_id, job_type, payload, status, attempts, max_attempts, run_after, created_at, updated_at
+	`
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		var j Job
+		var payload []byte
+		if err := rows.Scan(&j.JobID, &j.JobType, &payload, &j.Status, &j.Attempts, &j.MaxAttempts, &j.RunAfter, &j.CreatedAt, &j.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan claimed job: %w", err)
+		}
+		j.Payload = payload
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *pgRepository) MarkDone(jobID int64) error {
+	query := `UPDATE incident_outbox SET status = 'done', locked_at = NULL, last_error = NULL, updated_at = NOW() WHERE job_id = $1`
+	_, err := r.db.Exec(query, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to mark job %d as done: %w", jobID, err)
+	}
+	return nil
+}
+
+// MarkFailed stores the error and either reschedules the job at retryAt or
+// moves it to the dead-letter state.
+func (r *pgRepository) MarkFailed(jobID int64, errMsg string, retryAt time.Time, dead bool) error {
+	status := StatusPending
+	if dead {
+		status = StatusDead
+	}
+	query := `UPDATE incident_outbox SET status = $1, last_error = $2, run_after = $3, locked_at = NULL, updated_at = NOW() WHERE job_id = $4`
+	_, err := r.db.Exec(query, status, errMsg, retryAt, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to mark job %d as failed: %w", jobID, err)
+	}
+	return nil
+}
+
+// ReleaseStale returns jobs stuck in processing (the worker died mid-job)
+// to the queue, or dead-letters them if they are out of attempts. It returns
+// the released jobs with their new status.
+func (r *pgRepository) ReleaseStale(lockTimeout time.Duration) ([]Job, error) {
+	query := `
+	UPDATE incident_outbox
+	SET status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'pending' END,
+		last_error = COALESCE(last_error, 'worker lock expired'),
+		locked_at = NULL,
+		updated_at = NOW()
+	WHERE status = 'processing' AND locked_at < NOW() - ($1 * INTERVAL '1 second')
+	RETURNING job_id, job_type, payload, status, last_error
+	`
+	rows, err := r.db.Query(query, int64(lockTimeout.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to release stale jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		var j Job
+		if err := rows.Scan(&j.JobID, &j.JobType, &j.Payload, &j.Status, &j.LastError); err != nil {
+			return nil, fmt.Errorf("failed to scan released job: %w", err)
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *pgRepository) PurgeDone(olderThan time.Duration) (int64, error) {
+	query := `DELETE FROM incident_outbox WHERE status = 'done' AND updated_at < NOW() - ($1 * INTERVAL '1 second')`
+	result, err := r.db.Exec(query, int64(olderThan.Seconds()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge done jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func (r *pgRepository) List(inputs ListInputs) ([]Job, error) {
+	query := `
+	SELECT job_id, job_type, payload, status, attempts, max_attempts, COALESCE(last_error, ''), run_after, locked_at, created_at, updated_at
+	FROM incident_outbox
+	WHERE ($1 = '' OR status = $1)
+	  AND ($2 = '' OR job_type = $2)
+	ORDER BY job_id DESC
+	LIMIT $3
+	`
+	rows, err := r.db.Query(query, inputs.Status, inputs.JobType, inputs.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := make([]Job, 0, inputs.Limit)
+	for rows.Next() {
+		var j Job
+		var payload []byte
+		var lockedAt sql.NullTime
+		if err := rows.Scan(&j.JobID, &j.JobType, &payload, &j.Status, &j.Attempts, &j.MaxAttempts, &j.LastError, &j.RunAfter, &lockedAt, &j.CreatedAt, &j.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox job: %w", err)
+		}
+		j.Payload = payload
+		if lockedAt.Valid {
+			j.LockedAt = &lockedAt.Time
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *pgRepository) GetStats() ([]StatusCount, error) {
+	query := `SELECT job_type, status, COUNT(*) FROM incident_outbox GROUP BY job_type, status ORDER BY job_type, status`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outbox stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make([]StatusCount, 0)
+	for rows.Next() {
+		var s StatusCount
+		if err := rows.Scan(&s.JobType, &s.Status, &s.Total); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// Retry puts a dead job back in the queue with a fresh set of attempts.
+func (r *pgRepository) Retry(jobID int64) (bool, error) {
+	query := `UPDATE incident_outbox SET status = 'pending', attempts = 0, run_after = NOW(), updated_at = NOW() WHERE job_id = $1 AND status = 'dead'`
+	result, err := r.db.Exec(query, jobID)
+	if err != nil {
+		return false, fmt.Errorf("failed to retry job %d: %w", jobID, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
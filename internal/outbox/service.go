@@ -0,0 +1,239 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	pollInterval   = 2 * time.Second
+	lockTimeout    = 10 * time.Minute
+	purgeAfter     = 7 * 24 * time.Hour
+	baseBackoff    = 30 * time.Second
+	maxBackoff     = 1 * time.Hour
+	maxErrorLength = 1000
+)
+
+// Handler executes one job. Returning an error schedules a retry unless the
+// error is wrapped with Permanent.
+type Handler func(payload json.RawMessage) error
+
+// DeadHandler runs when a job of its type runs out of attempts (including a
+// worker dying mid-job), so the owner can clean up what the job left behind.
+// It is not called for Permanent errors: the handler already knew it was final.
+type DeadHandler func(payload json.RawMessage, err error)
+
+var (
+	handlersMu   sync.RWMutex
+	handlers     = map[string]Handler{}
+	deadHandlers = map[string]DeadHandler{}
+)
+
+// RegisterHandler binds a job type to the function that executes it.
+// Packages that enqueue jobs register their handlers at startup.
+func RegisterHandler(jobType string, h Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers[jobType] = h
+}
+
+// RegisterDeadHandler binds a job type to the cleanup run when it is dead-lettered.
+func RegisterDeadHandler(jobType string, h DeadHandler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	deadHandlers[jobType] = h
+}
+
+func getHandler(jobType string) (Handler, bool) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	h, ok := handlers[jobType]
+	return h, ok
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error as not worth retrying; the job goes straight to dead.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// backoffFor returns the delay before the next attempt: 30s, 1m, 2m, 4m... capped at 1h.
+func backoffFor(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := baseBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
+
+// Dispatcher polls incident_outbox and runs the claimed jobs on a bounded worker pool.
+type Dispatcher struct {
+	repo    Repository
+	workers int
+}
+
+func NewDispatcher(repo Repository, workers int) *Dispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Dispatcher{repo: repo, workers: workers}
+}
+
+// Start launches the polling loop in the background.
+func (d *Dispatcher) Start() {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		log.Printf("✅ Outbox dispatcher started with %d workers", d.workers)
+
+		lastMaintenance := time.Time{}
+		for range ticker.C {
+			if time.Since(lastMaintenance) > time.Minute {
+				d.maintenance()
+				lastMaintenance = time.Now()
+			}
+			// Keep draining while batches come back full
+			for {
+				if d.RunOnce() < d.workers*2 {
+					break
+				}
+			}
+		}
+	}()
+}
+
+// RunOnce claims one batch and processes it. Returns how many jobs were claimed.
+func (d *Dispatcher) RunOnce() int {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️ Panic in outbox dispatcher: %v", r)
+		}
+	}()
+
+	jobs, err := d.repo.ClaimBatch(d.workers * 2)
+	if err != nil {
+		log.Printf("❌ Outbox claim error: %v", err)
+		return 0
+	}
+	if len(jobs) == 0 {
+		return 0
+	}
+
+	var g errgroup.Group
+	g.SetLimit(d.workers)
+	for i := range jobs {
+		job := jobs[i]
+		g.Go(func() error {
+			d.process(job)
+			return nil
+		})
+	}
+	g.Wait()
+
+	return len(jobs)
+}
+
+func (d *Dispatcher) process(job Job) {
+	err := d.execute(job)
+	if err == nil {
+		if markErr := d.repo.MarkDone(job.JobID); markErr != nil {
+			log.Printf("⚠️ Outbox: %v", markErr)
+		}
+		return
+	}
+
+	errMsg := err.Error()
+	if len(errMsg) > maxErrorLength {
+		errMsg = errMsg[:maxErrorLength]
+	}
+
+	dead := isPermanent(err) || job.Attempts >= job.MaxAttempts
+	retryAt := time.Now().Add(backoffFor(job.Attempts))
+	if markErr := d.repo.MarkFailed(job.JobID, errMsg, retryAt, dead); markErr != nil {
+		log.Printf("⚠️ Outbox: %v", markErr)
+	}
+
+	if dead {
+		log.Printf("💀 Outbox job %d (%s) moved to dead letter after %d attempts: %s", job.JobID, job.JobType, job.Attempts, errMsg)
+		if !isPermanent(err) {
+			d.runDeadHandler(job, err)
+		}
+	} else {
+		log.Printf("🔁 Outbox job %d (%s) failed (attempt %d/%d), retrying at %s: %s",
+			job.JobID, job.JobType, job.Attempts, job.MaxAttempts, retryAt.Format(time.RFC3339), errMsg)
+	}
+}
+
+func (d *Dispatcher) execute(job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	h, ok := getHandler(job.JobType)
+	if !ok {
+		return Permanent(fmt.Errorf("no handler registered for job type %q", job.JobType))
+	}
+	return h(job.Payload)
+}
+
+// runDeadHandler never lets a cleanup panic take down the dispatcher.
+func (d *Dispatcher) runDeadHandler(job Job, err error) {
+	handlersMu.RLock()
+	h, ok := deadHandlers[job.JobType]
+	handlersMu.RUnlock()
+	if !ok {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️ Outbox: dead handler for job %d (%s) panicked: %v", job.JobID, job.JobType, r)
+		}
+	}()
+	h(job.Payload, err)
+}
+
+func (d *Dispatcher) maintenance() {
+	if released, err := d.repo.ReleaseStale(lockTimeout); err != nil {
+		log.Printf("⚠️ Outbox: %v", err)
+	} else if len(released) > 0 {
+		log.Printf("🔓 Outbox released %d stale jobs", len(released))
+		for _, job := range released {
+			if job.Status == "dead" {
+				d.runDeadHandler(job, errors.New(job.LastError))
+			}
+		}
+	}
+	if n, err := d.repo.PurgeDone(purgeAfter); err != nil {
+		log.Printf("⚠️ Outbox: %v", err)
+	} else if n > 0 {
+		log.Printf("🧹 Outbox purged %d finished jobs", n)
+	}
+}
@@ -0,0 +1,138 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackoffFor(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, 1 * time.Minute},
+		{3, 2 * time.Minute},
+		{5, 8 * time.Minute},
+		{8, 1 * time.Hour},
+		{50, 1 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := backoffFor(tt.attempt); got != tt.want {
+			t.Errorf("backoffFor(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+// mockRepository records what the dispatcher does with each job.
+type mockRepository struct {
+	mu     sync.Mutex
+	claim  []Job
+	done   []int64
+	failed map[int64]bool // jobID -> dead
+	stale  []Job
+}
+
+func (m *mockRepository) ClaimBatch(limit int) ([]Job, error) {
+	jobs := m.claim
+	m.claim = nil
+	return jobs, nil
+}
+func (m *mockRepository) MarkDone(jobID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.done = append(m.done, jobID)
+	return nil
+}
+func (m *mockRepository) MarkFailed(jobID int64, errMsg string, retryAt time.Time, dead bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failed[jobID] = dead
+	return nil
+}
+func (m *mockRepository) ReleaseStale(lockTimeout time.Duration) ([]Job, error) { return m.stale, nil }
+func (m *mockRepository) PurgeDone(olderThan time.Duration) (int64, error)      { return 0, nil }
+func (m *mockRepository) List(inputs ListInputs) ([]Job, error)                 { return nil, nil }
+func (m *mockRepository) GetStats() ([]StatusCount, error)                      { return nil, nil }
+func (m *mockRepository) Retry(jobID int64) (bool, error)                       { return false, nil }
+
+func TestDispatcherRunOnce(t *testing.T) {
+	RegisterHandler("test_ok", func(payload json.RawMessage) error { return nil })
+	RegisterHandler("test_retry", func(payload json.RawMessage) error { return errors.New("temporary") })
+	RegisterHandler("test_permanent", func(payload json.RawMessage) error { return Permanent(errors.New("bad input")) })
+	RegisterHandler("test_panic", func(payload json.RawMessage) error { panic("boom") })
+
+	repo := &mockRepository{
+		failed: map[int64]bool{},
+		claim: []Job{
+			{JobID: 1, JobType: "test_ok", Attempts: 1, MaxAttempts: 3},
+			{JobID: 2, JobType: "test_retry", Attempts: 1, MaxAttempts: 3},
+			{JobID: 3, JobType: "test_retry", Attempts: 3, MaxAttempts: 3},
+			{JobID: 4, JobType: "test_permanent", Attempts: 1, MaxAttempts: 3},
+			{JobID: 5, JobType: "unknown_type", Attempts: 1, MaxAttempts: 3},
+			{JobID: 6, JobType: "test_panic", Attempts: 1, MaxAttempts: 3},
+		},
+	}
+
+	d := NewDispatcher(repo, 2)
+	if n := d.RunOnce(); n != 6 {
+		t.Fatalf("RunOnce() claimed %d jobs, want 6", n)
+	}
+
+	if len(repo.done) != 1 || repo.done[0] != 1 {
+		t.Errorf("done = %v, want [1]", repo.done)
+	}
+
+	wantFailed := map[int64]bool{2: false, 3: true, 4: true, 5: true, 6: false}
+	for id, wantDead := range wantFailed {
+		dead, ok := repo.failed[id]
+		if !ok {
+			t.Errorf("job %d was not marked as failed", id)
+			continue
+		}
+		if dead != wantDead {
+			t.Errorf("job %d dead = %v, want %v", id, dead, wantDead)
+		}
+	}
+}
+
+func TestDeadHandlerOnlyWhenOutOfAttempts(t *testing.T) {
+	var mu sync.Mutex
+	var cleaned []string
+	RegisterHandler("test_dead_retry", func(payload json.RawMessage) error { return errors.New("s3 timeout") })
+	RegisterHandler("test_dead_permanent", func(payload json.RawMessage) error { return Permanent(errors.New("bad input")) })
+	for _, jobType := range []string{"test_dead_retry", "test_dead_permanent"} {
+		RegisterDeadHandler(jobType, func(payload json.RawMessage, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			cleaned = append(cleaned, string(payload)+": "+err.Error())
+		})
+	}
+
+	repo := &mockRepository{
+		failed: map[int64]bool{},
+		claim: []Job{
+			{JobID: 1, JobType: "test_dead_retry", Payload: json.RawMessage(`1`), Attempts: 1, MaxAttempts: 3},
+			{JobID: 2, JobType: "test_dead_retry", Payload: json.RawMessage(`2`), Attempts: 3, MaxAttempts: 3},
+			{JobID: 3, JobType: "test_dead_permanent", Payload: json.RawMessage(`3`), Attempts: 1, MaxAttempts: 3},
+		},
+		stale: []Job{
+			{JobID: 4, JobType: "test_dead_retry", Payload: json.RawMessage(`4`), Status: "dead", LastError: "worker lock expired"},
+			{JobID: 5, JobType: "test_dead_retry", Payload: json.RawMessage(`5`), Status: "pending"},
+		},
+	}
+
+	d := NewDispatcher(repo, 1)
+	d.RunOnce()
+	d.maintenance()
+
+	want := []string{"2: s3 timeout", "4: worker lock expired"}
+	if len(cleaned) != len(want) || cleaned[0] != want[0] || cleaned[1] != want[1] {
+		t.Errorf("dead handler calls = %v, want %v", cleaned, want)
+	}
+}
@@ -0,0 +1,97 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor es la posición de keyset de la última fila de una página.
+// Las consultas ordenan por (created_at DESC, id DESC) y piden las filas con (created_at, id) < (CreatedAt, ID).
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// Encode devuelve el cursor como string opaco para el cliente.
+// Usa microsegundos, la misma precisión que guarda PostgreSQL.
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UTC().UnixMicro(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode lee un cursor generado por Encode. Un string vacío es la primera página (nil, nil).
+func Decode(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+	micros, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || id <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{CreatedAt: time.UnixMicro(micros).UTC(), ID: id}, nil
+}
+
+// SortedCursor es un Cursor para listados con varios órdenes (/cluster/query). Sort es el orden con el
+// que se generó, para rechazar cursores de otro; Value es el valor de la columna de orden en los sorts
+// numéricos (credibility, votes, distance). En los sorts por fecha se usa CreatedAt.
+type SortedCursor struct {
+	Cursor
+	Sort  string
+	Value float64
+}
+
+func (c SortedCursor) Encode() string {
+	raw := fmt.Sprintf("%s:%d:%s:%d", c.Sort, c.CreatedAt.UTC().UnixMicro(), strconv.FormatFloat(c.Value, 'g', -1, 64), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeSorted lee un cursor generado por SortedCursor.Encode con el mismo sort. Vacío = primera página (nil, nil).
+func DecodeSorted(s, sort string) (*SortedCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 4 || parts[0] != sort {
+		return nil, ErrInvalidCursor
+	}
+	micros, err1 := strconv.ParseInt(parts[1], 10, 64)
+	value, err2 := strconv.ParseFloat(parts[2], 64)
+	id, err3 := strconv.ParseInt(parts[3], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil || id <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &SortedCursor{Cursor: Cursor{CreatedAt: time.UnixMicro(micros).UTC(), ID: id}, Sort: sort, Value: value}, nil
+}
+
+// Limit devuelve el tamaño de página pedido, o def si no viene, acotado a max
+func Limit(requested, def, max int) int {
+	if requested <= 0 {
+		return def
+	}
+	if requested > max {
+		return max
+	}
+	return requested
+}
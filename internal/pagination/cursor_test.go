@@ -0,0 +1,60 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := Cursor{CreatedAt: time.Date(2026, 10, 17, 14, 3, 5, 123456000, time.UTC), ID: 4821}
+
+	got, err := Decode(c.Encode())
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !got.CreatedAt.Equal(c.CreatedAt) || got.ID != c.ID {
+		t.Errorf("Decode() = %+v, want %+v", got, c)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	if c, err := Decode(""); c != nil || err != nil {
+		t.Errorf("empty cursor should be the first page, got %+v, %v", c, err)
+	}
+
+	for _, s := range []string{"not base64!", "MTIz", "YWJjOjEy", "MTIzOjA"} {
+		if _, err := Decode(s); err != ErrInvalidCursor {
+			t.Errorf("Decode(%q) error = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
+
+func TestSortedCursor(t *testing.T) {
+	c := SortedCursor{Cursor: Cursor{CreatedAt: time.Date(2026, 10, 17, 14, 3, 5, 0, time.UTC), ID: 9}, Sort: "distance", Value: 250.5}
+
+	got, err := DecodeSorted(c.Encode(), "distance")
+	if err != nil || got.ID != 9 || got.Value != 250.5 || !got.CreatedAt.Equal(c.CreatedAt) {
+		t.Errorf("DecodeSorted() = %+v, %v", got, err)
+	}
+	if _, err := DecodeSorted(c.Encode(), "votes"); err != ErrInvalidCursor {
+		t.Errorf("cursor from another sort: error = %v, want ErrInvalidCursor", err)
+	}
+	// Un cursor de Cursor.Encode no sirve como SortedCursor
+	if _, err := DecodeSorted(c.Cursor.Encode(), "distance"); err != ErrInvalidCursor {
+		t.Errorf("plain cursor: error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	tests := []struct{ requested, want int }{
+		{0, 100},
+		{-5, 100},
+		{50, 50},
+		{1000, 500},
+	}
+	for _, tt := range tests {
+		if got := Limit(tt.requested, 100, 500); got != tt.want {
+			t.Errorf("Limit(%d) = %d, want %d", tt.requested, got, tt.want)
+		}
+	}
+}
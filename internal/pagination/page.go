@@ -0,0 +1,21 @@
+package pagination
+
+// Page es una página de clusters ordenados del más reciente al más antiguo (created_at DESC, id DESC).
+// T es el Cluster de cada listado. Si HasMore es true se pide la siguiente con ?cursor=NextCursor.
+type Page[T any] struct {
+	Clusters   []T    `json:"clusters"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPage arma la página a partir de hasta limit+1 filas: el repositorio pide una de más para saber si hay
+// otra página. Recorta la fila extra y arma el cursor con la última fila devuelta, cuya posición da position.
+func NewPage[T any](rows []T, limit int, position func(T) Cursor) Page[T] {
+	page := Page[T]{Clusters: rows}
+	if len(rows) > limit {
+		page.Clusters = rows[:limit]
+		page.HasMore = true
+		page.NextCursor = position(page.Clusters[limit-1]).Encode()
+	}
+	return page
+}
@@ -0,0 +1,35 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+)
+
+type row struct {
+	ID        int64
+	CreatedAt time.Time
+}
+
+func TestNewPage(t *testing.T) {
+	base := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	rows := []row{
+		{ID: 30, CreatedAt: base},
+		{ID: 29, CreatedAt: base.Add(-time.Minute)},
+		{ID: 28, CreatedAt: base.Add(-2 * time.Minute)},
+	}
+	position := func(r row) Cursor { return Cursor{CreatedAt: r.CreatedAt, ID: r.ID} }
+
+	page := NewPage(rows, 2, position)
+	if !page.HasMore || len(page.Clusters) != 2 {
+		t.Fatalf("expected 2 rows and has_more, got %d, %v", len(page.Clusters), page.HasMore)
+	}
+	cursor, err := Decode(page.NextCursor)
+	if err != nil || cursor.ID != 29 || !cursor.CreatedAt.Equal(rows[1].CreatedAt) {
+		t.Errorf("next cursor should point at the last returned row, got %+v, %v", cursor, err)
+	}
+
+	last := NewPage(rows, 3, position)
+	if last.HasMore || last.NextCursor != "" || len(last.Clusters) != 3 {
+		t.Errorf("last page should not have more: %+v", last)
+	}
+}
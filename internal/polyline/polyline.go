@@ -0,0 +1,114 @@
+package polyline
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPolyline = errors.New("invalid encoded polyline")
+
+const earthRadius = 6371000.0 // metros
+
+// Point es un vértice de la línea
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Decode lee un encoded polyline de Google (precisión 1e5, el formato que devuelven Directions y Maps SDK)
+func Decode(s string) ([]Point, error) {
+	var points []Point
+	var lat, lng int64
+	for i := 0; i < len(s); {
+		for _, coord := range []*int64{&lat, &lng} {
+			var result int64
+			var shift uint
+			for {
+				if i >= len(s) || shift > 30 {
+					return nil, ErrInvalidPolyline
+				}
+				b := int64(s[i]) - 63
+				i++
+				if b < 0 || b > 0x3f {
+					return nil, ErrInvalidPolyline
+				}
+				result |= (b & 0x1f) << shift
+				shift += 5
+				if b < 0x20 {
+					break
+				}
+			}
+			if result&1 != 0 {
+				*coord += ^(result >> 1)
+			} else {
+				*coord += result >> 1
+			}
+		}
+		points = append(points, Point{Latitude: float64(lat) / 1e5, Longitude: float64(lng) / 1e5})
+	}
+	return points, nil
+}
+
+// WKT devuelve la línea como LINESTRING (lng lat) para ST_GeogFromText
+func WKT(points []Point) string {
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = strconv.FormatFloat(p.Longitude, 'f', -1, 64) + " " + strconv.FormatFloat(p.Latitude, 'f', -1, 64)
+	}
+	return fmt.Sprintf("SRID=4326;LINESTRING(%s)", strings.Join(coords, ","))
+}
+
+// LengthMeters es el largo total de la línea
+func LengthMeters(points []Point) float64 {
+	var total float64
+	for i := 1; i < len(points); i++ {
+		total += haversine(points[i-1], points[i])
+	}
+	return total
+}
+
+// DistanceMeters es la distancia de p al tramo más cercano de la línea.
+// Proyecta cada tramo en un plano local (equirectangular): a escala de una ruta urbana el error es despreciable.
+func DistanceMeters(points []Point, p Point) float64 {
+	if len(points) == 0 {
+		return math.Inf(1)
+	}
+	if len(points) == 1 {
+		return haversine(points[0], p)
+	}
+	best := math.Inf(1)
+	for i := 1; i < len(points); i++ {
+		if d := segmentDistance(points[i-1], points[i], p); d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+func segmentDistance(a, b, p Point) float64 {
+	cosLat := math.Cos(p.Latitude * math.Pi / 180)
+	toXY := func(q Point) (float64, float64) {
+		return (q.Longitude - p.Longitude) * cosLat, q.Latitude - p.Latitude
+	}
+	ax, ay := toXY(a)
+	bx, by := toXY(b)
+	dx, dy := bx-ax, by-ay
+
+	t := 0.0
+	if lenSq := dx*dx + dy*dy; lenSq > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/lenSq))
+	}
+	x, y := ax+t*dx, ay+t*dy
+	return math.Sqrt(x*x+y*y) * math.Pi / 180 * earthRadius
+}
+
+func haversine(a, b Point) float64 {
+	dLat := (b.Latitude - a.Latitude) * math.Pi / 180
+	dLng := (b.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(a.Latitude*math.Pi/180)*math.Cos(b.Latitude*math.Pi/180)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,54 @@
+package polyline
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDecodeGoogleExample(t *testing.T) {
+	// Ejemplo de la documentación de Google
+	points, err := Decode("_p~iF~ps|U_ulLnnqC_mqNvxq`@")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Point{{38.5, -120.2}, {40.7, -120.95}, {43.252, -126.453}}
+	if len(points) != len(want) {
+		t.Fatalf("expected %d points, got %d", len(want), len(points))
+	}
+	for i := range want {
+		if math.Abs(points[i].Latitude-want[i].Latitude) > 1e-9 || math.Abs(points[i].Longitude-want[i].Longitude) > 1e-9 {
+			t.Errorf("point %d: expected %v, got %v", i, want[i], points[i])
+		}
+	}
+}
+
+func TestDecodeRejectsTruncatedInput(t *testing.T) {
+	for _, s := range []string{"_p~iF~ps|U_", "_p~iF", " ", "_p~iF~ps|U_ulLnnqC_mqNvxq"} {
+		if _, err := Decode(s); err != ErrInvalidPolyline {
+			t.Errorf("Decode(%q): expected ErrInvalidPolyline, got %v", s, err)
+		}
+	}
+}
+
+func TestWKT(t *testing.T) {
+	got := WKT([]Point{{45.5017, -73.5673}, {45.5088, -73.554}})
+	want := "SRID=4326;LINESTRING(-73.5673 45.5017,-73.554 45.5088)"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestDistanceMeters(t *testing.T) {
+	// Tramo este-oeste sobre el ecuador: un grado de latitud al norte del medio son ~111 km
+	line := []Point{{0, 0}, {0, 2}}
+	if d := DistanceMeters(line, Point{1, 1}); math.Abs(d-111195) > 100 {
+		t.Errorf("expected ~111195m to the middle of the segment, got %.0f", d)
+	}
+	// Más allá del extremo la distancia es al vértice
+	if d := DistanceMeters(line, Point{0, 3}); math.Abs(d-111195) > 100 {
+		t.Errorf("expected ~111195m to the end vertex, got %.0f", d)
+	}
+	if d := DistanceMeters(line, Point{0, 0.5}); d > 1e-6 {
+		t.Errorf("expected 0 for a point on the line, got %f", d)
+	}
+}
@@ -0,0 +1,63 @@
+package publicfeed
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	cacheTTL        = time.Minute
+	maxCacheEntries = 500
+)
+
+// renderedFeed es la respuesta ya armada de un feed
+type renderedFeed struct {
+	body         []byte
+	contentType  string
+	etag         string
+	lastModified time.Time
+	expires      time.Time
+}
+
+// feedCache guarda los feeds renderizados un minuto: un widget embebido en un sitio con mucho tráfico
+// pide el mismo feed miles de veces y así llega a la base una vez por minuto por área
+type feedCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	entries map[string]renderedFeed
+	now     func() time.Time
+}
+
+func newFeedCache(ttl time.Duration, max int) *feedCache {
+	return &feedCache{ttl: ttl, max: max, entries: map[string]renderedFeed{}, now: time.Now}
+}
+
+func (c *feedCache) get(key string) (renderedFeed, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expires) {
+		return renderedFeed{}, false
+	}
+	return entry, true
+}
+
+func (c *feedCache) put(key string, entry renderedFeed) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if len(c.entries) >= c.max {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		// Si siguen todas vigentes se vacía: es solo un cache
+		if len(c.entries) >= c.max {
+			c.entries = map[string]renderedFeed{}
+		}
+	}
+	entry.expires = now.Add(c.ttl)
+	c.entries[key] = entry
+}
@@ -0,0 +1,103 @@
+package publicfeed
+
+import (
+	"alertly/internal/common"
+	"alertly/internal/database"
+	"alertly/internal/response"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var feeds = newFeedCache(cacheTTL, maxCacheEntries)
+
+// GetFeed devuelve los incidentes activos de un área como RSS, Atom o JSON Feed, para embeber en sitios de
+// noticias locales y páginas comunitarias.
+//
+//	GET /public/feed/rss?city=Toronto&categories=crime,fire_incident
+//	GET /public/feed/atom?neighbourhood_id=12
+//	GET /public/feed/json?latitude=43.65&longitude=-79.38&radius=2000
+func GetFeed(c *gin.Context) {
+	var inputs Inputs
+	if err := c.ShouldBindQuery(&inputs); err != nil {
+		log.Printf("Error en query params: %v", err)
+		response.Send(c, http.StatusBadRequest, true, ErrInvalidArea.Error(), nil)
+		return
+	}
+	filter, err := ParseInputs(c.Param("format"), inputs)
+	if err != nil {
+		response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+		return
+	}
+
+	key := CacheKey(filter)
+	rendered, ok := feeds.get(key)
+	if !ok {
+		service := NewService(NewRepository(database.DB))
+		feed, err := service.Build(filter, selfURL(filter))
+		if errors.Is(err, ErrNotFound) {
+			response.Send(c, http.StatusNotFound, true, err.Error(), nil)
+			return
+		}
+		if err != nil {
+			log.Printf("Error building public feed: %v", err)
+			response.Send(c, http.StatusInternalServerError, true, "We couldn’t load the feed. Please try again later.", nil)
+			return
+		}
+		body, contentType, err := Render(filter.Format, feed)
+		if err != nil {
+			log.Printf("Error rendering public feed: %v", err)
+			response.Send(c, http.StatusInternalServerError, true, "We couldn’t load the feed. Please try again later.", nil)
+			return
+		}
+		rendered = renderedFeed{body: body, contentType: contentType, etag: ETag(feed), lastModified: feed.Updated}
+		feeds.put(key, rendered)
+	}
+
+	// ✅ CACHE: los lectores de feeds y CDNs repiten el request con If-None-Match / If-Modified-Since
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Header("ETag", rendered.etag)
+	c.Header("Last-Modified", rendered.lastModified.Format(http.TimeFormat))
+	// Embebible desde cualquier sitio (sin cookies ni datos de usuario)
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.Writer.Header().Del("Access-Control-Allow-Credentials")
+
+	if notModified(c, rendered) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, rendered.contentType, rendered.body)
+}
+
+func notModified(c *gin.Context, rendered renderedFeed) bool {
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		return etagMatches(match, rendered.etag)
+	}
+	since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+	return err == nil && !rendered.lastModified.Truncate(time.Second).After(since)
+}
+
+// etagMatches compara If-None-Match como pide HTTP: "*", una lista separada por comas y comparación
+// débil (se ignora W/, que agregan algunos CDNs al comprimir)
+func etagMatches(header, etag string) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// selfURL es la URL canónica del feed (va en atom:link rel=self y feed_url). Se arma con API_URL y el
+// filtro, no con el Host ni la query del request, porque el feed renderizado se comparte en cache.
+func selfURL(f Filter) string {
+	return common.GetPublicAPIURL() + "/public/feed/" + f.Format + "?" + FeedQuery(f)
+}
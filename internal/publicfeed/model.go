@@ -0,0 +1,60 @@
+package publicfeed
+
+import (
+	"alertly/internal/clusterquery"
+	"time"
+)
+
+// Formatos de feed (/public/feed/:format)
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+	FormatJSON = "json"
+)
+
+// Inputs: un área (radio, barrio o ciudad) y categorías opcionales
+type Inputs struct {
+	Latitude        *float64 `form:"latitude"`
+	Longitude       *float64 `form:"longitude"`
+	Radius          float64  `form:"radius"` // metros, hasta 25000
+	NeighbourhoodID int64    `form:"neighbourhood_id"`
+	City            string   `form:"city"`
+	Categories      []string `form:"categories"` // category_code, repetido o separado por coma
+	Limit           int      `form:"limit"`
+}
+
+// Filter son los Inputs ya validados. Area solo usa Center, Neighbourhood y Categories.
+type Filter struct {
+	Format string
+	Area   clusterquery.Filter
+	City   string
+	Limit  int
+}
+
+// Item es un cluster activo con los datos que se pueden publicar: sin autor, con la ubicación
+// redondeada y sin dirección si algún report del cluster es anónimo
+type Item struct {
+	InclId          int64
+	Title           string
+	Summary         string
+	CategoryCode    string
+	SubcategoryCode string
+	City            string
+	Address         string
+	Latitude        float64
+	Longitude       float64
+	Credibility     float64
+	Official        bool // creado por el bot desde una fuente oficial
+	URL             string
+	Published       time.Time
+	Updated         time.Time
+}
+
+type Feed struct {
+	Title       string
+	Description string
+	HomeURL     string
+	SelfURL     string
+	Updated     time.Time
+	Items       []Item
+}
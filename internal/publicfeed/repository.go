@@ -0,0 +1,74 @@
+package publicfeed
+
+import (
+	"alertly/internal/clusterquery"
+	"alertly/internal/cronjobs/cjbot_creator"
+	"database/sql"
+	"fmt"
+)
+
+type Repository interface {
+	GetItems(f Filter) ([]Item, error)
+	GetNeighbourhoodName(nbhdID int64) (string, error)
+}
+
+type pgRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &pgRepository{db: db}
+}
+
+// GetItems devuelve los clusters activos del área, los más nuevos primero
+func (r *pgRepository) GetItems(f Filter) ([]Item, error) {
+	b := clusterquery.NewBuilder("c", cjbot_creator.BOT_USER_ID).Apply(f.Area)
+	if f.City != "" {
+		b.Where("LOWER(c.city) = LOWER(" + b.Arg(f.City) + ")")
+	}
+
+	query := fmt.Sprintf(`
+    SELECT
+        c.incl_id,
+        COALESCE(c.subcategory_name, ''),
+        COALESCE(c.description, ''),
+        COALESCE(c.category_code, ''),
+        COALESCE(c.subcategory_code, ''),
+        COALESCE(c.city, ''),
+        CASE WHEN EXISTS (
+            SELECT 1 FROM incident_reports r
+            WHERE r.incl_id = c.incl_id AND TRIM(COALESCE(r.is_anonymous, '0')) = '1'
+        ) THEN '' ELSE COALESCE(c.address, '') END,
+        c.center_latitude,
+        c.center_longitude,
+        COALESCE(c.credibility, 0),
+        COALESCE(c.account_id, 0) = $1,
+        c.created_at,
+        COALESCE(c.updated_at, c.created_at)
+    FROM incident_clusters c
+    WHERE %s`, b.SQL())
+	query += b.OrderBy(clusterquery.SortRecent, nil) + b.Limit(f.Limit)
+
+	rows, err := r.db.Query(query, b.Params()...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying feed: %w", err)
+	}
+	defer rows.Close()
+
+	var items []Item
+	for rows.Next() {
+		var it Item
+		if err := rows.Scan(&it.InclId, &it.Title, &it.Summary, &it.CategoryCode, &it.SubcategoryCode, &it.City,
+			&it.Address, &it.Latitude, &it.Longitude, &it.Credibility, &it.Official, &it.Published, &it.Updated); err != nil {
+			return nil, fmt.Errorf("error scanning feed item: %w", err)
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+func (r *pgRepository) GetNeighbourhoodName(nbhdID int64) (string, error) {
+	var name string
+	err := r.db.QueryRow(`SELECT name FROM neighbourhoods WHERE nbhd_id = $1`, nbhdID).Scan(&name)
+	return name, err
+}
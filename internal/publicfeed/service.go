@@ -0,0 +1,215 @@
+package publicfeed
+
+import (
+	"alertly/internal/clusterquery"
+	"alertly/internal/common"
+	"alertly/internal/pagination"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultItems    = 30
+	maxItems        = 100
+	maxRadius       = 25000 // metros
+	maxSummaryRunes = 500
+	// Las coordenadas públicas se redondean a 3 decimales (~110 m): ubican la cuadra, no la casa de quien reportó
+	coordinatePrecision = 1000
+)
+
+var (
+	ErrInvalidFormat = errors.New("format must be rss, atom or json")
+	ErrInvalidArea   = errors.New("send one area: latitude, longitude and radius (up to 25000 m), a neighbourhood_id or a city")
+	ErrNotFound      = errors.New("neighbourhood not found")
+)
+
+type Service interface {
+	Build(f Filter, selfURL string) (Feed, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) Build(f Filter, selfURL string) (Feed, error) {
+	area, err := s.areaTitle(f)
+	if err != nil {
+		return Feed{}, err
+	}
+	items, err := s.repo.GetItems(f)
+	if err != nil {
+		return Feed{}, err
+	}
+
+	feed := Feed{
+		Title:       "Alertly incidents " + area,
+		Description: "Active incidents reported on Alertly " + area + ".",
+		HomeURL:     common.GetPublicWebURL(),
+		SelfURL:     selfURL,
+		Items:       make([]Item, 0, len(items)),
+	}
+	if len(f.Area.Categories) > 0 {
+		feed.Title += " (" + strings.Join(f.Area.Categories, ", ") + ")"
+	}
+	for _, it := range items {
+		it = publicItem(it)
+		if it.Updated.After(feed.Updated) {
+			feed.Updated = it.Updated
+		}
+		feed.Items = append(feed.Items, it)
+	}
+	if feed.Updated.IsZero() {
+		// Feed vacío: no hay nada más nuevo que el epoch, así el ETag/Last-Modified no cambian en cada request
+		feed.Updated = time.Unix(0, 0).UTC()
+	}
+	return feed, nil
+}
+
+func (s *service) areaTitle(f Filter) (string, error) {
+	switch {
+	case f.Area.Neighbourhood > 0:
+		name, err := s.repo.GetNeighbourhoodName(f.Area.Neighbourhood)
+		if err == sql.ErrNoRows {
+			return "", ErrNotFound
+		}
+		if err != nil {
+			return "", err
+		}
+		return "in " + name, nil
+	case f.City != "":
+		return "in " + f.City, nil
+	default:
+		c := f.Area.Center
+		return fmt.Sprintf("within %s of %.3f, %.3f", formatDistance(c.Radius), c.Latitude, c.Longitude), nil
+	}
+}
+
+// publicItem deja solo lo publicable: ubicación redondeada, la hora en UTC y el resumen acotado
+func publicItem(it Item) Item {
+	it.Latitude = math.Round(it.Latitude*coordinatePrecision) / coordinatePrecision
+	it.Longitude = math.Round(it.Longitude*coordinatePrecision) / coordinatePrecision
+	it.Published = it.Published.UTC()
+	it.Updated = it.Updated.UTC()
+	if it.Updated.Before(it.Published) {
+		it.Updated = it.Published
+	}
+	if it.Title == "" {
+		it.Title = "Incident"
+	}
+	if it.City != "" {
+		it.Title += " in " + it.City
+	}
+	it.Summary = strings.TrimSpace(it.Summary)
+	if utf8.RuneCountInString(it.Summary) > maxSummaryRunes {
+		it.Summary = string([]rune(it.Summary)[:maxSummaryRunes-1]) + "…"
+	}
+	it.URL = common.GetIncidentWebURL(it.InclId)
+	return it
+}
+
+func formatDistance(meters float64) string {
+	if meters >= 1000 {
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", meters/1000), ".0") + " km"
+	}
+	return fmt.Sprintf("%.0f m", meters)
+}
+
+// ParseInputs valida el formato y el área. Solo clusters activos: el feed es para "qué está pasando ahora".
+func ParseInputs(format string, in Inputs) (Filter, error) {
+	f := Filter{
+		Format: strings.ToLower(format),
+		City:   strings.TrimSpace(in.City),
+		Limit:  pagination.Limit(in.Limit, defaultItems, maxItems),
+		Area: clusterquery.Filter{
+			Status:     clusterquery.StatusActive,
+			Categories: clusterquery.SplitList(in.Categories...),
+		},
+	}
+	if f.Format != FormatRSS && f.Format != FormatAtom && f.Format != FormatJSON {
+		return Filter{}, ErrInvalidFormat
+	}
+
+	hasCenter := in.Latitude != nil || in.Longitude != nil || in.Radius != 0
+	areas := 0
+	for _, set := range []bool{hasCenter, in.NeighbourhoodID != 0, f.City != ""} {
+		if set {
+			areas++
+		}
+	}
+	if areas != 1 {
+		return Filter{}, ErrInvalidArea
+	}
+
+	switch {
+	case hasCenter:
+		if in.Latitude == nil || in.Longitude == nil || in.Radius <= 0 || in.Radius > maxRadius ||
+			*in.Latitude < -90 || *in.Latitude > 90 || *in.Longitude < -180 || *in.Longitude > 180 {
+			return Filter{}, ErrInvalidArea
+		}
+		f.Area.Center = &clusterquery.Center{Point: clusterquery.Point{Latitude: *in.Latitude, Longitude: *in.Longitude}, Radius: in.Radius}
+	case in.NeighbourhoodID != 0:
+		if in.NeighbourhoodID < 0 {
+			return Filter{}, ErrInvalidArea
+		}
+		f.Area.Neighbourhood = in.NeighbourhoodID
+	default:
+		if utf8.RuneCountInString(f.City) > 100 {
+			return Filter{}, ErrInvalidArea
+		}
+	}
+	return f, nil
+}
+
+// CacheKey identifica el feed de un Filter (la misma área escrita distinto comparte entrada)
+func CacheKey(f Filter) string {
+	key := fmt.Sprintf("%s|%d|%s|%s|%d", f.Format, f.Area.Neighbourhood, strings.ToLower(f.City),
+		strings.Join(f.Area.Categories, ","), f.Limit)
+	if c := f.Area.Center; c != nil {
+		key += fmt.Sprintf("|%.5f,%.5f,%.0f", c.Latitude, c.Longitude, c.Radius)
+	}
+	return key
+}
+
+// FeedQuery es la query string canónica del filtro: la misma para todos los requests que comparten CacheKey,
+// así el link rel=self guardado en cache no depende de quién lo pidió primero
+func FeedQuery(f Filter) string {
+	v := url.Values{}
+	if c := f.Area.Center; c != nil {
+		v.Set("latitude", strconv.FormatFloat(c.Latitude, 'f', 5, 64))
+		v.Set("longitude", strconv.FormatFloat(c.Longitude, 'f', 5, 64))
+		v.Set("radius", strconv.FormatFloat(c.Radius, 'f', 0, 64))
+	}
+	if f.Area.Neighbourhood != 0 {
+		v.Set("neighbourhood_id", strconv.FormatInt(f.Area.Neighbourhood, 10))
+	}
+	if f.City != "" {
+		v.Set("city", strings.ToLower(f.City))
+	}
+	if len(f.Area.Categories) > 0 {
+		v.Set("categories", strings.Join(f.Area.Categories, ","))
+	}
+	v.Set("limit", strconv.Itoa(f.Limit))
+	return v.Encode()
+}
+
+// ETag cambia cuando entra, sale o se actualiza algún item
+func ETag(feed Feed) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s|%d", feed.Title, feed.Updated.UnixMicro())
+	for _, it := range feed.Items {
+		fmt.Fprintf(h, "|%d:%d", it.InclId, it.Updated.UnixMicro())
+	}
+	return fmt.Sprintf("\"%x\"", h.Sum64())
+}
@@ -0,0 +1,182 @@
+package publicfeed
+
+import (
+	"alertly/internal/clusterquery"
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fp(v float64) *float64 { return &v }
+
+type fakeRepo struct {
+	items []Item
+}
+
+func (r *fakeRepo) GetItems(f Filter) ([]Item, error) {
+	return r.items, nil
+}
+
+func (r *fakeRepo) GetNeighbourhoodName(nbhdID int64) (string, error) {
+	if nbhdID == 12 {
+		return "Annex", nil
+	}
+	return "", sql.ErrNoRows
+}
+
+func TestParseInputs(t *testing.T) {
+	f, err := ParseInputs("RSS", Inputs{Latitude: fp(43.65), Longitude: fp(-79.38), Radius: 2000, Categories: []string{"crime,fire_incident"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Format != FormatRSS || f.Area.Center == nil || len(f.Area.Categories) != 2 || f.Limit != defaultItems {
+		t.Errorf("unexpected filter: %+v", f)
+	}
+
+	invalid := []struct {
+		name   string
+		format string
+		in     Inputs
+		want   error
+	}{
+		{"format", "xml", Inputs{City: "Toronto"}, ErrInvalidFormat},
+		{"no area", "rss", Inputs{}, ErrInvalidArea},
+		{"two areas", "rss", Inputs{City: "Toronto", NeighbourhoodID: 12}, ErrInvalidArea},
+		{"radius without point", "rss", Inputs{Radius: 500}, ErrInvalidArea},
+		{"radius too large", "rss", Inputs{Latitude: fp(43.65), Longitude: fp(-79.38), Radius: 100000}, ErrInvalidArea},
+	}
+	for _, tc := range invalid {
+		if _, err := ParseInputs(tc.format, tc.in); err != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestBuildRoundsLocationAndTitles(t *testing.T) {
+	published := time.Date(2026, 10, 17, 14, 0, 0, 0, time.UTC)
+	repo := &fakeRepo{items: []Item{{InclId: 7, Title: "Car crash", City: "Toronto", Latitude: 43.653226, Longitude: -79.383184,
+		Summary: strings.Repeat("a", maxSummaryRunes+10), Published: published, Updated: published.Add(time.Hour)}}}
+
+	feed, err := NewService(repo).Build(Filter{Format: FormatRSS, Area: newArea(12)}, "https://api.alertly.ca/public/feed/rss?neighbourhood_id=12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Title != "Alertly incidents in Annex" {
+		t.Errorf("title = %q", feed.Title)
+	}
+	it := feed.Items[0]
+	if it.Latitude != 43.653 || it.Longitude != -79.383 {
+		t.Errorf("coordinates should be rounded to 3 decimals, got %v, %v", it.Latitude, it.Longitude)
+	}
+	if it.Title != "Car crash in Toronto" || !strings.HasSuffix(it.URL, "/incident/7") {
+		t.Errorf("unexpected item: %+v", it)
+	}
+	if len([]rune(it.Summary)) != maxSummaryRunes {
+		t.Errorf("summary should be cut to %d runes, got %d", maxSummaryRunes, len([]rune(it.Summary)))
+	}
+	if !feed.Updated.Equal(published.Add(time.Hour)) {
+		t.Errorf("feed updated = %v", feed.Updated)
+	}
+
+	if _, err := NewService(repo).Build(Filter{Area: newArea(99)}, ""); err != ErrNotFound {
+		t.Errorf("unknown neighbourhood: expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRenderFormats(t *testing.T) {
+	at := time.Date(2026, 10, 17, 14, 0, 0, 0, time.UTC)
+	feed := Feed{Title: "Alertly incidents in Toronto", HomeURL: "https://alertly.ca", SelfURL: "https://api/feed", Updated: at,
+		Items: []Item{{InclId: 7, Title: "Fire & smoke <downtown>", CategoryCode: "fire_incident", Latitude: 43.653, Longitude: -79.383,
+			URL: "https://alertly.ca/incident/7", Published: at, Updated: at}}}
+
+	for _, format := range []string{FormatRSS, FormatAtom} {
+		body, contentType, err := Render(format, feed)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if !strings.Contains(contentType, format+"+xml") {
+			t.Errorf("%s: content type = %q", format, contentType)
+		}
+		if err := xml.Unmarshal(body, new(interface{})); err != nil {
+			t.Errorf("%s: invalid XML: %v", format, err)
+		}
+		if !strings.Contains(string(body), "Fire &amp; smoke &lt;downtown&gt;") || !strings.Contains(string(body), "43.653 -79.383") {
+			t.Errorf("%s: unexpected body:\n%s", format, body)
+		}
+	}
+
+	body, _, err := Render(FormatJSON, feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc jsonFeed
+	if err := json.Unmarshal(body, &doc); err != nil || len(doc.Items) != 1 || doc.Items[0].Alertly.InclId != 7 {
+		t.Errorf("unexpected JSON feed: %s (%v)", body, err)
+	}
+}
+
+func TestETagChangesWithItems(t *testing.T) {
+	at := time.Date(2026, 10, 17, 14, 0, 0, 0, time.UTC)
+	a := Feed{Title: "t", Updated: at, Items: []Item{{InclId: 1, Updated: at}}}
+	b := Feed{Title: "t", Updated: at, Items: []Item{{InclId: 1, Updated: at}, {InclId: 2, Updated: at}}}
+	if ETag(a) == ETag(b) {
+		t.Errorf("ETag should change when an item is added")
+	}
+	if ETag(a) != ETag(Feed{Title: "t", Updated: at, Items: []Item{{InclId: 1, Updated: at}}}) {
+		t.Errorf("ETag should be stable for the same feed")
+	}
+}
+
+func TestFeedCacheExpires(t *testing.T) {
+	now := time.Date(2026, 10, 17, 14, 0, 0, 0, time.UTC)
+	cache := newFeedCache(time.Minute, 2)
+	cache.now = func() time.Time { return now }
+
+	cache.put("a", renderedFeed{etag: "1"})
+	if got, ok := cache.get("a"); !ok || got.etag != "1" {
+		t.Fatalf("expected a cached entry")
+	}
+	now = now.Add(time.Minute)
+	if _, ok := cache.get("a"); ok {
+		t.Errorf("entry should expire after the TTL")
+	}
+
+	cache.put("b", renderedFeed{})
+	cache.put("c", renderedFeed{})
+	if len(cache.entries) > 2 {
+		t.Errorf("cache should not grow past max, has %d entries", len(cache.entries))
+	}
+}
+
+func newArea(nbhdID int64) clusterquery.Filter {
+	return clusterquery.Filter{Neighbourhood: nbhdID}
+}
+
+func TestETagMatches(t *testing.T) {
+	etag := `"abc123"`
+	for header, want := range map[string]bool{
+		`"abc123"`:             true,
+		`W/"abc123"`:           true,
+		`"old", W/"abc123"`:    true,
+		`*`:                    true,
+		`"old"`:                false,
+		`"abc123-gzip", "xyz"`: false,
+	} {
+		if got := etagMatches(header, etag); got != want {
+			t.Errorf("etagMatches(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
+
+func TestSelfURLIgnoresRequest(t *testing.T) {
+	t.Setenv("API_URL", "https://api.example.com/")
+	f := Filter{Format: "atom", City: "Toronto", Limit: 50}
+	f.Area.Categories = []string{"crime", "fire_incident"}
+	want := "https://api.example.com/public/feed/atom?categories=crime%2Cfire_incident&city=toronto&limit=50"
+	if got := selfURL(f); got != want {
+		t.Errorf("selfURL() = %q, want %q", got, want)
+	}
+}
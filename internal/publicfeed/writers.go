@@ -0,0 +1,244 @@
+package publicfeed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// Render escribe el feed en el formato pedido y devuelve el Content-Type
+func Render(format string, feed Feed) ([]byte, string, error) {
+	switch format {
+	case FormatRSS:
+		body, err := renderRSS(feed)
+		return body, "application/rss+xml; charset=utf-8", err
+	case FormatAtom:
+		body, err := renderAtom(feed)
+		return body, "application/atom+xml; charset=utf-8", err
+	case FormatJSON:
+		body, err := renderJSON(feed)
+		return body, "application/feed+json; charset=utf-8", err
+	default:
+		return nil, "", ErrInvalidFormat
+	}
+}
+
+func geoPoint(it Item) string {
+	return fmt.Sprintf("%.3f %.3f", it.Latitude, it.Longitude)
+}
+
+// description suma la dirección (si se puede publicar) al resumen
+func description(it Item) string {
+	if it.Address == "" {
+		return it.Summary
+	}
+	if it.Summary == "" {
+		return it.Address
+	}
+	return it.Address + " — " + it.Summary
+}
+
+// RSS 2.0 con georss:point
+
+type rssDoc struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	GeoNS   string     `xml:"xmlns:georss,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	SelfLink      atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	TTL           int       `xml:"ttl"` // minutos
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+	Point       string   `xml:"georss:point"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+func renderRSS(feed Feed) ([]byte, error) {
+	doc := rssDoc{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		GeoNS:   "http://www.georss.org/georss",
+		Channel: rssChannel{
+			Title:         feed.Title,
+			Link:          feed.HomeURL,
+			Description:   feed.Description,
+			SelfLink:      atomLink{Href: feed.SelfURL, Rel: "self", Type: "application/rss+xml"},
+			LastBuildDate: feed.Updated.Format(time.RFC1123Z),
+			TTL:           5,
+		},
+	}
+	for _, it := range feed.Items {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       it.Title,
+			Link:        it.URL,
+			Description: description(it),
+			GUID:        rssGUID{IsPermaLink: true, Value: it.URL},
+			PubDate:     it.Published.Format(time.RFC1123Z),
+			Categories:  []string{it.CategoryCode},
+			Point:       geoPoint(it),
+		})
+	}
+	return marshalXML(doc)
+}
+
+// Atom 1.0 con georss:point
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	GeoNS   string      `xml:"xmlns:georss,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string         `xml:"id"`
+	Title     string         `xml:"title"`
+	Link      atomLink       `xml:"link"`
+	Published string         `xml:"published"`
+	Updated   string         `xml:"updated"`
+	Summary   string         `xml:"summary,omitempty"`
+	Category  atomCategory   `xml:"category"`
+	Author    atomAuthorName `xml:"author"`
+	Point     string         `xml:"georss:point"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomAuthorName struct {
+	Name string `xml:"name"`
+}
+
+func renderAtom(feed Feed) ([]byte, error) {
+	doc := atomFeed{
+		GeoNS:   "http://www.georss.org/georss",
+		ID:      feed.SelfURL,
+		Title:   feed.Title,
+		Updated: feed.Updated.Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: feed.SelfURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: feed.HomeURL, Rel: "alternate", Type: "text/html"},
+		},
+	}
+	for _, it := range feed.Items {
+		doc.Entries = append(doc.Entries, atomEntry{
+			ID:        it.URL,
+			Title:     it.Title,
+			Link:      atomLink{Href: it.URL, Rel: "alternate", Type: "text/html"},
+			Published: it.Published.Format(time.RFC3339),
+			Updated:   it.Updated.Format(time.RFC3339),
+			Summary:   description(it),
+			Category:  atomCategory{Term: it.CategoryCode},
+			Author:    atomAuthorName{Name: "Alertly"}, // nunca el usuario que reportó
+			Point:     geoPoint(it),
+		})
+	}
+	return marshalXML(doc)
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// JSON Feed 1.1 (https://jsonfeed.org/version/1.1); la ubicación va en la extensión _alertly
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string          `json:"id"`
+	URL           string          `json:"url"`
+	Title         string          `json:"title"`
+	ContentText   string          `json:"content_text"`
+	DatePublished string          `json:"date_published"`
+	DateModified  string          `json:"date_modified"`
+	Tags          []string        `json:"tags"`
+	Alertly       jsonFeedAlertly `json:"_alertly"`
+}
+
+type jsonFeedAlertly struct {
+	InclId          int64   `json:"incl_id"`
+	CategoryCode    string  `json:"category_code"`
+	SubcategoryCode string  `json:"subcategory_code"`
+	City            string  `json:"city,omitempty"`
+	Address         string  `json:"address,omitempty"`
+	Latitude        float64 `json:"latitude"`
+	Longitude       float64 `json:"longitude"`
+	Credibility     float64 `json:"credibility"`
+	Official        bool    `json:"official"`
+}
+
+func renderJSON(feed Feed) ([]byte, error) {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feed.Title,
+		HomePageURL: feed.HomeURL,
+		FeedURL:     feed.SelfURL,
+		Description: feed.Description,
+		Items:       []jsonFeedItem{},
+	}
+	for _, it := range feed.Items {
+		doc.Items = append(doc.Items, jsonFeedItem{
+			ID:            it.URL,
+			URL:           it.URL,
+			Title:         it.Title,
+			ContentText:   description(it),
+			DatePublished: it.Published.Format(time.RFC3339),
+			DateModified:  it.Updated.Format(time.RFC3339),
+			Tags:          []string{it.CategoryCode},
+			Alertly: jsonFeedAlertly{
+				InclId:          it.InclId,
+				CategoryCode:    it.CategoryCode,
+				SubcategoryCode: it.SubcategoryCode,
+				City:            it.City,
+				Address:         it.Address,
+				Latitude:        it.Latitude,
+				Longitude:       it.Longitude,
+				Credibility:     it.Credibility,
+				Official:        it.Official,
+			},
+		})
+	}
+	return json.Marshal(doc)
+}
@@ -0,0 +1,56 @@
+package realtime
+
+import (
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+)
+
+// Broker conecta a los productores (paths de escritura de incidentes) con los streams abiertos.
+// Hoy es el Hub en memoria. Con varias instancias de la API se puede reemplazar por uno que
+// publique con pg_notify y escuche con pq.Listener, sin tocar a los productores ni al handler.
+type Broker interface {
+	// Active indica si vale la pena armar eventos (en el Hub: si hay alguien suscripto)
+	Active() bool
+	Publish(e Event)
+	Subscribe(accountID int64, area Area) (*Subscription, error)
+	Unsubscribe(sub *Subscription)
+}
+
+var (
+	mu     sync.RWMutex
+	broker Broker
+	repo   Repository
+)
+
+// Start habilita los eventos en este proceso. Sin Start (p.ej. en la lambda de cronjobs)
+// PublishCluster no hace nada.
+func Start(db *sql.DB, b Broker) {
+	mu.Lock()
+	defer mu.Unlock()
+	broker, repo = b, NewRepository(db)
+}
+
+func current() (Broker, Repository) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return broker, repo
+}
+
+// PublishCluster lee el estado actual del cluster y publica el evento. Se llama después del commit;
+// un error solo se loguea para no afectar la escritura que lo originó.
+func PublishCluster(eventType string, inclID int64) {
+	b, r := current()
+	if b == nil || inclID == 0 || !b.Active() {
+		return
+	}
+	e, err := r.GetClusterEvent(inclID)
+	if err != nil {
+		log.Printf("⚠️ realtime: error loading cluster %d for %s: %v", inclID, eventType, err)
+		return
+	}
+	e.Type = eventType
+	e.At = time.Now().UTC()
+	b.Publish(e)
+}
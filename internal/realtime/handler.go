@@ -0,0 +1,89 @@
+package realtime
+
+import (
+	"alertly/internal/auth"
+	"alertly/internal/database"
+	"alertly/internal/response"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cada tanto se manda un comentario para que proxies y load balancers no corten la conexión
+const heartbeatInterval = 25 * time.Second
+
+// Stream abre un stream SSE con los eventos de los clusters de un bbox o de un lugar guardado:
+// cluster_created, cluster_updated, vote_changed y cluster_expired. Si llega un evento "resync"
+// el cliente se quedó atrás: tiene que pedir /cluster/sync y volver a conectarse.
+func Stream(c *gin.Context) {
+	accountID, err := auth.GetUserFromContext(c)
+	if err != nil {
+		response.Send(c, http.StatusUnauthorized, true, "Unauthorized", nil)
+		return
+	}
+
+	b, _ := current()
+	if b == nil {
+		response.Send(c, http.StatusServiceUnavailable, true, "Live updates are not available right now.", nil)
+		return
+	}
+
+	var inputs Inputs
+	if err := c.ShouldBindQuery(&inputs); err != nil {
+		log.Printf("Error en query params: %v", err)
+		response.Send(c, http.StatusBadRequest, true, "Invalid query parameters. Please check and try again.", nil)
+		return
+	}
+
+	area, err := ResolveArea(inputs, accountID, NewRepository(database.DB))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidArea):
+			response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+		case errors.Is(err, ErrPlaceNotFound):
+			response.Send(c, http.StatusNotFound, true, err.Error(), nil)
+		default:
+			log.Printf("Error resolving stream area: %v", err)
+			response.Send(c, http.StatusInternalServerError, true, "We couldn’t open the live updates. Please try again later.", nil)
+		}
+		return
+	}
+
+	sub, err := b.Subscribe(accountID, area)
+	if err != nil {
+		response.Send(c, http.StatusTooManyRequests, true, err.Error(), nil)
+		return
+	}
+	defer b.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // nginx no debe bufferear el stream
+	c.SSEvent("ready", gin.H{"heartbeat_seconds": int(heartbeatInterval.Seconds())})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case e, ok := <-sub.Events():
+			if !ok {
+				c.SSEvent("resync", gin.H{"reason": "lagging"})
+				return false
+			}
+			c.SSEvent(e.Type, e)
+			return true
+		case <-heartbeat.C:
+			io.WriteString(w, ": ping\n\n")
+			return true
+		}
+	})
+}
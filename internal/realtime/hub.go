@@ -0,0 +1,98 @@
+package realtime
+
+import (
+	"errors"
+	"sync"
+)
+
+const (
+	subscriptionBuffer         = 64 // eventos en cola por suscriptor antes de cortarlo por lento
+	maxSubscriptionsPerAccount = 5
+)
+
+var ErrTooManySubscriptions = errors.New("too many open streams for this account")
+
+// Subscription recibe los eventos de su Area. Si el canal se cierra sin que el cliente se haya ido,
+// el suscriptor se quedó atrás y tiene que resincronizar (GET /cluster/sync) y reconectarse.
+type Subscription struct {
+	accountID int64
+	area      Area
+	events    chan Event
+}
+
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Hub es el Broker en memoria: reparte cada evento a las suscripciones de esta instancia
+type Hub struct {
+	mu        sync.RWMutex
+	subs      map[*Subscription]struct{}
+	byAccount map[int64]int
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: map[*Subscription]struct{}{}, byAccount: map[int64]int{}}
+}
+
+func (h *Hub) Active() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs) > 0
+}
+
+func (h *Hub) Subscribe(accountID int64, area Area) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.byAccount[accountID] >= maxSubscriptionsPerAccount {
+		return nil, ErrTooManySubscriptions
+	}
+	sub := &Subscription{accountID: accountID, area: area, events: make(chan Event, subscriptionBuffer)}
+	h.subs[sub] = struct{}{}
+	h.byAccount[accountID]++
+	return sub, nil
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+// Publish nunca bloquea al productor: si la cola de un suscriptor está llena se lo desconecta
+func (h *Hub) Publish(e Event) {
+	var lagging []*Subscription
+	h.mu.RLock()
+	for sub := range h.subs {
+		if !sub.area.Contains(e) {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			lagging = append(lagging, sub)
+		}
+	}
+	h.mu.RUnlock()
+
+	if len(lagging) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, sub := range lagging {
+		h.remove(sub)
+	}
+}
+
+// remove saca la suscripción y cierra su canal; requiere h.mu tomado
+func (h *Hub) remove(sub *Subscription) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.events)
+	if h.byAccount[sub.accountID]--; h.byAccount[sub.accountID] <= 0 {
+		delete(h.byAccount, sub.accountID)
+	}
+}
@@ -0,0 +1,150 @@
+package realtime
+
+import (
+	"alertly/internal/polyline"
+	"database/sql"
+	"testing"
+)
+
+func fp(v float64) *float64 { return &v }
+
+var toronto = BBoxArea{MinLatitude: 43.5, MaxLatitude: 43.9, MinLongitude: -79.7, MaxLongitude: -79.1}
+
+func TestHubDeliversOnlyMatchingEvents(t *testing.T) {
+	h := NewHub()
+	sub, err := h.Subscribe(1, toronto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Publish(Event{Type: EventClusterCreated, InclId: 1, Latitude: 43.65, Longitude: -79.38})
+	h.Publish(Event{Type: EventClusterCreated, InclId: 2, Latitude: 45.50, Longitude: -73.56}) // Montreal
+
+	if got := len(sub.Events()); got != 1 {
+		t.Fatalf("expected 1 event, got %d", got)
+	}
+	if e := <-sub.Events(); e.InclId != 1 {
+		t.Errorf("unexpected event %+v", e)
+	}
+
+	h.Unsubscribe(sub)
+	if _, ok := <-sub.Events(); ok || h.Active() {
+		t.Errorf("unsubscribe should close the channel and leave the hub idle")
+	}
+	h.Unsubscribe(sub) // no debe cerrar dos veces
+}
+
+func TestHubDropsLaggingSubscriber(t *testing.T) {
+	h := NewHub()
+	slow, _ := h.Subscribe(1, toronto)
+	for i := 0; i <= subscriptionBuffer; i++ {
+		h.Publish(Event{InclId: int64(i), Latitude: 43.65, Longitude: -79.38})
+	}
+
+	n := 0
+	for range slow.Events() {
+		n++
+	}
+	if n != subscriptionBuffer || h.Active() {
+		t.Errorf("lagging subscriber should get %d events and be removed, got %d", subscriptionBuffer, n)
+	}
+}
+
+func TestHubLimitsSubscriptionsPerAccount(t *testing.T) {
+	h := NewHub()
+	for i := 0; i < maxSubscriptionsPerAccount; i++ {
+		if _, err := h.Subscribe(7, toronto); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := h.Subscribe(7, toronto); err != ErrTooManySubscriptions {
+		t.Errorf("error = %v, want ErrTooManySubscriptions", err)
+	}
+	if _, err := h.Subscribe(8, toronto); err != nil {
+		t.Errorf("other accounts should not be limited: %v", err)
+	}
+}
+
+type fakeRepo struct {
+	place CircleArea
+	err   error
+}
+
+func (r *fakeRepo) GetClusterEvent(inclID int64) (Event, error) {
+	return Event{InclId: inclID, Latitude: 43.65, Longitude: -79.38, CategoryCode: "crime"}, nil
+}
+
+func (r *fakeRepo) GetSavedPlace(accountID, aflID int64) (CircleArea, error) {
+	return r.place, r.err
+}
+
+func TestResolveArea(t *testing.T) {
+	repo := &fakeRepo{place: CircleArea{Latitude: 43.65, Longitude: -79.38}}
+
+	area, err := ResolveArea(Inputs{AflId: 3, Categories: "crime"}, 1, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	circle := area.(CircleArea)
+	if circle.Radius != defaultPlaceRadius {
+		t.Errorf("radius = %v, want default %v", circle.Radius, defaultPlaceRadius)
+	}
+	if !area.Contains(Event{Latitude: 43.655, Longitude: -79.38, CategoryCode: "crime"}) {
+		t.Errorf("event ~550 m away should be inside the saved place")
+	}
+	if area.Contains(Event{Latitude: 43.655, Longitude: -79.38, CategoryCode: "fire_incident"}) {
+		t.Errorf("categories filter should apply")
+	}
+
+	invalid := []struct {
+		name string
+		in   Inputs
+		err  error
+		want error
+	}{
+		{"nothing", Inputs{}, nil, ErrInvalidArea},
+		{"both", Inputs{AflId: 3, MinLatitude: fp(1), MaxLatitude: fp(2), MinLongitude: fp(1), MaxLongitude: fp(2)}, nil, ErrInvalidArea},
+		{"reversed bbox", Inputs{MinLatitude: fp(2), MaxLatitude: fp(1), MinLongitude: fp(1), MaxLongitude: fp(2)}, nil, ErrInvalidArea},
+		{"someone else's place", Inputs{AflId: 3}, sql.ErrNoRows, ErrPlaceNotFound},
+	}
+	for _, tt := range invalid {
+		repo.err = tt.err
+		if _, err := ResolveArea(tt.in, 1, repo); err != tt.want {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestPublishCluster(t *testing.T) {
+	PublishCluster(EventClusterCreated, 1) // sin Start no hace nada
+
+	h := NewHub()
+	mu.Lock()
+	broker, repo = h, &fakeRepo{}
+	mu.Unlock()
+	defer func() {
+		mu.Lock()
+		broker, repo = nil, nil
+		mu.Unlock()
+	}()
+
+	sub, _ := h.Subscribe(1, toronto)
+	PublishCluster(EventVoteChanged, 42)
+	e := <-sub.Events()
+	if e.Type != EventVoteChanged || e.InclId != 42 || e.At.IsZero() {
+		t.Errorf("unexpected event %+v", e)
+	}
+}
+
+func TestCircleAreaAlongRoute(t *testing.T) {
+	// Ruta hacia el este por Queen St (Toronto) con corredor de 200 m
+	area := CircleArea{Latitude: 43.6505, Longitude: -79.40, Radius: 200,
+		Route: []polyline.Point{{Latitude: 43.6505, Longitude: -79.40}, {Latitude: 43.6505, Longitude: -79.36}}}
+
+	if !area.Contains(Event{Latitude: 43.6515, Longitude: -79.37}) {
+		t.Errorf("event ~110 m from the middle of the route should be inside the corridor")
+	}
+	if area.Contains(Event{Latitude: 43.6545, Longitude: -79.37}) {
+		t.Errorf("event ~450 m from the route should be outside the corridor")
+	}
+}
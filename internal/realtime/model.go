@@ -0,0 +1,94 @@
+package realtime
+
+import (
+	"alertly/internal/polyline"
+	"math"
+	"time"
+)
+
+// Tipos de evento (nombre del evento SSE)
+const (
+	EventClusterCreated = "cluster_created"
+	EventClusterUpdated = "cluster_updated" // nuevo report, edición, dirección resuelta
+	EventVoteChanged    = "vote_changed"
+	EventClusterExpired = "cluster_expired" // expiró, se cerró o fue bloqueado: el cliente lo quita del mapa
+)
+
+// Event es lo que recibe el cliente: el tipo y el estado del cluster después del cambio
+type Event struct {
+	Type            string    `json:"type"`
+	InclId          int64     `json:"incl_id"`
+	Latitude        float64   `json:"latitude"`
+	Longitude       float64   `json:"longitude"`
+	CategoryCode    string    `json:"category_code"`
+	SubcategoryCode string    `json:"subcategory_code"`
+	Credibility     float64   `json:"credibility"`
+	TotalVotes      int64     `json:"counter_total_votes"`
+	VotesTrue       int64     `json:"counter_total_votes_true"`
+	VotesFalse      int64     `json:"counter_total_votes_false"`
+	IsActive        bool      `json:"is_active"`
+	At              time.Time `json:"at"`
+}
+
+// Inputs de GET /api/cluster/stream: un bbox o un lugar guardado (afl_id)
+type Inputs struct {
+	MinLatitude  *float64 `form:"min_latitude"`
+	MaxLatitude  *float64 `form:"max_latitude"`
+	MinLongitude *float64 `form:"min_longitude"`
+	MaxLongitude *float64 `form:"max_longitude"`
+	AflId        int64    `form:"afl_id"`
+	Categories   string   `form:"categories"` // category_code separados por coma; vacío = todas
+}
+
+// Area decide qué eventos le llegan a una suscripción
+type Area interface {
+	Contains(e Event) bool
+}
+
+// BBoxArea es el viewport del mapa
+type BBoxArea struct {
+	MinLatitude  float64
+	MaxLatitude  float64
+	MinLongitude float64
+	MaxLongitude float64
+	Categories   map[string]bool
+}
+
+func (a BBoxArea) Contains(e Event) bool {
+	return e.Latitude >= a.MinLatitude && e.Latitude <= a.MaxLatitude &&
+		e.Longitude >= a.MinLongitude && e.Longitude <= a.MaxLongitude &&
+		categoryAllowed(a.Categories, e.CategoryCode)
+}
+
+// CircleArea es un lugar guardado con su radio de alerta.
+// En los lugares tipo ruta Route trae los vértices y el radio se mide a la ruta (el corredor) en vez de al punto.
+type CircleArea struct {
+	Latitude   float64
+	Longitude  float64
+	Radius     float64 // metros
+	Route      []polyline.Point
+	Categories map[string]bool
+}
+
+func (a CircleArea) Contains(e Event) bool {
+	var distance float64
+	if len(a.Route) > 1 {
+		distance = polyline.DistanceMeters(a.Route, polyline.Point{Latitude: e.Latitude, Longitude: e.Longitude})
+	} else {
+		distance = haversineMeters(a.Latitude, a.Longitude, e.Latitude, e.Longitude)
+	}
+	return distance <= a.Radius && categoryAllowed(a.Categories, e.CategoryCode)
+}
+
+func categoryAllowed(categories map[string]bool, code string) bool {
+	return len(categories) == 0 || categories[code]
+}
+
+func haversineMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadius = 6371000.0
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLng := (lng2 - lng1) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
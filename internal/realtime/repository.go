@@ -0,0 +1,55 @@
+package realtime
+
+import (
+	"alertly/internal/polyline"
+	"database/sql"
+)
+
+type Repository interface {
+	GetClusterEvent(inclID int64) (Event, error)
+	GetSavedPlace(accountID, aflID int64) (CircleArea, error)
+}
+
+type pgRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &pgRepository{db: db}
+}
+
+// GetClusterEvent arma el evento con el estado actual del cluster (sin Type ni At)
+func (r *pgRepository) GetClusterEvent(inclID int64) (Event, error) {
+	var e Event
+	err := r.db.QueryRow(`
+    SELECT
+        incl_id,
+        center_latitude,
+        center_longitude,
+        COALESCE(category_code, ''),
+        COALESCE(subcategory_code, ''),
+        COALESCE(credibility, 0),
+        COALESCE(counter_total_votes, 0),
+        COALESCE(counter_total_votes_true, 0),
+        COALESCE(counter_total_votes_false, 0),
+        is_active = '1'
+    FROM incident_clusters
+    WHERE incl_id = $1`, inclID).Scan(&e.InclId, &e.Latitude, &e.Longitude, &e.CategoryCode, &e.SubcategoryCode,
+		&e.Credibility, &e.TotalVotes, &e.VotesTrue, &e.VotesFalse, &e.IsActive)
+	return e, err
+}
+
+// GetSavedPlace devuelve el lugar guardado si es de la cuenta (con la ruta si es de tipo ruta)
+func (r *pgRepository) GetSavedPlace(accountID, aflID int64) (CircleArea, error) {
+	var a CircleArea
+	var route string
+	err := r.db.QueryRow(`
+    SELECT latitude, longitude, COALESCE(route_buffer, radius, 0), COALESCE(ST_AsEncodedPolyline(route::geometry), '')
+    FROM account_favorite_locations
+    WHERE afl_id = $1 AND account_id = $2`, aflID, accountID).Scan(&a.Latitude, &a.Longitude, &a.Radius, &route)
+	if err != nil || route == "" {
+		return a, err
+	}
+	a.Route, err = polyline.Decode(route)
+	return a, err
+}
@@ -0,0 +1,50 @@
+package realtime
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+const defaultPlaceRadius = 1000 // metros, para lugares guardados sin radio
+
+var (
+	ErrInvalidArea   = errors.New("send a bbox (min_latitude, max_latitude, min_longitude, max_longitude) or the afl_id of a saved place")
+	ErrPlaceNotFound = errors.New("saved place not found")
+)
+
+// ResolveArea arma el Area de la suscripción a partir de los Inputs
+func ResolveArea(in Inputs, accountID int64, repo Repository) (Area, error) {
+	categories := map[string]bool{}
+	for _, cat := range strings.Split(in.Categories, ",") {
+		if cat = strings.TrimSpace(cat); cat != "" {
+			categories[cat] = true
+		}
+	}
+
+	hasBBox := in.MinLatitude != nil && in.MaxLatitude != nil && in.MinLongitude != nil && in.MaxLongitude != nil
+	switch {
+	case hasBBox && in.AflId == 0:
+		a := BBoxArea{MinLatitude: *in.MinLatitude, MaxLatitude: *in.MaxLatitude,
+			MinLongitude: *in.MinLongitude, MaxLongitude: *in.MaxLongitude, Categories: categories}
+		if a.MinLatitude >= a.MaxLatitude || a.MinLongitude >= a.MaxLongitude {
+			return nil, ErrInvalidArea
+		}
+		return a, nil
+	case in.AflId > 0 && in.MinLatitude == nil && in.MaxLatitude == nil && in.MinLongitude == nil && in.MaxLongitude == nil:
+		place, err := repo.GetSavedPlace(accountID, in.AflId)
+		if err == sql.ErrNoRows {
+			return nil, ErrPlaceNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if place.Radius <= 0 {
+			place.Radius = defaultPlaceRadius
+		}
+		place.Categories = categories
+		return place, nil
+	default:
+		return nil, ErrInvalidArea
+	}
+}
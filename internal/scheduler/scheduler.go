@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"alertly/internal/clustermerge"
 	"alertly/internal/cronjob"
 	"alertly/internal/cronjobs/cjbadgeearn"
 	"alertly/internal/cronjobs/cjblockincident"
@@ -14,6 +15,8 @@ import (
 	"alertly/internal/cronjobs/cjuserank"
 	"alertly/internal/cronjobs/notifications"
 	"alertly/internal/database"
+	"alertly/internal/middleware"
+	"alertly/internal/newincident"
 	"log"
 	"time"
 )
@@ -68,6 +71,30 @@ func StartCronjobs() {
 		}
 	}()
 
+	// Cronjob: cluster_merge (fusionar clusters duplicados que se solapan en espacio y tiempo)
+	go func() {
+		ticker := time.NewTicker(2 * time.Minute)
+		defer ticker.Stop()
+		log.Println("✅ Cronjob 'cluster_merge' scheduled every 2 minutes")
+		runClusterMergeCronjob()
+		for range ticker.C {
+			runClusterMergeCronjob()
+		}
+	}()
+
+	// ─── EVERY 10 MINUTES ───────────────────────────────────────────────────────
+
+	// Cronjob: bot_correlation (vincular clusters de usuarios con incidentes oficiales del bot)
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+		log.Println("✅ Cronjob 'bot_correlation' scheduled every 10 minutes")
+		runBotCorrelationCronjob()
+		for range ticker.C {
+			runBotCorrelationCronjob()
+		}
+	}()
+
 	// ─── EVERY 1 HOUR ───────────────────────────────────────────────────────────
 
 	// Cronjob: incident_expiration (expirar incidentes y calcular puntajes de votos)
@@ -114,6 +141,16 @@ func StartCronjobs() {
 		}
 	}()
 
+	// Cronjob: idempotency_purge (borrar respuestas de Idempotency-Key vencidas)
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+		log.Println("✅ Cronjob 'idempotency_purge' scheduled every 1 hour")
+		for range ticker.C {
+			runIdempotencyPurgeCronjob()
+		}
+	}()
+
 	// ─── EVERY 24 HOURS ─────────────────────────────────────────────────────────
 
 	// Cronjob: badge_earn (otorgar badges basados en actividad del usuario)
@@ -225,6 +262,35 @@ func runIncidentUpdateCronjob() {
 	svc.Run()
 }
 
+func runClusterMergeCronjob() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️ Panic in cluster_merge cronjob: %v", r)
+		}
+	}()
+	repo := clustermerge.NewRepository(database.DB)
+	svc := clustermerge.NewService(repo, newincident.NewService(newincident.NewRepository(database.DB)))
+	if _, err := svc.MergeOverlapping(); err != nil {
+		log.Printf("❌ cluster_merge cronjob error: %v", err)
+	}
+}
+
+func runIdempotencyPurgeCronjob() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️ Panic in idempotency_purge cronjob: %v", r)
+		}
+	}()
+	n, err := middleware.PurgeExpiredIdempotencyKeys(database.DB)
+	if err != nil {
+		log.Printf("❌ idempotency_purge cronjob error: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("🧹 Purged %d expired idempotency keys", n)
+	}
+}
+
 func runIncidentExpirationCronjob() {
 	defer func() {
 		if r := recover(); r != nil {
@@ -270,6 +336,17 @@ func runBotCreatorTPSCronjob() {
 	svc.RunTPS()
 }
 
+func runBotCorrelationCronjob() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️ Panic in bot_correlation cronjob: %v", r)
+		}
+	}()
+	repo := cjbot_creator.NewRepository(database.DB)
+	svc := cjbot_creator.NewService(repo)
+	svc.RunCorrelation()
+}
+
 func runBadgeEarnCronjob() {
 	defer func() {
 		if r := recover(); r != nil {
@@ -0,0 +1,36 @@
+package search
+
+import (
+	"alertly/internal/database"
+	"alertly/internal/response"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Search busca incidentes por texto (descripción, subcategoría, dirección, ciudad) en inglés y francés.
+// Acepta los mismos filtros del mapa y, si viene latitude/longitude, favorece los resultados cercanos.
+func Search(c *gin.Context) {
+	var inputs Inputs
+	if err := c.ShouldBindQuery(&inputs); err != nil {
+		log.Printf("Error en query params: %v", err)
+		response.Send(c, http.StatusBadRequest, true, "Please type something to search.", nil)
+		return
+	}
+
+	filter, err := ParseInputs(inputs)
+	if err != nil {
+		response.Send(c, http.StatusBadRequest, true, err.Error(), nil)
+		return
+	}
+
+	service := NewService(NewRepository(database.DB))
+	result, err := service.Search(filter)
+	if err != nil {
+		log.Printf("Error searching incidents: %v", err)
+		response.Send(c, http.StatusInternalServerError, true, "We couldn’t complete the search. Please try again later.", nil)
+		return
+	}
+	response.Send(c, http.StatusOK, false, "Success", result)
+}
@@ -0,0 +1,77 @@
+package search
+
+import "time"
+
+// Inputs de GET /cluster/search. Solo q es obligatorio; el resto son los mismos filtros del mapa
+// (getclustersbylocation) más una ubicación opcional para ordenar por cercanía.
+type Inputs struct {
+	Query        string   `form:"q" binding:"required"`
+	MinLatitude  *float64 `form:"min_latitude"`
+	MaxLatitude  *float64 `form:"max_latitude"`
+	MinLongitude *float64 `form:"min_longitude"`
+	MaxLongitude *float64 `form:"max_longitude"`
+	Latitude     *float64 `form:"latitude"`  // punto de referencia para el ranking por distancia
+	Longitude    *float64 `form:"longitude"` // punto de referencia para el ranking por distancia
+	FromDate     string   `form:"from_date"` // YYYY-MM-DD
+	ToDate       string   `form:"to_date"`   // YYYY-MM-DD
+	InsuID       int      `form:"insu_id"`
+	Categories   string   `form:"categories"`       // category_code separados por coma
+	IncludeEnded bool     `form:"include_inactive"` // por defecto solo clusters activos; los bloqueados nunca
+	Page         int      `form:"page"`
+	Limit        int      `form:"limit"`
+}
+
+// Filter son los Inputs ya validados
+type Filter struct {
+	Query        string
+	BBox         *BBox
+	Origin       *Point
+	FromDate     *time.Time
+	ToDate       *time.Time
+	InsuID       int
+	Categories   []string
+	IncludeEnded bool
+	Offset       int
+	Limit        int
+}
+
+type BBox struct {
+	MinLatitude  float64
+	MaxLatitude  float64
+	MinLongitude float64
+	MaxLongitude float64
+}
+
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Result es un cluster que coincide con la búsqueda. Los snippets vienen con los términos
+// encontrados entre <mark></mark>.
+type Result struct {
+	InclId          int64     `json:"incl_id"`
+	Latitude        float64   `json:"latitude"`
+	Longitude       float64   `json:"longitude"`
+	InsuId          int64     `json:"insu_id"`
+	CategoryCode    string    `json:"category_code"`
+	SubcategoryCode string    `json:"subcategory_code"`
+	SubcategoryName string    `json:"subcategory_name"`
+	Address         string    `json:"address"`
+	City            string    `json:"city"`
+	IsActive        bool      `json:"is_active"`
+	CreatedAt       time.Time `json:"created_at"`
+	Snippet         string    `json:"snippet"`         // descripción del cluster o del reporte que coincidió
+	AddressSnippet  string    `json:"address_snippet"` // dirección y ciudad resaltadas
+	MatchedInreId   *int64    `json:"matched_inre_id"` // reporte que coincidió, si la coincidencia vino de un reporte
+	Relevance       float64   `json:"relevance"`       // ts_rank_cd
+	DistanceMeters  *float64  `json:"distance_meters"` // nil si no se envió latitude/longitude
+	Score           float64   `json:"score"`           // relevancia ajustada por distancia, define el orden
+}
+
+// ResultsPage es una página de resultados ordenados por Score
+type ResultsPage struct {
+	Results []Result `json:"results"`
+	Page    int      `json:"page"`
+	HasMore bool     `json:"has_more"`
+}
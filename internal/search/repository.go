@@ -0,0 +1,167 @@
+package search
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type Repository interface {
+	Search(f Filter) ([]Result, error)
+}
+
+type pgRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &pgRepository{db: db}
+}
+
+// headlineOptions: los términos se marcan con highlightStart/highlightEnd y el servicio los
+// convierte a <mark> después de escapar el texto
+var headlineOptions = fmt.Sprintf("StartSel=\"%s\", StopSel=\"%s\", MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=\" … \"",
+	highlightStart, highlightEnd)
+
+var addressHeadlineOptions = fmt.Sprintf("StartSel=\"%s\", StopSel=\"%s\", HighlightAll=true", highlightStart, highlightEnd)
+
+// Search busca q en search_vector de clusters y reportes (migración 013) con las configuraciones
+// english, french y simple. Devuelve f.Limit+1 filas desde f.Offset ordenadas por score.
+func (r *pgRepository) Search(f Filter) ([]Result, error) {
+	params := []interface{}{f.Query}
+	// Los bloqueados por moderación nunca aparecen, tampoco con include_inactive
+	where := []string{"c.merged_into_incl_id IS NULL", "c.blocked_at IS NULL"}
+
+	if !f.IncludeEnded {
+		where = append(where, "c.is_active = '1'")
+	}
+	if f.BBox != nil {
+		n := len(params)
+		where = append(where, fmt.Sprintf("c.center_latitude BETWEEN $%d AND $%d AND c.center_longitude BETWEEN $%d AND $%d", n+1, n+2, n+3, n+4))
+		params = append(params, f.BBox.MinLatitude, f.BBox.MaxLatitude, f.BBox.MinLongitude, f.BBox.MaxLongitude)
+	}
+	// Mismo criterio que el mapa: clusters vigentes en algún momento del rango
+	if f.ToDate != nil {
+		params = append(params, f.ToDate.Format("2006-01-02"))
+		where = append(where, fmt.Sprintf("c.start_time <= $%d::date + INTERVAL '1 day'", len(params)))
+	}
+	if f.FromDate != nil {
+		params = append(params, f.FromDate.Format("2006-01-02"))
+		where = append(where, fmt.Sprintf("c.end_time >= $%d::date", len(params)))
+	}
+	if f.InsuID != 0 {
+		params = append(params, f.InsuID)
+		where = append(where, fmt.Sprintf("c.insu_id = $%d", len(params)))
+	}
+	if len(f.Categories) > 0 {
+		placeholders := make([]string, len(f.Categories))
+		for i, cat := range f.Categories {
+			params = append(params, cat)
+			placeholders[i] = fmt.Sprintf("$%d", len(params))
+		}
+		where = append(where, "c.category_code IN ("+strings.Join(placeholders, ",")+")")
+	}
+
+	// 📍 Sin ubicación la distancia es NULL y el score es solo la relevancia
+	distance := "NULL::double precision"
+	if f.Origin != nil {
+		n := len(params)
+		distance = fmt.Sprintf("ST_Distance(c.center_location, ST_MakePoint($%d, $%d)::geography)", n+1, n+2)
+		params = append(params, f.Origin.Longitude, f.Origin.Latitude)
+	}
+
+	n := len(params)
+	params = append(params, reportRankWeight, distanceScale, headlineOptions, addressHeadlineOptions, f.Limit+1, f.Offset)
+
+	// candidates usa los índices GIN: clusters que coinciden por sí mismos o por alguno de sus reportes
+	query := fmt.Sprintf(`
+    WITH q AS (
+        SELECT websearch_to_tsquery('english', $1) || websearch_to_tsquery('french', $1) || websearch_to_tsquery('simple', $1) AS query
+    ),
+    candidates AS (
+        SELECT c.incl_id FROM incident_clusters c, q WHERE c.search_vector @@ q.query
+        UNION
+        SELECT ir.incl_id FROM incident_reports ir, q
+        WHERE ir.search_vector @@ q.query AND ir.withdrawn_at IS NULL AND COALESCE(ir.status, '') <> 'rejected'
+    ),
+    matches AS (
+        SELECT
+            c.incl_id,
+            c.center_latitude,
+            c.center_longitude,
+            c.insu_id,
+            COALESCE(c.category_code, '') AS category_code,
+            COALESCE(c.subcategory_code, '') AS subcategory_code,
+            COALESCE(c.subcategory_name, '') AS subcategory_name,
+            COALESCE(c.address, '') AS address,
+            COALESCE(c.city, '') AS city,
+            c.is_active = '1' AS is_active,
+            COALESCE(c.created_at, NOW()) AS created_at,
+            COALESCE(c.description, '') AS description,
+            ts_rank_cd(c.search_vector, q.query) AS cluster_rank,
+            rep.inre_id AS report_id,
+            rep.description AS report_description,
+            $%d * COALESCE(rep.rank, 0) AS report_rank,
+            %s AS distance
+        FROM candidates
+        JOIN incident_clusters c ON c.incl_id = candidates.incl_id
+        CROSS JOIN q
+        LEFT JOIN LATERAL (
+            SELECT ir.inre_id, COALESCE(ir.description, '') AS description, ts_rank_cd(ir.search_vector, q.query) AS rank
+            FROM incident_reports ir
+            WHERE ir.incl_id = c.incl_id AND ir.withdrawn_at IS NULL AND COALESCE(ir.status, '') <> 'rejected'
+              AND ir.search_vector @@ q.query
+            ORDER BY rank DESC, ir.inre_id DESC
+            LIMIT 1
+        ) rep ON true
+        WHERE %s
+    ),
+    ranked AS (
+        SELECT *,
+            GREATEST(cluster_rank, report_rank) AS relevance,
+            GREATEST(cluster_rank, report_rank) / (1 + COALESCE(distance, 0) / $%d) AS score
+        FROM matches
+        ORDER BY score DESC, incl_id DESC
+        LIMIT $%d OFFSET $%d
+    )
+    SELECT
+        ranked.incl_id, ranked.center_latitude, ranked.center_longitude, ranked.insu_id,
+        ranked.category_code, ranked.subcategory_code, ranked.subcategory_name,
+        ranked.address, ranked.city, ranked.is_active, ranked.created_at,
+        CASE WHEN ranked.report_rank > ranked.cluster_rank THEN ranked.report_id END,
+        ts_headline('english',
+            CASE WHEN ranked.report_rank > ranked.cluster_rank THEN ranked.report_description ELSE ranked.description END,
+            q.query, $%d),
+        ts_headline('simple', concat_ws(', ', NULLIF(ranked.address, ''), NULLIF(ranked.city, '')), q.query, $%d),
+        ranked.relevance, ranked.distance, ranked.score
+    FROM ranked
+    CROSS JOIN q
+    ORDER BY ranked.score DESC, ranked.incl_id DESC`,
+		n+1, distance, strings.Join(where, "\n          AND "), n+2, n+5, n+6, n+3, n+4)
+
+	rows, err := r.db.Query(query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("error searching clusters: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]Result, 0, f.Limit+1)
+	for rows.Next() {
+		var res Result
+		var reportID sql.NullInt64
+		var distanceMeters sql.NullFloat64
+		if err := rows.Scan(&res.InclId, &res.Latitude, &res.Longitude, &res.InsuId, &res.CategoryCode,
+			&res.SubcategoryCode, &res.SubcategoryName, &res.Address, &res.City, &res.IsActive, &res.CreatedAt,
+			&reportID, &res.Snippet, &res.AddressSnippet, &res.Relevance, &distanceMeters, &res.Score); err != nil {
+			return nil, fmt.Errorf("error scanning search result: %w", err)
+		}
+		if reportID.Valid {
+			res.MatchedInreId = &reportID.Int64
+		}
+		if distanceMeters.Valid {
+			res.DistanceMeters = &distanceMeters.Float64
+		}
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,148 @@
+package search
+
+import (
+	"alertly/internal/pagination"
+	"errors"
+	"html"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 50
+	maxPage         = 50 // paginación por offset: más allá no tiene sentido para una búsqueda
+
+	minQueryLength = 2
+	maxQueryLength = 200
+
+	// Un reporte que coincide pesa un poco menos que el propio cluster
+	reportRankWeight = 0.8
+	// A distanceScale metros la relevancia se divide por 2
+	distanceScale = 5000.0
+
+	// Marcadores que ts_headline pone alrededor de los términos encontrados
+	highlightStart = "⟦"
+	highlightEnd   = "⟧"
+)
+
+var (
+	ErrInvalidQuery     = errors.New("q must be between 2 and 200 characters")
+	ErrInvalidArea      = errors.New("send all of min_latitude, max_latitude, min_longitude and max_longitude, and both latitude and longitude")
+	ErrInvalidDateRange = errors.New("from_date and to_date must be YYYY-MM-DD and from_date can't be after to_date")
+)
+
+type Service interface {
+	Search(f Filter) (ResultsPage, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) Search(f Filter) (ResultsPage, error) {
+	results, err := s.repo.Search(f)
+	if err != nil {
+		return ResultsPage{Results: []Result{}}, err
+	}
+
+	page := ResultsPage{Results: results, Page: f.Offset/f.Limit + 1}
+	// El repositorio devuelve una fila de más para saber si hay otra página
+	if len(results) > f.Limit {
+		page.Results = results[:f.Limit]
+		page.HasMore = page.Page < maxPage
+	}
+	for i := range page.Results {
+		page.Results[i].Snippet = highlight(page.Results[i].Snippet)
+		page.Results[i].AddressSnippet = highlight(page.Results[i].AddressSnippet)
+	}
+	return page, nil
+}
+
+var markReplacer = strings.NewReplacer(highlightStart, "<mark>", highlightEnd, "</mark>")
+
+// highlight escapa el texto del usuario y recién después convierte los marcadores en <mark>,
+// así el cliente puede mostrar el snippet como HTML sin riesgo
+func highlight(s string) string {
+	return markReplacer.Replace(html.EscapeString(s))
+}
+
+// ParseInputs valida los Inputs y arma el Filter
+func ParseInputs(in Inputs) (Filter, error) {
+	q := strings.Join(strings.Fields(in.Query), " ")
+	if n := utf8.RuneCountInString(q); n < minQueryLength || n > maxQueryLength {
+		return Filter{}, ErrInvalidQuery
+	}
+	f := Filter{Query: q, InsuID: in.InsuID, IncludeEnded: in.IncludeEnded}
+
+	bboxFields := countSet(in.MinLatitude, in.MaxLatitude, in.MinLongitude, in.MaxLongitude)
+	switch bboxFields {
+	case 0:
+	case 4:
+		b := BBox{MinLatitude: *in.MinLatitude, MaxLatitude: *in.MaxLatitude, MinLongitude: *in.MinLongitude, MaxLongitude: *in.MaxLongitude}
+		if b.MinLatitude >= b.MaxLatitude || b.MinLongitude >= b.MaxLongitude {
+			return Filter{}, ErrInvalidArea
+		}
+		f.BBox = &b
+	default:
+		return Filter{}, ErrInvalidArea
+	}
+
+	switch countSet(in.Latitude, in.Longitude) {
+	case 0:
+	case 2:
+		if *in.Latitude < -90 || *in.Latitude > 90 || *in.Longitude < -180 || *in.Longitude > 180 {
+			return Filter{}, ErrInvalidArea
+		}
+		f.Origin = &Point{Latitude: *in.Latitude, Longitude: *in.Longitude}
+	default:
+		return Filter{}, ErrInvalidArea
+	}
+
+	if in.FromDate != "" {
+		from, err := time.Parse("2006-01-02", in.FromDate)
+		if err != nil {
+			return Filter{}, ErrInvalidDateRange
+		}
+		f.FromDate = &from
+	}
+	if in.ToDate != "" {
+		to, err := time.Parse("2006-01-02", in.ToDate)
+		if err != nil || (f.FromDate != nil && to.Before(*f.FromDate)) {
+			return Filter{}, ErrInvalidDateRange
+		}
+		f.ToDate = &to
+	}
+
+	for _, cat := range strings.Split(in.Categories, ",") {
+		if cat = strings.TrimSpace(cat); cat != "" {
+			f.Categories = append(f.Categories, cat)
+		}
+	}
+
+	f.Limit = pagination.Limit(in.Limit, defaultPageSize, maxPageSize)
+	page := in.Page
+	if page < 1 {
+		page = 1
+	}
+	if page > maxPage {
+		page = maxPage
+	}
+	f.Offset = (page - 1) * f.Limit
+	return f, nil
+}
+
+func countSet(values ...*float64) int {
+	n := 0
+	for _, v := range values {
+		if v != nil {
+			n++
+		}
+	}
+	return n
+}
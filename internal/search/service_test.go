@@ -0,0 +1,67 @@
+package search
+
+import "testing"
+
+type fakeRepo struct {
+	results []Result
+	got     Filter
+}
+
+func (r *fakeRepo) Search(f Filter) ([]Result, error) {
+	r.got = f
+	return r.results, nil
+}
+
+func fp(v float64) *float64 { return &v }
+
+func TestParseInputs(t *testing.T) {
+	f, err := ParseInputs(Inputs{Query: "  broken   traffic light ", Categories: "crime, traffic_accident,", Page: 3, Limit: 10})
+	if err != nil {
+		t.Fatalf("ParseInputs() error = %v", err)
+	}
+	if f.Query != "broken traffic light" || len(f.Categories) != 2 || f.Offset != 20 || f.Limit != 10 || f.BBox != nil || f.Origin != nil {
+		t.Errorf("unexpected filter: %+v", f)
+	}
+
+	full := Inputs{Query: "vol", MinLatitude: fp(43.5), MaxLatitude: fp(43.9), MinLongitude: fp(-79.7), MaxLongitude: fp(-79.1),
+		Latitude: fp(43.65), Longitude: fp(-79.38), FromDate: "2026-10-01", ToDate: "2026-10-17", Limit: 1000}
+	if f, err := ParseInputs(full); err != nil || f.BBox == nil || f.Origin == nil || f.FromDate == nil || f.ToDate == nil || f.Limit != maxPageSize {
+		t.Fatalf("full inputs: got %+v, %v", f, err)
+	}
+
+	invalid := []struct {
+		name string
+		in   Inputs
+		want error
+	}{
+		{"too short", Inputs{Query: " a "}, ErrInvalidQuery},
+		{"partial bbox", Inputs{Query: "fire", MinLatitude: fp(43.5)}, ErrInvalidArea},
+		{"latitude only", Inputs{Query: "fire", Latitude: fp(43.5)}, ErrInvalidArea},
+		{"reversed bbox", Inputs{Query: "fire", MinLatitude: fp(44), MaxLatitude: fp(43), MinLongitude: fp(-80), MaxLongitude: fp(-79)}, ErrInvalidArea},
+		{"bad date", Inputs{Query: "fire", FromDate: "17/10/2026"}, ErrInvalidDateRange},
+		{"reversed dates", Inputs{Query: "fire", FromDate: "2026-10-17", ToDate: "2026-10-01"}, ErrInvalidDateRange},
+	}
+	for _, tt := range invalid {
+		if _, err := ParseInputs(tt.in); err != tt.want {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestSearchPageAndHighlight(t *testing.T) {
+	repo := &fakeRepo{results: []Result{
+		{InclId: 1, Snippet: "<script> near " + highlightStart + "Yonge" + highlightEnd + " St"},
+		{InclId: 2},
+		{InclId: 3},
+	}}
+	page, err := NewService(repo).Search(Filter{Query: "yonge", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Results) != 2 || !page.HasMore || page.Page != 1 {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+	if want := "&lt;script&gt; near <mark>Yonge</mark> St"; page.Results[0].Snippet != want {
+		t.Errorf("snippet = %q, want %q", page.Results[0].Snippet, want)
+	}
+}
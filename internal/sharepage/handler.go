@@ -0,0 +1,72 @@
+package sharepage
+
+import (
+	"alertly/internal/common"
+	"alertly/internal/database"
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// View renderiza la página de compartir de un cluster con Open Graph / Twitter cards, para que los links
+// compartidos en redes y mensajería tengan preview (título, barrio, foto y antigüedad) y abran la app.
+//
+//	GET /public/share/incident/:incl_id
+func View(c *gin.Context) {
+	idStr := c.Param("incl_id")
+	shareURL := selfURL(idStr)
+
+	// ✅ VALIDACIÓN: mismos límites que /public/cluster/getbyid
+	inclId, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || inclId <= 0 || inclId > 999999999 {
+		render(c, http.StatusNotFound, UnavailablePage(shareURL))
+		return
+	}
+
+	service := NewService(NewRepository(database.DB))
+	page, err := service.Build(inclId, shareURL)
+	if errors.Is(err, ErrNotFound) {
+		render(c, http.StatusNotFound, UnavailablePage(shareURL))
+		return
+	}
+	if err != nil {
+		log.Printf("Error building share page for cluster %d: %v", inclId, err)
+		render(c, http.StatusInternalServerError, UnavailablePage(shareURL))
+		return
+	}
+
+	// Cluster fusionado: el link viejo lleva a la página del cluster que lo absorbió. 302 y no 301:
+	// un split puede revertir el merge y los navegadores/crawlers guardan los 301 para siempre.
+	if page.RedirectTo > 0 {
+		c.Redirect(http.StatusFound, selfURL(strconv.FormatInt(page.RedirectTo, 10)))
+		return
+	}
+	render(c, http.StatusOK, page)
+}
+
+func render(c *gin.Context, status int, page Page) {
+	var body bytes.Buffer
+	if err := Render(&body, page); err != nil {
+		log.Printf("Error rendering share page: %v", err)
+		c.String(http.StatusInternalServerError, "We couldn’t load this page. Please try again later.")
+		return
+	}
+	// ✅ CACHE: los crawlers de redes sociales piden la misma página muchas veces al compartirse
+	if status == http.StatusOK {
+		c.Header("Cache-Control", "public, max-age=300")
+	} else {
+		c.Header("Cache-Control", "public, max-age=60")
+	}
+	c.Data(status, "text/html; charset=utf-8", body.Bytes())
+}
+
+// selfURL es la URL canónica de la página (og:url), armada con API_URL y no con el Host del request
+// (la respuesta se cachea); sin query string para no arrastrar utm_* y similares
+func selfURL(inclID string) string {
+	return common.GetPublicAPIURL() + "/public/share/incident/" + url.PathEscape(inclID)
+}
@@ -0,0 +1,40 @@
+package sharepage
+
+import "time"
+
+// Cluster es lo que la página de compartir necesita del cluster; nunca la dirección exacta ni quién reportó
+type Cluster struct {
+	InclId           int64
+	Title            string // subcategory_name
+	CategoryCode     string
+	Neighbourhood    string // barrio que contiene el centro del cluster, si hay límites cargados
+	City             string
+	Province         string
+	CoverURL         string // primera foto lista (o poster del video) de un report activo
+	LegacyMediaURL   string // incident_clusters.media_url, para clusters anteriores a incident_media
+	LegacyMediaType  string // incident_clusters.media_type: con 'video' la media_url es el MP4, no sirve de portada
+	CreatedAt        time.Time
+	IsActive         bool
+	Blocked          bool
+	MergedIntoInclId int64
+	Official         bool
+}
+
+// Page son los datos del template share.html
+type Page struct {
+	InclId      int64
+	Title       string
+	Description string
+	Location    string
+	Age         string
+	ImageURL    string
+	TwitterCard string // summary_large_image con foto, summary con el logo
+	URL         string // landing del incidente en el sitio web
+	ShareURL    string // esta página (og:url)
+	DeepLink    string // abre el cluster en la app
+	IOSStoreURL string
+	AndroidURL  string
+	Status      string // active, expired o unavailable
+	NoIndex     bool
+	RedirectTo  int64 // cluster fusionado: la página del cluster que lo absorbió
+}
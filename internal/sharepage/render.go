@@ -0,0 +1,33 @@
+package sharepage
+
+import (
+	"html/template"
+	"io"
+	"path/filepath"
+	"sync"
+)
+
+// templatePath es relativo al working directory, igual que los templates de emails (ver Dockerfile)
+var templatePath = filepath.Join("internal", "sharepage", "templates", "share.html")
+
+var (
+	shareTmpl    *template.Template
+	shareTmplErr error
+	shareOnce    sync.Once
+)
+
+var funcs = template.FuncMap{
+	// appURL marca el deep link como seguro: html/template reemplaza por #ZgotmplZ los esquemas que no son http(s)
+	"appURL": func(s string) template.URL { return template.URL(s) },
+}
+
+// Render escribe la página HTML; el template se parsea una sola vez
+func Render(w io.Writer, page Page) error {
+	shareOnce.Do(func() {
+		shareTmpl, shareTmplErr = template.New("share.html").Funcs(funcs).ParseFiles(templatePath)
+	})
+	if shareTmplErr != nil {
+		return shareTmplErr
+	}
+	return shareTmpl.ExecuteTemplate(w, "share", page)
+}
@@ -0,0 +1,67 @@
+package sharepage
+
+import (
+	"alertly/internal/cronjobs/cjbot_creator"
+	"database/sql"
+	"fmt"
+)
+
+type Repository interface {
+	GetCluster(inclID int64) (Cluster, error)
+}
+
+type pgRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &pgRepository{db: db}
+}
+
+// GetCluster trae el cluster sin filtrar por is_active: un link compartido sigue funcionando cuando expira.
+// La ubicación sale a nivel barrio (el barrio más chico que contiene el centro), nunca la dirección.
+func (r *pgRepository) GetCluster(inclID int64) (Cluster, error) {
+	query := `
+    SELECT
+        c.incl_id,
+        COALESCE(c.subcategory_name, ''),
+        COALESCE(c.category_code, ''),
+        COALESCE((
+            SELECT n.name FROM neighbourhoods n
+            WHERE c.center_location IS NOT NULL AND ST_Covers(n.boundary, c.center_location)
+            ORDER BY (n.kind = 'neighbourhood') DESC, n.area_sq_meters ASC
+            LIMIT 1
+        ), ''),
+        COALESCE(c.city, ''),
+        COALESCE(c.province, ''),
+        COALESCE((
+            SELECT CASE WHEN m.media_type = 'video' THEN COALESCE(m.poster_url, '') ELSE m.media_url END
+            FROM incident_media m
+            INNER JOIN incident_reports r ON r.inre_id = m.inre_id
+            WHERE r.incl_id = c.incl_id AND COALESCE(r.is_active, '0') = '1' AND m.status = 'ready'
+              AND (m.media_type <> 'video' OR COALESCE(m.poster_url, '') <> '')
+            ORDER BY r.created_at ASC, m.position ASC
+            LIMIT 1
+        ), ''),
+        COALESCE(c.media_url, ''),
+        COALESCE(c.media_type, ''),
+        c.created_at,
+        COALESCE(c.is_active, '0') = '1',
+        c.blocked_at IS NOT NULL,
+        COALESCE(c.merged_into_incl_id, 0),
+        COALESCE(c.account_id, 0) = $2
+    FROM incident_clusters c
+    WHERE c.incl_id = $1`
+
+	var cl Cluster
+	err := r.db.QueryRow(query, inclID, cjbot_creator.BOT_USER_ID).Scan(&cl.InclId, &cl.Title, &cl.CategoryCode,
+		&cl.Neighbourhood, &cl.City, &cl.Province, &cl.CoverURL, &cl.LegacyMediaURL, &cl.LegacyMediaType, &cl.CreatedAt, &cl.IsActive,
+		&cl.Blocked, &cl.MergedIntoInclId, &cl.Official)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Cluster{}, err
+		}
+		return Cluster{}, fmt.Errorf("error querying share cluster: %w", err)
+	}
+	return cl, nil
+}
@@ -0,0 +1,159 @@
+package sharepage
+
+import (
+	"alertly/internal/common"
+	"alertly/internal/cronjobs/cjbot_creator"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+const (
+	StatusActive      = "active"
+	StatusExpired     = "expired"
+	StatusUnavailable = "unavailable"
+)
+
+var ErrNotFound = errors.New("incident not found")
+
+type Service interface {
+	Build(inclID int64, shareURL string) (Page, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+// Build arma los metadatos de la página. Un cluster fusionado devuelve solo RedirectTo; uno bloqueado,
+// una página genérica sin título, ubicación ni foto (y noindex).
+func (s *service) Build(inclID int64, shareURL string) (Page, error) {
+	cl, err := s.repo.GetCluster(inclID)
+	if err == sql.ErrNoRows {
+		return Page{}, ErrNotFound
+	}
+	if err != nil {
+		return Page{}, err
+	}
+	if cl.MergedIntoInclId > 0 && cl.MergedIntoInclId != cl.InclId {
+		return Page{RedirectTo: cl.MergedIntoInclId}, nil
+	}
+
+	ios, android := common.GetAppStoreURLs()
+	page := Page{
+		InclId:      cl.InclId,
+		URL:         common.GetIncidentWebURL(cl.InclId),
+		ShareURL:    shareURL,
+		DeepLink:    common.GetAppDeepLink(cl.InclId),
+		IOSStoreURL: ios,
+		AndroidURL:  android,
+		ImageURL:    defaultImageURL(),
+		TwitterCard: "summary",
+	}
+
+	if cl.Blocked {
+		page = UnavailablePage(shareURL)
+		page.InclId = cl.InclId
+		page.Title = "This incident is no longer available"
+		page.Description = "It was removed after community review. See what’s happening around you on Alertly."
+		return page, nil
+	}
+
+	page.Location = location(cl)
+	page.Age = common.TimeAgo(cl.CreatedAt)
+	page.Title = cl.Title
+	if page.Title == "" {
+		page.Title = "Incident"
+	}
+	if place := firstNonEmpty(cl.Neighbourhood, cl.City); place != "" {
+		page.Title += " in " + place
+	}
+
+	reported := "Reported " + page.Age
+	if page.Location != "" {
+		reported += " in " + page.Location
+	}
+	if cl.IsActive {
+		page.Status = StatusActive
+		page.Description = reported + ". Open Alertly for live updates, photos and community votes."
+	} else {
+		page.Status = StatusExpired
+		page.Title = "Resolved: " + page.Title
+		page.Description = reported + ". This incident is no longer active."
+	}
+
+	if cover := coverURL(cl); cover != "" {
+		page.ImageURL = cover
+		page.TwitterCard = "summary_large_image"
+	}
+	return page, nil
+}
+
+// UnavailablePage es la página genérica para clusters bloqueados o inexistentes: sin deep link y con noindex
+func UnavailablePage(shareURL string) Page {
+	ios, android := common.GetAppStoreURLs()
+	return Page{
+		Title:       "This incident doesn’t exist",
+		Description: "See what’s happening around you on Alertly.",
+		URL:         common.GetPublicWebURL(),
+		ShareURL:    shareURL,
+		IOSStoreURL: ios,
+		AndroidURL:  android,
+		ImageURL:    defaultImageURL(),
+		TwitterCard: "summary",
+		Status:      StatusUnavailable,
+		NoIndex:     true,
+	}
+}
+
+// location es "barrio, ciudad, provincia" con lo que haya; nunca la calle
+func location(cl Cluster) string {
+	var parts []string
+	for _, p := range []string{cl.Neighbourhood, cl.City, cl.Province} {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// coverURL elige la portada: foto de incident_media, la media_url vieja del cluster (si no es un video) o la imagen oficial del bot
+func coverURL(cl Cluster) string {
+	legacy := cl.LegacyMediaURL
+	if cl.LegacyMediaType == "video" {
+		legacy = ""
+	}
+	for _, u := range []string{cl.CoverURL, legacy} {
+		if u = strings.TrimSpace(u); u != "" && u != "processing" {
+			return absoluteURL(u)
+		}
+	}
+	if cl.Official {
+		return cjbot_creator.OfficialReportImageURL
+	}
+	return ""
+}
+
+// absoluteURL: las fotos en S3 ya se guardan con URL completa, las anteriores solo con el nombre de archivo
+func absoluteURL(u string) string {
+	if strings.HasPrefix(u, "https://") || strings.HasPrefix(u, "http://") {
+		return u
+	}
+	return common.GetImageURL(strings.TrimPrefix(u, "/"))
+}
+
+func defaultImageURL() string {
+	return common.GetPublicWebURL() + "/images/main-logo.png"
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
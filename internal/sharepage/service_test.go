@@ -0,0 +1,121 @@
+package sharepage
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeRepo struct {
+	clusters map[int64]Cluster
+}
+
+func (r *fakeRepo) GetCluster(inclID int64) (Cluster, error) {
+	cl, ok := r.clusters[inclID]
+	if !ok {
+		return Cluster{}, sql.ErrNoRows
+	}
+	return cl, nil
+}
+
+func newService() Service {
+	created := time.Now().Add(-2*time.Hour - time.Minute)
+	return NewService(&fakeRepo{clusters: map[int64]Cluster{
+		7: {InclId: 7, Title: "Car crash", Neighbourhood: "Annex", City: "Toronto", Province: "ON",
+			CoverURL: "https://images.alertly.ca/incidents/7.webp", CreatedAt: created, IsActive: true},
+		8:  {InclId: 8, Title: "Fire", City: "Toronto", LegacyMediaURL: "processing", CreatedAt: created},
+		9:  {InclId: 9, Title: "Car crash", Neighbourhood: "Annex", CoverURL: "x.webp", CreatedAt: created, Blocked: true},
+		10: {InclId: 10, MergedIntoInclId: 7, CreatedAt: created},
+		11: {InclId: 11, Title: "Fire", City: "Toronto", LegacyMediaURL: "https://images.alertly.ca/videos/11.mp4",
+			LegacyMediaType: "video", CreatedAt: created, IsActive: true},
+	}})
+}
+
+func TestBuildActiveCluster(t *testing.T) {
+	page, err := newService().Build(7, "https://api.alertly.ca/public/share/incident/7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Title != "Car crash in Annex" || page.Location != "Annex, Toronto, ON" || page.Age != "2 hours ago" {
+		t.Errorf("unexpected page: %+v", page)
+	}
+	if page.Status != StatusActive || page.TwitterCard != "summary_large_image" || page.ImageURL != "https://images.alertly.ca/incidents/7.webp" {
+		t.Errorf("unexpected status or cover: %+v", page)
+	}
+	if !strings.HasSuffix(page.DeepLink, "://incident/7") || !strings.HasSuffix(page.URL, "/incident/7") {
+		t.Errorf("unexpected links: %+v", page)
+	}
+}
+
+func TestBuildFallbacks(t *testing.T) {
+	s := newService()
+
+	expired, err := s.Build(8, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired.Status != StatusExpired || !strings.HasPrefix(expired.Title, "Resolved: Fire in Toronto") {
+		t.Errorf("expired cluster: %+v", expired)
+	}
+	if expired.TwitterCard != "summary" || expired.ImageURL != defaultImageURL() {
+		t.Errorf("a cluster still processing its media should use the default image: %+v", expired)
+	}
+
+	blocked, err := s.Build(9, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blocked.Status != StatusUnavailable || !blocked.NoIndex || blocked.DeepLink != "" ||
+		strings.Contains(blocked.Title, "Car crash") || blocked.Location != "" || blocked.ImageURL != defaultImageURL() {
+		t.Errorf("blocked cluster should not expose its content: %+v", blocked)
+	}
+
+	video, err := s.Build(11, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if video.TwitterCard != "summary" || video.ImageURL != defaultImageURL() {
+		t.Errorf("a video cluster without poster should not use the MP4 as og:image: %+v", video)
+	}
+
+	merged, err := s.Build(10, "")
+	if err != nil || merged.RedirectTo != 7 {
+		t.Errorf("merged cluster should redirect to 7, got %+v (%v)", merged, err)
+	}
+
+	if _, err := s.Build(404, ""); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRenderEscapesAndKeepsDeepLink(t *testing.T) {
+	templatePath = "templates/share.html"
+	page, err := newService().Build(7, "https://api.alertly.ca/public/share/incident/7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page.Title = `Fight <script>alert(1)</script> "here"`
+
+	var body bytes.Buffer
+	if err := Render(&body, page); err != nil {
+		t.Fatal(err)
+	}
+	html := body.String()
+	if strings.Contains(html, "<script>alert(1)") {
+		t.Errorf("title should be escaped:\n%s", html)
+	}
+	for _, want := range []string{
+		`<meta property="og:image" content="https://images.alertly.ca/incidents/7.webp">`,
+		`<meta name="twitter:card" content="summary_large_image">`,
+		`href="alertly://incident/7"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %s in:\n%s", want, html)
+		}
+	}
+	if strings.Contains(html, "ZgotmplZ") {
+		t.Errorf("deep link was filtered by html/template:\n%s", html)
+	}
+}
@@ -0,0 +1,54 @@
+package tiles
+
+import (
+	"alertly/internal/database"
+	"alertly/internal/response"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Los clusters cambian a cada rato: cache corto en el cliente/CDN
+const tileCacheControl = "public, max-age=30, stale-while-revalidate=30"
+
+const mvtContentType = "application/vnd.mapbox-vector-tile"
+
+// GetTile sirve /tiles/:z/:x/:y.mvt con los clusters activos como Mapbox Vector Tile
+func GetTile(c *gin.Context) {
+	tile, err := ParseTile(c.Param("z"), c.Param("x"), c.Param("y"))
+	if err != nil {
+		response.Send(c, http.StatusBadRequest, true, "Invalid tile. Use /tiles/{z}/{x}/{y}.mvt", nil)
+		return
+	}
+
+	service := NewService(NewRepository(database.DB))
+	mvt, err := service.GetTile(tile)
+	if err != nil {
+		log.Printf("Error generating tile: %v", err)
+		response.Send(c, http.StatusInternalServerError, true, "Could not load the map tile. Please try again later.", nil)
+		return
+	}
+
+	etag := tileETag(mvt)
+	c.Header("Cache-Control", tileCacheControl)
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	if len(mvt) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.Data(http.StatusOK, mvtContentType, mvt)
+}
+
+// tileETag identifica el contenido del tile para responder 304 si no cambió
+func tileETag(mvt []byte) string {
+	h := fnv.New64a()
+	h.Write(mvt)
+	return fmt.Sprintf("\"%x\"", h.Sum64())
+}
@@ -0,0 +1,14 @@
+package tiles
+
+// Tile es una tesela XYZ (esquema web mercator, y=0 arriba)
+type Tile struct {
+	Z int
+	X int
+	Y int
+}
+
+// Capas del tile
+const (
+	LayerClusters = "clusters" // clusters creados por usuarios
+	LayerOfficial = "official" // clusters creados por el bot desde fuentes oficiales
+)
@@ -0,0 +1,60 @@
+package tiles
+
+import (
+	"alertly/internal/cronjobs/cjbot_creator"
+	"database/sql"
+	"fmt"
+)
+
+type Repository interface {
+	GetTile(t Tile) ([]byte, error)
+}
+
+type pgRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) Repository {
+	return &pgRepository{db: db}
+}
+
+// GetTile genera el tile MVT de los clusters activos con ST_AsMVT.
+// Los clusters del bot van en la capa "official" y el resto en "clusters".
+// Atributos: incl_id, category_code, subcategory_code, credibility, age_minutes y official_confirmed.
+func (r *pgRepository) GetTile(t Tile) ([]byte, error) {
+	query := fmt.Sprintf(`
+    WITH bounds AS (
+        SELECT ST_TileEnvelope($1, $2, $3) AS geom
+    ),
+    features AS (
+        SELECT
+            ST_AsMVTGeom(ST_Transform(c.center_location::geometry, 3857), bounds.geom, %d, %d, true) AS geom,
+            c.incl_id,
+            COALESCE(c.category_code, '') AS category_code,
+            COALESCE(c.subcategory_code, '') AS subcategory_code,
+            ROUND(COALESCE(c.credibility, 0)::numeric, 1)::float8 AS credibility,
+            GREATEST(EXTRACT(EPOCH FROM (NOW() - c.created_at)) / 60, 0)::int AS age_minutes,
+            c.official_confirmed_at IS NOT NULL AS official_confirmed,
+            COALESCE(c.account_id, 0) = $4 AS is_bot
+        FROM incident_clusters c, bounds
+        WHERE c.is_active = '1'
+          AND c.center_location && ST_Transform(bounds.geom, 4326)::geography
+    )
+    SELECT
+        COALESCE((SELECT ST_AsMVT(f, '%s', %d, 'geom') FROM (
+            SELECT geom, incl_id, category_code, subcategory_code, credibility, age_minutes, official_confirmed
+            FROM features WHERE NOT is_bot
+        ) f), ''::bytea)
+        ||
+        COALESCE((SELECT ST_AsMVT(f, '%s', %d, 'geom') FROM (
+            SELECT geom, incl_id, category_code, subcategory_code, credibility, age_minutes
+            FROM features WHERE is_bot
+        ) f), ''::bytea)`,
+		tileExtent, tileBuffer, LayerClusters, tileExtent, LayerOfficial, tileExtent)
+
+	var mvt []byte
+	if err := r.db.QueryRow(query, t.Z, t.X, t.Y, cjbot_creator.BOT_USER_ID).Scan(&mvt); err != nil {
+		return nil, fmt.Errorf("error generating tile %d/%d/%d: %w", t.Z, t.X, t.Y, err)
+	}
+	return mvt, nil
+}
@@ -0,0 +1,51 @@
+package tiles
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxZoom    = 22
+	tileExtent = 4096 // resolución interna del tile MVT
+	tileBuffer = 64   // margen para que los íconos en el borde no se corten
+)
+
+var ErrInvalidTile = errors.New("invalid tile coordinates")
+
+type Service interface {
+	GetTile(t Tile) ([]byte, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) GetTile(t Tile) ([]byte, error) {
+	return s.repo.GetTile(t)
+}
+
+// ParseTile valida z/x/y del path. y debe terminar en ".mvt".
+func ParseTile(z, x, y string) (Tile, error) {
+	if !strings.HasSuffix(y, ".mvt") {
+		return Tile{}, ErrInvalidTile
+	}
+	var t Tile
+	var err error
+	if t.Z, err = strconv.Atoi(z); err != nil || t.Z < 0 || t.Z > maxZoom {
+		return Tile{}, ErrInvalidTile
+	}
+	max := 1 << t.Z
+	if t.X, err = strconv.Atoi(x); err != nil || t.X < 0 || t.X >= max {
+		return Tile{}, ErrInvalidTile
+	}
+	if t.Y, err = strconv.Atoi(strings.TrimSuffix(y, ".mvt")); err != nil || t.Y < 0 || t.Y >= max {
+		return Tile{}, ErrInvalidTile
+	}
+	return t, nil
+}
@@ -0,0 +1,30 @@
+package tiles
+
+import "testing"
+
+func TestParseTile(t *testing.T) {
+	tests := []struct {
+		z, x, y string
+		want    Tile
+		wantErr bool
+	}{
+		{"0", "0", "0.mvt", Tile{0, 0, 0}, false},
+		{"12", "1144", "1497.mvt", Tile{12, 1144, 1497}, false},
+		{"12", "1144", "1497", Tile{}, true},     // sin extensión
+		{"12", "1144", "1497.pbf", Tile{}, true}, // otra extensión
+		{"2", "4", "0.mvt", Tile{}, true},        // x fuera de rango (0-3)
+		{"2", "0", "-1.mvt", Tile{}, true},       // y negativo
+		{"23", "0", "0.mvt", Tile{}, true},       // zoom máximo 22
+		{"a", "0", "0.mvt", Tile{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTile(tt.z, tt.x, tt.y)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTile(%s, %s, %s) error = %v, wantErr %v", tt.z, tt.x, tt.y, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseTile(%s, %s, %s) = %+v, want %+v", tt.z, tt.x, tt.y, got, tt.want)
+		}
+	}
+}